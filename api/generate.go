@@ -0,0 +1,4 @@
+// Package api holds the protobuf definitions of the service and the code generated from them.
+package api
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative tenant/v1/tenant.proto
@@ -22,4 +22,8 @@ type TenantService interface {
 		ctx context.Context, tenantID string, subscription *entities.TenantSubscriptionDetails,
 	) error
 	UpdateTenantPaymentDetails(ctx context.Context, id string, paymentDetails *entities.TenantPaymentDetails) error
+	CreateAPIKey(ctx context.Context, tenantID string, key *entities.APIKey) (string, error)
+	ListAPIKeys(ctx context.Context, tenantID string) ([]*entities.APIKey, error)
+	RotateAPIKey(ctx context.Context, tenantID string, keyID string) (*entities.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, tenantID string, keyID string) error
 }
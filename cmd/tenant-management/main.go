@@ -3,15 +3,43 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
 	"github.com/hebecoding/tenant-management/infrastructure/config"
 	"github.com/hebecoding/tenant-management/infrastructure/database/mongo"
+	"github.com/hebecoding/tenant-management/infrastructure/database/postgres"
+	"github.com/hebecoding/tenant-management/infrastructure/database/sqlite"
+	"github.com/hebecoding/tenant-management/infrastructure/health"
+	"github.com/hebecoding/tenant-management/infrastructure/messaging"
+	"github.com/hebecoding/tenant-management/infrastructure/metrics"
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	"github.com/hebecoding/tenant-management/infrastructure/ratelimit"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/cache"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	pgrepositories "github.com/hebecoding/tenant-management/infrastructure/repositories/postgres"
+	sqliterepositories "github.com/hebecoding/tenant-management/infrastructure/repositories/sqlite"
+	"github.com/hebecoding/tenant-management/infrastructure/rest"
+	"github.com/hebecoding/tenant-management/infrastructure/rpc"
+	"github.com/hebecoding/tenant-management/infrastructure/verification"
+	"github.com/hebecoding/tenant-management/infrastructure/webhooks"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc"
 )
 
+// shutdownTimeout is how long requests in flight are given to finish when the application stops.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// init logger
 	logger := utils.NewLogger()
@@ -27,37 +55,610 @@ func main() {
 		logger.Fatal(err)
 	}
 
-	// init db
-	db, err := mongo.NewMongoDB(
-		context.Background(), logger, config.Config.DB.URL, "tenant-management", "tenants", "rbac",
+	// invalid settings fall back to their defaults or fail their component, the service is not
+	// ready until they are fixed
+	configErr := config.Config.Validate()
+	if configErr != nil {
+		logger.Error(configErr)
+	}
+
+	// expose the metrics of repositories, services, the api and the mongo pools at /metrics
+	serviceMetrics := metrics.New(logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// tell orchestrators whether the service is alive and ready to serve
+	checker := health.NewChecker(logger, health.WithTimeout(config.Config.Health.Timeout))
+	workers := health.NewWorkers(logger)
+	checker.AddLivenessCheck("workers", workers.Check)
+	checker.AddReadinessCheck("config", func(context.Context) error { return configErr })
+
+	// init mongo, it keeps the audit logs, the outbox and webhooks, which are unavailable with the
+	// other database backends
+	var (
+		db             *mongo.DB
+		auditService   rest.AuditService
+		repositoryOpts []repositories.Option
+		// outboxRepository is nil unless domain events are published
+		outboxRepository *repositories.OutboxRepository
 	)
+	if config.Config.DB.IsMongo() {
+		var err error
+		db, err = mongo.NewMongoDB(
+			context.Background(), logger, config.Config.DB.URL, "tenant-management", "tenants", "rbac",
+			options.Client().SetPoolMonitor(serviceMetrics.PoolMonitor()),
+		)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer func(db *mongo.DB) {
+			err := db.Client.Disconnect(context.Background())
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}(db)
+		checker.AddReadinessCheck("mongo", func(ctx context.Context) error { return db.Client.Ping(ctx, nil) })
+
+		// every change to tenants and roles is audited
+		auditRepository := repositories.NewAuditRepository(db.Database.Collection("audit"), logger)
+		if err := auditRepository.CreateIndexes(ctx); err != nil {
+			logger.Fatal(err)
+		}
+		auditService = service.NewAuditService(logger, auditRepository)
+		repositoryOpts = append(repositoryOpts, repositories.WithAuditTrail(auditRepository))
+
+		// publish the domain events raised by changes through the outbox
+		outboxRepository, err = newOutbox(ctx, logger, db)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		if outboxRepository != nil {
+			repositoryOpts = append(repositoryOpts, repositories.WithOutbox(outboxRepository))
+		}
+	} else {
+		logger.Infof(
+			"audit logs, domain events and webhooks are unavailable with the %s backend", config.Config.DB.Backend,
+		)
+	}
+
+	// indexes are built in the background, the service is not ready until they are
+	indexes := &health.Step{}
+	checker.AddReadinessCheck("indexes", indexes.Check)
+	workers.Do(
+		ctx, "index reconciliation", indexes, func(ctx context.Context) error {
+			return reconcileIndexes(ctx, logger, db)
+		},
+	)
+
+	repos, err := newRepositories(ctx, logger, db, repositoryOpts...)
 	if err != nil {
 		logger.Fatal(err)
 	}
+	defer repos.close()
+	if repos.ping != nil {
+		checker.AddReadinessCheck(config.Config.DB.Backend, repos.ping)
+	}
+	tenantRepository, rolesRepository := repos.tenants, repos.roles
 
-	defer func(db *mongo.DB) {
-		err := db.Client.Disconnect(context.Background())
+	// count tenants and subscriptions in the background, in the database when it is able to
+	stats, ok := tenantRepository.(metrics.StatsSource)
+	if !ok {
+		stats = metrics.ScanStats(tenantRepository)
+	}
+	statsInterval := config.Config.Metrics.StatsInterval
+	if statsInterval <= 0 {
+		statsInterval = time.Minute
+	}
+	workers.Go(
+		ctx, "business metrics", func(ctx context.Context) {
+			serviceMetrics.StartStats(ctx, stats, statsInterval)
+		},
+	)
+
+	tenantRepository = metrics.InstrumentTenantRepository(tenantRepository, serviceMetrics)
+	var invalidators []repositories.Invalidator
+	if cachedRepository := newTenantCache(logger, tenantRepository); cachedRepository != nil {
+		tenantRepository = cachedRepository
+		invalidators = append(invalidators, cachedRepository)
+		serviceMetrics.RegisterTenantCache(cachedRepository)
+	}
+	tenantService := service.NewTenantService(logger, tenantRepository)
+	roleService := service.NewRoleService(logger, rolesRepository)
+	domainService := service.NewDomainService(logger, tenantRepository, verification.NewVerifier(logger))
+
+	// re-verify custom domains in the background
+	reverifyInterval := config.Config.Domains.ReverifyInterval
+	if reverifyInterval <= 0 {
+		reverifyInterval = time.Hour
+	}
+	workers.Go(
+		ctx, "domain reverification", func(ctx context.Context) {
+			domainService.StartReverification(ctx, reverifyInterval)
+		},
+	)
+
+	// upgrade tenant documents of older schema versions in the background, requests are served
+	// meanwhile since tenants are upgraded when they are read
+	if migrator := newMigrator(logger, db); migrator != nil {
+		migration := &health.Step{}
+		checker.AddReadinessCheck("migrations", migration.Outcome)
+		workers.Do(
+			ctx, "tenant migration", migration, func(ctx context.Context) error {
+				_, err := migrator.Run(ctx)
+				return err
+			},
+		)
+	}
+
+	// follow the changes to tenants and roles, including the ones made directly in the database
+	if watcher, err := newWatcher(ctx, logger, db, invalidators...); err != nil {
+		logger.Fatal(err)
+	} else if watcher != nil {
+		workers.Go(ctx, "change watcher", watcher.Start)
+	}
+
+	// deliver events to the webhook endpoints of tenants
+	var webhookAPI rest.WebhookService
+	if db != nil {
+		webhookService, err := newWebhookService(ctx, logger, db, tenantRepository)
 		if err != nil {
 			logger.Fatal(err)
 		}
-	}(db)
+		webhookAPI = webhookService
+		deliveryInterval := config.Config.Webhooks.DeliveryInterval
+		if deliveryInterval <= 0 {
+			deliveryInterval = 5 * time.Second
+		}
+		workers.Go(
+			ctx, "webhook delivery", func(ctx context.Context) {
+				webhookService.StartDelivery(ctx, deliveryInterval)
+			},
+		)
+
+		if outboxRepository != nil {
+			relay, closePublisher, err := newRelay(logger, outboxRepository, webhookService.DispatchEvent)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			defer closePublisher()
+			workers.Go(ctx, "outbox relay", relay.Start)
+		}
+	}
+
+	// authenticate api requests
+	authenticator, err := newAuthenticator(logger, tenantService)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	unaryInterceptors := []grpc.UnaryServerInterceptor{authenticator.UnaryServerInterceptor()}
+	middlewares := []func(http.Handler) http.Handler{authenticator.Middleware}
 
-	keepRunning()
+	// limit the requests of tenants
+	limiter, err := newLimiter(ctx, logger, db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	if limiter != nil {
+		unaryInterceptors = append(unaryInterceptors, limiter.UnaryServerInterceptor())
+		middlewares = append(middlewares, limiter.Middleware)
+	}
+
+	// serve grpc api
+	apiTenantService := metrics.InstrumentTenantService(tenantService, serviceMetrics)
+	grpcServer := rpc.NewServer(
+		logger, apiTenantService, roleService,
+		grpc.ChainUnaryInterceptor(append(unaryInterceptors, rpc.AuthorizationInterceptor(authn.Authorize))...),
+		grpc.ChainStreamInterceptor(authenticator.StreamServerInterceptor()),
+	)
+	go func() {
+		if err := grpcServer.ListenAndServe(":" + config.Config.Application.GRPCPort); err != nil {
+			logger.Fatal(err)
+		}
+	}()
+
+	// serve rest api
+	restServer, err := rest.NewServer(
+		logger, apiTenantService, roleService, domainService, auditService, webhookAPI,
+		rest.WithMiddleware(middlewares...),
+		rest.WithAuthorizer(authn.Authorize),
+		rest.WithObserver(serviceMetrics.ObserveRequest),
+		rest.WithHandler("/healthz", checker.LivenessHandler()),
+		rest.WithHandler("/readyz", checker.ReadinessHandler()),
+		rest.WithHandler("/metrics", serviceMetrics.Handler()),
+	)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	httpServer := &http.Server{
+		Addr:              ":" + config.Config.Application.Port,
+		Handler:           restServer.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal(err)
+		}
+	}()
+
+	waitForSignal()
+	logger.Info("stopping application")
+
+	// stop taking requests and let the ones in flight finish, then stop the background workers
+	// before the deferred calls close the connections they use
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		logger.Error(err)
+	}
+	grpcServer.GracefulStop()
+	cancel()
+	workers.Wait()
 }
 
-func keepRunning() {
-	// Create a channel to listen for OS signals.
-	signals := make(chan os.Signal, 1)
+// newAuthenticator accepts the API keys issued to tenants, and bearer tokens
+// when a JSON Web Key Set is configured.
+func newAuthenticator(logger *utils.Logger, tenants authn.Tenants) (*authn.Authenticator, error) {
+	cfg := config.Config.Auth
+	opts := []authn.Option{authn.WithAPIKeys(authn.NewAPIKeyAuthenticator(logger, tenants))}
+	if cfg.PlatformTenantID != "" {
+		opts = append(opts, authn.WithPlatformTenant(cfg.PlatformTenantID))
+	} else {
+		logger.Info("no platform tenant configured, tenants and platform roles cannot be managed through the api")
+	}
 
-	// Notify the channel when the application receives a SIGINT or SIGTERM signal.
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	var keys authn.KeySet
+	switch {
+	case cfg.JWKSFile != "":
+		keySet, err := authn.LoadKeySetFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = keySet
+	case cfg.JWKSURL != "":
+		var keySetOpts []authn.KeySetOption
+		if cfg.JWKSRefreshInterval > 0 {
+			keySetOpts = append(keySetOpts, authn.WithRefreshInterval(cfg.JWKSRefreshInterval))
+		}
+		keys = authn.NewRemoteKeySet(cfg.JWKSURL, keySetOpts...)
+	default:
+		logger.Info("no jwks configured, bearer tokens are not accepted")
+	}
 
-	// Wait for the OS signal to stop the application.
-	<-signals
+	if keys != nil {
+		jwtOpts := []authn.JWTOption{authn.WithIssuer(cfg.Issuer), authn.WithAudience(cfg.Audience)}
+		if cfg.TenantClaim != "" {
+			jwtOpts = append(jwtOpts, authn.WithTenantClaim(cfg.TenantClaim))
+		}
+		opts = append(opts, authn.WithJWT(authn.NewJWTAuthenticator(logger, keys, tenants, jwtOpts...)))
+	}
+
+	return authn.NewAuthenticator(logger, opts...), nil
+}
+
+// newLimiter limits the requests of tenants according to their subscription plan,
+// it returns nil when rate limiting is disabled.
+func newLimiter(ctx context.Context, logger *utils.Logger, db *mongo.DB) (*ratelimit.Limiter, error) {
+	cfg := config.Config.RateLimit
+	if !cfg.Enabled {
+		logger.Info("rate limiting is disabled")
+		return nil, nil
+	}
 
-	fmt.Println("Stopping application...")
-	// Perform any necessary cleanup or shutdown tasks here.
+	var store ratelimit.Store
+	switch cfg.Backend {
+	case "", "memory":
+		store = ratelimit.NewMemoryStore()
+	case "mongo":
+		if db == nil {
+			logger.Infof("rate limits are kept in memory with the %s backend", config.Config.DB.Backend)
+			store = ratelimit.NewMemoryStore()
+			break
+		}
+		mongoStore := ratelimit.NewMongoStore(db.Database.Collection("rate_limits"))
+		if err := mongoStore.CreateIndexes(ctx); err != nil {
+			return nil, err
+		}
+		store = mongoStore
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.Backend)
+	}
+
+	quota := func(q config.RateLimitQuota) ratelimit.Quota {
+		return ratelimit.Quota{
+			Tenant: ratelimit.Limit(q.Tenant),
+			APIKey: ratelimit.Limit(q.APIKey),
+		}
+	}
+
+	opts := []ratelimit.Option{ratelimit.WithDefaultQuota(quota(cfg.Default))}
+	if cfg.Mode != "" {
+		opts = append(opts, ratelimit.WithMode(ratelimit.Mode(cfg.Mode)))
+	}
+	for plan, q := range cfg.Plans {
+		opts = append(opts, ratelimit.WithPlan(plan, quota(q)))
+	}
+
+	return ratelimit.NewLimiter(logger, store, opts...)
+}
 
-	// Exit the application.
-	os.Exit(0)
+// storage are the tenant and roles repositories of the configured backend.
+type storage struct {
+	tenants repository.TenantRepository
+	roles   repository.RolesRepository
+	// ping checks that the database is reachable, it is nil with mongo which is checked on its own.
+	ping health.Check
+	// close closes the connections of the repositories.
+	close func()
+}
+
+// newRepositories returns the repositories of the configured backend. Changes are only audited
+// and published through the outbox with the mongo backend.
+func newRepositories(
+	ctx context.Context, logger *utils.Logger, db *mongo.DB, opts ...repositories.Option,
+) (*storage, error) {
+	switch backend := config.Config.DB.Backend; backend {
+	case "", config.MongoBackend:
+		return &storage{
+			tenants: repositories.NewTenantRepository(db.Tenant, logger, opts...),
+			roles:   repositories.NewRolesRepository(db.RBAC, logger, opts...),
+			close:   func() {},
+		}, nil
+	case config.PostgresBackend:
+		cfg := config.Config.DB.Postgres
+		pool, err := postgres.NewPostgresDB(ctx, logger, cfg.URL, cfg.MaxConns)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Info("tenants and roles are stored in postgres, changes are neither audited nor published")
+		return &storage{
+			tenants: pgrepositories.NewTenantRepository(pool, logger),
+			roles:   pgrepositories.NewRolesRepository(pool, logger),
+			ping:    pool.Ping,
+			close:   pool.Close,
+		}, nil
+	case config.SQLiteBackend:
+		path := config.Config.DB.SQLite.Path
+		if path == "" {
+			path = "tenant-management.db"
+		}
+		sqliteDB, err := sqlite.NewSQLiteDB(ctx, logger, path)
+		if err != nil {
+			return nil, err
+		}
+
+		logger.Info("tenants and roles are stored in sqlite, changes are neither audited nor published")
+		return &storage{
+			tenants: sqliterepositories.NewTenantRepository(sqliteDB, logger),
+			roles:   sqliterepositories.NewRolesRepository(sqliteDB, logger),
+			ping:    sqliteDB.PingContext,
+			close:   func() { _ = sqliteDB.Close() },
+		}, nil
+	default:
+		return nil, errors.Errorf("unknown database backend %q", backend)
+	}
+}
+
+// reconcileIndexes brings the indexes of the tenants collection in line with their declaration.
+func reconcileIndexes(ctx context.Context, logger *utils.Logger, db *mongo.DB) error {
+	if !config.Config.DB.IsMongo() {
+		return nil
+	}
+
+	var opts []repositories.IndexOption
+	if config.Config.DB.Indexes.DropStale {
+		opts = append(opts, repositories.WithDropStale())
+	}
+	if config.Config.DB.Indexes.RebuildDrifted {
+		opts = append(opts, repositories.WithRebuildDrifted())
+	}
+
+	report, err := repositories.ReconcileIndexes(ctx, db.Tenant, repositories.TenantIndexes, opts...)
+	if err != nil {
+		return err
+	}
+	logger.Info(report.String())
+
+	return nil
+}
+
+// newMigrator returns the migrator of tenant documents, it returns nil unless it runs on startup.
+func newMigrator(logger *utils.Logger, db *mongo.DB) *repositories.Migrator {
+	cfg := config.Config.Migrations
+	if !cfg.RunOnStartup {
+		return nil
+	}
+	if !config.Config.DB.IsMongo() {
+		logger.Infof("migrating tenant documents is unavailable with the %s backend", config.Config.DB.Backend)
+		return nil
+	}
+
+	opts := []repositories.MigratorOption{repositories.WithBatchSize(cfg.BatchSize)}
+	if cfg.DryRun {
+		opts = append(opts, repositories.WithDryRun())
+	}
+
+	return repositories.NewMigrator(db.Tenant, db.Database.Collection("migrations"), migrations.Tenants, logger, opts...)
+}
+
+// newOutbox returns the outbox domain events are written to, it returns nil when events are disabled.
+func newOutbox(ctx context.Context, logger *utils.Logger, db *mongo.DB) (*repositories.OutboxRepository, error) {
+	if !config.Config.Events.Enabled {
+		logger.Info("domain events are disabled")
+		return nil, nil
+	}
+
+	outbox := repositories.NewOutboxRepository(db.Database.Collection("outbox"), logger)
+	if err := outbox.CreateIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	return outbox, nil
+}
+
+// newTenantCache returns the cache of tenant lookups in front of tenants, it returns nil when
+// caching is disabled.
+func newTenantCache(logger *utils.Logger, tenants repository.TenantRepository) *cache.TenantRepository {
+	cfg := config.Config.Cache
+	if !cfg.Enabled {
+		logger.Info("tenant cache is disabled")
+		return nil
+	}
+
+	var opts []cache.Option
+	if cfg.Capacity > 0 {
+		opts = append(opts, cache.WithCapacity(cfg.Capacity))
+	}
+	if cfg.TTL > 0 {
+		opts = append(opts, cache.WithTTL(cfg.TTL))
+	}
+	if cfg.NegativeTTL > 0 {
+		opts = append(opts, cache.WithNegativeTTL(cfg.NegativeTTL))
+	}
+
+	return cache.NewTenantRepository(tenants, logger, opts...)
+}
+
+// newWatcher returns the watcher projecting the changes to tenants and roles into the tenant
+// directory and dropping changed tenants from caches, it returns nil when watching changes is disabled.
+func newWatcher(
+	ctx context.Context, logger *utils.Logger, db *mongo.DB, caches ...repositories.Invalidator,
+) (*repositories.Watcher, error) {
+	cfg := config.Config.Changes
+	if !cfg.Enabled {
+		logger.Info("watching changes is disabled")
+		return nil, nil
+	}
+	if !config.Config.DB.IsMongo() {
+		logger.Infof("watching changes is unavailable with the %s backend", config.Config.DB.Backend)
+		return nil, nil
+	}
+
+	directory := repositories.NewTenantDirectoryRepository(db.Database.Collection("tenant_directory"), db.RBAC, logger)
+	if err := directory.CreateIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	var opts []repositories.WatcherOption
+	if cfg.PollInterval > 0 {
+		opts = append(opts, repositories.WithPollInterval(cfg.PollInterval))
+	}
+
+	tokens := repositories.NewResumeTokenRepository(db.Database.Collection("resume_tokens"))
+	watcher := repositories.NewWatcher(logger, tokens, opts...)
+	tenantHandlers := []repositories.Handler{directory.HandleTenant}
+	for _, tenantCache := range caches {
+		tenantHandlers = append(tenantHandlers, repositories.Invalidate(tenantCache))
+	}
+	watcher.Watch(db.Tenant, tenantHandlers...)
+	watcher.Watch(db.RBAC, directory.HandleRole)
+
+	return watcher, nil
+}
+
+// newWebhookService returns the service managing webhook endpoints and delivering events to them.
+func newWebhookService(
+	ctx context.Context, logger *utils.Logger, db *mongo.DB, tenants repository.TenantRepository,
+) (*service.WebhookService, error) {
+	cfg := config.Config.Webhooks
+
+	repository := repositories.NewWebhookRepository(
+		db.Database.Collection("webhooks"), db.Database.Collection("webhook_deliveries"), logger,
+	)
+	if err := repository.CreateIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	var senderOpts []webhooks.Option
+	if cfg.AllowPrivateNetworks {
+		logger.Info("webhook endpoints on private networks are allowed")
+		senderOpts = append(senderOpts, webhooks.WithPrivateNetworks())
+	}
+
+	webhookService := service.NewWebhookService(logger, repository, tenants, webhooks.NewSender(logger, senderOpts...))
+	webhookService.AllowHTTP = cfg.AllowHTTP
+	if cfg.MaxAttempts > 0 {
+		webhookService.MaxAttempts = cfg.MaxAttempts
+	}
+
+	return webhookService, nil
+}
+
+// newRelay publishes the events of the outbox with the configured publisher, and hands them to
+// the given handlers once the publisher accepted them.
+// The returned function releases the connection of the publisher.
+func newRelay(
+	logger *utils.Logger, outbox *repositories.OutboxRepository, handlers ...messaging.Handler,
+) (*messaging.Relay, func(), error) {
+	cfg := config.Config.Events
+
+	var (
+		publisher messaging.Publisher
+		closer    = func() {}
+	)
+	switch cfg.Publisher {
+	case "", "memory":
+		publisher = messaging.NewMemoryPublisher()
+	case "nats":
+		conn, err := nats.Connect(cfg.NATS.URL)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to connect to nats")
+		}
+		js, err := conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, nil, errors.Wrap(err, "failed to connect to jetstream")
+		}
+
+		var opts []messaging.NATSOption
+		if cfg.NATS.SubjectPrefix != "" {
+			opts = append(opts, messaging.WithSubjectPrefix(cfg.NATS.SubjectPrefix))
+		}
+		publisher = messaging.NewNATSPublisher(js, opts...)
+		closer = conn.Close
+	case "kafka":
+		writer := &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Kafka.Brokers...),
+			Topic:        cfg.Kafka.Topic,
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+		}
+		publisher = messaging.NewKafkaPublisher(writer)
+		closer = func() {
+			if err := writer.Close(); err != nil {
+				logger.Error(err)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown event publisher %q", cfg.Publisher)
+	}
+
+	var opts []messaging.RelayOption
+	if cfg.Relay.Interval > 0 {
+		opts = append(opts, messaging.WithInterval(cfg.Relay.Interval))
+	}
+	if cfg.Relay.BatchSize > 0 {
+		opts = append(opts, messaging.WithBatchSize(cfg.Relay.BatchSize))
+	}
+	if cfg.Relay.Lease > 0 {
+		opts = append(opts, messaging.WithLease(cfg.Relay.Lease))
+	}
+
+	fanout := messaging.Fanout{publisher}
+	for _, handler := range handlers {
+		fanout = append(fanout, handler)
+	}
+
+	return messaging.NewRelay(logger, outbox, fanout, opts...), closer, nil
+}
+
+// waitForSignal blocks until the application receives a SIGINT or SIGTERM signal.
+func waitForSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	<-signals
 }
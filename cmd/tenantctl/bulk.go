@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hebecoding/tenant-management/infrastructure/bulk"
+	"github.com/pkg/errors"
+)
+
+func importTenants(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	format := flags.String("format", "", "ndjson or csv, by default csv for .csv files and ndjson otherwise")
+	mode := flags.String("mode", string(bulk.InsertOnly), "insert fails existing tenants, upsert updates them")
+	dryRun := flags.Bool("dry-run", false, "only validate and report what would be imported")
+	maxErrors := flags.Int("max-errors", 0, "stop after more records failed, 0 imports every record")
+	errorsPath := flags.String("errors", "", "write the failed records as JSON Lines to a file instead of standard error")
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+	bulkFormat, err := fileFormat(*format, path)
+	if err != nil {
+		return err
+	}
+	bulkMode, err := bulk.ParseMode(*mode)
+	if err != nil {
+		return err
+	}
+
+	in, err := openInput(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	reader, err := bulk.NewReader(bulkFormat, in)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	onError := func(e bulk.RecordError) { writeRecordError(a.stderr, e) }
+	if *errorsPath != "" {
+		out, err := os.Create(*errorsPath)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", *errorsPath)
+		}
+		defer out.Close()
+
+		encoder := json.NewEncoder(out)
+		onError = func(e bulk.RecordError) { _ = encoder.Encode(e) }
+	}
+
+	c, err := a.newDirect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	opts := []bulk.Option{bulk.WithMode(bulkMode), bulk.WithMaxErrors(*maxErrors), bulk.WithErrorHandler(onError)}
+	if *dryRun {
+		opts = append(opts, bulk.WithDryRun())
+	}
+
+	report, err := bulk.NewImporter(c.tenantService, c.logger, opts...).Import(c.attribute(ctx), reader)
+	if writeErr := write(a.stdout, a.output, report, func() table { return importTable(report) }); writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		return err
+	}
+	if report.Failed > 0 {
+		return errors.Errorf("%d of %d records failed", report.Failed, report.Read)
+	}
+
+	return nil
+}
+
+func exportTenants(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	format := flags.String("format", "", "ndjson or csv, by default csv for .csv files and ndjson otherwise")
+	status := flags.String("status", "", "only export active or suspended tenants")
+	path := flags.String("f", "-", "file to export to, - writes standard output")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	bulkFormat, err := fileFormat(*format, *path)
+	if err != nil {
+		return err
+	}
+	filter := map[string]interface{}{}
+	switch *status {
+	case "":
+	case "active", "suspended":
+		filter["is_active"] = *status == "active"
+	default:
+		return errors.Errorf("unknown status %q, expected active or suspended", *status)
+	}
+
+	c, err := a.newDirect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	out := a.stdout
+	if *path != "-" {
+		file, err := os.Create(*path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create %s", *path)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer, err := bulk.NewWriter(bulkFormat, out)
+	if err != nil {
+		return err
+	}
+	exported, err := bulk.Export(ctx, c.tenants, filter, writer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "%d tenants exported\n", exported)
+	return nil
+}
+
+// fileFormat returns the format named by a flag, or else the format of a file by its extension.
+func fileFormat(name string, path string) (bulk.Format, error) {
+	if name != "" {
+		return bulk.ParseFormat(name)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return bulk.CSV, nil
+	}
+
+	return bulk.NDJSON, nil
+}
+
+// openInput opens a file, the path - reads standard input.
+func openInput(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+
+	file, err := os.Open(path)
+	return file, errors.Wrapf(err, "failed to open %s", path)
+}
+
+func writeRecordError(w io.Writer, e bulk.RecordError) {
+	tenant := e.Subdomain
+	if tenant == "" {
+		tenant = e.TenantID
+	}
+	if tenant != "" {
+		tenant = " (" + tenant + ")"
+	}
+
+	fmt.Fprintf(w, "record %d on line %d%s: %s\n", e.Record, e.Line, tenant, e.Message)
+	for _, field := range e.Errors {
+		fmt.Fprintf(w, "  %s: %s\n", field.Field, field.Message)
+	}
+}
+
+func importTable(report bulk.ImportReport) table {
+	return table{
+		header: []string{"READ", "CREATED", "UPDATED", "FAILED", "DRY RUN"},
+		rows: [][]string{
+			{
+				strconv.Itoa(report.Read), strconv.Itoa(report.Created), strconv.Itoa(report.Updated),
+				strconv.Itoa(report.Failed), strconv.FormatBool(report.DryRun),
+			},
+		},
+	}
+}
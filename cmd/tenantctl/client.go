@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/config"
+	"github.com/hebecoding/tenant-management/infrastructure/database/mongo"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// client is what commands operate the service through, either directly on its database or
+// through its HTTP API.
+type client interface {
+	// ListTenants lists the tenants in status, active or suspended, or every tenant when status is empty.
+	ListTenants(ctx context.Context, status string) ([]*entities.Tenant, error)
+	GetTenant(ctx context.Context, id string) (*entities.Tenant, error)
+	CreateTenant(ctx context.Context, tenant *entities.Tenant) error
+	UpdateTenant(ctx context.Context, tenant *entities.Tenant) error
+	// SuspendTenant deactivates a tenant, it is the soft delete of the service.
+	SuspendTenant(ctx context.Context, id string) error
+	// PurgeTenant removes a tenant and its custom roles for good.
+	PurgeTenant(ctx context.Context, id string) error
+
+	ListRoles(ctx context.Context) ([]*entities.Role, error)
+	GetRole(ctx context.Context, id string) (*entities.Role, error)
+	// CreateRole creates a platform role, or a custom role when it belongs to a tenant.
+	CreateRole(ctx context.Context, role *entities.Role) error
+	UpdateRole(ctx context.Context, role *entities.Role) error
+	DeleteRole(ctx context.Context, id string) error
+
+	Close()
+}
+
+// connect returns the client of the HTTP API when a server is given, and of the database otherwise.
+func (a *app) connect(ctx context.Context) (client, error) {
+	if a.server != "" {
+		return newHTTPClient(a.server, a.token, a.apiKey), nil
+	}
+
+	return a.newDirect(ctx)
+}
+
+// directClient operates the service directly on its mongo database, changes are audited and
+// published like the ones made by the service.
+type directClient struct {
+	db      *mongo.DB
+	logger  *utils.Logger
+	actor   string
+	tenants *repositories.TenantRepository
+	roles   *repositories.RolesRepository
+
+	tenantService *service.TenantService
+	roleService   service.RoleService
+}
+
+func (a *app) connectDirect(ctx context.Context) (*directClient, error) {
+	if a.server != "" {
+		return nil, errors.New("this command needs direct access to the database, it is unavailable with --server")
+	}
+
+	level := "warn"
+	if a.verbose {
+		level = "info"
+	}
+	logger := utils.NewLogger(
+		utils.Config{
+			Level:            level,
+			Encoding:         "console",
+			OutputPaths:      []string{"stderr"},
+			ErrorOutputPaths: []string{"stderr"},
+		},
+	)
+
+	if err := config.ReadInConfig(logger); err != nil {
+		if a.mongoURL == "" {
+			return nil, err
+		}
+		config.Config = &config.Configurations{}
+	}
+	if a.mongoURL != "" {
+		config.Config.DB.URL = a.mongoURL
+	}
+	if !config.Config.DB.IsMongo() {
+		return nil, errors.Errorf("direct access is unavailable with the %s backend, use --server", config.Config.DB.Backend)
+	}
+
+	db, err := mongo.NewMongoDB(ctx, logger, config.Config.DB.URL, "tenant-management", "tenants", "rbac")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Client.Ping(ctx, nil); err != nil {
+		_ = db.Client.Disconnect(context.Background())
+		return nil, errors.Wrap(err, "failed to reach mongo")
+	}
+
+	auditRepository := repositories.NewAuditRepository(db.Database.Collection("audit"), logger)
+	opts := []repositories.Option{repositories.WithAuditTrail(auditRepository)}
+	if config.Config.Events.Enabled {
+		opts = append(opts, repositories.WithOutbox(repositories.NewOutboxRepository(db.Database.Collection("outbox"), logger)))
+	}
+
+	tenants := repositories.NewTenantRepository(db.Tenant, logger, opts...)
+	roles := repositories.NewRolesRepository(db.RBAC, logger, opts...)
+
+	return &directClient{
+		db:            db,
+		logger:        logger,
+		actor:         a.actor,
+		tenants:       tenants,
+		roles:         roles,
+		tenantService: service.NewTenantService(logger, tenants),
+		roleService:   service.NewRoleService(logger, roles),
+	}, nil
+}
+
+// attribute attributes the changes made with ctx to the actor, changes without one are made
+// by the system.
+func (c *directClient) attribute(ctx context.Context) context.Context {
+	if c.actor == "" {
+		return ctx
+	}
+
+	return audit.WithActor(ctx, audit.Actor{ID: c.actor})
+}
+
+func (c *directClient) ListTenants(ctx context.Context, status string) ([]*entities.Tenant, error) {
+	filter := map[string]any{}
+	if status != "" {
+		filter["is_active"] = status == "active"
+	}
+
+	return c.tenantService.SearchTenants(ctx, filter)
+}
+
+func (c *directClient) GetTenant(ctx context.Context, id string) (*entities.Tenant, error) {
+	return c.tenantService.GetTenantByID(ctx, id)
+}
+
+func (c *directClient) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	return c.tenantService.CreateTenant(c.attribute(ctx), tenant)
+}
+
+func (c *directClient) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	return c.tenantService.UpdateTenant(c.attribute(ctx), tenant.ID, tenant)
+}
+
+func (c *directClient) SuspendTenant(ctx context.Context, id string) error {
+	return c.tenantService.DeleteTenant(c.attribute(ctx), id)
+}
+
+func (c *directClient) PurgeTenant(ctx context.Context, id string) error {
+	ctx = c.attribute(ctx)
+	if err := c.tenants.PurgeTenant(ctx, id); err != nil {
+		return err
+	}
+
+	roles, err := c.roles.FindAllRoles(ctx)
+	if err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if role.TenantID != id {
+			continue
+		}
+		if err := c.roles.DeleteRole(ctx, utils.XID{ID: role.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *directClient) ListRoles(ctx context.Context) ([]*entities.Role, error) {
+	return c.roleService.GetRoles(ctx)
+}
+
+func (c *directClient) GetRole(ctx context.Context, id string) (*entities.Role, error) {
+	return c.roleService.GetRole(ctx, utils.XID{ID: id})
+}
+
+func (c *directClient) CreateRole(ctx context.Context, role *entities.Role) error {
+	if role.IsCustom() {
+		// custom roles can only be created for existing tenants
+		if _, err := c.tenantService.GetTenantByID(ctx, role.TenantID); err != nil {
+			return err
+		}
+		return c.roleService.CreateCustomRole(c.attribute(ctx), role)
+	}
+
+	return c.roleService.CreateRole(c.attribute(ctx), role)
+}
+
+func (c *directClient) UpdateRole(ctx context.Context, role *entities.Role) error {
+	return c.roleService.UpdateRole(c.attribute(ctx), role)
+}
+
+func (c *directClient) DeleteRole(ctx context.Context, id string) error {
+	return c.roleService.DeleteRole(c.attribute(ctx), utils.XID{ID: id})
+}
+
+func (c *directClient) Close() {
+	if err := c.db.Client.Disconnect(context.Background()); err != nil {
+		c.logger.Error(err)
+	}
+	_ = c.logger.Sync()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectClient_ListTenants(t *testing.T) {
+	ctx := context.Background()
+	logger := utils.NewLogger(
+		utils.Config{Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"}},
+	)
+	tenants := memory.NewTenantRepository(logger)
+	for _, tenant := range []*entities.Tenant{
+		{ID: "tenant-1", Name: "Acme", Subdomain: "acme", IsActive: true},
+		{ID: "tenant-2", Name: "Globex", Subdomain: "globex"},
+	} {
+		require.NoError(t, tenants.CreateTenant(ctx, tenant))
+	}
+	c := &directClient{logger: logger, tenantService: service.NewTenantService(logger, tenants)}
+
+	var testCases = []struct {
+		Name        string
+		Status      string
+		ExpectedIDs []string
+	}{
+		{
+			Name:        "Happy Path: every tenant",
+			ExpectedIDs: []string{"tenant-1", "tenant-2"},
+		},
+		{
+			Name:        "Happy Path: active tenants",
+			Status:      "active",
+			ExpectedIDs: []string{"tenant-1"},
+		},
+		{
+			Name:        "Happy Path: suspended tenants",
+			Status:      "suspended",
+			ExpectedIDs: []string{"tenant-2"},
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				listed, err := c.ListTenants(ctx, tt.Status)
+				require.NoError(t, err)
+
+				ids := make([]string, 0, len(listed))
+				for _, tenant := range listed {
+					ids = append(ids, tenant.ID)
+				}
+				assert.ElementsMatch(t, tt.ExpectedIDs, ids)
+			},
+		)
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps tenants and roles in memory, copies are handed out like a remote service would.
+type fakeClient struct {
+	tenants map[string]*entities.Tenant
+	roles   map[string]*entities.Role
+	purged  []string
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		tenants: map[string]*entities.Tenant{
+			"tenant-1": {
+				ID: "tenant-1", Name: "Acme", Subdomain: "acme", IsActive: true,
+				PrimaryContacts: []*entities.TenantContactDetails{
+					{ID: "contact-1", FirstName: "Ada", LastName: "Lovelace", Email: "ada@acme.io"},
+				},
+			},
+			"tenant-2": {ID: "tenant-2", Name: "Globex", Subdomain: "globex"},
+		},
+		roles: map[string]*entities.Role{
+			"admin":   {ID: "admin", Name: "Admin", Permissions: []entities.Permission{entities.ReadPermission}},
+			"auditor": {ID: "auditor", TenantID: "tenant-1", Name: "Auditor"},
+			"billing": {ID: "billing", TenantID: "tenant-2", Name: "Billing"},
+		},
+	}
+}
+
+func clone[T any](v *T) *T {
+	encoded, _ := json.Marshal(v)
+	var c T
+	_ = json.Unmarshal(encoded, &c)
+	return &c
+}
+
+func (f *fakeClient) ListTenants(_ context.Context, status string) ([]*entities.Tenant, error) {
+	var tenants []*entities.Tenant
+	for _, tenant := range f.tenants {
+		if status == "" || tenant.IsActive == (status == "active") {
+			tenants = append(tenants, clone(tenant))
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, nil
+}
+
+func (f *fakeClient) GetTenant(_ context.Context, id string) (*entities.Tenant, error) {
+	tenant, ok := f.tenants[id]
+	if !ok {
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+	return clone(tenant), nil
+}
+
+func (f *fakeClient) CreateTenant(_ context.Context, tenant *entities.Tenant) error {
+	tenant.ID = "tenant-" + tenant.Subdomain
+	f.tenants[tenant.ID] = clone(tenant)
+	return nil
+}
+
+func (f *fakeClient) UpdateTenant(_ context.Context, tenant *entities.Tenant) error {
+	f.tenants[tenant.ID] = clone(tenant)
+	return nil
+}
+
+func (f *fakeClient) SuspendTenant(_ context.Context, id string) error {
+	f.tenants[id].IsActive = false
+	return nil
+}
+
+func (f *fakeClient) PurgeTenant(_ context.Context, id string) error {
+	delete(f.tenants, id)
+	f.purged = append(f.purged, id)
+	return nil
+}
+
+func (f *fakeClient) ListRoles(context.Context) ([]*entities.Role, error) {
+	var roles []*entities.Role
+	for _, role := range f.roles {
+		roles = append(roles, clone(role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].ID < roles[j].ID })
+	return roles, nil
+}
+
+func (f *fakeClient) GetRole(_ context.Context, id string) (*entities.Role, error) {
+	role, ok := f.roles[id]
+	if !ok {
+		return nil, apperrors.ErrNoRoleDocumentsFound
+	}
+	return clone(role), nil
+}
+
+func (f *fakeClient) CreateRole(_ context.Context, role *entities.Role) error {
+	role.ID = strings.ToLower(role.Name)
+	f.roles[role.ID] = clone(role)
+	return nil
+}
+
+func (f *fakeClient) UpdateRole(_ context.Context, role *entities.Role) error {
+	f.roles[role.ID] = clone(role)
+	return nil
+}
+
+func (f *fakeClient) DeleteRole(_ context.Context, id string) error {
+	delete(f.roles, id)
+	return nil
+}
+
+func (f *fakeClient) Close() {}
+
+// runWith runs tenantctl against f, returning what it wrote to stdout.
+func runWith(f *fakeClient, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	a := &app{
+		stdout:    &stdout,
+		stderr:    &stderr,
+		newClient: func(context.Context) (client, error) { return f, nil },
+		newDirect: func(context.Context) (*directClient, error) { return nil, errors.New("no database") },
+	}
+
+	err := a.run(context.Background(), args)
+	return stdout.String(), err
+}
+
+func TestTenantCommands(t *testing.T) {
+	var testCases = []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput []string
+		ExpectedError  string
+		Check          func(t *testing.T, f *fakeClient)
+	}{
+		{
+			Name:           "Happy Path: List tenants",
+			Args:           []string{"tenants", "list"},
+			ExpectedOutput: []string{"ID", "tenant-1", "Acme", "active", "tenant-2", "suspended"},
+		},
+		{
+			Name:           "Happy Path: List suspended tenants",
+			Args:           []string{"-o", "json", "tenants", "list", "--status", "suspended"},
+			ExpectedOutput: []string{`"_id": "tenant-2"`},
+		},
+		{
+			Name: "Happy Path: Create tenant",
+			Args: []string{"tenants", "create", "--name", "Initech", "--subdomain", "initech"},
+			Check: func(t *testing.T, f *fakeClient) {
+				require.Contains(t, f.tenants, "tenant-initech")
+				assert.True(t, f.tenants["tenant-initech"].IsActive)
+			},
+		},
+		{
+			Name: "Happy Path: Suspend tenant",
+			Args: []string{"tenants", "suspend", "tenant-1"},
+			Check: func(t *testing.T, f *fakeClient) {
+				assert.False(t, f.tenants["tenant-1"].IsActive)
+			},
+		},
+		{
+			Name:           "Happy Path: Restore tenant",
+			Args:           []string{"-o", "yaml", "tenants", "restore", "tenant-2"},
+			ExpectedOutput: []string{"_id: tenant-2", "is_active: true"},
+			Check: func(t *testing.T, f *fakeClient) {
+				assert.True(t, f.tenants["tenant-2"].IsActive)
+			},
+		},
+		{
+			Name: "Happy Path: Purge tenant",
+			Args: []string{"tenants", "purge", "--confirm", "tenant-2", "tenant-2"},
+			Check: func(t *testing.T, f *fakeClient) {
+				assert.Equal(t, []string{"tenant-2"}, f.purged)
+			},
+		},
+		{
+			Name:          "Error Path: Purge tenant without confirmation",
+			Args:          []string{"tenants", "purge", "tenant-2"},
+			ExpectedError: "confirm with --confirm tenant-2",
+		},
+		{
+			Name:          "Error Path: Restore active tenant",
+			Args:          []string{"tenants", "restore", "tenant-1"},
+			ExpectedError: "tenant tenant-1 is not suspended",
+		},
+		{
+			Name:          "Error Path: Unknown import mode",
+			Args:          []string{"tenants", "import", "--mode", "merge", "tenants.csv"},
+			ExpectedError: `unknown mode "merge"`,
+		},
+		{
+			Name:          "Error Path: Exports need the database",
+			Args:          []string{"tenants", "export", "--status", "active"},
+			ExpectedError: "no database",
+		},
+		{
+			Name:          "Error Path: Unknown output format",
+			Args:          []string{"-o", "xml", "tenants", "list"},
+			ExpectedError: "unknown output format",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				f := newFakeClient()
+				output, err := runWith(f, tt.Args...)
+				if tt.ExpectedError != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.ExpectedError)
+					return
+				}
+
+				require.NoError(t, err)
+				for _, expected := range tt.ExpectedOutput {
+					assert.Contains(t, output, expected)
+				}
+				if tt.Check != nil {
+					tt.Check(t, f)
+				}
+			},
+		)
+	}
+}
+
+func TestRoleCommands(t *testing.T) {
+	var testCases = []struct {
+		Name           string
+		Args           []string
+		ExpectedOutput []string
+		ExpectedError  string
+		Check          func(t *testing.T, f *fakeClient)
+	}{
+		{
+			Name:           "Happy Path: List the roles of a tenant",
+			Args:           []string{"roles", "list", "--tenant", "tenant-1"},
+			ExpectedOutput: []string{"auditor"},
+			Check: func(t *testing.T, f *fakeClient) {
+				output, err := runWith(f, "roles", "list", "--tenant", "tenant-1")
+				require.NoError(t, err)
+				assert.NotContains(t, output, "billing")
+				assert.NotContains(t, output, "admin")
+			},
+		},
+		{
+			Name: "Happy Path: Create custom role",
+			Args: []string{"roles", "create", "--name", "Support", "--tenant", "tenant-1", "--permissions", "read, Edit"},
+			Check: func(t *testing.T, f *fakeClient) {
+				require.Contains(t, f.roles, "support")
+				assert.Equal(t, "tenant-1", f.roles["support"].TenantID)
+				assert.Equal(
+					t, []entities.Permission{entities.ReadPermission, entities.EditPermission}, f.roles["support"].Permissions,
+				)
+			},
+		},
+		{
+			Name: "Happy Path: Update role",
+			Args: []string{"roles", "update", "--description", "Reads everything", "auditor"},
+			Check: func(t *testing.T, f *fakeClient) {
+				assert.Equal(t, "Reads everything", f.roles["auditor"].Description)
+				assert.Equal(t, "tenant-1", f.roles["auditor"].TenantID)
+			},
+		},
+		{
+			Name:           "Happy Path: Assign role by contact email",
+			Args:           []string{"roles", "assign", "--tenant", "tenant-1", "--contact", "ADA@acme.io", "auditor"},
+			ExpectedOutput: []string{"contact-1", "Ada Lovelace", "Auditor"},
+			Check: func(t *testing.T, f *fakeClient) {
+				roles := f.tenants["tenant-1"].PrimaryContacts[0].Roles
+				require.Len(t, roles, 1)
+				assert.Equal(t, "auditor", roles[0].ID)
+
+				_, err := runWith(f, "roles", "unassign", "--tenant", "tenant-1", "--contact", "contact-1", "auditor")
+				require.NoError(t, err)
+				assert.Empty(t, f.tenants["tenant-1"].PrimaryContacts[0].Roles)
+			},
+		},
+		{
+			Name:          "Error Path: Assign custom role of another tenant",
+			Args:          []string{"roles", "assign", "--tenant", "tenant-1", "--contact", "contact-1", "billing"},
+			ExpectedError: "custom role of another tenant",
+		},
+		{
+			Name:          "Error Path: Unassign role not held",
+			Args:          []string{"roles", "unassign", "--tenant", "tenant-1", "--contact", "contact-1", "admin"},
+			ExpectedError: "does not hold role admin",
+		},
+		{
+			Name:          "Error Path: Unknown contact",
+			Args:          []string{"roles", "assign", "--tenant", "tenant-1", "--contact", "nobody", "admin"},
+			ExpectedError: "has no contact nobody",
+		},
+		{
+			Name:          "Error Path: Unknown permission",
+			Args:          []string{"roles", "create", "--name", "Support", "--permissions", "admin"},
+			ExpectedError: "unknown permission",
+		},
+		{
+			Name:          "Error Path: Migrations need the database",
+			Args:          []string{"migrate", "--dry-run"},
+			ExpectedError: "no database",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				f := newFakeClient()
+				output, err := runWith(f, tt.Args...)
+				if tt.ExpectedError != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.ExpectedError)
+					return
+				}
+
+				require.NoError(t, err)
+				for _, expected := range tt.ExpectedOutput {
+					assert.Contains(t, output, expected)
+				}
+				if tt.Check != nil {
+					tt.Check(t, f)
+				}
+			},
+		)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.Equal(t, 0, run(context.Background(), []string{"tenants", "help"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "tenants purge")
+
+	stderr.Reset()
+	assert.Equal(t, 1, run(context.Background(), []string{"tenants", "rename"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), `unknown command "rename"`)
+
+	stderr.Reset()
+	assert.Equal(t, 1, run(context.Background(), []string{"tenants", "get"}, &stdout, &stderr))
+	assert.Contains(t, stderr.String(), "Usage: tenantctl tenants get <id>")
+	assert.Empty(t, stdout.String())
+}
+
+func TestSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.ndjson")
+	args := []string{"-o", "json", "seed", "--seed", "42", "--epoch", "2024-01-01", "--tenants", "5", "--batch-size", "2",
+		"--companies", "2", "--out", path}
+
+	output, err := runWith(newFakeClient(), args...)
+	require.NoError(t, err)
+
+	var report seedReport
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	assert.Equal(t, int64(42), report.Seed)
+	assert.Equal(t, "2024-01-01", report.Epoch)
+	assert.Equal(t, 5, report.Tenants)
+	assert.Equal(t, 10, report.Companies)
+
+	first, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, bytes.Count(first, []byte("\n")))
+
+	_, err = runWith(newFakeClient(), args...)
+	require.NoError(t, err)
+	second, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	_, err = runWith(newFakeClient(), "seed", "--tenants", "1")
+	assert.EqualError(t, err, "no database")
+}
+
+func TestLoad(t *testing.T) {
+	output, err := runWith(
+		newFakeClient(), "-o", "json", "load", "--in-memory", "--seed", "42", "--tenants", "20", "--requests", "100",
+		"--concurrency", "2", "--mix", "get=1,search=1,update=1",
+	)
+	require.NoError(t, err)
+
+	var report loadReport
+	require.NoError(t, json.Unmarshal([]byte(output), &report))
+	assert.Equal(t, "memory", report.Target)
+	assert.Equal(t, 20, report.Seeded)
+	assert.Equal(t, 100, report.Requests)
+	assert.Zero(t, report.Errors)
+	assert.Len(t, report.Operations, 3)
+
+	output, err = runWith(newFakeClient(), "load", "--in-memory", "--tenants", "5", "--requests", "10", "--mix", "list")
+	require.NoError(t, err)
+	assert.Contains(t, output, "OPERATION")
+	assert.Contains(t, output, "total")
+
+	_, err = runWith(newFakeClient(), "load", "--tenants", "1")
+	assert.EqualError(t, err, "no database")
+
+	_, err = runWith(newFakeClient(), "load", "--in-memory", "--mix", "delete")
+	assert.ErrorContains(t, err, "unknown operation")
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/loadtest"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+)
+
+// loadReport is the report of a load run, with the tenants it ran on.
+type loadReport struct {
+	Seed    int64  `json:"seed"`
+	Target  string `json:"target"`
+	Tenants int    `json:"tenants"`
+	Seeded  int    `json:"seeded"`
+	*loadtest.Report
+}
+
+func load(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	inMemory := flags.Bool("in-memory", false, "run against an in-memory repository, the baseline of the service without storage")
+	count := flags.Int("tenants", 1000, "how many tenants to seed before the run, 0 runs on the existing active tenants")
+	seedValue := flags.Int64("seed", 0, "seed of the generated tenants and of the workload, 0 picks one")
+	mixValue := flags.String("mix", "get=70,list=2,search=18,update=8,create=2", "operations of the workload by weight")
+	concurrency := flags.Int("concurrency", 8, "how many operations run at once")
+	duration := flags.Duration("duration", 30*time.Second, "how long the workload runs")
+	requests := flags.Int("requests", 0, "stop after this many operations, before the duration is over")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	mix, err := loadtest.ParseMix(*mixValue)
+	if err != nil {
+		return err
+	}
+	if *count < 0 || *concurrency <= 0 || *duration <= 0 || *requests < 0 {
+		return errors.New("--tenants, --concurrency, --duration and --requests must be positive")
+	}
+
+	report := loadReport{Seed: *seedValue}
+	if report.Seed == 0 {
+		report.Seed = time.Now().UnixNano()
+	}
+
+	target, existing, done, err := loadTarget(ctx, a, *inMemory, *concurrency)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	var tenants []*entities.Tenant
+	if *count > 0 {
+		tenants = tests.NewGenerator(tests.WithSeed(report.Seed)).Tenants(*count)
+		for _, tenant := range tenants {
+			// seeded tenants must not collide with the tenants of earlier runs
+			tenant.Subdomain = "load-" + tenant.ID
+		}
+		if a.output == formatTable {
+			fmt.Fprintf(a.stderr, "seeding %d tenants\n", len(tenants))
+		}
+		if err := loadtest.Seed(ctx, target.target, tenants, *concurrency); err != nil {
+			return err
+		}
+		report.Seeded = len(tenants)
+	} else if tenants, err = existing(ctx); err != nil {
+		return err
+	}
+	report.Target = target.name
+	report.Tenants = len(tenants)
+
+	if a.output == formatTable {
+		fmt.Fprintf(a.stderr, "running on %d tenants for %s\n", len(tenants), *duration)
+	}
+	runner := loadtest.NewRunner(
+		target.target, tenants, loadtest.WithMix(mix), loadtest.WithConcurrency(*concurrency),
+		loadtest.WithDuration(*duration), loadtest.WithRequests(*requests), loadtest.WithSeed(report.Seed),
+	)
+	if report.Report, err = runner.Run(ctx); err != nil {
+		return err
+	}
+
+	if a.output == formatTable {
+		for _, operation := range report.Operations {
+			if operation.FirstError != "" {
+				fmt.Fprintf(a.stderr, "%d %s operations failed, the first with: %s\n", operation.Errors, operation.Operation, operation.FirstError)
+			}
+		}
+	}
+
+	return write(a.stdout, a.output, report, func() table { return loadTable(report.Report) })
+}
+
+// namedTarget is the target of a load run, named in its report.
+type namedTarget struct {
+	name   string
+	target loadtest.Target
+}
+
+// loadTarget returns what a load run operates on: the HTTP API when a server is given, an
+// in-memory repository, or else the repository of the database. Existing returns its active
+// tenants and done releases it.
+func loadTarget(ctx context.Context, a *app, inMemory bool, concurrency int) (
+	target namedTarget, existing func(context.Context) ([]*entities.Tenant, error), done func(), err error,
+) {
+	switch {
+	case a.server != "":
+		// connections are kept for every worker, the run would otherwise measure handshakes
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.MaxIdleConnsPerHost = concurrency
+		client := &http.Client{Timeout: 30 * time.Second, Transport: transport}
+		target = namedTarget{name: a.server, target: loadtest.NewHTTPTarget(a.server, client, loadtest.HTTPHeader(a.token, a.apiKey))}
+		api := newHTTPClient(a.server, a.token, a.apiKey)
+		existing := func(ctx context.Context) ([]*entities.Tenant, error) { return api.ListTenants(ctx, "active") }
+		return target, existing, transport.CloseIdleConnections, nil
+	case inMemory:
+		logger := utils.NewLogger(
+			utils.Config{Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"}},
+		)
+		tenants := memory.NewTenantRepository(logger)
+		return namedTarget{name: "memory", target: loadtest.NewRepositoryTarget(tenants)}, tenants.GetTenants, func() {}, nil
+	default:
+		c, err := a.newDirect(ctx)
+		if err != nil {
+			return namedTarget{}, nil, nil, err
+		}
+
+		// load is neither audited nor raises events, it would measure them instead of the storage
+		tenants := repositories.NewTenantRepository(c.db.Tenant, c.logger)
+		return namedTarget{name: "mongo", target: loadtest.NewRepositoryTarget(tenants)}, tenants.GetTenants, c.Close, nil
+	}
+}
+
+func loadTable(report *loadtest.Report) table {
+	t := table{header: []string{"OPERATION", "REQUESTS", "ERRORS", "OPS/S", "MEAN", "P50", "P90", "P95", "P99", "MAX"}}
+	row := func(name string, requests int, failed int, throughput float64, latency loadtest.Latency) []string {
+		return []string{
+			name, strconv.Itoa(requests), strconv.Itoa(failed), strconv.FormatFloat(throughput, 'f', 1, 64),
+			roundLatency(latency.Mean), roundLatency(latency.P50), roundLatency(latency.P90),
+			roundLatency(latency.P95), roundLatency(latency.P99), roundLatency(latency.Max),
+		}
+	}
+
+	for _, operation := range report.Operations {
+		t.rows = append(t.rows, row(string(operation.Operation), operation.Requests, operation.Errors, operation.Throughput, operation.Latency))
+	}
+	t.rows = append(t.rows, row("total", report.Requests, report.Errors, report.Throughput, report.Latency))
+
+	return t
+}
+
+// roundLatency rounds latencies so that tables stay readable.
+func roundLatency(latency time.Duration) string {
+	switch {
+	case latency >= time.Second:
+		return latency.Round(10 * time.Millisecond).String()
+	case latency >= time.Millisecond:
+		return latency.Round(10 * time.Microsecond).String()
+	default:
+		return latency.Round(10 * time.Nanosecond).String()
+	}
+}
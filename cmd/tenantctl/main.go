@@ -0,0 +1,202 @@
+// Command tenantctl operates the tenant service: it manages tenants and roles, runs the migrations
+// of tenant documents, reconciles indexes and measures the service under load.
+//
+// It talks to the HTTP API of a running service when a server is given, and directly to the
+// database configured in application.yaml otherwise. Purging tenants, migrations and indexes
+// need direct access to the database.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// errUsage reports invalid arguments, the usage of the command was already written.
+	errUsage = errors.New("invalid usage")
+	// errHelp reports that the usage of a command was asked for and written.
+	errHelp = errors.New("help requested")
+)
+
+// app holds the global flags of tenantctl and what commands write to.
+type app struct {
+	stdout io.Writer
+	stderr io.Writer
+
+	output   string
+	server   string
+	token    string
+	apiKey   string
+	mongoURL string
+	actor    string
+	timeout  time.Duration
+	verbose  bool
+
+	// newClient returns the client commands operate the service through, tests replace it.
+	newClient func(ctx context.Context) (client, error)
+	// newDirect returns the client with direct access to the database.
+	newDirect func(ctx context.Context) (*directClient, error)
+}
+
+// command is a command of tenantctl, or a subcommand of a group of commands.
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, a *app, usage string, args []string) error
+}
+
+var commands = map[string]command{
+	"tenants": {usage: "tenants <command>", summary: "list, create, suspend, restore and purge tenants", run: group(tenantCommands)},
+	"roles":   {usage: "roles <command>", summary: "manage roles and assign them to contacts", run: group(roleCommands)},
+	"migrate": {usage: "migrate [flags]", summary: "migrate tenant documents to the latest schema version", run: migrate},
+	"indexes": {usage: "indexes [flags]", summary: "reconcile the indexes of the tenants collection", run: indexes},
+	"seed":    {usage: "seed [flags]", summary: "generate realistic tenants into the database or a file", run: seed},
+	"load":    {usage: "load [flags]", summary: "run a mixed workload and report throughput and latencies", run: load},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs tenantctl with args and returns its exit code.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr}
+	a.newClient = a.connect
+	a.newDirect = a.connectDirect
+
+	if err := a.run(ctx, args); err != nil {
+		if errors.Is(err, errHelp) {
+			return 0
+		}
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(stderr, "tenantctl:", err)
+		}
+		return 1
+	}
+
+	return 0
+}
+
+func (a *app) run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("tenantctl", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.StringVar(&a.output, "o", formatTable, "output format: table, json or yaml")
+	flags.StringVar(&a.server, "server", os.Getenv("TENANTCTL_SERVER"), "URL of the HTTP API, the database is used directly when empty")
+	flags.StringVar(&a.token, "token", os.Getenv("TENANTCTL_TOKEN"), "bearer token authenticating requests to the HTTP API")
+	flags.StringVar(&a.apiKey, "api-key", os.Getenv("TENANTCTL_API_KEY"), "API key authenticating requests to the HTTP API")
+	flags.StringVar(&a.mongoURL, "mongo-url", "", "mongo connection string, overriding the configured one")
+	flags.StringVar(&a.actor, "actor", "", "contact ID changes made directly in the database are attributed to")
+	flags.DurationVar(&a.timeout, "timeout", 0, "time limit of the command, unlimited when zero")
+	flags.BoolVar(&a.verbose, "v", false, "log what is done to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(a.stderr, "Usage: tenantctl [flags] <command> [flags] [arguments]")
+		writeCommands(a.stderr, commands)
+		fmt.Fprintln(a.stderr, "\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return errHelp
+		}
+		return errUsage
+	}
+	if err := checkFormat(a.output); err != nil {
+		return err
+	}
+
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	return dispatch(ctx, a, commands, flags.Args(), flags.Usage)
+}
+
+// group returns a command running the subcommands of a group.
+func group(subcommands map[string]command) func(ctx context.Context, a *app, usage string, args []string) error {
+	return func(ctx context.Context, a *app, usage string, args []string) error {
+		return dispatch(
+			ctx, a, subcommands, args, func() {
+				fmt.Fprintf(a.stderr, "Usage: tenantctl [flags] %s [flags] [arguments]\n", usage)
+				writeCommands(a.stderr, subcommands)
+			},
+		)
+	}
+}
+
+func dispatch(ctx context.Context, a *app, commands map[string]command, args []string, usage func()) error {
+	if len(args) == 0 || args[0] == "help" {
+		usage()
+		if len(args) == 0 {
+			return errUsage
+		}
+		return errHelp
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(a.stderr, "unknown command %q\n", args[0])
+		usage()
+		return errUsage
+	}
+
+	return cmd.run(ctx, a, cmd.usage, args[1:])
+}
+
+func writeCommands(w io.Writer, commands map[string]command) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", commands[name].usage, commands[name].summary)
+	}
+	_ = tw.Flush()
+}
+
+// newFlagSet returns the flag set of a command, its usage lists the flags below the usage line.
+func newFlagSet(a *app, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(usage, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: tenantctl %s\n", usage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parse parses the flags of a command, which expects count positional arguments.
+func parse(flags *flag.FlagSet, args []string, count int) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return errHelp
+		}
+		return errUsage
+	}
+	if flags.NArg() != count {
+		flags.Usage()
+		return errUsage
+	}
+
+	return nil
+}
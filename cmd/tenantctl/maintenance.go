@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+)
+
+func migrate(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	dryRun := flags.Bool("dry-run", false, "only report what would be migrated")
+	batchSize := flags.Int("batch-size", 0, "how many tenants are migrated between checkpoints")
+	status := flags.Bool("status", false, "list the recorded migrations instead of running them")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	c, err := a.newDirect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	opts := []repositories.MigratorOption{repositories.WithBatchSize(*batchSize)}
+	if *dryRun {
+		opts = append(opts, repositories.WithDryRun())
+	}
+	if a.output == formatTable {
+		opts = append(
+			opts, repositories.WithProgress(
+				func(p repositories.MigrationProgress) {
+					fmt.Fprintf(a.stderr, "%d of %d tenants migrated, %d failed\n", p.Migrated, p.Pending, p.Failed)
+				},
+			),
+		)
+	}
+	migrator := repositories.NewMigrator(
+		c.db.Tenant, c.db.Database.Collection("migrations"), migrations.Tenants, c.logger, opts...,
+	)
+
+	if *status {
+		records, err := migrator.Applied(ctx)
+		if err != nil {
+			return err
+		}
+
+		return write(a.stdout, a.output, records, func() table { return migrationRecordTable(records) })
+	}
+
+	progress, err := migrator.Run(ctx)
+	if writeErr := write(a.stdout, a.output, progress, func() table { return migrationProgressTable(progress) }); writeErr != nil {
+		return writeErr
+	}
+
+	return err
+}
+
+func indexes(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	reportOnly := flags.Bool("report-only", false, "only report the differences with the declared indexes")
+	dropStale := flags.Bool("drop-stale", false, "drop the indexes that are not declared")
+	rebuildDrifted := flags.Bool("rebuild-drifted", false, "drop the indexes differing from their declaration and create them again")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	c, err := a.newDirect(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var opts []repositories.IndexOption
+	if *reportOnly {
+		opts = append(opts, repositories.WithReportOnly())
+	}
+	if *dropStale {
+		opts = append(opts, repositories.WithDropStale())
+	}
+	if *rebuildDrifted {
+		opts = append(opts, repositories.WithRebuildDrifted())
+	}
+
+	report, err := c.tenants.ReconcileIndexes(ctx, opts...)
+	if report == nil {
+		return err
+	}
+	if writeErr := write(a.stdout, a.output, report, func() table { return indexTable(report) }); writeErr != nil {
+		return writeErr
+	}
+
+	return err
+}
+
+func migrationProgressTable(p repositories.MigrationProgress) table {
+	return table{
+		header: []string{"TARGET", "PENDING", "MIGRATED", "SKIPPED", "FAILED", "DRY RUN"},
+		rows: [][]string{
+			{
+				strconv.Itoa(p.Target), strconv.FormatInt(p.Pending, 10), strconv.FormatInt(p.Migrated, 10),
+				strconv.FormatInt(p.Skipped, 10), strconv.FormatInt(p.Failed, 10), strconv.FormatBool(p.DryRun),
+			},
+		},
+	}
+}
+
+func migrationRecordTable(records []*repositories.MigrationRecord) table {
+	t := table{header: []string{"VERSION", "DESCRIPTION", "MIGRATED", "STARTED", "APPLIED"}}
+	for _, record := range records {
+		t.rows = append(
+			t.rows, []string{
+				strconv.Itoa(record.Version), record.Description, strconv.FormatInt(record.Migrated, 10),
+				formatTime(record.StartedAt), formatTime(record.AppliedAt),
+			},
+		)
+	}
+
+	return t
+}
+
+// indexTable lists the indexes of a report by name, with what was found and done.
+func indexTable(report *repositories.IndexReport) table {
+	t := table{header: []string{"INDEX", "STATUS", "DETAIL"}}
+	for _, name := range report.Unchanged {
+		t.rows = append(t.rows, []string{name, "unchanged", ""})
+	}
+	for _, name := range report.Missing {
+		detail := "not created"
+		if contains(report.Created, name) {
+			detail = "created"
+		}
+		t.rows = append(t.rows, []string{name, "missing", detail})
+	}
+	for _, drift := range report.Drifted {
+		detail := fmt.Sprintf("declared %s, exists as %s", drift.Declared, drift.Existing)
+		if contains(report.Rebuilt, drift.Name) {
+			detail += ", rebuilt"
+		}
+		t.rows = append(t.rows, []string{drift.Name, "drifted", detail})
+	}
+	for _, name := range report.Stale {
+		detail := "not declared"
+		if contains(report.Dropped, name) {
+			detail = "dropped"
+		}
+		t.rows = append(t.rows, []string{name, "stale", detail})
+	}
+
+	return t
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
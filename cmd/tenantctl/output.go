@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatYAML:
+		return nil
+	default:
+		return errors.Errorf("unknown output format %q, expected table, json or yaml", format)
+	}
+}
+
+// table is the tabular rendering of a value, one row per line below the header.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// write writes value in the output format, rendered by t as a table.
+func write(w io.Writer, format string, value any, t func() table) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return errors.Wrap(encoder.Encode(value), "failed to write json")
+	case formatYAML:
+		return writeYAML(w, value)
+	default:
+		return writeTable(w, t())
+	}
+}
+
+func writeTable(w io.Writer, t table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return errors.Wrap(tw.Flush(), "failed to write table")
+}
+
+// writeYAML writes value as YAML. Values are encoded as JSON first, so that fields are named
+// and ordered as the API returns them.
+func writeYAML(w io.Writer, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "failed to write yaml")
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(encoded, &node); err != nil {
+		return errors.Wrap(err, "failed to write yaml")
+	}
+	blockStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return errors.Wrap(err, "failed to write yaml")
+	}
+
+	return errors.Wrap(encoder.Close(), "failed to write yaml")
+}
+
+// blockStyle drops the flow style and quotes JSON is parsed with, keeping the quotes strings
+// need to stay strings.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
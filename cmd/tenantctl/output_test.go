@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	role := &entities.Role{
+		ID: "role-1", Name: "true", Description: "Reads everything",
+		Permissions: []entities.Permission{entities.ReadPermission, entities.EditPermission},
+	}
+
+	var testCases = []struct {
+		Name           string
+		Format         string
+		ExpectedOutput string
+	}{
+		{
+			Name:   "Happy Path: Table",
+			Format: formatTable,
+			ExpectedOutput: "ID      NAME  TENANT  PERMISSIONS  DESCRIPTION\n" +
+				"role-1  true  -       read,edit    Reads everything\n",
+		},
+		{
+			Name:   "Happy Path: JSON",
+			Format: formatJSON,
+			ExpectedOutput: "{\n  \"_id\": \"role-1\",\n  \"name\": \"true\",\n  \"description\": \"Reads everything\",\n" +
+				"  \"permissions\": [\n    \"read\",\n    \"edit\"\n  ]\n}\n",
+		},
+		{
+			Name:   "Happy Path: YAML keeps the order of fields and quotes strings only when needed",
+			Format: formatYAML,
+			ExpectedOutput: "_id: role-1\nname: \"true\"\ndescription: Reads everything\npermissions:\n" +
+				"  - read\n  - edit\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				var output bytes.Buffer
+				require.NoError(t, write(&output, tt.Format, role, func() table { return roleTable(role) }))
+				assert.Equal(t, tt.ExpectedOutput, output.String())
+			},
+		)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	path := t.TempDir() + "/tenant.yaml"
+	content := "name: Acme\nsubdomain: acme\ncreated_at: 2023-06-01T10:00:00Z\nprimary_contacts:\n  - email: ada@acme.io\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	var tenant entities.Tenant
+	require.NoError(t, readFile(path, &tenant))
+	assert.Equal(t, "Acme", tenant.Name)
+	assert.Equal(t, "acme", tenant.Subdomain)
+	assert.Equal(t, 2023, tenant.CreatedAt.Year())
+	require.Len(t, tenant.PrimaryContacts, 1)
+	assert.Equal(t, "ada@acme.io", tenant.PrimaryContacts[0].Email)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// httpClient operates the service through its HTTP API.
+type httpClient struct {
+	server string
+	token  string
+	apiKey string
+	client *http.Client
+}
+
+func newHTTPClient(server string, token string, apiKey string) *httpClient {
+	return &httpClient{
+		server: strings.TrimSuffix(server, "/"),
+		token:  token,
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// problemError is an error answered by the API as problem details.
+type problemError struct {
+	apperrors.Problem
+}
+
+func (e *problemError) Error() string {
+	message := fmt.Sprintf("%d %s", e.Status, e.Title)
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	for _, field := range e.Errors {
+		message += fmt.Sprintf("; %s: %s", field.Field, field.Message)
+	}
+
+	return message
+}
+
+// do sends a request to the API, encoding in as its body and decoding the response into out
+// unless they are nil.
+func (c *httpClient) do(ctx context.Context, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.apiKey != "" {
+		req.Header.Set(authn.APIKeyHeader, c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send %s %s", method, path)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		problem := &problemError{}
+		if err := json.NewDecoder(resp.Body).Decode(&problem.Problem); err != nil || problem.Status == 0 {
+			problem.Status = resp.StatusCode
+			problem.Title = http.StatusText(resp.StatusCode)
+		}
+		return problem
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "failed to decode response of %s %s", method, path)
+}
+
+// ListTenants lists the active tenants, the only ones the API lists.
+func (c *httpClient) ListTenants(ctx context.Context, status string) ([]*entities.Tenant, error) {
+	if status == "suspended" {
+		return nil, errors.New("the API only lists active tenants, suspended ones need direct access to the database")
+	}
+
+	var tenants []*entities.Tenant
+	return tenants, c.do(ctx, http.MethodGet, "/tenants", nil, &tenants)
+}
+
+func (c *httpClient) GetTenant(ctx context.Context, id string) (*entities.Tenant, error) {
+	var tenant entities.Tenant
+	if err := c.do(ctx, http.MethodGet, "/tenants/"+url.PathEscape(id), nil, &tenant); err != nil {
+		return nil, err
+	}
+
+	return &tenant, nil
+}
+
+func (c *httpClient) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	return c.do(ctx, http.MethodPost, "/tenants", tenant, tenant)
+}
+
+func (c *httpClient) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	return c.do(ctx, http.MethodPut, "/tenants/"+url.PathEscape(tenant.ID), tenant, nil)
+}
+
+func (c *httpClient) SuspendTenant(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/tenants/"+url.PathEscape(id), nil, nil)
+}
+
+func (c *httpClient) PurgeTenant(context.Context, string) error {
+	return errors.New("the API only suspends tenants, purging them needs direct access to the database")
+}
+
+func (c *httpClient) ListRoles(ctx context.Context) ([]*entities.Role, error) {
+	var roles []*entities.Role
+	return roles, c.do(ctx, http.MethodGet, "/roles", nil, &roles)
+}
+
+func (c *httpClient) GetRole(ctx context.Context, id string) (*entities.Role, error) {
+	var role entities.Role
+	if err := c.do(ctx, http.MethodGet, "/roles/"+url.PathEscape(id), nil, &role); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (c *httpClient) CreateRole(ctx context.Context, role *entities.Role) error {
+	if role.IsCustom() {
+		return c.do(ctx, http.MethodPost, "/tenants/"+url.PathEscape(role.TenantID)+"/roles", role, role)
+	}
+
+	return c.do(ctx, http.MethodPost, "/roles", role, role)
+}
+
+func (c *httpClient) UpdateRole(ctx context.Context, role *entities.Role) error {
+	return c.do(ctx, http.MethodPut, "/roles/"+url.PathEscape(role.ID), role, nil)
+}
+
+func (c *httpClient) DeleteRole(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/roles/"+url.PathEscape(id), nil, nil)
+}
+
+func (c *httpClient) Close() {}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClient(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				assert.Equal(t, "key", r.Header.Get(authn.APIKeyHeader))
+
+				switch r.Method + " " + r.URL.Path {
+				case "GET /tenants/tenant-1":
+					_ = json.NewEncoder(w).Encode(entities.Tenant{ID: "tenant-1", Name: "Acme"})
+				case "POST /tenants/tenant-1/roles":
+					var role entities.Role
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&role))
+					role.ID = "role-1"
+					w.WriteHeader(http.StatusCreated)
+					_ = json.NewEncoder(w).Encode(role)
+				case "DELETE /tenants/tenant-1":
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					apperrors.WriteProblem(w, r, apperrors.ErrNoTenantDocumentsFound)
+				}
+			},
+		),
+	)
+	defer server.Close()
+
+	c := newHTTPClient(server.URL+"/", "token", "key")
+	ctx := context.Background()
+
+	tenant, err := c.GetTenant(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", tenant.Name)
+
+	role := &entities.Role{TenantID: "tenant-1", Name: "Auditor"}
+	require.NoError(t, c.CreateRole(ctx, role))
+	assert.Equal(t, "role-1", role.ID)
+
+	require.NoError(t, c.SuspendTenant(ctx, "tenant-1"))
+
+	_, err = c.GetTenant(ctx, "tenant-2")
+	var problem *problemError
+	require.ErrorAs(t, err, &problem)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, apperrors.From(apperrors.ErrNoTenantDocumentsFound).Code, problem.Code)
+
+	assert.Error(t, c.PurgeTenant(ctx, "tenant-1"))
+	assert.Equal(
+		t, []string{"GET /tenants/tenant-1", "POST /tenants/tenant-1/roles", "DELETE /tenants/tenant-1", "GET /tenants/tenant-2"},
+		requests,
+	)
+}
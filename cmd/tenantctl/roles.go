@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+var roleCommands = map[string]command{
+	"list":     {usage: "roles list [--tenant id | --platform]", summary: "list roles", run: listRoles},
+	"get":      {usage: "roles get <id>", summary: "show a role", run: getRole},
+	"create":   {usage: "roles create [-f file] [--name name --permissions read,write]", summary: "create a role", run: createRole},
+	"update":   {usage: "roles update [-f file] [--name name --permissions read,write] <id>", summary: "update a role", run: updateRole},
+	"delete":   {usage: "roles delete <id>", summary: "delete a role", run: deleteRole},
+	"assign":   {usage: "roles assign --tenant id --contact id|email <role id>", summary: "assign a role to a contact", run: assignRole},
+	"unassign": {usage: "roles unassign --tenant id --contact id|email <role id>", summary: "take a role back from a contact", run: unassignRole},
+}
+
+func listRoles(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	tenantID := flags.String("tenant", "", "only list the custom roles of a tenant")
+	platform := flags.Bool("platform", false, "only list platform roles")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *tenantID != "" && *platform {
+		return errors.New("--tenant and --platform are exclusive")
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	roles, err := c.ListRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	listed := make([]*entities.Role, 0, len(roles))
+	for _, role := range roles {
+		switch {
+		case *platform && role.IsCustom():
+		case *tenantID != "" && role.TenantID != *tenantID:
+		default:
+			listed = append(listed, role)
+		}
+	}
+
+	return write(a.stdout, a.output, listed, func() table { return roleTable(listed...) })
+}
+
+func getRole(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	role, err := c.GetRole(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, role, func() table { return roleTable(role) })
+}
+
+// roleFlags are the flags setting the fields of a role, over the ones of a file.
+type roleFlags struct {
+	file        *string
+	name        *string
+	description *string
+	permissions *string
+}
+
+func (f roleFlags) apply(role *entities.Role) error {
+	if *f.file != "" {
+		if err := readFile(*f.file, role); err != nil {
+			return err
+		}
+	}
+	if *f.name != "" {
+		role.Name = *f.name
+	}
+	if *f.description != "" {
+		role.Description = *f.description
+	}
+	if *f.permissions != "" {
+		permissions, err := parsePermissions(*f.permissions)
+		if err != nil {
+			return err
+		}
+		role.Permissions = permissions
+	}
+
+	return nil
+}
+
+func createRole(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	fields := roleFlags{
+		file:        flags.String("f", "", "JSON or YAML file of the role, - reads standard input"),
+		name:        flags.String("name", "", "name of the role"),
+		description: flags.String("description", "", "description of the role"),
+		permissions: flags.String("permissions", "", "comma separated permissions of the role"),
+	}
+	tenantID := flags.String("tenant", "", "tenant defining the role, platform roles belong to none")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	role := &entities.Role{}
+	if err := fields.apply(role); err != nil {
+		return err
+	}
+	if *tenantID != "" {
+		role.TenantID = *tenantID
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.CreateRole(ctx, role); err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, role, func() table { return roleTable(role) })
+}
+
+func updateRole(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	fields := roleFlags{
+		file:        flags.String("f", "", "JSON or YAML file of the role, - reads standard input"),
+		name:        flags.String("name", "", "name of the role"),
+		description: flags.String("description", "", "description of the role"),
+		permissions: flags.String("permissions", "", "comma separated permissions of the role"),
+	}
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	role, err := c.GetRole(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// a role cannot be moved between tenants
+	id, tenantID := role.ID, role.TenantID
+	if err := fields.apply(role); err != nil {
+		return err
+	}
+	role.ID, role.TenantID = id, tenantID
+
+	if err := c.UpdateRole(ctx, role); err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, role, func() table { return roleTable(role) })
+}
+
+func deleteRole(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	return c.DeleteRole(ctx, flags.Arg(0))
+}
+
+func assignRole(ctx context.Context, a *app, usage string, args []string) error {
+	return changeAssignment(ctx, a, usage, args, true)
+}
+
+func unassignRole(ctx context.Context, a *app, usage string, args []string) error {
+	return changeAssignment(ctx, a, usage, args, false)
+}
+
+// changeAssignment assigns a role to a contact of a tenant, or takes it back. Roles are held by
+// the contacts of tenants, assignments are updates of tenants.
+func changeAssignment(ctx context.Context, a *app, usage string, args []string, assign bool) error {
+	flags := newFlagSet(a, usage)
+	tenantID := flags.String("tenant", "", "tenant of the contact")
+	contactID := flags.String("contact", "", "ID or email of the contact")
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+	if *tenantID == "" || *contactID == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tenant, err := c.GetTenant(ctx, *tenantID)
+	if err != nil {
+		return err
+	}
+	contact := findContact(tenant, *contactID)
+	if contact == nil {
+		return errors.Errorf("tenant %s has no contact %s", tenant.ID, *contactID)
+	}
+
+	roleID := flags.Arg(0)
+	held := -1
+	for i, role := range contact.Roles {
+		if role.ID == roleID {
+			held = i
+			break
+		}
+	}
+
+	switch {
+	case assign && held >= 0:
+		return errors.Errorf("contact %s already holds role %s", contact.ID, roleID)
+	case !assign && held < 0:
+		return errors.Errorf("contact %s does not hold role %s", contact.ID, roleID)
+	case assign:
+		role, err := c.GetRole(ctx, roleID)
+		if err != nil {
+			return err
+		}
+		if role.IsCustom() && role.TenantID != tenant.ID {
+			return errors.Errorf("role %s is a custom role of another tenant", role.ID)
+		}
+		contact.Roles = append(contact.Roles, role)
+	default:
+		contact.Roles = append(contact.Roles[:held], contact.Roles[held+1:]...)
+	}
+
+	if err := c.UpdateTenant(ctx, tenant); err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, contact, func() table { return contactTable(contact) })
+}
+
+// findContact returns the primary contact of a tenant with the given ID or email.
+func findContact(tenant *entities.Tenant, idOrEmail string) *entities.TenantContactDetails {
+	for _, contact := range tenant.PrimaryContacts {
+		if contact.ID == idOrEmail || strings.EqualFold(contact.Email, idOrEmail) {
+			return contact
+		}
+	}
+
+	return nil
+}
+
+func parsePermissions(value string) ([]entities.Permission, error) {
+	var permissions []entities.Permission
+	for _, name := range strings.Split(value, ",") {
+		permission := entities.Permission(strings.ToLower(strings.TrimSpace(name)))
+		switch permission {
+		case entities.ReadPermission, entities.WritePermission, entities.EditPermission, entities.DeletePermission:
+			permissions = append(permissions, permission)
+		default:
+			return nil, errors.Errorf("unknown permission %q, expected read, write, edit or delete", name)
+		}
+	}
+
+	return permissions, nil
+}
+
+func roleTable(roles ...*entities.Role) table {
+	t := table{header: []string{"ID", "NAME", "TENANT", "PERMISSIONS", "DESCRIPTION"}}
+	for _, role := range roles {
+		tenantID := role.TenantID
+		if tenantID == "" {
+			tenantID = "-"
+		}
+
+		t.rows = append(t.rows, []string{role.ID, role.Name, tenantID, joinPermissions(role.Permissions), role.Description})
+	}
+
+	return t
+}
+
+func contactTable(contacts ...*entities.TenantContactDetails) table {
+	t := table{header: []string{"ID", "NAME", "EMAIL", "ROLES"}}
+	for _, contact := range contacts {
+		names := make([]string, 0, len(contact.Roles))
+		for _, role := range contact.Roles {
+			names = append(names, role.Name)
+		}
+
+		t.rows = append(
+			t.rows, []string{
+				contact.ID, strings.TrimSpace(contact.FirstName + " " + contact.LastName), contact.Email,
+				strings.Join(names, ","),
+			},
+		)
+	}
+
+	return t
+}
+
+func joinPermissions(permissions []entities.Permission) string {
+	names := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		names = append(names, string(permission))
+	}
+
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/bulk"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+)
+
+// seedReport sums up what was seeded, the seed and epoch generate the same tenants again.
+type seedReport struct {
+	Seed          int64  `json:"seed"`
+	Epoch         string `json:"epoch"`
+	Tenants       int    `json:"tenants"`
+	Inactive      int    `json:"inactive"`
+	Companies     int    `json:"companies"`
+	Contacts      int    `json:"contacts"`
+	Subscriptions int    `json:"subscriptions"`
+}
+
+func seed(ctx context.Context, a *app, usage string, args []string) error {
+	defaults := tests.DefaultProfile()
+	flags := newFlagSet(a, usage)
+	seedValue := flags.Int64("seed", 0, "seed of the generator, 0 picks one")
+	epoch := flags.String("epoch", "", "date tenants are created at, YYYY-MM-DD, today by default")
+	count := flags.Int("tenants", 100, "how many tenants to generate")
+	companies := flags.String("companies", "1-2", "companies per tenant, a count or a range")
+	contacts := flags.String("contacts", "1-2", "contacts per tenant, a count or a range")
+	paymentMethods := flags.String("payment-methods", "1-2", "payment methods per tenant, a count or a range")
+	subscriptions := flags.String("subscriptions", "1-2", "subscriptions per company, a count or a range")
+	plans := flags.String("plans", "starter=1,basic=1,premium=1,enterprise=1", "plans of subscriptions by weight")
+	inactive := flags.Float64("inactive", defaults.InactiveRatio, "share of suspended tenants, from 0 to 1")
+	batchSize := flags.Int("batch-size", 500, "how many tenants are written at once")
+	out := flags.String("out", "", "write the tenants to a file instead of the database, - writes standard output")
+	format := flags.String("format", "", "format of --out, ndjson or csv, by default csv for .csv files and ndjson otherwise")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	profile := defaults
+	ranges := []struct {
+		value string
+		r     *tests.Range
+	}{
+		{*companies, &profile.Companies}, {*contacts, &profile.Contacts},
+		{*paymentMethods, &profile.PaymentMethods}, {*subscriptions, &profile.Subscriptions},
+	}
+	for _, flag := range ranges {
+		parsed, err := tests.ParseRange(flag.value)
+		if err != nil {
+			return err
+		}
+		*flag.r = parsed
+	}
+	parsedPlans, err := tests.ParsePlans(*plans)
+	if err != nil {
+		return err
+	}
+	profile.Plans = parsedPlans
+	if *inactive < 0 || *inactive > 1 {
+		return errors.New("--inactive must be between 0 and 1")
+	}
+	profile.InactiveRatio = *inactive
+	if *count < 0 || *batchSize <= 0 {
+		return errors.New("--tenants and --batch-size must be positive")
+	}
+
+	report := seedReport{Seed: *seedValue}
+	if report.Seed == 0 {
+		report.Seed = time.Now().UnixNano()
+	}
+	start := time.Now().UTC().Truncate(24 * time.Hour)
+	if *epoch != "" {
+		if start, err = time.Parse("2006-01-02", *epoch); err != nil {
+			return errors.Errorf("invalid epoch %q, expected YYYY-MM-DD", *epoch)
+		}
+	}
+	report.Epoch = start.Format("2006-01-02")
+
+	insert, done, err := seedTarget(ctx, a, *out, *format)
+	if err != nil {
+		return err
+	}
+
+	generator := tests.NewGenerator(tests.WithSeed(report.Seed), tests.WithProfile(profile), tests.WithEpoch(start))
+	for report.Tenants < *count {
+		if err := ctx.Err(); err != nil {
+			done()
+			return err
+		}
+
+		size := *batchSize
+		if remaining := *count - report.Tenants; remaining < size {
+			size = remaining
+		}
+		tenants := generator.Tenants(size)
+		if err := insert(tenants); err != nil {
+			done()
+			return err
+		}
+
+		for _, tenant := range tenants {
+			report.add(tenant)
+		}
+		if *out == "" && a.output == formatTable {
+			fmt.Fprintf(a.stderr, "%d of %d tenants seeded\n", report.Tenants, *count)
+		}
+	}
+	if err := done(); err != nil {
+		return err
+	}
+
+	if *out == "-" {
+		fmt.Fprintf(a.stderr, "%d tenants generated with seed %d and epoch %s\n", report.Tenants, report.Seed, report.Epoch)
+		return nil
+	}
+
+	return write(a.stdout, a.output, report, func() table { return seedTable(report) })
+}
+
+// seedTarget returns where seeded tenants are written: a file given by out, or else the database.
+// Done releases it once every tenant was written.
+func seedTarget(ctx context.Context, a *app, out string, format string) (
+	insert func([]*entities.Tenant) error, done func() error, err error,
+) {
+	if out == "" {
+		c, err := a.newDirect(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// seeded tenants are neither audited nor raise events
+		tenants := repositories.NewTenantRepository(c.db.Tenant, c.logger)
+		insert = func(batch []*entities.Tenant) error { return tenants.InsertTenants(ctx, batch) }
+		return insert, func() error { c.Close(); return nil }, nil
+	}
+
+	bulkFormat, err := fileFormat(format, out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w, file := a.stdout, (*os.File)(nil)
+	if out != "-" {
+		if file, err = os.Create(out); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to create %s", out)
+		}
+		w = file
+	}
+
+	writer, err := bulk.NewWriter(bulkFormat, w)
+	if err != nil {
+		return nil, nil, err
+	}
+	insert = func(batch []*entities.Tenant) error {
+		for _, tenant := range batch {
+			if err := writer.Write(tenant); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	done = func() error {
+		err := writer.Flush()
+		if file != nil {
+			if closeErr := file.Close(); err == nil {
+				err = errors.Wrapf(closeErr, "failed to write %s", out)
+			}
+		}
+		return err
+	}
+
+	return insert, done, nil
+}
+
+func (r *seedReport) add(tenant *entities.Tenant) {
+	r.Tenants++
+	if !tenant.IsActive {
+		r.Inactive++
+	}
+	r.Companies += len(tenant.Companies)
+	r.Contacts += len(tenant.PrimaryContacts)
+	for _, company := range tenant.Companies {
+		r.Subscriptions += len(company.Subscriptions)
+	}
+}
+
+func seedTable(report seedReport) table {
+	return table{
+		header: []string{"SEED", "EPOCH", "TENANTS", "INACTIVE", "COMPANIES", "CONTACTS", "SUBSCRIPTIONS"},
+		rows: [][]string{
+			{
+				strconv.FormatInt(report.Seed, 10), report.Epoch, strconv.Itoa(report.Tenants),
+				strconv.Itoa(report.Inactive), strconv.Itoa(report.Companies), strconv.Itoa(report.Contacts),
+				strconv.Itoa(report.Subscriptions),
+			},
+		},
+	}
+}
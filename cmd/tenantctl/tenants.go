@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+var tenantCommands = map[string]command{
+	"list":    {usage: "tenants list [--status active|suspended]", summary: "list tenants", run: listTenants},
+	"get":     {usage: "tenants get <id>", summary: "show a tenant", run: getTenant},
+	"create":  {usage: "tenants create [-f file] [--name name --subdomain subdomain]", summary: "create a tenant", run: createTenant},
+	"suspend": {usage: "tenants suspend <id>", summary: "deactivate a tenant", run: suspendTenant},
+	"restore": {usage: "tenants restore <id>", summary: "reactivate a suspended tenant", run: restoreTenant},
+	"purge":   {usage: "tenants purge --confirm <id> <id>", summary: "remove a tenant and its custom roles for good", run: purgeTenant},
+	"import": {
+		usage:   "tenants import [--format ndjson|csv] [--mode insert|upsert] [--dry-run] [--max-errors n] [--errors file] <file>",
+		summary: "import tenants from JSON Lines or CSV", run: importTenants,
+	},
+	"export": {
+		usage:   "tenants export [--format ndjson|csv] [--status active|suspended] [-f file]",
+		summary: "export tenants as JSON Lines or CSV", run: exportTenants,
+	},
+}
+
+func listTenants(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	status := flags.String("status", "", "only list active or suspended tenants")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+	if *status != "" && *status != "active" && *status != "suspended" {
+		return errors.Errorf("unknown status %q, expected active or suspended", *status)
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tenants, err := c.ListTenants(ctx, *status)
+	if err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, tenants, func() table { return tenantTable(tenants...) })
+}
+
+func getTenant(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tenant, err := c.GetTenant(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, tenant, func() table { return tenantTable(tenant) })
+}
+
+func createTenant(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	file := flags.String("f", "", "JSON or YAML file of the tenant, - reads standard input")
+	name := flags.String("name", "", "name of the tenant")
+	subdomain := flags.String("subdomain", "", "subdomain of the tenant")
+	inactive := flags.Bool("inactive", false, "create the tenant suspended")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	tenant := &entities.Tenant{IsActive: true}
+	if *file != "" {
+		if err := readFile(*file, tenant); err != nil {
+			return err
+		}
+	}
+	if *name != "" {
+		tenant.Name = *name
+	}
+	if *subdomain != "" {
+		tenant.Subdomain = *subdomain
+	}
+	if *inactive {
+		tenant.IsActive = false
+	}
+	if tenant.Name == "" || tenant.Subdomain == "" {
+		return errors.New("tenants need a name and a subdomain")
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.CreateTenant(ctx, tenant); err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, tenant, func() table { return tenantTable(tenant) })
+}
+
+func suspendTenant(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.SuspendTenant(ctx, flags.Arg(0)); err != nil {
+		return err
+	}
+
+	return showTenant(ctx, a, c, flags.Arg(0))
+}
+
+func restoreTenant(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	tenant, err := c.GetTenant(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if tenant.IsActive {
+		return errors.Errorf("tenant %s is not suspended", tenant.ID)
+	}
+
+	tenant.IsActive = true
+	if err := c.UpdateTenant(ctx, tenant); err != nil {
+		return err
+	}
+
+	return showTenant(ctx, a, c, tenant.ID)
+}
+
+func purgeTenant(ctx context.Context, a *app, usage string, args []string) error {
+	flags := newFlagSet(a, usage)
+	confirm := flags.String("confirm", "", "ID of the tenant again, purging cannot be undone")
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+	if *confirm != flags.Arg(0) {
+		return errors.Errorf("purging cannot be undone, confirm with --confirm %s", flags.Arg(0))
+	}
+
+	c, err := a.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.PurgeTenant(ctx, flags.Arg(0)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "tenant %s purged\n", flags.Arg(0))
+	return nil
+}
+
+// showTenant writes the tenant as it is after a change.
+func showTenant(ctx context.Context, a *app, c client, id string) error {
+	tenant, err := c.GetTenant(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return write(a.stdout, a.output, tenant, func() table { return tenantTable(tenant) })
+}
+
+func tenantTable(tenants ...*entities.Tenant) table {
+	t := table{header: []string{"ID", "NAME", "SUBDOMAIN", "STATUS", "CONTACTS", "COMPANIES", "CREATED"}}
+	for _, tenant := range tenants {
+		status := "suspended"
+		if tenant.IsActive {
+			status = "active"
+		}
+
+		t.rows = append(
+			t.rows, []string{
+				tenant.ID, tenant.Name, tenant.Subdomain, status, strconv.Itoa(len(tenant.PrimaryContacts)),
+				strconv.Itoa(len(tenant.Companies)), formatTime(tenant.CreatedAt),
+			},
+		)
+	}
+
+	return t
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// readFile decodes a JSON or YAML file into v, by the JSON names of its fields. The path -
+// reads standard input.
+func readFile(path string, v any) error {
+	var (
+		content []byte
+		err     error
+	)
+	if path == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", path)
+	}
+
+	// JSON is YAML, YAML is decoded and encoded as JSON again
+	var document any
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return errors.Wrapf(err, "failed to decode %s", path)
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode %s", path)
+	}
+
+	return errors.Wrapf(json.Unmarshal(encoded, v), "failed to decode %s", path)
+}
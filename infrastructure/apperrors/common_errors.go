@@ -1,17 +1,172 @@
 package apperrors
 
 import (
-	"github.com/pkg/errors"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
 )
 
 var (
-	ErrCreatingTenantDocument      = errors.New("error creating tenant document in database")
-	ErrRetrievingTenantDocument    = errors.New("error retrieving tenant document(s) from database")
-	ErrNoTenantDocumentsFound      = errors.New("no tenant documents found")
-	ErrUpdatingTenantDocument      = errors.New("error updating tenant document(s) in database")
-	ErrDeletingTenantDocument      = errors.New("error deleting tenant document(s) from database")
-	ErrUnmarshallingTenantDocument = errors.New("error unmarshalling tenant document")
-	ErrInvalidTenantSubscription   = errors.New("invalid tenant subscription")
+	ErrCreatingTenantDocument = newError(
+		"tenant_create_failed", http.StatusInternalServerError, codes.Internal,
+		"error creating tenant document in database",
+	)
+	ErrRetrievingTenantDocument = newError(
+		"tenant_retrieve_failed", http.StatusInternalServerError, codes.Internal,
+		"error retrieving tenant document(s) from database",
+	)
+	ErrNoTenantDocumentsFound = newError(
+		"tenant_not_found", http.StatusNotFound, codes.NotFound,
+		"no tenant documents found",
+	)
+	ErrTenantAlreadyExists = newError(
+		"tenant_already_exists", http.StatusConflict, codes.AlreadyExists,
+		"tenant already exists",
+	)
+	ErrUpdatingTenantDocument = newError(
+		"tenant_update_failed", http.StatusInternalServerError, codes.Internal,
+		"error updating tenant document(s) in database",
+	)
+	ErrDeletingTenantDocument = newError(
+		"tenant_delete_failed", http.StatusInternalServerError, codes.Internal,
+		"error deleting tenant document(s) from database",
+	)
+	ErrUnmarshallingTenantDocument = newError(
+		"tenant_decode_failed", http.StatusInternalServerError, codes.Internal,
+		"error unmarshalling tenant document",
+	)
+	ErrInvalidTenantSubscription = newError(
+		"invalid_subscription", http.StatusBadRequest, codes.InvalidArgument,
+		"invalid tenant subscription",
+	)
+	ErrInvalidDomain = newError(
+		"invalid_domain", http.StatusBadRequest, codes.InvalidArgument,
+		"invalid domain name",
+	)
+	ErrDomainAlreadyClaimed = newError(
+		"domain_already_claimed", http.StatusConflict, codes.AlreadyExists,
+		"domain is already claimed by a tenant",
+	)
+	ErrNoDomainFound = newError(
+		"domain_not_found", http.StatusNotFound, codes.NotFound,
+		"no tenant domain found",
+	)
+	ErrDomainVerificationFailed = newError(
+		"domain_verification_failed", http.StatusUnprocessableEntity, codes.FailedPrecondition,
+		"domain ownership verification failed",
+	)
+	ErrDomainVerificationUnavailable = newError(
+		"domain_verification_unavailable", http.StatusServiceUnavailable, codes.Unavailable,
+		"domain ownership could not be checked, try again later",
+	)
+	ErrCreatingRoleDocument = newError(
+		"role_create_failed", http.StatusInternalServerError, codes.Internal,
+		"error creating role document in database",
+	)
+	ErrRetrievingRoleDocument = newError(
+		"role_retrieve_failed", http.StatusInternalServerError, codes.Internal,
+		"error retrieving role document(s) from database",
+	)
+	ErrNoRoleDocumentsFound = newError(
+		"role_not_found", http.StatusNotFound, codes.NotFound,
+		"no role documents found",
+	)
+	ErrUpdatingRoleDocument = newError(
+		"role_update_failed", http.StatusInternalServerError, codes.Internal,
+		"error updating role document in database",
+	)
+	ErrDeletingRoleDocument = newError(
+		"role_delete_failed", http.StatusInternalServerError, codes.Internal,
+		"error deleting role document from database",
+	)
+	ErrInvalidRole = newError(
+		"invalid_role", http.StatusBadRequest, codes.InvalidArgument,
+		"invalid role",
+	)
+	ErrNoAPIKeyFound = newError(
+		"api_key_not_found", http.StatusNotFound, codes.NotFound,
+		"no api key found",
+	)
+	ErrInvalidAPIKey = newError(
+		"invalid_api_key", http.StatusBadRequest, codes.InvalidArgument,
+		"invalid api key",
+	)
+	ErrCreatingAuditRecord = newError(
+		"audit_create_failed", http.StatusInternalServerError, codes.Internal,
+		"failed to record audit trail",
+	)
+	ErrRetrievingAuditRecords = newError(
+		"audit_retrieve_failed", http.StatusInternalServerError, codes.Internal,
+		"failed to retrieve audit trail",
+	)
+	ErrAuditChainBroken = newError(
+		"audit_chain_broken", http.StatusInternalServerError, codes.DataLoss,
+		"audit trail integrity check failed",
+	)
+	ErrCreatingOutboxEvent = newError(
+		"outbox_create_failed", http.StatusInternalServerError, codes.Internal,
+		"failed to record domain events",
+	)
+	ErrInvalidWebhook = newError(
+		"invalid_webhook", http.StatusBadRequest, codes.InvalidArgument,
+		"invalid webhook endpoint",
+	)
+	ErrNoWebhookFound = newError(
+		"webhook_not_found", http.StatusNotFound, codes.NotFound,
+		"no webhook endpoint found",
+	)
+	ErrNoWebhookDeliveryFound = newError(
+		"webhook_delivery_not_found", http.StatusNotFound, codes.NotFound,
+		"no webhook delivery found",
+	)
+	ErrSavingWebhook = newError(
+		"webhook_save_failed", http.StatusInternalServerError, codes.Internal,
+		"error saving webhook in database",
+	)
+	ErrRetrievingWebhooks = newError(
+		"webhook_retrieve_failed", http.StatusInternalServerError, codes.Internal,
+		"error retrieving webhook(s) from database",
+	)
+	ErrUnauthenticated = newError(
+		"unauthenticated", http.StatusUnauthorized, codes.Unauthenticated,
+		"authentication required",
+	)
+	ErrForbidden = newError(
+		"forbidden", http.StatusForbidden, codes.PermissionDenied,
+		"permission denied",
+	)
+	ErrRateLimited = newError(
+		"rate_limited", http.StatusTooManyRequests, codes.ResourceExhausted,
+		"rate limit exceeded",
+	)
+	ErrValidation = newError(
+		"validation_failed", http.StatusBadRequest, codes.InvalidArgument,
+		"request validation failed",
+	)
+	ErrRouteNotFound = newError(
+		"route_not_found", http.StatusNotFound, codes.Unimplemented,
+		"route not found",
+	)
+	ErrMethodNotAllowed = newError(
+		"method_not_allowed", http.StatusMethodNotAllowed, codes.Unimplemented,
+		"method not allowed",
+	)
+	ErrCanceled = newError(
+		"canceled", http.StatusRequestTimeout, codes.Canceled,
+		"request canceled",
+	)
+	ErrTimeout = newError(
+		"timeout", http.StatusGatewayTimeout, codes.DeadlineExceeded,
+		"request timed out",
+	)
+	ErrFeatureUnavailable = newError(
+		"feature_unavailable", http.StatusNotImplemented, codes.Unimplemented,
+		"feature unavailable with the configured database backend",
+	)
+	ErrInternal = newError(
+		"internal", http.StatusInternalServerError, codes.Internal,
+		"internal error",
+	)
 )
 
 const (
@@ -23,4 +178,11 @@ const (
 	ErrUnmarshallingTenant    = "error unmarshalling tenants"
 	ErrNoTenantFound          = "no tenant found - %v"
 	ErrUpdatingTenant         = "error updating tenant - %v"
+	ErrVerifyingDomain        = "error verifying domain - %v"
+	ErrCreatingRole           = "error creating role - %v"
+	ErrDeletingRole           = "error deleting role - %v"
+	ErrRetrievingRole         = "error retrieving role - %v"
+	ErrRetrievingRoles        = "error retrieving roles"
+	ErrNoRoleFound            = "no role found - %v"
+	ErrUpdatingRole           = "error updating role - %v"
 )
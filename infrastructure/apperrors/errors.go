@@ -0,0 +1,117 @@
+package apperrors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+// FieldError describes why a single field of a request was rejected.
+type FieldError struct {
+	// Field is the path of the field as built by FieldPath, e.g. companies.0.name.
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// FieldPath joins the names and indexes leading to a field with dots, the notation of the
+// fields of every FieldError. Empty segments are skipped.
+func FieldPath(segments ...any) string {
+	parts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if part := fmt.Sprint(segment); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Error is an application error with a stable machine readable code.
+// The code never changes once published, clients can rely on it to tell errors apart,
+// while the message is meant for humans.
+type Error struct {
+	Code     string
+	Message  string
+	Status   int
+	GRPCCode codes.Code
+	Fields   []FieldError
+	cause    error
+}
+
+func newError(code string, status int, grpcCode codes.Code, message string) *Error {
+	return &Error{
+		Code:     code,
+		Message:  message,
+		Status:   status,
+		GRPCCode: grpcCode,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.cause == nil {
+		return e.Message
+	}
+
+	return e.Message + ": " + e.cause.Error()
+}
+
+// Unwrap returns the cause of the error, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an application error with the same code,
+// so copies returned by Wrap and WithFields match the error they were created from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error caused by cause.
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.cause = cause
+	return &wrapped
+}
+
+// WithFields returns a copy of the error detailing the offending fields.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	detailed := *e
+	detailed.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &detailed
+}
+
+// From returns the application error carried by err.
+// Context errors are reported as cancellations and timeouts, any other error as an internal error.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *Error
+	switch {
+	case errors.As(err, &appErr):
+		return appErr
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout.Wrap(err)
+	default:
+		return ErrInternal.Wrap(err)
+	}
+}
+
+// detail describes err for clients. Server errors only expose their message,
+// their causes may contain details of the infrastructure.
+func detail(err error) string {
+	appErr := From(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		return appErr.Message
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,155 @@
+package apperrors_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+func TestError_Wrap(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := apperrors.ErrRetrievingTenantDocument.Wrap(cause)
+
+	assert.True(t, errors.Is(err, apperrors.ErrRetrievingTenantDocument))
+	assert.True(t, errors.Is(err, cause))
+	assert.False(t, errors.Is(err, apperrors.ErrNoTenantDocumentsFound))
+	assert.Equal(t, "error retrieving tenant document(s) from database: connection refused", err.Error())
+
+	// the sentinel itself is left untouched
+	assert.Nil(t, errors.Unwrap(apperrors.ErrRetrievingTenantDocument))
+}
+
+func TestFieldPath(t *testing.T) {
+	assert.Equal(t, "companies.0.subscriptions.1.plan", apperrors.FieldPath("companies", 0, "subscriptions", 1, "plan"))
+	assert.Equal(t, "companies.0.name", apperrors.FieldPath(apperrors.FieldPath("companies", 0), "", "name"))
+	assert.Equal(t, "", apperrors.FieldPath())
+}
+
+func TestFrom(t *testing.T) {
+	var testCases = []struct {
+		Name         string
+		Err          error
+		ExpectedCode string
+	}{
+		{
+			Name:         "Application error",
+			Err:          apperrors.ErrNoTenantDocumentsFound,
+			ExpectedCode: "tenant_not_found",
+		},
+		{
+			Name:         "Wrapped application error",
+			Err:          errors.Wrap(apperrors.ErrDomainVerificationFailed, "no TXT record"),
+			ExpectedCode: "domain_verification_failed",
+		},
+		{
+			Name:         "Canceled context",
+			Err:          context.Canceled,
+			ExpectedCode: "canceled",
+		},
+		{
+			Name:         "Deadline exceeded",
+			Err:          errors.Wrap(context.DeadlineExceeded, "querying"),
+			ExpectedCode: "timeout",
+		},
+		{
+			Name:         "Unknown error",
+			Err:          errors.New("boom"),
+			ExpectedCode: "internal",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				appErr := apperrors.From(tt.Err)
+				assert.Equal(t, tt.ExpectedCode, appErr.Code)
+				assert.True(t, errors.Is(appErr, tt.Err) || errors.Is(tt.Err, appErr))
+			},
+		)
+	}
+
+	assert.Nil(t, apperrors.From(nil))
+}
+
+func TestWriteProblem(t *testing.T) {
+	var testCases = []struct {
+		Name           string
+		Err            error
+		ExpectedStatus int
+		ExpectedCode   string
+		ExpectedDetail string
+		ExpectedFields []apperrors.FieldError
+	}{
+		{
+			Name: "Client error with fields",
+			Err: apperrors.ErrInvalidRole.WithFields(
+				apperrors.FieldError{Field: "name", Message: "is required"},
+			),
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedCode:   "invalid_role",
+			ExpectedDetail: "invalid role",
+			ExpectedFields: []apperrors.FieldError{{Field: "name", Message: "is required"}},
+		},
+		{
+			Name:           "Server error hides its cause",
+			Err:            apperrors.ErrUpdatingTenantDocument.Wrap(errors.New("server selection timeout")),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedCode:   "tenant_update_failed",
+			ExpectedDetail: "error updating tenant document(s) in database",
+		},
+		{
+			Name:           "Unknown error",
+			Err:            errors.New("secret"),
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedCode:   "internal",
+			ExpectedDetail: "internal error",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				rec := httptest.NewRecorder()
+				apperrors.WriteProblem(rec, httptest.NewRequest(http.MethodGet, "/tenants/1", nil), tt.Err)
+
+				assert.Equal(t, tt.ExpectedStatus, rec.Code)
+				assert.Equal(t, apperrors.ProblemContentType, rec.Header().Get("Content-Type"))
+
+				var problem apperrors.Problem
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+				assert.Equal(t, apperrors.ProblemTypePrefix+tt.ExpectedCode, problem.Type)
+				assert.Equal(t, tt.ExpectedCode, problem.Code)
+				assert.Equal(t, tt.ExpectedStatus, problem.Status)
+				assert.Equal(t, http.StatusText(tt.ExpectedStatus), problem.Title)
+				assert.Equal(t, tt.ExpectedDetail, problem.Detail)
+				assert.Equal(t, "/tenants/1", problem.Instance)
+				assert.Equal(t, tt.ExpectedFields, problem.Errors)
+			},
+		)
+	}
+}
+
+func TestGRPCStatus(t *testing.T) {
+	st := apperrors.GRPCStatus(
+		apperrors.ErrValidation.WithFields(apperrors.FieldError{Field: "tenant", Message: "is required"}),
+	)
+
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	assert.Equal(t, "tenant", badRequest.FieldViolations[0].Field)
+
+	assert.Equal(t, codes.NotFound, apperrors.GRPCStatus(apperrors.ErrNoRoleDocumentsFound).Code())
+	assert.Nil(t, apperrors.GRPCStatus(nil))
+}
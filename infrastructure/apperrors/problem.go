@@ -0,0 +1,78 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ProblemContentType is the media type of problem details, see RFC 7807.
+	ProblemContentType = "application/problem+json"
+	// ProblemTypePrefix prefixes the error code to build the problem type URI.
+	ProblemTypePrefix = "urn:problem-type:tenant-management:"
+)
+
+// Problem is the RFC 7807 representation of an application error.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblem describes err as a problem that occurred on instance.
+func NewProblem(err error, instance string) *Problem {
+	appErr := From(err)
+
+	return &Problem{
+		Type:     ProblemTypePrefix + appErr.Code,
+		Title:    http.StatusText(appErr.Status),
+		Status:   appErr.Status,
+		Detail:   detail(err),
+		Instance: instance,
+		Code:     appErr.Code,
+		Errors:   appErr.Fields,
+	}
+}
+
+// WriteProblem writes err as problem details in response to r.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	problem := NewProblem(err, r.URL.Path)
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// GRPCStatus maps err to a gRPC status. Field errors are attached as bad request details.
+func GRPCStatus(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	appErr := From(err)
+	st := status.New(appErr.GRPCCode, detail(err))
+	if len(appErr.Fields) == 0 {
+		return st
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(appErr.Fields))
+	for _, field := range appErr.Fields {
+		violations = append(
+			violations, &errdetails.BadRequest_FieldViolation{Field: field.Field, Description: field.Message},
+		)
+	}
+
+	detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailErr != nil {
+		return st
+	}
+
+	return detailed
+}
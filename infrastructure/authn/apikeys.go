@@ -0,0 +1,84 @@
+package authn
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// IsAPIKey reports whether the credential looks like an API key rather than a JWT.
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, service.APIKeyPrefix)
+}
+
+// APIKeyAuthenticator authenticates the API keys issued to tenants.
+type APIKeyAuthenticator struct {
+	tenants Tenants
+	logger  utils.LoggerInterface
+	now     func() time.Time
+}
+
+func NewAPIKeyAuthenticator(logger utils.LoggerInterface, tenants Tenants) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		tenants: tenants,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Authenticate verifies the key and returns the principal of the contact that created it,
+// restricted to the scopes of the key.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	prefix, ok := service.ParseAPIKeyPrefix(key)
+	if !ok {
+		return nil, errors.Wrap(apperrors.ErrUnauthenticated, "malformed api key")
+	}
+
+	tenant, err := a.tenants.GetTenantByAPIKeyPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNoTenantDocumentsFound) {
+			return nil, errors.Wrap(apperrors.ErrUnauthenticated, "invalid api key")
+		}
+		return nil, err
+	}
+
+	now := a.now()
+	for _, apiKey := range tenant.APIKeys {
+		if apiKey.Prefix != prefix || !apiKey.Matches(key) {
+			continue
+		}
+
+		switch {
+		case apiKey.IsRevoked():
+			a.logger.Infof("rejected revoked api key %s of tenant %s", prefix, tenant.ID)
+			return nil, errors.Wrap(apperrors.ErrUnauthenticated, "api key is revoked")
+		case apiKey.IsExpired(now):
+			a.logger.Infof("rejected expired api key %s of tenant %s", prefix, tenant.ID)
+			return nil, errors.Wrap(apperrors.ErrUnauthenticated, "api key is expired")
+		}
+
+		principal, err := newPrincipal(tenant, MethodAPIKey, apiKey.CreatedBy, "")
+		if err != nil {
+			return nil, err
+		}
+
+		principal.APIKey = apiKey
+
+		// failing to record the usage must not fail the request
+		if now.Sub(apiKey.LastUsedAt) >= service.APIKeyUsageResolution {
+			if err := a.tenants.TouchAPIKey(ctx, tenant.ID, apiKey.ID, now); err != nil {
+				a.logger.Error(err)
+			}
+		}
+
+		return principal, nil
+	}
+
+	a.logger.Infof("rejected api key %s of tenant %s", prefix, tenant.ID)
+	return nil, errors.Wrap(apperrors.ErrUnauthenticated, "invalid api key")
+}
@@ -0,0 +1,675 @@
+package authn_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenants struct {
+	tenants []*entities.Tenant
+}
+
+func (f *fakeTenants) GetTenantByID(_ context.Context, id string) (*entities.Tenant, error) {
+	for _, tenant := range f.tenants {
+		if tenant.ID == id {
+			return tenant, nil
+		}
+	}
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (f *fakeTenants) GetTenantByAPIKeyPrefix(_ context.Context, prefix string) (*entities.Tenant, error) {
+	for _, tenant := range f.tenants {
+		for _, key := range tenant.APIKeys {
+			if key.Prefix == prefix {
+				return tenant, nil
+			}
+		}
+	}
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (f *fakeTenants) TouchAPIKey(_ context.Context, tenantID string, keyID string, at time.Time) error {
+	tenant, err := f.GetTenantByID(context.Background(), tenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range tenant.APIKeys {
+		if key.ID == keyID {
+			key.LastUsedAt = at
+			return nil
+		}
+	}
+	return apperrors.ErrNoAPIKeyFound
+}
+
+// newAPIKey returns an API key created by contactID together with its secret.
+func newAPIKey(t *testing.T, id string, contactID string, scopes ...entities.APIKeyScope) (string, *entities.APIKey) {
+	key := &entities.APIKey{
+		ID:        id,
+		Name:      id,
+		Prefix:    "tm_" + id,
+		Scopes:    scopes,
+		CreatedBy: contactID,
+		CreatedAt: time.Now(),
+	}
+
+	secret := key.Prefix + "_" + base64.RawURLEncoding.EncodeToString([]byte("secret-of-"+id))
+	require.NoError(t, key.SetSecret(secret))
+	return secret, key
+}
+
+type testKeys struct {
+	rsa  *rsa.PrivateKey
+	ec   *ecdsa.PrivateKey
+	jwks []byte
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks, err := json.Marshal(
+		map[string]any{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA", "kid": "rsa-1", "use": "sig",
+					"n": encode(rsaKey.N.Bytes()), "e": encode(big.NewInt(int64(rsaKey.E)).Bytes()),
+				},
+				{
+					"kty": "EC", "kid": "ec-1", "crv": "P-256",
+					"x": encode(ecKey.X.FillBytes(make([]byte, 32))), "y": encode(ecKey.Y.FillBytes(make([]byte, 32))),
+				},
+				{"kty": "oct", "kid": "hmac-1", "k": encode([]byte("secret"))},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	return &testKeys{rsa: rsaKey, ec: ecKey, jwks: jwks}
+}
+
+func (k *testKeys) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	var key any
+	switch method {
+	case jwt.SigningMethodRS256:
+		key = k.rsa
+	case jwt.SigningMethodES256:
+		key = k.ec
+	default:
+		key = []byte("secret")
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func newTestTenants() *fakeTenants {
+	role := &entities.Role{ID: "role-1", Name: "editor", Permissions: []entities.Permission{entities.EditPermission}}
+	return &fakeTenants{
+		tenants: []*entities.Tenant{
+			{
+				ID:       "tenant-1",
+				IsActive: true,
+				PrimaryContacts: []*entities.TenantContactDetails{
+					{ID: "contact-1", Email: "ada@example.com", IsActive: true, Roles: []*entities.Role{role}},
+					{ID: "contact-2", Email: "gone@example.com"},
+				},
+			},
+			{
+				ID: "tenant-2",
+				PrimaryContacts: []*entities.TenantContactDetails{
+					{ID: "contact-3", IsActive: true},
+				},
+			},
+		},
+	}
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":       "contact-1",
+		"tenant_id": "tenant-1",
+		"iss":       "https://issuer.test",
+		"aud":       "tenant-management",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func with(claims jwt.MapClaims, key string, value any) jwt.MapClaims {
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	keys := newTestKeys(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, keys.jwks, 0o600))
+	keySet, err := authn.LoadKeySetFile(path)
+	require.NoError(t, err)
+
+	authenticator := authn.NewJWTAuthenticator(
+		utils.NewLogger(), keySet, newTestTenants(),
+		authn.WithIssuer("https://issuer.test"), authn.WithAudience("tenant-management"),
+	)
+
+	var testCases = []struct {
+		Name            string
+		Token           string
+		ExpectedContact string
+		ExpectedError   error
+	}{
+		{
+			Name:            "Happy Path: RS256 token",
+			Token:           keys.sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims()),
+			ExpectedContact: "contact-1",
+		},
+		{
+			Name:            "Happy Path: ES256 token",
+			Token:           keys.sign(t, jwt.SigningMethodES256, "ec-1", validClaims()),
+			ExpectedContact: "contact-1",
+		},
+		{
+			Name: "Happy Path: contact matched by email",
+			Token: keys.sign(
+				t, jwt.SigningMethodES256, "ec-1",
+				with(with(validClaims(), "sub", "idp|42"), "email", "ADA@example.com"),
+			),
+			ExpectedContact: "contact-1",
+		},
+		{
+			Name:          "Error Path: HS256 token",
+			Token:         keys.sign(t, jwt.SigningMethodHS256, "hmac-1", validClaims()),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: key of another algorithm",
+			Token:         keys.sign(t, jwt.SigningMethodES256, "rsa-1", validClaims()),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: unknown key",
+			Token:         keys.sign(t, jwt.SigningMethodRS256, "rsa-2", validClaims()),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name: "Error Path: expired token",
+			Token: keys.sign(
+				t, jwt.SigningMethodRS256, "rsa-1", with(validClaims(), "exp", time.Now().Add(-time.Hour).Unix()),
+			),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: token without expiration",
+			Token:         keys.sign(t, jwt.SigningMethodRS256, "rsa-1", with(validClaims(), "exp", nil)),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: wrong issuer",
+			Token:         keys.sign(t, jwt.SigningMethodRS256, "rsa-1", with(validClaims(), "iss", "https://evil.test")),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: wrong audience",
+			Token:         keys.sign(t, jwt.SigningMethodRS256, "rsa-1", with(validClaims(), "aud", "billing")),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: unknown tenant",
+			Token:         keys.sign(t, jwt.SigningMethodRS256, "rsa-1", with(validClaims(), "tenant_id", "nope")),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name: "Error Path: inactive tenant",
+			Token: keys.sign(
+				t, jwt.SigningMethodRS256, "rsa-1",
+				with(with(validClaims(), "tenant_id", "tenant-2"), "sub", "contact-3"),
+			),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: inactive contact",
+			Token:         keys.sign(t, jwt.SigningMethodRS256, "rsa-1", with(validClaims(), "sub", "contact-2")),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: malformed token",
+			Token:         "not.a.token",
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				principal, err := authenticator.Authenticate(context.Background(), tt.Token)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedContact, principal.Contact.ID)
+				assert.Equal(t, "tenant-1", principal.TenantID)
+				assert.Equal(t, authn.MethodJWT, principal.Method)
+				assert.True(t, principal.HasPermission(entities.EditPermission))
+				assert.False(t, principal.HasPermission(entities.DeletePermission))
+			},
+		)
+	}
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	keys := newTestKeys(t)
+
+	var fetches int32
+	var unavailable atomic.Bool
+	endpoint := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if unavailable.Load() {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				atomic.AddInt32(&fetches, 1)
+				_, _ = w.Write(keys.jwks)
+			},
+		),
+	)
+	defer endpoint.Close()
+
+	authenticator := authn.NewJWTAuthenticator(
+		utils.NewLogger(), authn.NewRemoteKeySet(endpoint.URL), newTestTenants(),
+	)
+
+	for i := 0; i < 3; i++ {
+		_, err := authenticator.Authenticate(
+			context.Background(), keys.sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims()),
+		)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches), "keys should be cached")
+
+	// unknown keys do not trigger a fetch per token
+	_, err := authenticator.Authenticate(
+		context.Background(), keys.sign(t, jwt.SigningMethodRS256, "rsa-2", validClaims()),
+	)
+	assert.True(t, errors.Is(err, apperrors.ErrUnauthenticated))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// an unreachable key set is a server error, not a client one
+	unavailable.Store(true)
+	_, err = authn.NewJWTAuthenticator(
+		utils.NewLogger(), authn.NewRemoteKeySet(endpoint.URL), newTestTenants(),
+	).Authenticate(context.Background(), keys.sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims()))
+	assert.True(t, errors.Is(err, apperrors.ErrInternal), "unexpected error: %v", err)
+}
+
+func TestAPIKeyAuthenticator_Authenticate(t *testing.T) {
+	tenants := newTestTenants()
+
+	key, record := newAPIKey(t, "ci", "contact-1")
+	assert.NotContains(t, record.Hash, key)
+
+	revokedKey, revoked := newAPIKey(t, "old", "contact-1")
+	revoked.RevokedAt = time.Now()
+
+	expiredKey, expired := newAPIKey(t, "expired", "contact-1")
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	orphanKey, orphan := newAPIKey(t, "orphan", "contact-2")
+
+	tenants.tenants[0].APIKeys = []*entities.APIKey{record, revoked, expired, orphan}
+	authenticator := authn.NewAPIKeyAuthenticator(utils.NewLogger(), tenants)
+
+	var testCases = []struct {
+		Name          string
+		Key           string
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: valid key",
+			Key:  key,
+		},
+		{
+			Name:          "Error Path: wrong secret",
+			Key:           record.Prefix + "_" + base64.RawURLEncoding.EncodeToString(make([]byte, 32)),
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: revoked key",
+			Key:           revokedKey,
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: expired key",
+			Key:           expiredKey,
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: key of an inactive contact",
+			Key:           orphanKey,
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: unknown prefix",
+			Key:           "tm_000000000000_secret",
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+		{
+			Name:          "Error Path: malformed key",
+			Key:           "tm_",
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				principal, err := authenticator.Authenticate(context.Background(), tt.Key)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, "contact-1", principal.Contact.ID)
+				assert.Equal(t, authn.MethodAPIKey, principal.Method)
+				assert.Same(t, record, principal.APIKey)
+				assert.False(t, record.LastUsedAt.IsZero(), "last use not recorded")
+			},
+		)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	_, key := newAPIKey(
+		t, "ci", "contact-1",
+		entities.APIKeyScope{
+			Resource:    entities.DomainsResource,
+			Permissions: []entities.Permission{entities.ReadPermission},
+		},
+	)
+
+	admin := &entities.Role{
+		Name: "admin",
+		Permissions: []entities.Permission{
+			entities.ReadPermission, entities.WritePermission, entities.EditPermission, entities.DeletePermission,
+		},
+	}
+	viewer := &entities.Role{Name: "viewer", Permissions: []entities.Permission{entities.ReadPermission}}
+
+	user := &authn.Principal{
+		Subject: "contact-1", TenantID: "tenant-1", Method: authn.MethodJWT, Roles: []*entities.Role{admin},
+	}
+	reader := &authn.Principal{
+		Subject: "contact-2", TenantID: "tenant-1", Method: authn.MethodJWT, Roles: []*entities.Role{viewer},
+	}
+	operator := &authn.Principal{
+		Subject: "contact-3", TenantID: "platform", Method: authn.MethodJWT, Roles: []*entities.Role{admin},
+		Platform: true,
+	}
+	machine := &authn.Principal{Subject: "contact-1", TenantID: "tenant-1", Method: authn.MethodAPIKey, APIKey: key}
+
+	var testCases = []struct {
+		Name          string
+		Principal     *authn.Principal
+		Resource      entities.Resource
+		Permission    entities.Permission
+		TenantID      string
+		ExpectedError error
+	}{
+		{
+			Name:       "Happy Path: user on its tenant",
+			Principal:  user,
+			Resource:   entities.RolesResource,
+			Permission: entities.DeletePermission,
+			TenantID:   "tenant-1",
+		},
+		{
+			Name:       "Happy Path: platform principal outside of tenants",
+			Principal:  operator,
+			Resource:   entities.RolesResource,
+			Permission: entities.WritePermission,
+		},
+		{
+			Name:       "Happy Path: platform principal on another tenant",
+			Principal:  operator,
+			Resource:   entities.TenantsResource,
+			Permission: entities.DeletePermission,
+			TenantID:   "tenant-1",
+		},
+		{
+			Name:       "Happy Path: user within its roles",
+			Principal:  reader,
+			Resource:   entities.CompaniesResource,
+			Permission: entities.ReadPermission,
+			TenantID:   "tenant-1",
+		},
+		{
+			Name:          "Error Path: user outside of its roles",
+			Principal:     reader,
+			Resource:      entities.CompaniesResource,
+			Permission:    entities.EditPermission,
+			TenantID:      "tenant-1",
+			ExpectedError: apperrors.ErrForbidden,
+		},
+		{
+			Name:          "Error Path: tenant principal outside of tenants",
+			Principal:     user,
+			Resource:      entities.TenantsResource,
+			Permission:    entities.ReadPermission,
+			ExpectedError: apperrors.ErrForbidden,
+		},
+		{
+			Name:       "Happy Path: api key within its scopes",
+			Principal:  machine,
+			Resource:   entities.DomainsResource,
+			Permission: entities.ReadPermission,
+			TenantID:   "tenant-1",
+		},
+		{
+			Name:          "Error Path: api key outside of its scopes",
+			Principal:     machine,
+			Resource:      entities.DomainsResource,
+			Permission:    entities.WritePermission,
+			TenantID:      "tenant-1",
+			ExpectedError: apperrors.ErrForbidden,
+		},
+		{
+			Name:          "Error Path: other tenant",
+			Principal:     user,
+			Resource:      entities.TenantsResource,
+			Permission:    entities.ReadPermission,
+			TenantID:      "tenant-2",
+			ExpectedError: apperrors.ErrForbidden,
+		},
+		{
+			Name:          "Error Path: unauthenticated",
+			Resource:      entities.TenantsResource,
+			Permission:    entities.ReadPermission,
+			ExpectedError: apperrors.ErrUnauthenticated,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				ctx := context.Background()
+				if tt.Principal != nil {
+					ctx = authn.WithPrincipal(ctx, tt.Principal)
+				}
+
+				err := authn.Authorize(ctx, tt.Resource, tt.Permission, tt.TenantID)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+					return
+				}
+				assert.NoError(t, err)
+			},
+		)
+	}
+}
+
+func TestAuthenticator_PlatformTenant(t *testing.T) {
+	tenants := newTestTenants()
+	apiKey, record := newAPIKey(t, "ci", "contact-1")
+	tenants.tenants[0].APIKeys = []*entities.APIKey{record}
+
+	var testCases = []struct {
+		Name             string
+		Opts             []authn.Option
+		ExpectedPlatform bool
+	}{
+		{
+			Name:             "Happy Path: principal of the platform tenant",
+			Opts:             []authn.Option{authn.WithPlatformTenant("tenant-1")},
+			ExpectedPlatform: true,
+		},
+		{
+			Name: "Happy Path: principal of another tenant",
+			Opts: []authn.Option{authn.WithPlatformTenant("tenant-2")},
+		},
+		{
+			Name: "Happy Path: without platform tenant",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				logger := utils.NewLogger()
+				opts := append([]authn.Option{authn.WithAPIKeys(authn.NewAPIKeyAuthenticator(logger, tenants))}, tt.Opts...)
+				principal, err := authn.NewAuthenticator(logger, opts...).Authenticate(context.Background(), apiKey)
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedPlatform, principal.Platform)
+			},
+		)
+	}
+}
+
+func TestAuthenticator_Middleware(t *testing.T) {
+	keys := newTestKeys(t)
+	keySet, err := authn.ParseKeySet(keys.jwks)
+	require.NoError(t, err)
+
+	tenants := newTestTenants()
+	apiKey, record := newAPIKey(t, "ci", "contact-1")
+	tenants.tenants[0].APIKeys = []*entities.APIKey{record}
+
+	logger := utils.NewLogger()
+	authenticator := authn.NewAuthenticator(
+		logger,
+		authn.WithJWT(authn.NewJWTAuthenticator(logger, keySet, tenants)),
+		authn.WithAPIKeys(authn.NewAPIKeyAuthenticator(logger, tenants)),
+	)
+
+	handler := authenticator.Middleware(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				principal, ok := authn.PrincipalFromContext(r.Context())
+				require.True(t, ok)
+				_, _ = w.Write([]byte(principal.Method))
+			},
+		),
+	)
+
+	var testCases = []struct {
+		Name           string
+		Header         string
+		Value          string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{
+			Name:           "Bearer JWT",
+			Header:         "Authorization",
+			Value:          "Bearer " + keys.sign(t, jwt.SigningMethodRS256, "rsa-1", validClaims()),
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "jwt",
+		},
+		{
+			Name:           "Bearer API key",
+			Header:         "Authorization",
+			Value:          "Bearer " + apiKey,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "api_key",
+		},
+		{
+			Name:           "API key header",
+			Header:         authn.APIKeyHeader,
+			Value:          apiKey,
+			ExpectedStatus: http.StatusOK,
+			ExpectedBody:   "api_key",
+		},
+		{
+			Name:           "Missing credentials",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+		{
+			Name:           "Basic credentials",
+			Header:         "Authorization",
+			Value:          "Basic dXNlcjpwYXNz",
+			ExpectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/tenants", nil)
+				if tt.Header != "" {
+					req.Header.Set(tt.Header, tt.Value)
+				}
+
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+
+				assert.Equal(t, tt.ExpectedStatus, rec.Code)
+				if tt.ExpectedStatus == http.StatusOK {
+					assert.Equal(t, tt.ExpectedBody, rec.Body.String())
+					return
+				}
+
+				assert.Equal(t, apperrors.ProblemContentType, rec.Header().Get("Content-Type"))
+				assert.NotEmpty(t, rec.Header().Get("WWW-Authenticate"))
+			},
+		)
+	}
+}
@@ -0,0 +1,246 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// KeySet returns the public keys tokens are signed with.
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+var errUnknownKey = errors.New("unknown signing key")
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// StaticKeySet is a fixed set of public keys indexed by key ID.
+type StaticKeySet map[string]crypto.PublicKey
+
+// Key returns the key with the given ID. Tokens without a key ID are accepted
+// when the set holds a single key.
+func (s StaticKeySet) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	if kid == "" && len(s) == 1 {
+		for _, key := range s {
+			return key, nil
+		}
+	}
+
+	key, ok := s[kid]
+	if !ok {
+		return nil, errors.Wrapf(errUnknownKey, "kid %q", kid)
+	}
+
+	return key, nil
+}
+
+// ParseKeySet parses a JSON Web Key Set. Only RSA and P-256 signing keys are kept,
+// the others cannot verify RS256 or ES256 tokens.
+func ParseKeySet(data []byte) (StaticKeySet, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrap(err, "failed to decode jwks")
+	}
+
+	keys := StaticKeySet{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q", jwk.Kid)
+		}
+
+		if key != nil {
+			keys[jwk.Kid] = key
+		}
+	}
+
+	return keys, nil
+}
+
+// LoadKeySetFile reads a JSON Web Key Set from a file.
+func LoadKeySetFile(path string) (StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read jwks file")
+	}
+
+	return ParseKeySet(data)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		if !e.IsInt64() {
+			return nil, errors.New("rsa exponent is too large")
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, nil
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+
+		return key, nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid base64url value")
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+type KeySetOption func(*RemoteKeySet)
+
+// WithHTTPClient sets the client used to fetch the key set.
+func WithHTTPClient(client *http.Client) KeySetOption {
+	return func(s *RemoteKeySet) {
+		s.client = client
+	}
+}
+
+// WithRefreshInterval sets how long fetched keys are used before the key set is fetched again.
+func WithRefreshInterval(interval time.Duration) KeySetOption {
+	return func(s *RemoteKeySet) {
+		s.refreshInterval = interval
+	}
+}
+
+// RemoteKeySet fetches a JSON Web Key Set from an endpoint and caches it.
+// The key set is fetched again once stale, or when a token is signed with an unknown key,
+// no more than once per minimum refresh interval so unknown keys cannot flood the endpoint.
+type RemoteKeySet struct {
+	url                string
+	client             *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+
+	mu        sync.Mutex
+	keys      StaticKeySet
+	fetchedAt time.Time
+}
+
+func NewRemoteKeySet(url string, opts ...KeySetOption) *RemoteKeySet {
+	s := &RemoteKeySet{
+		url:                url,
+		client:             &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    time.Hour,
+		minRefreshInterval: 10 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.fetchedAt)
+	if s.keys != nil && age < s.refreshInterval {
+		key, err := s.keys.Key(ctx, kid)
+		if err == nil || age < s.minRefreshInterval {
+			return key, err
+		}
+	}
+
+	keys, err := s.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	return s.keys.Key(ctx, kid)
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (StaticKeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build jwks request")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch jwks")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("failed to fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read jwks")
+	}
+
+	return ParseKeySet(data)
+}
@@ -0,0 +1,139 @@
+package authn
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/pkg/errors"
+)
+
+// DefaultTenantClaim is the claim holding the ID of the tenant the subject belongs to.
+const DefaultTenantClaim = "tenant_id"
+
+type JWTOption func(*JWTAuthenticator)
+
+// WithIssuer rejects tokens that were not issued by issuer.
+func WithIssuer(issuer string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.issuer = issuer
+	}
+}
+
+// WithAudience rejects tokens that were not issued for audience.
+func WithAudience(audience string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.audience = audience
+	}
+}
+
+// WithTenantClaim sets the claim holding the tenant ID, DefaultTenantClaim by default.
+func WithTenantClaim(claim string) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.tenantClaim = claim
+	}
+}
+
+// WithLeeway sets the clock skew tolerated when checking the validity period of tokens.
+func WithLeeway(leeway time.Duration) JWTOption {
+	return func(a *JWTAuthenticator) {
+		a.leeway = leeway
+	}
+}
+
+// JWTAuthenticator authenticates RS256 and ES256 signed bearer tokens.
+// The subject of a token is the ID of a contact of the tenant named by the tenant claim,
+// tokens without a known subject can still match a contact by their email claim.
+type JWTAuthenticator struct {
+	keys        KeySet
+	tenants     Tenants
+	logger      utils.LoggerInterface
+	issuer      string
+	audience    string
+	tenantClaim string
+	leeway      time.Duration
+}
+
+func NewJWTAuthenticator(
+	logger utils.LoggerInterface, keys KeySet, tenants Tenants, opts ...JWTOption,
+) *JWTAuthenticator {
+	a := &JWTAuthenticator{
+		keys:        keys,
+		tenants:     tenants,
+		logger:      logger,
+		tenantClaim: DefaultTenantClaim,
+		leeway:      30 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Authenticate verifies the token and returns the principal it identifies.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithLeeway(a.leeway),
+	}
+	if a.issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(a.issuer))
+	}
+	if a.audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(a.audience))
+	}
+
+	var keySetErr error
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(
+		token, claims, func(t *jwt.Token) (any, error) {
+			kid, _ := t.Header["kid"].(string)
+			key, err := a.keys.Key(ctx, kid)
+			if err != nil && !errors.Is(err, errUnknownKey) {
+				keySetErr = err
+			}
+			return key, err
+		}, parserOpts...,
+	)
+	if keySetErr != nil {
+		// the token may be valid, the keys to verify it are unavailable
+		a.logger.Error(keySetErr)
+		return nil, apperrors.ErrInternal.Wrap(keySetErr)
+	}
+	if err != nil {
+		a.logger.Infof("rejected bearer token: %v", err)
+		return nil, apperrors.ErrUnauthenticated.Wrap(err)
+	}
+
+	// tokens must expire, a leaked token would be valid forever otherwise
+	if exp, err := claims.GetExpirationTime(); err != nil || exp == nil {
+		return nil, errors.Wrap(apperrors.ErrUnauthenticated, "token has no expiration time")
+	}
+
+	subject, _ := claims.GetSubject()
+	tenantID, _ := claims[a.tenantClaim].(string)
+	email, _ := claims["email"].(string)
+	if subject == "" || tenantID == "" {
+		return nil, errors.Wrapf(apperrors.ErrUnauthenticated, "token is missing the sub or %s claim", a.tenantClaim)
+	}
+
+	tenant, err := a.tenants.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrNoTenantDocumentsFound) {
+			return nil, errors.Wrap(apperrors.ErrUnauthenticated, "unknown tenant")
+		}
+		return nil, err
+	}
+
+	principal, err := newPrincipal(tenant, MethodJWT, subject, email)
+	if err != nil {
+		return nil, err
+	}
+
+	principal.Claims = claims
+	return principal, nil
+}
@@ -0,0 +1,196 @@
+package authn
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// APIKeyHeader carries API keys, they are also accepted as bearer tokens.
+const APIKeyHeader = "X-API-Key"
+
+// unauthenticatedMethods are the gRPC methods served without credentials, so
+// orchestrators and tooling can probe the server.
+var unauthenticatedMethods = []string{
+	"/grpc.health.v1.Health/",
+	"/grpc.reflection.",
+}
+
+type Option func(*Authenticator)
+
+// WithJWT accepts bearer tokens verified by authenticator.
+func WithJWT(authenticator *JWTAuthenticator) Option {
+	return func(a *Authenticator) {
+		a.jwt = authenticator
+	}
+}
+
+// WithAPIKeys accepts API keys verified by authenticator.
+func WithAPIKeys(authenticator *APIKeyAuthenticator) Option {
+	return func(a *Authenticator) {
+		a.apiKeys = authenticator
+	}
+}
+
+// WithPlatformTenant makes the principals of the tenant platform principals, they operate the
+// service on behalf of every tenant. No principal is a platform principal without it.
+func WithPlatformTenant(tenantID string) Option {
+	return func(a *Authenticator) {
+		a.platformTenantID = tenantID
+	}
+}
+
+// Authenticator authenticates requests with whichever credential they carry
+// and stores the resulting principal in their context.
+type Authenticator struct {
+	jwt              *JWTAuthenticator
+	apiKeys          *APIKeyAuthenticator
+	platformTenantID string
+	logger           utils.LoggerInterface
+}
+
+func NewAuthenticator(logger utils.LoggerInterface, opts ...Option) *Authenticator {
+	a := &Authenticator{
+		logger: logger,
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Authenticate returns the principal identified by credential, an API key or a JWT.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	var (
+		principal *Principal
+		err       error
+	)
+	switch {
+	case credential == "":
+		return nil, errors.Wrap(apperrors.ErrUnauthenticated, "no credentials")
+	case IsAPIKey(credential) && a.apiKeys != nil:
+		principal, err = a.apiKeys.Authenticate(ctx, credential)
+	case !IsAPIKey(credential) && a.jwt != nil:
+		principal, err = a.jwt.Authenticate(ctx, credential)
+	default:
+		return nil, errors.Wrap(apperrors.ErrUnauthenticated, "unsupported credentials")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	principal.Platform = a.platformTenantID != "" && principal.TenantID == a.platformTenantID
+
+	return principal, nil
+}
+
+// Middleware rejects HTTP requests without valid credentials.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			principal, err := a.Authenticate(r.Context(), credentialFromRequest(r))
+			if err != nil {
+				if errors.Is(err, apperrors.ErrUnauthenticated) {
+					w.Header().Set("WWW-Authenticate", `Bearer realm="tenant-management"`)
+				} else {
+					a.logger.Error(err)
+				}
+				apperrors.WriteProblem(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		},
+	)
+}
+
+// UnaryServerInterceptor rejects unary gRPC calls without valid credentials.
+func (a *Authenticator) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (any, error) {
+		if isUnauthenticatedMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		principal, err := a.Authenticate(ctx, credentialFromMetadata(ctx))
+		if err != nil {
+			return nil, apperrors.GRPCStatus(err).Err()
+		}
+
+		return handler(WithPrincipal(ctx, principal), req)
+	}
+}
+
+// StreamServerInterceptor rejects streaming gRPC calls without valid credentials.
+func (a *Authenticator) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if isUnauthenticatedMethod(info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		principal, err := a.Authenticate(stream.Context(), credentialFromMetadata(stream.Context()))
+		if err != nil {
+			return apperrors.GRPCStatus(err).Err()
+		}
+
+		return handler(srv, &principalStream{ServerStream: stream, ctx: WithPrincipal(stream.Context(), principal)})
+	}
+}
+
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+func isUnauthenticatedMethod(method string) bool {
+	for _, prefix := range unauthenticatedMethods {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func credentialFromRequest(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+
+	return bearerToken(r.Header.Get("Authorization"))
+}
+
+func credentialFromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(strings.ToLower(APIKeyHeader)); len(keys) > 0 {
+		return keys[0]
+	}
+
+	if values := md.Get("authorization"); len(values) > 0 {
+		return bearerToken(values[0])
+	}
+
+	return ""
+}
+
+func bearerToken(header string) string {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,165 @@
+package authn
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+type Method string
+
+const (
+	MethodJWT    Method = "jwt"
+	MethodAPIKey Method = "api_key"
+)
+
+// Principal is the authenticated caller: a contact of a tenant acting with its roles,
+// either directly with a JWT or through an API key issued on its behalf.
+type Principal struct {
+	Subject  string
+	TenantID string
+	Method   Method
+	// Tenant is the tenant of the principal as it was when the principal authenticated.
+	Tenant  *entities.Tenant
+	Contact *entities.TenantContactDetails
+	Roles   []*entities.Role
+	// Claims holds the verified claims of the JWT, it is empty for API keys.
+	Claims map[string]any
+	// APIKey is the key the principal authenticated with, it is empty for JWTs.
+	APIKey *entities.APIKey
+	// Platform is set for the principals of the platform tenant, the operators of the service.
+	// They may access every tenant and the resources outside of tenants.
+	Platform bool
+}
+
+// HasPermission reports whether any role of the principal grants permission.
+func (p *Principal) HasPermission(permission entities.Permission) bool {
+	for _, role := range p.Roles {
+		for _, granted := range role.Permissions {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Allows reports whether the principal may exercise permission on resource.
+// API keys are restricted to their scopes, other principals to the permissions of their roles.
+func (p *Principal) Allows(resource entities.Resource, permission entities.Permission) bool {
+	if p.APIKey == nil {
+		return p.HasPermission(permission)
+	}
+
+	return p.APIKey.Allows(resource, permission)
+}
+
+// Tenants looks up the tenants principals belong to.
+type Tenants interface {
+	GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error)
+	GetTenantByAPIKeyPrefix(ctx context.Context, prefix string) (*entities.Tenant, error)
+	TouchAPIKey(ctx context.Context, tenantID string, keyID string, at time.Time) error
+}
+
+// Authorize checks that the principal of ctx may exercise permission on resource of a tenant.
+// Principals can only access their own tenant, tenantID is empty for resources outside of tenants,
+// which only platform principals may access.
+func Authorize(
+	ctx context.Context, resource entities.Resource, permission entities.Permission, tenantID string,
+) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return apperrors.ErrUnauthenticated
+	}
+
+	switch {
+	case principal.Platform:
+	case tenantID == "":
+		return errors.Wrapf(apperrors.ErrForbidden, "only platform principals may %s %s", permission, resource)
+	case tenantID != principal.TenantID:
+		return errors.Wrapf(apperrors.ErrForbidden, "no access to tenant %s", tenantID)
+	}
+
+	if !principal.Allows(resource, permission) {
+		return errors.Wrapf(apperrors.ErrForbidden, "not allowed to %s %s", permission, resource)
+	}
+
+	return nil
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal.
+// Changes made with the returned context are audited as made by the principal.
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	if principal != nil {
+		actor := audit.Actor{ID: principal.Subject}
+		if principal.APIKey != nil {
+			actor.APIKey = principal.APIKey.Prefix
+		}
+		ctx = audit.WithActor(ctx, actor)
+	}
+
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored in ctx, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// ClaimsFromContext returns the verified JWT claims of the principal stored in ctx.
+// It can be used as the claims function of the tenancy resolver.
+func ClaimsFromContext(ctx context.Context) (map[string]any, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || principal.Claims == nil {
+		return nil, false
+	}
+
+	return principal.Claims, true
+}
+
+// newPrincipal maps a subject of an active tenant to the contact it identifies.
+// The contact is matched by ID first, then by email.
+func newPrincipal(tenant *entities.Tenant, method Method, contactID string, email string) (*Principal, error) {
+	if !tenant.IsActive {
+		return nil, errors.Wrapf(apperrors.ErrUnauthenticated, "tenant %s is inactive", tenant.ID)
+	}
+
+	var contact *entities.TenantContactDetails
+	for _, c := range tenant.PrimaryContacts {
+		if contactID != "" && c.ID == contactID {
+			contact = c
+			break
+		}
+	}
+
+	if contact == nil && email != "" {
+		for _, c := range tenant.PrimaryContacts {
+			if strings.EqualFold(c.Email, email) {
+				contact = c
+				break
+			}
+		}
+	}
+
+	if contact == nil || !contact.IsActive {
+		return nil, errors.Wrap(apperrors.ErrUnauthenticated, "unknown or inactive contact")
+	}
+
+	return &Principal{
+		Subject:  contact.ID,
+		TenantID: tenant.ID,
+		Method:   method,
+		Tenant:   tenant,
+		Contact:  contact,
+		Roles:    contact.Roles,
+	}, nil
+}
@@ -0,0 +1,87 @@
+// Package bulk imports and exports tenants in bulk, as JSON Lines or as CSV.
+//
+// Both formats are streamed: readers decode one tenant at a time and writers encode them as they
+// come, so that imports and exports use the same memory whatever their size.
+package bulk
+
+import (
+	"io"
+	"strings"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// Format is the format of an import or export.
+type Format string
+
+const (
+	// NDJSON is one JSON tenant per line, with every field of tenants.
+	NDJSON Format = "ndjson"
+	// CSV is one row per tenant, contact, company and subscription, see NewCSVWriter.
+	CSV Format = "csv"
+)
+
+// ParseFormat returns the format of a name, jsonl is an alias of ndjson.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "csv":
+		return CSV, nil
+	default:
+		return "", errors.Errorf("unknown format %q, expected ndjson or csv", name)
+	}
+}
+
+// Record is a tenant read from an import.
+type Record struct {
+	// Number is the position of the tenant in the import, from 1.
+	Number int
+	// Line is the line the tenant starts on.
+	Line   int
+	Tenant *entities.Tenant
+	// Partial records only carry some fields of tenants, the other fields of the tenants they
+	// update are kept.
+	Partial bool
+	// Err is why the tenant could not be decoded, reading goes on with the next one.
+	Err error
+}
+
+// Reader reads the tenants of an import one at a time.
+type Reader interface {
+	// Read returns the next record, and io.EOF once every record was read. Errors decoding a
+	// record are reported by the record, errors returned stop the import.
+	Read() (*Record, error)
+}
+
+// Writer writes the tenants of an export one at a time.
+type Writer interface {
+	Write(tenant *entities.Tenant) error
+	// Flush writes what is buffered, it must be called once every tenant was written.
+	Flush() error
+}
+
+// NewReader returns the reader of an import in format.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case NDJSON:
+		return NewNDJSONReader(r), nil
+	case CSV:
+		return NewCSVReader(r)
+	default:
+		return nil, errors.Errorf("unknown format %q", format)
+	}
+}
+
+// NewWriter returns the writer of an export in format.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case NDJSON:
+		return NewNDJSONWriter(w), nil
+	case CSV:
+		return NewCSVWriter(w), nil
+	default:
+		return nil, errors.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,212 @@
+package bulk_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/bulk"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var createdAt = time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+func newTenant(id string, subdomain string) *entities.Tenant {
+	return &entities.Tenant{
+		ID: id, Name: strings.ToUpper(subdomain[:1]) + subdomain[1:], Subdomain: subdomain, IsActive: true,
+		CreatedAt: createdAt,
+		PrimaryContacts: []*entities.TenantContactDetails{
+			{ID: id + "-contact", FirstName: "Ada", LastName: "Lovelace", Email: "ada@" + subdomain + ".io", IsActive: true},
+		},
+		Companies: []*entities.TenantCompanyDetails{
+			{
+				ID: id + "-company", Name: "Analytical, Engines", Industry: "computing", IsActive: true,
+				Address: &entities.Address{Address: "1 Main St", City: "London", Country: "UK"},
+				Subscriptions: []*entities.TenantSubscriptionDetails{
+					{
+						ID: id + "-subscription", Plan: "pro", BillingCycle: "monthly", DiscountRate: 12.5,
+						Discount: true, Active: true, StartDate: createdAt, EndDate: createdAt.AddDate(1, 0, 0),
+					},
+				},
+			},
+		},
+	}
+}
+
+func readAll(t *testing.T, r bulk.Reader) []*bulk.Record {
+	t.Helper()
+
+	var records []*bulk.Record
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, format := range []bulk.Format{bulk.NDJSON, bulk.CSV} {
+		format := format
+		t.Run(
+			string(format), func(t *testing.T) {
+				tenants := []*entities.Tenant{newTenant("tenant-1", "acme"), newTenant("tenant-2", "globex")}
+				tenants[1].Companies = nil
+
+				var buffer bytes.Buffer
+				w, err := bulk.NewWriter(format, &buffer)
+				require.NoError(t, err)
+				for _, tenant := range tenants {
+					require.NoError(t, w.Write(tenant))
+				}
+				require.NoError(t, w.Flush())
+
+				r, err := bulk.NewReader(format, &buffer)
+				require.NoError(t, err)
+				records := readAll(t, r)
+				require.Len(t, records, 2)
+				for i, record := range records {
+					assert.NoError(t, record.Err)
+					assert.Equal(t, i+1, record.Number)
+					assert.Equal(t, format == bulk.CSV, record.Partial)
+					assert.Equal(t, tenants[i], record.Tenant)
+				}
+			},
+		)
+	}
+}
+
+func TestNDJSONReader(t *testing.T) {
+	input := `{"_id":"tenant-1","name":"Acme","subdomain":"acme"}
+
+{"_id":"tenant-2","nickname":"Globex"}
+{"_id":"tenant-3",`
+
+	records := readAll(t, bulk.NewNDJSONReader(strings.NewReader(input)))
+	require.Len(t, records, 3)
+
+	assert.NoError(t, records[0].Err)
+	assert.Equal(t, "acme", records[0].Tenant.Subdomain)
+	assert.Equal(t, 3, records[1].Line)
+	assert.True(t, errors.Is(records[1].Err, apperrors.ErrValidation))
+	assert.Contains(t, records[1].Err.Error(), "nickname")
+	assert.Equal(t, 3, records[2].Number)
+	assert.True(t, errors.Is(records[2].Err, apperrors.ErrValidation))
+}
+
+func TestCSVReader(t *testing.T) {
+	var testCases = []struct {
+		Name           string
+		Input          string
+		ExpectedError  string
+		ExpectedFields []apperrors.FieldError
+		Check          func(t *testing.T, records []*bulk.Record)
+	}{
+		{
+			Name: "Happy Path: Columns in any order",
+			Input: "subdomain,name,record,email,plan\n" +
+				"acme,Acme,tenant,,\n" +
+				",,contact,ada@acme.io,\n" +
+				",Acme Ltd,company,,\n" +
+				",,subscription,,pro\n" +
+				"globex,Globex,tenant,,\n",
+			Check: func(t *testing.T, records []*bulk.Record) {
+				require.Len(t, records, 2)
+				assert.Equal(t, "ada@acme.io", records[0].Tenant.PrimaryContacts[0].Email)
+				assert.Equal(t, "pro", records[0].Tenant.Companies[0].Subscriptions[0].Plan)
+				assert.Nil(t, records[0].Tenant.Companies[0].Address)
+				assert.Equal(t, 6, records[1].Line)
+			},
+		},
+		{
+			Name: "Error Path: Invalid values",
+			Input: "record,id,tenant_id,is_active,discount_rate,start_date\n" +
+				"tenant,tenant-1,,maybe,,\n" +
+				"company,company-1,tenant-2,,,\n" +
+				"subscription,,,,ten,2024-13-01\n",
+			ExpectedFields: []apperrors.FieldError{
+				{Field: "is_active", Message: `line 2: "maybe" is not a boolean`},
+				{Field: "tenant_id", Message: "line 3: does not match the tenant row above"},
+				{Field: "discount_rate", Message: `line 4: "ten" is not a number`},
+				{Field: "start_date", Message: `line 4: "2024-13-01" is not a date`},
+			},
+		},
+		{
+			Name:  "Error Path: Subscription without company",
+			Input: "record,plan\ntenant,\nsubscription,pro\n",
+			ExpectedFields: []apperrors.FieldError{
+				{Field: "record", Message: "line 3: subscription row before any company row"},
+			},
+		},
+		{
+			Name:          "Error Path: Unknown column",
+			Input:         "record,nickname\n",
+			ExpectedError: `unknown column "nickname"`,
+		},
+		{
+			Name:          "Error Path: Missing record column",
+			Input:         "id,name\n",
+			ExpectedError: "missing column record",
+		},
+		{
+			Name:          "Error Path: Contact before tenant",
+			Input:         "record,email\ncontact,ada@acme.io\n",
+			ExpectedError: "line 2: contact row before any tenant row",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				r, err := bulk.NewCSVReader(strings.NewReader(tt.Input))
+				var records []*bulk.Record
+				if err == nil {
+					for {
+						var record *bulk.Record
+						record, err = r.Read()
+						if err != nil {
+							break
+						}
+						records = append(records, record)
+					}
+				}
+
+				if tt.ExpectedError != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.ExpectedError)
+					return
+				}
+				require.Equal(t, io.EOF, err)
+
+				if tt.ExpectedFields != nil {
+					require.Len(t, records, 1)
+					require.True(t, errors.Is(records[0].Err, apperrors.ErrValidation))
+					assert.Equal(t, tt.ExpectedFields, apperrors.From(records[0].Err).Fields)
+					return
+				}
+				for _, record := range records {
+					assert.NoError(t, record.Err)
+				}
+				tt.Check(t, records)
+			},
+		)
+	}
+}
+
+func TestCSVWriter_Empty(t *testing.T) {
+	var buffer bytes.Buffer
+	w := bulk.NewCSVWriter(&buffer)
+	require.NoError(t, w.Flush())
+
+	assert.True(t, strings.HasPrefix(buffer.String(), "record,id,tenant_id,company_id,name,subdomain,"))
+	assert.Equal(t, 1, strings.Count(buffer.String(), "\n"))
+}
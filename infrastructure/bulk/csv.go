@@ -0,0 +1,412 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// The kinds of rows of CSV files, named by the record column.
+const (
+	tenantRow       = "tenant"
+	contactRow      = "contact"
+	companyRow      = "company"
+	subscriptionRow = "subscription"
+)
+
+// csvColumns are the columns of CSV files. Rows only fill the columns of their kind, name and
+// is_active are shared by the kinds having them.
+var csvColumns = []string{
+	"record", "id", "tenant_id", "company_id", "name", "subdomain", "is_active", "created_at", "updated_at",
+	"first_name", "last_name", "email", "phone_number", "avatar_url", "job_title", "preferred_language", "timezone",
+	"website_url", "logo_url", "industry", "registration_number",
+	"address", "address2", "city", "state", "zip_code", "country",
+	"plan", "billing_cycle", "payment_status", "payment_gateway", "discount", "discount_rate", "auto_renew",
+	"start_date", "end_date", "next_billing_date", "last_payment_date",
+}
+
+var csvColumnIndex = func() map[string]int {
+	index := make(map[string]int, len(csvColumns))
+	for i, column := range csvColumns {
+		index[column] = i
+	}
+	return index
+}()
+
+type csvWriter struct {
+	writer *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns the writer of CSV exports. Tenants are flattened to a row per tenant,
+// followed by a row per contact and a row per company, each company followed by a row per
+// subscription. The record column tells the kinds of rows apart, child rows carry the IDs of
+// their tenant and company.
+//
+// Payment details, metadata, domains, API keys and the roles of contacts are only exported as
+// JSON Lines.
+func NewCSVWriter(w io.Writer) Writer {
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) Write(tenant *entities.Tenant) error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	rows := [][]string{
+		csvRecord(
+			map[string]string{
+				"record": tenantRow, "id": tenant.ID, "name": tenant.Name, "subdomain": tenant.Subdomain,
+				"is_active": formatBool(tenant.IsActive), "created_at": formatTime(tenant.CreatedAt),
+				"updated_at": formatTime(tenant.UpdatedAt),
+			},
+		),
+	}
+	for _, contact := range tenant.PrimaryContacts {
+		rows = append(
+			rows, csvRecord(
+				map[string]string{
+					"record": contactRow, "id": contact.ID, "tenant_id": tenant.ID, "is_active": formatBool(contact.IsActive),
+					"first_name": contact.FirstName, "last_name": contact.LastName, "email": contact.Email,
+					"phone_number": contact.PhoneNumber, "avatar_url": contact.AvatarURL, "job_title": contact.JobTitle,
+					"preferred_language": contact.PreferredLanguage, "timezone": contact.Timezone,
+				},
+			),
+		)
+	}
+	for _, company := range tenant.Companies {
+		values := map[string]string{
+			"record": companyRow, "id": company.ID, "tenant_id": tenant.ID, "name": company.Name,
+			"is_active": formatBool(company.IsActive), "website_url": company.WebsiteURL, "logo_url": company.LogoURL,
+			"industry": company.Industry, "registration_number": company.RegistrationNumber,
+		}
+		if address := company.Address; address != nil {
+			values["address"], values["address2"] = address.Address, address.Address2
+			values["city"], values["state"] = address.City, address.State
+			values["zip_code"], values["country"] = address.ZipCode, address.Country
+		}
+		rows = append(rows, csvRecord(values))
+
+		for _, subscription := range company.Subscriptions {
+			rows = append(
+				rows, csvRecord(
+					map[string]string{
+						"record": subscriptionRow, "id": subscription.ID, "tenant_id": tenant.ID, "company_id": company.ID,
+						"is_active": formatBool(subscription.Active), "plan": subscription.Plan,
+						"billing_cycle": subscription.BillingCycle, "payment_status": subscription.PaymentStatus,
+						"payment_gateway": subscription.PaymentGateway, "discount": formatBool(subscription.Discount),
+						"discount_rate":     strconv.FormatFloat(subscription.DiscountRate, 'f', -1, 64),
+						"auto_renew":        formatBool(subscription.AutoRenew),
+						"start_date":        formatTime(subscription.StartDate),
+						"end_date":          formatTime(subscription.EndDate),
+						"next_billing_date": formatTime(subscription.NextBillingDate),
+						"last_payment_date": formatTime(subscription.LastPaymentDate),
+					},
+				),
+			)
+		}
+	}
+
+	return errors.Wrapf(w.writer.WriteAll(rows), "failed to write tenant %s", tenant.ID)
+}
+
+func (w *csvWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	w.writer.Flush()
+	return errors.Wrap(w.writer.Error(), "failed to write tenants")
+}
+
+// writeHeader writes the header once, exports without tenants still have one.
+func (w *csvWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+
+	return errors.Wrap(w.writer.Write(csvColumns), "failed to write header")
+}
+
+func csvRecord(values map[string]string) []string {
+	record := make([]string, len(csvColumns))
+	for column, value := range values {
+		record[csvColumnIndex[column]] = value
+	}
+
+	return record
+}
+
+func formatBool(b bool) string {
+	return strconv.FormatBool(b)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	number  int
+	// record is the tenant being read, until the row of the next tenant
+	record  *Record
+	company *entities.TenantCompanyDetails
+	fields  []apperrors.FieldError
+}
+
+// NewCSVReader returns the reader of CSV imports, in the layout of NewCSVWriter. The header
+// names the columns, which may come in any order and be left out, only the record column is
+// required. Rows belong to the tenant row above them, subscription rows to the company row
+// above them.
+//
+// Records are partial: updated tenants keep the fields CSV files do not carry.
+func NewCSVReader(r io.Reader) (Reader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read header")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if _, ok := csvColumnIndex[column]; !ok {
+			return nil, errors.Errorf("unknown column %q", column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["record"]; !ok {
+		return nil, errors.New("missing column record")
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (*Record, error) {
+	for {
+		values, err := r.reader.Read()
+		if err == io.EOF {
+			if r.record == nil {
+				return nil, io.EOF
+			}
+			return r.finish(), nil
+		}
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, errors.Wrap(err, "failed to read tenants")
+		}
+
+		line, _ := r.reader.FieldPos(0)
+		row := &csvRow{values: values, columns: r.columns, line: line}
+		if err != nil {
+			row.invalid("record", "wrong number of columns")
+		}
+
+		kind := row.get("record")
+		if kind == tenantRow {
+			finished := r.record
+			if finished != nil {
+				finished = r.finish()
+			}
+			r.start(row)
+			if finished != nil {
+				return finished, nil
+			}
+			continue
+		}
+
+		if r.record == nil {
+			return nil, errors.Errorf("line %d: %s row before any tenant row", line, kind)
+		}
+		r.add(kind, row)
+		r.fields = append(r.fields, row.fields...)
+	}
+}
+
+// start starts reading the tenant of a tenant row.
+func (r *csvReader) start(row *csvRow) {
+	r.number++
+	r.company = nil
+	r.record = &Record{
+		Number: r.number,
+		Line:   row.line,
+		Tenant: &entities.Tenant{
+			ID:        row.get("id"),
+			Name:      row.get("name"),
+			Subdomain: row.get("subdomain"),
+			IsActive:  row.bool("is_active"),
+			CreatedAt: row.time("created_at"),
+			UpdatedAt: row.time("updated_at"),
+		},
+		Partial: true,
+	}
+	r.fields = row.fields
+}
+
+// add adds a contact, company or subscription row to the tenant being read.
+func (r *csvReader) add(kind string, row *csvRow) {
+	tenant := r.record.Tenant
+	if tenantID := row.get("tenant_id"); tenantID != "" && tenantID != tenant.ID {
+		row.invalid("tenant_id", "does not match the tenant row above")
+	}
+
+	switch kind {
+	case contactRow:
+		tenant.PrimaryContacts = append(
+			tenant.PrimaryContacts, &entities.TenantContactDetails{
+				ID:                row.get("id"),
+				FirstName:         row.get("first_name"),
+				LastName:          row.get("last_name"),
+				Email:             row.get("email"),
+				PhoneNumber:       row.get("phone_number"),
+				AvatarURL:         row.get("avatar_url"),
+				JobTitle:          row.get("job_title"),
+				PreferredLanguage: row.get("preferred_language"),
+				Timezone:          row.get("timezone"),
+				IsActive:          row.bool("is_active"),
+			},
+		)
+	case companyRow:
+		r.company = &entities.TenantCompanyDetails{
+			ID:                 row.get("id"),
+			Name:               row.get("name"),
+			WebsiteURL:         row.get("website_url"),
+			LogoURL:            row.get("logo_url"),
+			Industry:           row.get("industry"),
+			RegistrationNumber: row.get("registration_number"),
+			IsActive:           row.bool("is_active"),
+		}
+		address := &entities.Address{
+			Address:  row.get("address"),
+			Address2: row.get("address2"),
+			City:     row.get("city"),
+			State:    row.get("state"),
+			ZipCode:  row.get("zip_code"),
+			Country:  row.get("country"),
+		}
+		if *address != (entities.Address{}) {
+			r.company.Address = address
+		}
+		tenant.Companies = append(tenant.Companies, r.company)
+	case subscriptionRow:
+		if r.company == nil {
+			row.invalid("record", "subscription row before any company row")
+			return
+		}
+		if companyID := row.get("company_id"); companyID != "" && companyID != r.company.ID {
+			row.invalid("company_id", "does not match the company row above")
+		}
+
+		r.company.Subscriptions = append(
+			r.company.Subscriptions, &entities.TenantSubscriptionDetails{
+				ID:              row.get("id"),
+				Plan:            row.get("plan"),
+				BillingCycle:    row.get("billing_cycle"),
+				PaymentStatus:   row.get("payment_status"),
+				PaymentGateway:  row.get("payment_gateway"),
+				DiscountRate:    row.float("discount_rate"),
+				Discount:        row.bool("discount"),
+				Active:          row.bool("is_active"),
+				AutoRenew:       row.bool("auto_renew"),
+				StartDate:       row.time("start_date"),
+				EndDate:         row.time("end_date"),
+				NextBillingDate: row.time("next_billing_date"),
+				LastPaymentDate: row.time("last_payment_date"),
+			},
+		)
+	default:
+		row.invalid("record", fmt.Sprintf("unknown kind of row %q", kind))
+	}
+}
+
+// finish returns the tenant being read, with the errors of its rows.
+func (r *csvReader) finish() *Record {
+	record := r.record
+	if len(r.fields) > 0 {
+		record.Err = apperrors.ErrValidation.WithFields(r.fields...)
+	}
+	r.record, r.company, r.fields = nil, nil, nil
+
+	return record
+}
+
+// csvRow is a row of a CSV file, its fields are the columns it failed to decode.
+type csvRow struct {
+	values  []string
+	columns map[string]int
+	line    int
+	fields  []apperrors.FieldError
+}
+
+func (r *csvRow) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.values) {
+		return ""
+	}
+
+	return strings.TrimSpace(r.values[i])
+}
+
+func (r *csvRow) bool(column string) bool {
+	value := r.get(column)
+	if value == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.invalid(column, fmt.Sprintf("%q is not a boolean", value))
+	}
+
+	return b
+}
+
+func (r *csvRow) float(column string) float64 {
+	value := r.get(column)
+	if value == "" {
+		return 0
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.invalid(column, fmt.Sprintf("%q is not a number", value))
+	}
+
+	return f
+}
+
+// time parses RFC 3339 date-times, and dates alone as midnight UTC.
+func (r *csvRow) time(column string) time.Time {
+	value := r.get(column)
+	if value == "" {
+		return time.Time{}
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC()
+		}
+	}
+	r.invalid(column, fmt.Sprintf("%q is not a date", value))
+
+	return time.Time{}
+}
+
+func (r *csvRow) invalid(column string, message string) {
+	r.fields = append(r.fields, apperrors.FieldError{Field: column, Message: fmt.Sprintf("line %d: %s", r.line, message)})
+}
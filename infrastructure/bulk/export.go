@@ -0,0 +1,31 @@
+package bulk
+
+import (
+	"context"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+// Source streams the tenants of exports, *mongo.TenantRepository implements it.
+type Source interface {
+	EachTenant(ctx context.Context, filter map[string]interface{}, fn func(tenant *entities.Tenant) error) error
+}
+
+// Export writes the tenants of source matching filter to w, and returns how many it wrote.
+func Export(ctx context.Context, source Source, filter map[string]interface{}, w Writer) (int, error) {
+	exported := 0
+	err := source.EachTenant(
+		ctx, filter, func(tenant *entities.Tenant) error {
+			if err := w.Write(tenant); err != nil {
+				return err
+			}
+			exported++
+			return nil
+		},
+	)
+	if err != nil {
+		return exported, err
+	}
+
+	return exported, w.Flush()
+}
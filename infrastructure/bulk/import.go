@@ -0,0 +1,295 @@
+package bulk
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// Mode is how imports treat tenants that already exist.
+type Mode string
+
+const (
+	// InsertOnly fails the records of tenants that already exist.
+	InsertOnly Mode = "insert"
+	// Upsert updates the tenants that already exist.
+	Upsert Mode = "upsert"
+)
+
+// ParseMode returns the mode of a name.
+func ParseMode(name string) (Mode, error) {
+	switch Mode(strings.ToLower(name)) {
+	case InsertOnly:
+		return InsertOnly, nil
+	case Upsert:
+		return Upsert, nil
+	default:
+		return "", errors.Errorf("unknown mode %q, expected insert or upsert", name)
+	}
+}
+
+// Tenants are the operations on tenants imports use, *service.TenantService implements them.
+type Tenants interface {
+	CreateTenant(ctx context.Context, tenant *entities.Tenant) error
+	UpdateTenant(ctx context.Context, id string, tenant *entities.Tenant) error
+	GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error)
+	GetTenantBySubdomain(ctx context.Context, subdomain string) (*entities.Tenant, error)
+}
+
+// RecordError is why a record of an import failed.
+type RecordError struct {
+	Record    int                    `json:"record"`
+	Line      int                    `json:"line"`
+	TenantID  string                 `json:"tenant_id,omitempty"`
+	Subdomain string                 `json:"subdomain,omitempty"`
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Errors    []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// ImportReport sums up an import.
+type ImportReport struct {
+	Read    int  `json:"read"`
+	Created int  `json:"created"`
+	Updated int  `json:"updated"`
+	Failed  int  `json:"failed"`
+	DryRun  bool `json:"dry_run"`
+}
+
+// Importer imports tenants one record at a time. Records failing to decode, to validate or to be
+// written are reported and skipped, the import goes on with the next one.
+type Importer struct {
+	tenants   Tenants
+	logger    utils.LoggerInterface
+	mode      Mode
+	dryRun    bool
+	maxErrors int
+	onError   func(RecordError)
+}
+
+type Option func(*Importer)
+
+// WithMode sets how existing tenants are treated, imports are insert only by default.
+func WithMode(mode Mode) Option {
+	return func(i *Importer) {
+		i.mode = mode
+	}
+}
+
+// WithDryRun validates records and reports what an import would do without writing anything.
+func WithDryRun() Option {
+	return func(i *Importer) {
+		i.dryRun = true
+	}
+}
+
+// WithErrorHandler calls handle with the error of every failed record.
+func WithErrorHandler(handle func(RecordError)) Option {
+	return func(i *Importer) {
+		i.onError = handle
+	}
+}
+
+// WithMaxErrors stops imports once more than max records failed, imports go to the end by default.
+func WithMaxErrors(max int) Option {
+	return func(i *Importer) {
+		i.maxErrors = max
+	}
+}
+
+func NewImporter(tenants Tenants, logger utils.LoggerInterface, opts ...Option) *Importer {
+	i := &Importer{
+		tenants: tenants,
+		logger:  logger,
+		mode:    InsertOnly,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+
+	return i
+}
+
+// Import imports the records of r. Errors returned stopped the import: the import failed to read
+// or to look up tenants, was canceled or failed more records than allowed. The report tells what
+// was done until then.
+func (i *Importer) Import(ctx context.Context, r Reader) (ImportReport, error) {
+	report := ImportReport{DryRun: i.dryRun}
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		record, err := r.Read()
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Read++
+
+		created, err := i.importRecord(ctx, record)
+		var failure *recordFailure
+		switch {
+		case errors.As(err, &failure):
+			report.Failed++
+			i.fail(record, failure.err)
+			if i.maxErrors > 0 && report.Failed > i.maxErrors {
+				return report, errors.Errorf("import stopped after %d failed records", report.Failed)
+			}
+		case err != nil:
+			return report, errors.Wrapf(err, "failed to import record %d", record.Number)
+		case created:
+			report.Created++
+		default:
+			report.Updated++
+		}
+	}
+}
+
+// recordFailure is the error of a record failing, other errors stop imports.
+type recordFailure struct {
+	err error
+}
+
+func (f *recordFailure) Error() string {
+	return f.err.Error()
+}
+
+// importRecord creates or updates the tenant of a record, and tells which.
+func (i *Importer) importRecord(ctx context.Context, record *Record) (bool, error) {
+	if record.Err != nil {
+		return false, &recordFailure{record.Err}
+	}
+
+	tenant := record.Tenant
+	tenant.Subdomain = strings.ToLower(tenant.Subdomain)
+	if err := service.ValidateTenant(tenant); err != nil {
+		return false, &recordFailure{err}
+	}
+	fillIDs(tenant)
+
+	existing, err := i.find(ctx, tenant)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case existing == nil:
+		if i.dryRun {
+			return true, nil
+		}
+		if err := i.tenants.CreateTenant(ctx, tenant); err != nil {
+			return false, &recordFailure{err}
+		}
+		return true, nil
+	case i.mode != Upsert:
+		return false, &recordFailure{apperrors.ErrTenantAlreadyExists.WithFields(existingField(tenant, existing))}
+	case tenant.ID != "" && tenant.ID != existing.ID:
+		return false, &recordFailure{
+			apperrors.ErrTenantAlreadyExists.WithFields(
+				apperrors.FieldError{Field: "subdomain", Message: "belongs to tenant " + existing.ID},
+			),
+		}
+	}
+
+	if record.Partial {
+		merge(tenant, existing)
+	}
+	if i.dryRun {
+		return false, nil
+	}
+	if err := i.tenants.UpdateTenant(ctx, existing.ID, tenant); err != nil {
+		return false, &recordFailure{err}
+	}
+
+	return false, nil
+}
+
+// find returns the tenant a record imports, by ID and then by subdomain, or nil when it does not
+// exist yet.
+func (i *Importer) find(ctx context.Context, tenant *entities.Tenant) (*entities.Tenant, error) {
+	if tenant.ID != "" {
+		existing, err := i.tenants.GetTenantByID(ctx, tenant.ID)
+		if !errors.Is(err, apperrors.ErrNoTenantDocumentsFound) {
+			return existing, err
+		}
+	}
+
+	existing, err := i.tenants.GetTenantBySubdomain(ctx, tenant.Subdomain)
+	if errors.Is(err, apperrors.ErrNoTenantDocumentsFound) {
+		return nil, nil
+	}
+
+	return existing, err
+}
+
+func (i *Importer) fail(record *Record, err error) {
+	appErr := apperrors.From(err)
+	failure := RecordError{
+		Record:  record.Number,
+		Line:    record.Line,
+		Code:    appErr.Code,
+		Message: err.Error(),
+		Errors:  appErr.Fields,
+	}
+	if record.Tenant != nil {
+		failure.TenantID, failure.Subdomain = record.Tenant.ID, record.Tenant.Subdomain
+	}
+
+	i.logger.Warnf("record %d on line %d failed: %s", failure.Record, failure.Line, failure.Message)
+	if i.onError != nil {
+		i.onError(failure)
+	}
+}
+
+func existingField(tenant *entities.Tenant, existing *entities.Tenant) apperrors.FieldError {
+	if tenant.ID == existing.ID {
+		return apperrors.FieldError{Field: "_id", Message: "already exists"}
+	}
+
+	return apperrors.FieldError{Field: "subdomain", Message: "belongs to tenant " + existing.ID}
+}
+
+// fillIDs gives IDs to the contacts, companies and subscriptions of a tenant missing them.
+func fillIDs(tenant *entities.Tenant) {
+	for _, contact := range tenant.PrimaryContacts {
+		if contact.ID == "" {
+			contact.ID = utils.NewXID().ID
+		}
+	}
+	for _, company := range tenant.Companies {
+		if company.ID == "" {
+			company.ID = utils.NewXID().ID
+		}
+		for _, subscription := range company.Subscriptions {
+			if subscription.ID == "" {
+				subscription.ID = utils.NewXID().ID
+			}
+		}
+	}
+}
+
+// merge copies the fields partial records do not carry from the tenant they update. Contacts keep
+// their roles, they are matched by ID or email.
+func merge(tenant *entities.Tenant, existing *entities.Tenant) {
+	tenant.PaymentDetails = existing.PaymentDetails
+	tenant.TenantMetadata = existing.TenantMetadata
+	tenant.DeletedAt = existing.DeletedAt
+
+	for _, contact := range tenant.PrimaryContacts {
+		for _, current := range existing.PrimaryContacts {
+			if current.ID == contact.ID || (current.Email != "" && strings.EqualFold(current.Email, contact.Email)) {
+				contact.Roles = current.Roles
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package bulk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/bulk"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var logger = utils.NewLogger()
+
+// fakeTenants keeps tenants in memory by ID.
+type fakeTenants struct {
+	tenants map[string]*entities.Tenant
+	writes  int
+}
+
+func (f *fakeTenants) CreateTenant(_ context.Context, tenant *entities.Tenant) error {
+	if tenant.ID == "" {
+		tenant.ID = "tenant-" + tenant.Subdomain
+	}
+	f.tenants[tenant.ID] = tenant
+	f.writes++
+	return nil
+}
+
+func (f *fakeTenants) UpdateTenant(_ context.Context, id string, tenant *entities.Tenant) error {
+	tenant.ID = id
+	f.tenants[id] = tenant
+	f.writes++
+	return nil
+}
+
+func (f *fakeTenants) GetTenantByID(_ context.Context, id string) (*entities.Tenant, error) {
+	tenant, ok := f.tenants[id]
+	if !ok {
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+	return tenant, nil
+}
+
+func (f *fakeTenants) GetTenantBySubdomain(_ context.Context, subdomain string) (*entities.Tenant, error) {
+	for _, tenant := range f.tenants {
+		if tenant.Subdomain == subdomain {
+			return tenant, nil
+		}
+	}
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (f *fakeTenants) EachTenant(
+	_ context.Context, filter map[string]interface{}, fn func(tenant *entities.Tenant) error,
+) error {
+	for _, id := range []string{"tenant-1", "tenant-2"} {
+		tenant, ok := f.tenants[id]
+		if !ok || (filter["is_active"] != nil && filter["is_active"] != tenant.IsActive) {
+			continue
+		}
+		if err := fn(tenant); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newFakeTenants() *fakeTenants {
+	existing := newTenant("tenant-1", "acme")
+	existing.TenantMetadata = &entities.TenantMetadata{}
+	existing.PrimaryContacts[0].Roles = []*entities.Role{{ID: "admin", Name: "Admin"}}
+
+	return &fakeTenants{tenants: map[string]*entities.Tenant{"tenant-1": existing}}
+}
+
+func ndjson(tenants ...*entities.Tenant) string {
+	var lines []string
+	for _, tenant := range tenants {
+		encoded, _ := json.Marshal(tenant)
+		lines = append(lines, string(encoded))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestImporter_Import(t *testing.T) {
+	renamed := newTenant("tenant-1", "acme")
+	renamed.Name = "Acme Renamed"
+	renamed.PrimaryContacts[0].ID = ""
+
+	invalid := newTenant("tenant-3", "Not A Subdomain!")
+	taken := newTenant("tenant-4", "acme")
+
+	var testCases = []struct {
+		Name           string
+		Format         bulk.Format
+		Input          string
+		Options        []bulk.Option
+		ExpectedReport bulk.ImportReport
+		ExpectedCodes  []string
+		ExpectedError  string
+		ExpectedWrites int
+		Check          func(t *testing.T, f *fakeTenants)
+	}{
+		{
+			Name:           "Happy Path: Insert new tenants",
+			Input:          ndjson(newTenant("", "globex"), newTenant("tenant-5", "initech")),
+			ExpectedReport: bulk.ImportReport{Read: 2, Created: 2},
+			ExpectedWrites: 2,
+			Check: func(t *testing.T, f *fakeTenants) {
+				require.Contains(t, f.tenants, "tenant-globex")
+				require.Contains(t, f.tenants, "tenant-5")
+			},
+		},
+		{
+			Name:           "Error Path: Insert only fails existing tenants",
+			Input:          ndjson(renamed, newTenant("", "globex")),
+			ExpectedReport: bulk.ImportReport{Read: 2, Created: 1, Failed: 1},
+			ExpectedCodes:  []string{"tenant_already_exists"},
+			ExpectedWrites: 1,
+		},
+		{
+			Name:           "Happy Path: Upsert updates existing tenants",
+			Input:          ndjson(renamed),
+			Options:        []bulk.Option{bulk.WithMode(bulk.Upsert)},
+			ExpectedReport: bulk.ImportReport{Read: 1, Updated: 1},
+			ExpectedWrites: 1,
+			Check: func(t *testing.T, f *fakeTenants) {
+				tenant := f.tenants["tenant-1"]
+				assert.Equal(t, "Acme Renamed", tenant.Name)
+				assert.NotEmpty(t, tenant.PrimaryContacts[0].ID)
+				assert.Nil(t, tenant.TenantMetadata)
+				assert.Empty(t, tenant.PrimaryContacts[0].Roles)
+			},
+		},
+		{
+			Name:           "Happy Path: Upsert of partial records keeps the other fields",
+			Format:         bulk.CSV,
+			Input:          "record,subdomain,name,email\ntenant,acme,Acme Renamed,\ncontact,,,ADA@acme.io\n",
+			Options:        []bulk.Option{bulk.WithMode(bulk.Upsert)},
+			ExpectedReport: bulk.ImportReport{Read: 1, Updated: 1},
+			ExpectedWrites: 1,
+			Check: func(t *testing.T, f *fakeTenants) {
+				tenant := f.tenants["tenant-1"]
+				assert.Equal(t, "Acme Renamed", tenant.Name)
+				assert.NotNil(t, tenant.TenantMetadata)
+				require.Len(t, tenant.PrimaryContacts[0].Roles, 1)
+				assert.Equal(t, "admin", tenant.PrimaryContacts[0].Roles[0].ID)
+			},
+		},
+		{
+			Name:           "Error Path: Upsert of a subdomain taken by another tenant",
+			Input:          ndjson(taken),
+			Options:        []bulk.Option{bulk.WithMode(bulk.Upsert)},
+			ExpectedReport: bulk.ImportReport{Read: 1, Failed: 1},
+			ExpectedCodes:  []string{"tenant_already_exists"},
+		},
+		{
+			Name:           "Happy Path: Dry run writes nothing",
+			Input:          ndjson(renamed, newTenant("", "globex")),
+			Options:        []bulk.Option{bulk.WithMode(bulk.Upsert), bulk.WithDryRun()},
+			ExpectedReport: bulk.ImportReport{Read: 2, Created: 1, Updated: 1, DryRun: true},
+		},
+		{
+			Name:           "Error Path: Invalid records are skipped",
+			Input:          ndjson(invalid) + "\n{\"name\":\n" + ndjson(newTenant("", "globex")),
+			ExpectedReport: bulk.ImportReport{Read: 3, Created: 1, Failed: 2},
+			ExpectedCodes:  []string{"validation_failed", "validation_failed"},
+			ExpectedWrites: 1,
+		},
+		{
+			Name:           "Error Path: Too many errors stop the import",
+			Input:          ndjson(invalid, invalid, newTenant("", "globex")),
+			Options:        []bulk.Option{bulk.WithMaxErrors(1)},
+			ExpectedReport: bulk.ImportReport{Read: 2, Failed: 2},
+			ExpectedCodes:  []string{"validation_failed", "validation_failed"},
+			ExpectedError:  "import stopped after 2 failed records",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				format := tt.Format
+				if format == "" {
+					format = bulk.NDJSON
+				}
+				r, err := bulk.NewReader(format, strings.NewReader(tt.Input))
+				require.NoError(t, err)
+
+				var codes []string
+				opts := append(
+					[]bulk.Option{bulk.WithErrorHandler(func(e bulk.RecordError) { codes = append(codes, e.Code) })},
+					tt.Options...,
+				)
+
+				f := newFakeTenants()
+				report, err := bulk.NewImporter(f, logger, opts...).Import(context.Background(), r)
+				if tt.ExpectedError != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), tt.ExpectedError)
+				} else {
+					require.NoError(t, err)
+				}
+
+				assert.Equal(t, tt.ExpectedReport, report)
+				assert.Equal(t, tt.ExpectedCodes, codes)
+				assert.Equal(t, tt.ExpectedWrites, f.writes)
+				if tt.Check != nil {
+					tt.Check(t, f)
+				}
+			},
+		)
+	}
+}
+
+func TestImporter_Import_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := bulk.NewNDJSONReader(strings.NewReader(ndjson(newTenant("", "globex"))))
+	_, err := bulk.NewImporter(newFakeTenants(), logger).Import(ctx, r)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestExport(t *testing.T) {
+	f := newFakeTenants()
+	suspended := newTenant("tenant-2", "globex")
+	suspended.IsActive = false
+	f.tenants["tenant-2"] = suspended
+
+	var buffer bytes.Buffer
+	exported, err := bulk.Export(
+		context.Background(), f, map[string]interface{}{"is_active": true}, bulk.NewNDJSONWriter(&buffer),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 1, exported)
+
+	records := readAll(t, bulk.NewNDJSONReader(&buffer))
+	require.Len(t, records, 1)
+	assert.Equal(t, "tenant-1", records[0].Tenant.ID)
+}
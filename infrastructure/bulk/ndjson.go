@@ -0,0 +1,76 @@
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// maxLineSize is the size of the longest line of JSON Lines imports, it bounds the memory a
+// record takes.
+const maxLineSize = 16 << 20
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+	number  int
+}
+
+// NewNDJSONReader returns the reader of JSON Lines imports, one tenant per line. Blank lines are
+// skipped.
+func NewNDJSONReader(r io.Reader) Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+
+	return &ndjsonReader{scanner: scanner}
+}
+
+func (r *ndjsonReader) Read() (*Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		r.number++
+		record := &Record{Number: r.number, Line: r.line, Tenant: &entities.Tenant{}}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(record.Tenant); err != nil {
+			record.Err = apperrors.ErrValidation.Wrap(err)
+		}
+
+		return record, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read line %d", r.line+1)
+	}
+
+	return nil, io.EOF
+}
+
+type ndjsonWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewNDJSONWriter returns the writer of JSON Lines exports, one tenant per line.
+func NewNDJSONWriter(w io.Writer) Writer {
+	buffer := bufio.NewWriter(w)
+	return &ndjsonWriter{buffer: buffer, encoder: json.NewEncoder(buffer)}
+}
+
+func (w *ndjsonWriter) Write(tenant *entities.Tenant) error {
+	return errors.Wrapf(w.encoder.Encode(tenant), "failed to write tenant %s", tenant.ID)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return errors.Wrap(w.buffer.Flush(), "failed to write tenants")
+}
@@ -1,6 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/hebecoding/digital-dash-commons/utils"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -9,23 +15,199 @@ import (
 var Config *Configurations
 
 type Configurations struct {
-	Environment string         `mapstructure:"environment"`
-	Application Application    `mapstructure:"application"`
-	DB          DatabaseConfig `mapstructure:"database"`
+	Environment string           `mapstructure:"environment"`
+	Application Application      `mapstructure:"application"`
+	DB          DatabaseConfig   `mapstructure:"database"`
+	Domains     DomainConfig     `mapstructure:"domains"`
+	Auth        AuthConfig       `mapstructure:"auth"`
+	RateLimit   RateLimitConfig  `mapstructure:"rate_limit"`
+	Events      EventsConfig     `mapstructure:"events"`
+	Webhooks    WebhooksConfig   `mapstructure:"webhooks"`
+	Changes     ChangesConfig    `mapstructure:"changes"`
+	Cache       CacheConfig      `mapstructure:"cache"`
+	Migrations  MigrationsConfig `mapstructure:"migrations"`
+	Health      HealthConfig     `mapstructure:"health"`
+	Metrics     MetricsConfig    `mapstructure:"metrics"`
 }
 
 type Application struct {
-	Name    string `mapstructure:"name"`
-	Port    string `mapstructure:"port"`
-	Version string `mapstructure:"version"`
+	Name     string `mapstructure:"name"`
+	Port     string `mapstructure:"port"`
+	GRPCPort string `mapstructure:"grpc_port"`
+	Version  string `mapstructure:"version"`
 }
 
 type DatabaseConfig struct {
+	// Backend is where tenants and roles are stored, mongo, postgres or sqlite. Audit logs, the
+	// outbox and webhooks are only kept in mongo, the service runs without them with the others.
+	Backend string `mapstructure:"backend"`
+	// URL is the mongo connection string, required with the mongo backend.
+	URL      string         `mapstructure:"url"`
+	Username string         `mapstructure:"username"`
+	Password string         `mapstructure:"password"`
+	Postgres PostgresConfig `mapstructure:"postgres"`
+	SQLite   SQLiteConfig   `mapstructure:"sqlite"`
+	Indexes  IndexesConfig  `mapstructure:"indexes"`
+}
+
+// IndexesConfig configures the reconciliation of the declared mongo indexes at startup. Missing
+// indexes are always created, drifted and stale ones are only reported unless asked otherwise.
+type IndexesConfig struct {
+	// DropStale drops the indexes that are not declared.
+	DropStale bool `mapstructure:"drop_stale"`
+	// RebuildDrifted drops the indexes differing from their declaration and creates them again.
+	RebuildDrifted bool `mapstructure:"rebuild_drifted"`
+}
+
+// IsMongo reports whether tenants and roles are stored in mongo, the default backend.
+func (c DatabaseConfig) IsMongo() bool {
+	return c.Backend == "" || c.Backend == MongoBackend
+}
+
+type PostgresConfig struct {
 	URL      string `mapstructure:"url"`
-	Username string `mapstructure:"username"`
-	Password string `mapstructure:"password"`
+	MaxConns int32  `mapstructure:"max_conns"`
+}
+
+type SQLiteConfig struct {
+	// Path is the database file, created when missing.
+	Path string `mapstructure:"path"`
+}
+
+type DomainConfig struct {
+	// ReverifyInterval is how often verified custom domains are checked again.
+	ReverifyInterval time.Duration `mapstructure:"reverify_interval"`
+}
+
+type AuthConfig struct {
+	// JWKSFile is a local JSON Web Key Set verifying bearer tokens. It takes precedence over JWKSURL.
+	JWKSFile string `mapstructure:"jwks_file"`
+	// JWKSURL is the endpoint serving the JSON Web Key Set verifying bearer tokens.
+	JWKSURL string `mapstructure:"jwks_url"`
+	// JWKSRefreshInterval is how long keys fetched from JWKSURL are cached.
+	JWKSRefreshInterval time.Duration `mapstructure:"jwks_refresh_interval"`
+	Issuer              string        `mapstructure:"issuer"`
+	Audience            string        `mapstructure:"audience"`
+	// TenantClaim is the claim holding the tenant ID of the subject.
+	TenantClaim string `mapstructure:"tenant_claim"`
+	// PlatformTenantID is the tenant of the operators of the service, its principals manage every
+	// tenant and the platform roles. Nobody can without it.
+	PlatformTenantID string `mapstructure:"platform_tenant_id"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Mode is token_bucket or fixed_window.
+	Mode string `mapstructure:"mode"`
+	// Backend is memory, limiting each instance on its own, or mongo, sharing limits between instances.
+	// It is memory unless tenants are stored in mongo.
+	Backend string `mapstructure:"backend"`
+	// Default is the quota of tenants without a configured active plan.
+	Default RateLimitQuota `mapstructure:"default"`
+	// Plans are the quotas of the subscription plans, by plan name.
+	Plans map[string]RateLimitQuota `mapstructure:"plans"`
+}
+
+type RateLimitQuota struct {
+	Tenant RateLimitRule `mapstructure:"tenant"`
+	APIKey RateLimitRule `mapstructure:"api_key"`
+}
+
+type RateLimitRule struct {
+	Requests int           `mapstructure:"requests"`
+	Period   time.Duration `mapstructure:"period"`
+	Burst    int           `mapstructure:"burst"`
+}
+
+type EventsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Publisher is memory, keeping events in the process, nats or kafka.
+	Publisher string      `mapstructure:"publisher"`
+	NATS      NATSConfig  `mapstructure:"nats"`
+	Kafka     KafkaConfig `mapstructure:"kafka"`
+	Relay     RelayConfig `mapstructure:"relay"`
+}
+
+type NATSConfig struct {
+	URL string `mapstructure:"url"`
+	// SubjectPrefix prefixes the subjects events are published on, a JetStream stream must capture them.
+	SubjectPrefix string `mapstructure:"subject_prefix"`
+}
+
+type KafkaConfig struct {
+	Brokers []string `mapstructure:"brokers"`
+	Topic   string   `mapstructure:"topic"`
+}
+
+type RelayConfig struct {
+	// Interval is how often the outbox is polled for events to publish.
+	Interval  time.Duration `mapstructure:"interval"`
+	BatchSize int           `mapstructure:"batch_size"`
+	// Lease is how long claimed events are reserved to a relay.
+	Lease time.Duration `mapstructure:"lease"`
+}
+
+// WebhooksConfig configures the delivery of events to the webhook endpoints of tenants,
+// webhooks receive the events published through the outbox.
+type WebhooksConfig struct {
+	// MaxAttempts is how many times a delivery is attempted before it is dead.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// DeliveryInterval is how often deliveries due are attempted.
+	DeliveryInterval time.Duration `mapstructure:"delivery_interval"`
+	// AllowHTTP accepts endpoints without TLS, for local development.
+	AllowHTTP bool `mapstructure:"allow_http"`
+	// AllowPrivateNetworks accepts endpoints on loopback and private addresses, for local development.
+	AllowPrivateNetworks bool `mapstructure:"allow_private_networks"`
+}
+
+// ChangesConfig configures the watcher following the changes to tenants and roles, including
+// the ones made directly in the database, to keep caches and read models up to date.
+type ChangesConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// PollInterval is how often collections are scanned when change streams are unavailable,
+	// which is the case on standalone servers.
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+}
+
+// CacheConfig configures the in-process cache of tenant lookups. Without the watcher following
+// changes, changes made by other instances are only seen once cached tenants expire.
+type CacheConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Capacity is how many tenants, and how many subdomains, are cached.
+	Capacity int           `mapstructure:"capacity"`
+	TTL      time.Duration `mapstructure:"ttl"`
+	// NegativeTTL is how long unknown subdomains are remembered as such.
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+}
+
+// MigrationsConfig configures the batch migrator upgrading tenant documents to the latest schema
+// version. Tenants of older versions are upgraded when they are read either way.
+type MigrationsConfig struct {
+	// RunOnStartup runs the migrator in the background when the service starts.
+	RunOnStartup bool `mapstructure:"run_on_startup"`
+	BatchSize    int  `mapstructure:"batch_size"`
+	// DryRun only reports what the migrator would upgrade.
+	DryRun bool `mapstructure:"dry_run"`
+}
+
+// HealthConfig configures the checks behind /healthz and /readyz.
+type HealthConfig struct {
+	// Timeout fails checks lasting longer, such as pinging the database.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// MetricsConfig configures the metrics served at /metrics.
+type MetricsConfig struct {
+	// StatsInterval is how often the gauges of tenants and subscriptions are refreshed.
+	StatsInterval time.Duration `mapstructure:"stats_interval"`
 }
 
+const (
+	MongoBackend    = "mongo"
+	PostgresBackend = "postgres"
+	SQLiteBackend   = "sqlite"
+)
+
 const (
 	Local = "local"
 	Dev   = "dev"
@@ -61,3 +243,79 @@ func ReadInConfig(logger utils.LoggerInterface) error {
 
 	return nil
 }
+
+// Validate reports every setting that is missing or out of range, settings left empty take
+// their defaults.
+func (c *Configurations) Validate() error {
+	var problems []string
+	invalid := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	// oneOf checks settings with a fixed set of values, left empty they take their default
+	oneOf := func(name string, value string, allowed ...string) {
+		if value == "" {
+			return
+		}
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		invalid("%s must be one of %s, got %q", name, strings.Join(allowed, ", "), value)
+	}
+
+	ports := map[string]string{"application.port": c.Application.Port, "application.grpc_port": c.Application.GRPCPort}
+	for name, port := range ports {
+		if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+			invalid("%s must be a port number, got %q", name, port)
+		}
+	}
+
+	if c.DB.IsMongo() && c.DB.URL == "" {
+		invalid("database.url is required with the mongo backend")
+	}
+	oneOf("database.backend", c.DB.Backend, MongoBackend, PostgresBackend, SQLiteBackend)
+	if c.DB.Backend == PostgresBackend && c.DB.Postgres.URL == "" {
+		invalid("database.postgres.url is required with the postgres backend")
+	}
+
+	if c.RateLimit.Enabled {
+		oneOf("rate_limit.mode", c.RateLimit.Mode, "token_bucket", "fixed_window")
+		oneOf("rate_limit.backend", c.RateLimit.Backend, "memory", "mongo")
+	}
+
+	if c.Events.Enabled {
+		oneOf("events.publisher", c.Events.Publisher, "memory", "nats", "kafka")
+		if c.Events.Publisher == "nats" && c.Events.NATS.URL == "" {
+			invalid("events.nats.url is required with the nats publisher")
+		}
+		if c.Events.Publisher == "kafka" && (len(c.Events.Kafka.Brokers) == 0 || c.Events.Kafka.Topic == "") {
+			invalid("events.kafka.brokers and events.kafka.topic are required with the kafka publisher")
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"domains.reverify_interval":  c.Domains.ReverifyInterval,
+		"auth.jwks_refresh_interval": c.Auth.JWKSRefreshInterval,
+		"events.relay.interval":      c.Events.Relay.Interval,
+		"events.relay.lease":         c.Events.Relay.Lease,
+		"webhooks.delivery_interval": c.Webhooks.DeliveryInterval,
+		"changes.poll_interval":      c.Changes.PollInterval,
+		"cache.ttl":                  c.Cache.TTL,
+		"cache.negative_ttl":         c.Cache.NegativeTTL,
+		"health.timeout":             c.Health.Timeout,
+		"metrics.stats_interval":     c.Metrics.StatsInterval,
+	}
+	for name, duration := range durations {
+		if duration < 0 {
+			invalid("%s must not be negative, got %s", name, duration)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+
+	return errors.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+}
@@ -0,0 +1,98 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func validConfig() config.Configurations {
+	return config.Configurations{
+		Application: config.Application{Port: "8080", GRPCPort: "9090"},
+		DB:          config.DatabaseConfig{URL: "mongodb://localhost:27017"},
+	}
+}
+
+func TestConfigurations_Validate(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Change        func(c *config.Configurations)
+		ExpectedError string
+	}{
+		{
+			Name:   "Happy Path: defaults",
+			Change: func(c *config.Configurations) {},
+		},
+		{
+			Name: "Happy Path: kafka publisher",
+			Change: func(c *config.Configurations) {
+				c.Events = config.EventsConfig{
+					Enabled: true, Publisher: "kafka", Kafka: config.KafkaConfig{Brokers: []string{"kafka:9092"}, Topic: "tenants"},
+				}
+			},
+		},
+		{
+			Name: "Error Path: every problem is reported",
+			Change: func(c *config.Configurations) {
+				c.Application.Port = "http"
+				c.DB.Backend = "mysql"
+				c.Cache.TTL = -time.Second
+			},
+			ExpectedError: `invalid configuration: application.port must be a port number, got "http"; ` +
+				`cache.ttl must not be negative, got -1s; ` +
+				`database.backend must be one of mongo, postgres, sqlite, got "mysql"`,
+		},
+		{
+			Name: "Error Path: postgres without url",
+			Change: func(c *config.Configurations) {
+				c.DB.Backend = config.PostgresBackend
+			},
+			ExpectedError: "invalid configuration: database.postgres.url is required with the postgres backend",
+		},
+		{
+			Name: "Happy Path: sqlite without mongo",
+			Change: func(c *config.Configurations) {
+				c.DB = config.DatabaseConfig{Backend: config.SQLiteBackend}
+			},
+		},
+		{
+			Name: "Error Path: mongo without url",
+			Change: func(c *config.Configurations) {
+				c.DB.URL = ""
+			},
+			ExpectedError: "invalid configuration: database.url is required with the mongo backend",
+		},
+		{
+			Name: "Error Path: nats without url",
+			Change: func(c *config.Configurations) {
+				c.Events = config.EventsConfig{Enabled: true, Publisher: "nats"}
+			},
+			ExpectedError: "invalid configuration: events.nats.url is required with the nats publisher",
+		},
+		{
+			Name: "Happy Path: disabled rate limits are not checked",
+			Change: func(c *config.Configurations) {
+				c.RateLimit.Mode = "sliding_window"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				c := validConfig()
+				tt.Change(&c)
+
+				err := c.Validate()
+				if tt.ExpectedError == "" {
+					assert.NoError(t, err)
+					return
+				}
+				assert.EqualError(t, err, tt.ExpectedError)
+			},
+		)
+	}
+}
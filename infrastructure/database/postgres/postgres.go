@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// NewPostgresDB connects to postgres and brings its schema up to date. MaxConns bounds the
+// connections of the pool, the default of pgx is used when it is not positive.
+func NewPostgresDB(ctx context.Context, logger *utils.Logger, url string, maxConns int32) (*pgxpool.Pool, error) {
+	logger.Info("connecting to postgres")
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse postgres url")
+	}
+	if maxConns > 0 {
+		cfg.MaxConns = maxConns
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to postgres instance")
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, errors.Wrap(err, "failed to ping postgres instance")
+	}
+
+	logger.Info("migrating postgres schema")
+	if err := repositories.Migrate(ctx, pool, logger); err != nil {
+		pool.Close()
+		return nil, errors.Wrap(err, "failed to migrate postgres schema")
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	repositories "github.com/hebecoding/tenant-management/infrastructure/repositories/sqlite"
+	"github.com/pkg/errors"
+	// registers the pure go sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+// NewSQLiteDB opens the sqlite database at path, creating it and its schema when missing.
+//
+// SQLite allows a single writer at a time, the database is used through a single connection
+// so that writers queue up instead of failing as busy.
+func NewSQLiteDB(ctx context.Context, logger *utils.Logger, path string) (*sql.DB, error) {
+	logger.Infof("opening sqlite database %s", path)
+	pragmas := url.Values{}
+	pragmas.Add("_pragma", "foreign_keys(1)")
+	pragmas.Add("_pragma", "journal_mode(WAL)")
+	pragmas.Add("_pragma", "busy_timeout(5000)")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+pragmas.Encode())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open sqlite database")
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, errors.Wrap(err, "failed to open sqlite database")
+	}
+
+	if err := repositories.CreateSchema(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
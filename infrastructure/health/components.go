@@ -0,0 +1,128 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/pkg/errors"
+)
+
+// Step is a step of the startup of the service, such as setting up indexes. Its check fails
+// until the step completed, and afterwards when it failed. The zero value is a step in progress.
+type Step struct {
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+// Complete records the outcome of the step.
+func (s *Step) Complete(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.done = true
+	s.err = err
+}
+
+func (s *Step) Check(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.done {
+		return errors.New("in progress")
+	}
+	return s.err
+}
+
+// Outcome checks the step without waiting for it, it only fails once the step failed. It is the
+// check of the steps the service serves requests during.
+func (s *Step) Outcome(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+// Workers runs the background workers of the service and tracks that they keep running until
+// the service stops.
+type Workers struct {
+	logger  utils.LoggerInterface
+	running sync.WaitGroup
+
+	mu sync.Mutex
+	// stopped are why workers stopped before the service, by worker name.
+	stopped map[string]string
+}
+
+func NewWorkers(logger utils.LoggerInterface) *Workers {
+	return &Workers{logger: logger, stopped: map[string]string{}}
+}
+
+// Go runs a worker until ctx is done. A worker returning or panicking before is reported as
+// stopped, panics are recovered.
+func (w *Workers) Go(ctx context.Context, name string, run func(ctx context.Context)) {
+	w.running.Add(1)
+	go func() {
+		defer w.running.Done()
+
+		reason := "returned"
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				reason = fmt.Sprintf("panicked: %v", recovered)
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			w.logger.Errorf("worker %s stopped: %s", name, reason)
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			w.stopped[name] = reason
+		}()
+
+		run(ctx)
+	}()
+}
+
+// Do runs a task of the startup of the service, such as a migration, and records its outcome
+// in step. Unlike workers, tasks are done once they return, Wait waits for the ones running.
+func (w *Workers) Do(ctx context.Context, name string, step *Step, run func(ctx context.Context) error) {
+	w.running.Add(1)
+	go func() {
+		defer w.running.Done()
+
+		err := run(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Errorf("%s failed: %v", name, err)
+		}
+		step.Complete(err)
+	}()
+}
+
+// Wait waits for the workers and tasks to return, once the context they were started with is done.
+func (w *Workers) Wait() {
+	w.running.Wait()
+}
+
+// Check fails when a worker stopped before the service.
+func (w *Workers) Check(context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.stopped) == 0 {
+		return nil
+	}
+
+	stopped := make([]string, 0, len(w.stopped))
+	for name, reason := range w.stopped {
+		stopped = append(stopped, fmt.Sprintf("%s %s", name, reason))
+	}
+	sort.Strings(stopped)
+
+	return errors.Errorf("workers stopped: %s", strings.Join(stopped, "; "))
+}
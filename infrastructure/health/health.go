@@ -0,0 +1,184 @@
+// Package health tells orchestrators whether the service is alive and ready to serve, from
+// checks of its dependencies and components.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/pkg/errors"
+)
+
+// Status is the outcome of a check, or of all of them.
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusFail Status = "fail"
+)
+
+// Check checks a dependency or a component of the service, an error fails it. Checks must
+// return once ctx is done.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of a check.
+type Result struct {
+	Name    string        `json:"name"`
+	Status  Status        `json:"status"`
+	Latency time.Duration `json:"latency_ns"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Report is the outcome of checks, it passes when all of them pass.
+type Report struct {
+	Status    Status    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the liveness and readiness checks of the service.
+type Checker struct {
+	logger    utils.LoggerInterface
+	timeout   time.Duration
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+type Option func(*Checker)
+
+// WithTimeout fails checks lasting longer than timeout, checks get 2 seconds by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		if timeout > 0 {
+			c.timeout = timeout
+		}
+	}
+}
+
+func NewChecker(logger utils.LoggerInterface, opts ...Option) *Checker {
+	c := &Checker{logger: logger, timeout: 2 * time.Second}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// AddLivenessCheck adds a check failing when the service must be restarted. Liveness checks
+// are part of the readiness checks too.
+func (c *Checker) AddLivenessCheck(name string, check Check) {
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+}
+
+// AddReadinessCheck adds a check failing while the service cannot serve requests, such as
+// when a dependency is unavailable.
+func (c *Checker) AddReadinessCheck(name string, check Check) {
+	c.readiness = append(c.readiness, namedCheck{name: name, check: check})
+}
+
+// Live runs the liveness checks.
+func (c *Checker) Live(ctx context.Context) *Report {
+	return c.run(ctx, c.liveness)
+}
+
+// Ready runs the liveness and readiness checks.
+func (c *Checker) Ready(ctx context.Context) *Report {
+	return c.run(ctx, append(append([]namedCheck{}, c.liveness...), c.readiness...))
+}
+
+// run runs checks concurrently, each within the timeout.
+func (c *Checker) run(ctx context.Context, checks []namedCheck) *Report {
+	report := &Report{Status: StatusPass, CheckedAt: time.Now().UTC(), Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		i, check := i, check
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.check(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusPass {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) check(ctx context.Context, check namedCheck) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// checks ignoring their context are given up on, their result is dropped
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		done <- check.check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.Errorf("timed out after %s", c.timeout)
+	}
+
+	result := Result{Name: check.name, Status: StatusPass, Latency: time.Since(start)}
+	if err != nil {
+		c.logger.Warnf("health check %s failed: %v", check.name, err)
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// LivenessHandler serves the liveness report, with status 503 when it fails.
+func (c *Checker) LivenessHandler() http.Handler {
+	return c.handler(c.Live)
+}
+
+// ReadinessHandler serves the readiness report, with status 503 when it fails.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return c.handler(c.Ready)
+}
+
+func (c *Checker) handler(run func(ctx context.Context) *Report) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				w.Header().Set("Allow", "GET, HEAD")
+				apperrors.WriteProblem(w, r, apperrors.ErrMethodNotAllowed)
+				return
+			}
+
+			report := run(r.Context())
+			status := http.StatusOK
+			if report.Status != StatusPass {
+				status = http.StatusServiceUnavailable
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(status)
+			if err := json.NewEncoder(w).Encode(report); err != nil {
+				c.logger.Error(err)
+			}
+		},
+	)
+}
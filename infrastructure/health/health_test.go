@@ -0,0 +1,209 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/health"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pass(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("unreachable") }
+
+// hang ignores its context, it never returns.
+func hang(context.Context) error { select {} }
+
+func TestChecker(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Liveness          map[string]health.Check
+		Readiness         map[string]health.Check
+		ExpectedLiveness  health.Status
+		ExpectedReadiness health.Status
+		ExpectedErrors    map[string]string
+	}{
+		{
+			Name:              "Happy Path: every check passes",
+			Liveness:          map[string]health.Check{"workers": pass},
+			Readiness:         map[string]health.Check{"mongo": pass, "indexes": pass},
+			ExpectedLiveness:  health.StatusPass,
+			ExpectedReadiness: health.StatusPass,
+		},
+		{
+			Name:              "Happy Path: without checks",
+			ExpectedLiveness:  health.StatusPass,
+			ExpectedReadiness: health.StatusPass,
+		},
+		{
+			Name:              "Error Path: failing readiness checks do not fail liveness",
+			Liveness:          map[string]health.Check{"workers": pass},
+			Readiness:         map[string]health.Check{"mongo": fail, "indexes": pass},
+			ExpectedLiveness:  health.StatusPass,
+			ExpectedReadiness: health.StatusFail,
+			ExpectedErrors:    map[string]string{"mongo": "unreachable"},
+		},
+		{
+			Name:              "Error Path: failing liveness checks fail readiness",
+			Liveness:          map[string]health.Check{"workers": fail},
+			Readiness:         map[string]health.Check{"mongo": pass},
+			ExpectedLiveness:  health.StatusFail,
+			ExpectedReadiness: health.StatusFail,
+			ExpectedErrors:    map[string]string{"workers": "unreachable"},
+		},
+		{
+			Name:              "Error Path: checks time out",
+			Readiness:         map[string]health.Check{"mongo": hang},
+			ExpectedLiveness:  health.StatusPass,
+			ExpectedReadiness: health.StatusFail,
+			ExpectedErrors:    map[string]string{"mongo": "timed out after 50ms"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				checker := health.NewChecker(utils.NewLogger(), health.WithTimeout(50*time.Millisecond))
+				for name, check := range tt.Liveness {
+					checker.AddLivenessCheck(name, check)
+				}
+				for name, check := range tt.Readiness {
+					checker.AddReadinessCheck(name, check)
+				}
+
+				assert.Equal(t, tt.ExpectedLiveness, checker.Live(context.Background()).Status)
+
+				report := checker.Ready(context.Background())
+				assert.Equal(t, tt.ExpectedReadiness, report.Status)
+				require.Len(t, report.Checks, len(tt.Liveness)+len(tt.Readiness))
+				for _, result := range report.Checks {
+					assert.Equal(t, tt.ExpectedErrors[result.Name], result.Error, result.Name)
+					if result.Error == "" {
+						assert.Equal(t, health.StatusPass, result.Status, result.Name)
+					}
+					assert.Less(t, result.Latency, time.Second, result.Name)
+				}
+			},
+		)
+	}
+}
+
+func TestChecker_Handlers(t *testing.T) {
+	checker := health.NewChecker(utils.NewLogger())
+	checker.AddLivenessCheck("workers", pass)
+	checker.AddReadinessCheck("mongo", fail)
+
+	tests := []struct {
+		Name           string
+		Method         string
+		Handler        http.Handler
+		ExpectedStatus int
+		ExpectedChecks int
+	}{
+		{
+			Name:           "Happy Path: liveness",
+			Method:         http.MethodGet,
+			Handler:        checker.LivenessHandler(),
+			ExpectedStatus: http.StatusOK,
+			ExpectedChecks: 1,
+		},
+		{
+			Name:           "Error Path: readiness fails",
+			Method:         http.MethodGet,
+			Handler:        checker.ReadinessHandler(),
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedChecks: 2,
+		},
+		{
+			Name:           "Error Path: method not allowed",
+			Method:         http.MethodPost,
+			Handler:        checker.LivenessHandler(),
+			ExpectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				res := httptest.NewRecorder()
+				tt.Handler.ServeHTTP(res, httptest.NewRequest(tt.Method, "/", nil))
+				assert.Equal(t, tt.ExpectedStatus, res.Code)
+				if tt.ExpectedChecks == 0 {
+					return
+				}
+
+				assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+				var report health.Report
+				require.NoError(t, json.Unmarshal(res.Body.Bytes(), &report))
+				assert.Len(t, report.Checks, tt.ExpectedChecks)
+			},
+		)
+	}
+}
+
+func TestStep(t *testing.T) {
+	var step health.Step
+	assert.EqualError(t, step.Check(context.Background()), "in progress")
+
+	assert.NoError(t, step.Outcome(context.Background()))
+
+	step.Complete(nil)
+	assert.NoError(t, step.Check(context.Background()))
+
+	step.Complete(errors.New("duplicate key"))
+	assert.EqualError(t, step.Check(context.Background()), "duplicate key")
+	assert.EqualError(t, step.Outcome(context.Background()), "duplicate key")
+}
+
+func TestWorkers_Do(t *testing.T) {
+	ctx := context.Background()
+	workers := health.NewWorkers(utils.NewLogger())
+
+	var migration, indexes health.Step
+	workers.Do(ctx, "migration", &migration, func(context.Context) error { return errors.New("unreachable") })
+	workers.Do(ctx, "indexes", &indexes, func(context.Context) error { return nil })
+	workers.Wait()
+
+	assert.EqualError(t, migration.Check(ctx), "unreachable")
+	assert.NoError(t, indexes.Check(ctx))
+	// tasks returning are not reported as stopped workers
+	assert.NoError(t, workers.Check(ctx))
+}
+
+func TestWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	workers := health.NewWorkers(utils.NewLogger())
+
+	stopped := make(chan struct{})
+	workers.Go(
+		ctx, "relay", func(ctx context.Context) {
+			defer close(stopped)
+			<-ctx.Done()
+		},
+	)
+	workers.Go(ctx, "delivery", func(context.Context) {})
+	workers.Go(ctx, "watcher", func(context.Context) { panic("closed cursor") })
+
+	assert.Eventually(
+		t, func() bool {
+			err := workers.Check(ctx)
+			return err != nil && err.Error() == "workers stopped: delivery returned; watcher panicked: closed cursor"
+		}, time.Second, time.Millisecond,
+	)
+
+	// workers stopping with the service are not reported
+	cancel()
+	workers.Wait()
+	<-stopped
+	assert.EqualError(t, workers.Check(ctx), "workers stopped: delivery returned; watcher panicked: closed cursor")
+}
@@ -0,0 +1,373 @@
+// Package loadtest runs mixed workloads of tenant operations against a repository or the HTTP
+// API, and reports their throughput and latency percentiles.
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+)
+
+// Operation is an operation of a workload.
+type Operation string
+
+const (
+	// Get looks a tenant up by ID.
+	Get Operation = "get"
+	// List lists the active tenants.
+	List Operation = "list"
+	// Search looks a tenant up by another key than its ID, see the targets.
+	Search Operation = "search"
+	// Update replaces a tenant.
+	Update Operation = "update"
+	// Create creates a new tenant.
+	Create Operation = "create"
+)
+
+var operations = []Operation{Get, List, Search, Update, Create}
+
+// Mix is the weight of each operation of a workload, operations are run in proportion to their
+// weight.
+type Mix map[Operation]int
+
+// DefaultMix is a read mostly workload.
+func DefaultMix() Mix {
+	return Mix{Get: 70, List: 2, Search: 18, Update: 8, Create: 2}
+}
+
+// ParseMix parses weights of operations, get=70,update=30.
+func ParseMix(value string) (Mix, error) {
+	mix := make(Mix)
+	for _, entry := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(entry), "=")
+		operation := Operation(strings.ToLower(name))
+		if !known(operation) {
+			return nil, errors.Errorf("unknown operation %q, expected get, list, search, update or create", name)
+		}
+
+		mix[operation] = 1
+		if found {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 0 {
+				return nil, errors.Errorf("invalid weight of operation %s", name)
+			}
+			mix[operation] = w
+		}
+	}
+
+	return mix, nil
+}
+
+func known(operation Operation) bool {
+	for _, o := range operations {
+		if o == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Target runs the operations of workloads.
+type Target interface {
+	Create(ctx context.Context, tenant *entities.Tenant) error
+	Get(ctx context.Context, id string) error
+	List(ctx context.Context) error
+	Search(ctx context.Context, tenant *entities.Tenant) error
+	Update(ctx context.Context, tenant *entities.Tenant) error
+}
+
+// Latency sums up the latencies of an operation.
+type Latency struct {
+	Mean time.Duration `json:"mean_ns"`
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	P95  time.Duration `json:"p95_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+// OperationReport sums up the runs of an operation.
+type OperationReport struct {
+	Operation  Operation `json:"operation"`
+	Requests   int       `json:"requests"`
+	Errors     int       `json:"errors"`
+	Throughput float64   `json:"throughput"`
+	Latency    Latency   `json:"latency"`
+	// FirstError is the first error of the operation, the others are only counted.
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// Report sums up a run. Throughputs are in operations per second, latencies include the
+// failed operations.
+type Report struct {
+	Duration    time.Duration     `json:"duration_ns"`
+	Concurrency int               `json:"concurrency"`
+	Requests    int               `json:"requests"`
+	Errors      int               `json:"errors"`
+	Throughput  float64           `json:"throughput"`
+	Latency     Latency           `json:"latency"`
+	Operations  []OperationReport `json:"operations"`
+}
+
+// Runner runs a workload against a target.
+type Runner struct {
+	target      Target
+	tenants     []*entities.Tenant
+	mix         Mix
+	concurrency int
+	duration    time.Duration
+	requests    int
+	seed        int64
+	generator   *tests.Generator
+}
+
+type Option func(*Runner)
+
+// WithMix sets the weights of operations, instead of DefaultMix.
+func WithMix(mix Mix) Option {
+	return func(r *Runner) {
+		r.mix = mix
+	}
+}
+
+// WithConcurrency sets how many operations run at once.
+func WithConcurrency(concurrency int) Option {
+	return func(r *Runner) {
+		if concurrency > 0 {
+			r.concurrency = concurrency
+		}
+	}
+}
+
+// WithDuration stops runs after a duration, runs last 10 seconds by default.
+func WithDuration(duration time.Duration) Option {
+	return func(r *Runner) {
+		r.duration = duration
+	}
+}
+
+// WithRequests stops runs after a number of operations, before their duration is over.
+func WithRequests(requests int) Option {
+	return func(r *Runner) {
+		r.requests = requests
+	}
+}
+
+// WithSeed makes the choices of operations and of the tenants they operate on the same on every
+// run, with a single worker.
+func WithSeed(seed int64) Option {
+	return func(r *Runner) {
+		r.seed = seed
+	}
+}
+
+// NewRunner returns the runner of workloads against target. Operations on existing tenants pick
+// them among tenants.
+func NewRunner(target Target, tenants []*entities.Tenant, opts ...Option) *Runner {
+	r := &Runner{
+		target:      target,
+		tenants:     tenants,
+		mix:         DefaultMix(),
+		concurrency: 1,
+		duration:    10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.seed == 0 {
+		r.seed = time.Now().UnixNano()
+	}
+	r.generator = tests.NewGenerator(tests.WithSeed(r.seed))
+
+	return r
+}
+
+// sample is the latency of an operation.
+type sample struct {
+	operation Operation
+	latency   time.Duration
+	err       error
+}
+
+// Run runs the workload until its duration is over, its number of operations is reached or ctx
+// is canceled.
+func (r *Runner) Run(ctx context.Context) (*Report, error) {
+	picks, err := r.picks()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.tenants) == 0 {
+		for _, operation := range picks {
+			if operation != Create && operation != List {
+				return nil, errors.Errorf("operation %s needs existing tenants", operation)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.duration)
+	defer cancel()
+
+	var (
+		started int64
+		wg      sync.WaitGroup
+		results = make([][]sample, r.concurrency)
+	)
+	start := time.Now()
+	for worker := 0; worker < r.concurrency; worker++ {
+		worker := worker
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			random := rand.New(rand.NewSource(r.seed + int64(worker)))
+			for ctx.Err() == nil {
+				if r.requests > 0 && atomic.AddInt64(&started, 1) > int64(r.requests) {
+					return
+				}
+
+				operation := picks[random.Intn(len(picks))]
+				begin := time.Now()
+				err := r.run(ctx, operation, random)
+				latency := time.Since(begin)
+
+				// operations interrupted by the end of the run are not counted
+				if err != nil && ctx.Err() != nil {
+					return
+				}
+				results[worker] = append(results[worker], sample{operation: operation, latency: latency, err: err})
+			}
+		}()
+	}
+	wg.Wait()
+
+	return r.report(time.Since(start), results), nil
+}
+
+// picks returns the operations of the mix repeated by their weight, picking one at random
+// follows the weights.
+func (r *Runner) picks() ([]Operation, error) {
+	var picks []Operation
+	for _, operation := range operations {
+		for i := 0; i < r.mix[operation]; i++ {
+			picks = append(picks, operation)
+		}
+	}
+	if len(picks) == 0 {
+		return nil, errors.New("the mix has no operation")
+	}
+
+	return picks, nil
+}
+
+func (r *Runner) run(ctx context.Context, operation Operation, random *rand.Rand) error {
+	var tenant *entities.Tenant
+	if len(r.tenants) > 0 {
+		tenant = r.tenants[random.Intn(len(r.tenants))]
+	}
+
+	switch operation {
+	case Get:
+		return r.target.Get(ctx, tenant.ID)
+	case List:
+		return r.target.List(ctx)
+	case Search:
+		return r.target.Search(ctx, tenant)
+	case Update:
+		// tenants are shared by the workers, updates change a copy
+		updated := *tenant
+		updated.Name = fmt.Sprintf("%s %d", tenant.Name, random.Intn(1000))
+		return r.target.Update(ctx, &updated)
+	default:
+		// created tenants are unique across runs, whatever their seed
+		created := r.generator.Tenant()
+		created.ID = utils.NewXID().ID
+		created.Subdomain = "load-" + created.ID
+		return r.target.Create(ctx, created)
+	}
+}
+
+func (r *Runner) report(elapsed time.Duration, results [][]sample) *Report {
+	report := &Report{Duration: elapsed, Concurrency: r.concurrency}
+
+	var all []time.Duration
+	byOperation := make(map[Operation]*OperationReport)
+	latencies := make(map[Operation][]time.Duration)
+	for _, samples := range results {
+		for _, s := range samples {
+			operation, ok := byOperation[s.operation]
+			if !ok {
+				operation = &OperationReport{Operation: s.operation}
+				byOperation[s.operation] = operation
+			}
+
+			operation.Requests++
+			if s.err != nil {
+				operation.Errors++
+				if operation.FirstError == "" {
+					operation.FirstError = s.err.Error()
+				}
+			}
+			latencies[s.operation] = append(latencies[s.operation], s.latency)
+			all = append(all, s.latency)
+		}
+	}
+
+	seconds := elapsed.Seconds()
+	for _, o := range operations {
+		operation, ok := byOperation[o]
+		if !ok {
+			continue
+		}
+
+		operation.Throughput = float64(operation.Requests) / seconds
+		operation.Latency = summarize(latencies[o])
+		report.Requests += operation.Requests
+		report.Errors += operation.Errors
+		report.Operations = append(report.Operations, *operation)
+	}
+	report.Throughput = float64(report.Requests) / seconds
+	report.Latency = summarize(all)
+
+	return report
+}
+
+// summarize returns the mean and the nearest rank percentiles of latencies.
+func summarize(latencies []time.Duration) Latency {
+	if len(latencies) == 0 {
+		return Latency{}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+
+	percentile := func(p int) time.Duration {
+		rank := (p*len(latencies) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return latencies[rank-1]
+	}
+
+	return Latency{
+		Mean: total / time.Duration(len(latencies)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P95:  percentile(95),
+		P99:  percentile(99),
+		Max:  latencies[len(latencies)-1],
+	}
+}
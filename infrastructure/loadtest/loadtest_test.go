@@ -0,0 +1,195 @@
+package loadtest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/infrastructure/loadtest"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quietLogger() utils.LoggerInterface {
+	return utils.NewLogger(utils.Config{
+		Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"},
+	})
+}
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Value         string
+		Expected      loadtest.Mix
+		ExpectedError bool
+	}{
+		{
+			Name:     "Happy Path: weights",
+			Value:    "get=70, update=30",
+			Expected: loadtest.Mix{loadtest.Get: 70, loadtest.Update: 30},
+		},
+		{
+			Name:     "Happy Path: operations without weight weigh 1",
+			Value:    "list,SEARCH=2",
+			Expected: loadtest.Mix{loadtest.List: 1, loadtest.Search: 2},
+		},
+		{
+			Name:          "Error Path: unknown operation",
+			Value:         "get=1,delete=1",
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error Path: invalid weight",
+			Value:         "get=-1",
+			ExpectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.Name, func(t *testing.T) {
+			mix, err := loadtest.ParseMix(tt.Value)
+			if tt.ExpectedError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.Expected, mix)
+		})
+	}
+}
+
+func TestRunner_Run(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTenantRepository(quietLogger())
+	target := loadtest.NewRepositoryTarget(repo)
+	tenants := tests.NewGenerator(tests.WithSeed(1)).Tenants(50)
+	require.NoError(t, loadtest.Seed(ctx, target, tenants, 4))
+
+	runner := loadtest.NewRunner(
+		target, tenants, loadtest.WithConcurrency(4), loadtest.WithRequests(200), loadtest.WithDuration(time.Minute),
+		loadtest.WithMix(loadtest.Mix{loadtest.Get: 1, loadtest.List: 1, loadtest.Search: 1, loadtest.Update: 1, loadtest.Create: 1}),
+	)
+	report, err := runner.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 200, report.Requests)
+	assert.Zero(t, report.Errors)
+	assert.Equal(t, 4, report.Concurrency)
+	assert.Positive(t, report.Throughput)
+	assert.Len(t, report.Operations, 5)
+
+	var created int
+	for _, operation := range report.Operations {
+		assert.Positive(t, operation.Requests, operation.Operation)
+		assert.LessOrEqual(t, operation.Latency.P50, operation.Latency.P99)
+		assert.LessOrEqual(t, operation.Latency.P99, operation.Latency.Max)
+		if operation.Operation == loadtest.Create {
+			created = operation.Requests
+		}
+	}
+
+	all, err := repo.SearchTenants(ctx, map[string]any{})
+	require.NoError(t, err)
+	assert.Len(t, all, 50+created)
+}
+
+func TestRunner_Run_Errors(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTenantRepository(quietLogger())
+	tenants := tests.NewGenerator(tests.WithSeed(1)).Tenants(5)
+
+	// the tenants were never seeded, so every operation on them fails
+	runner := loadtest.NewRunner(
+		loadtest.NewRepositoryTarget(repo), tenants, loadtest.WithRequests(20),
+		loadtest.WithMix(loadtest.Mix{loadtest.Get: 1}),
+	)
+	report, err := runner.Run(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, 20, report.Requests)
+	assert.Equal(t, 20, report.Errors)
+	require.Len(t, report.Operations, 1)
+	assert.NotEmpty(t, report.Operations[0].FirstError)
+}
+
+func TestRunner_Run_Invalid(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Mix     loadtest.Mix
+		Tenants []*entities.Tenant
+	}{
+		{
+			Name: "Error Path: empty mix",
+			Mix:  loadtest.Mix{loadtest.Get: 0},
+		},
+		{
+			Name: "Error Path: operations on existing tenants without tenants",
+			Mix:  loadtest.Mix{loadtest.Create: 1, loadtest.Update: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.Name, func(t *testing.T) {
+			target := loadtest.NewRepositoryTarget(memory.NewTenantRepository(quietLogger()))
+			_, err := loadtest.NewRunner(target, tt.Tenants, loadtest.WithMix(tt.Mix)).Run(context.Background())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHTTPTarget(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get(authn.APIKeyHeader) != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPut:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte("{}"))
+		}
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	tenant := tests.NewGenerator(tests.WithSeed(1)).Tenant()
+	target := loadtest.NewHTTPTarget(ts.URL+"/", ts.Client(), loadtest.HTTPHeader("token", "key"))
+	require.NoError(t, target.Create(ctx, tenant))
+	require.NoError(t, target.Get(ctx, tenant.ID))
+	require.NoError(t, target.List(ctx))
+	require.NoError(t, target.Search(ctx, tenant))
+	require.NoError(t, target.Update(ctx, tenant))
+
+	assert.Equal(t, []string{
+		"POST /tenants",
+		"GET /tenants/" + tenant.ID,
+		"GET /tenants",
+		"GET /payment-details/" + tenant.PaymentDetails[0].ID + "/tenant",
+		"PUT /tenants/" + tenant.ID,
+	}, requests)
+
+	unauthenticated := loadtest.NewHTTPTarget(ts.URL, ts.Client(), loadtest.HTTPHeader("", ""))
+	assert.ErrorContains(t, unauthenticated.Get(ctx, tenant.ID), "401")
+}
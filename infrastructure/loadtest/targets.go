@@ -0,0 +1,222 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/pkg/errors"
+)
+
+type repositoryTarget struct {
+	tenants repository.TenantRepository
+}
+
+// NewRepositoryTarget returns the target running operations on a tenant repository. Searches
+// look tenants up by the email of their first contact, or by subdomain when they have none.
+func NewRepositoryTarget(tenants repository.TenantRepository) Target {
+	return &repositoryTarget{tenants: tenants}
+}
+
+func (t *repositoryTarget) Create(ctx context.Context, tenant *entities.Tenant) error {
+	return t.tenants.CreateTenant(ctx, tenant)
+}
+
+func (t *repositoryTarget) Get(ctx context.Context, id string) error {
+	_, err := t.tenants.GetTenantByID(ctx, id)
+	return err
+}
+
+func (t *repositoryTarget) List(ctx context.Context) error {
+	_, err := t.tenants.GetTenants(ctx)
+	return err
+}
+
+func (t *repositoryTarget) Search(ctx context.Context, tenant *entities.Tenant) error {
+	filter := map[string]any{"subdomain": tenant.Subdomain}
+	if len(tenant.PrimaryContacts) > 0 {
+		filter = map[string]any{"primary_contacts.email": tenant.PrimaryContacts[0].Email}
+	}
+
+	_, err := t.tenants.SearchTenants(ctx, filter)
+	return err
+}
+
+func (t *repositoryTarget) Update(ctx context.Context, tenant *entities.Tenant) error {
+	return t.tenants.UpdateTenant(ctx, tenant)
+}
+
+type httpTarget struct {
+	server string
+	header http.Header
+	client *http.Client
+}
+
+// NewHTTPTarget returns the target running operations on the HTTP API of server, sending header
+// with every request. Searches look tenants up by the ID of their first payment method, or by
+// ID when they have none.
+func NewHTTPTarget(server string, client *http.Client, header http.Header) Target {
+	return &httpTarget{server: strings.TrimSuffix(server, "/"), header: header, client: client}
+}
+
+// HTTPHeader returns the headers authenticating requests with a bearer token or an API key.
+func HTTPHeader(token string, apiKey string) http.Header {
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	if apiKey != "" {
+		header.Set(authn.APIKeyHeader, apiKey)
+	}
+
+	return header
+}
+
+func (t *httpTarget) Create(ctx context.Context, tenant *entities.Tenant) error {
+	return t.do(ctx, http.MethodPost, "/tenants", tenant, http.StatusCreated)
+}
+
+func (t *httpTarget) Get(ctx context.Context, id string) error {
+	return t.do(ctx, http.MethodGet, "/tenants/"+url.PathEscape(id), nil, http.StatusOK)
+}
+
+func (t *httpTarget) List(ctx context.Context) error {
+	return t.do(ctx, http.MethodGet, "/tenants", nil, http.StatusOK)
+}
+
+func (t *httpTarget) Search(ctx context.Context, tenant *entities.Tenant) error {
+	if len(tenant.PaymentDetails) == 0 {
+		return t.Get(ctx, tenant.ID)
+	}
+
+	path := "/payment-details/" + url.PathEscape(tenant.PaymentDetails[0].ID) + "/tenant"
+	return t.do(ctx, http.MethodGet, path, nil, http.StatusOK)
+}
+
+func (t *httpTarget) Update(ctx context.Context, tenant *entities.Tenant) error {
+	return t.do(ctx, http.MethodPut, "/tenants/"+url.PathEscape(tenant.ID), tenant, http.StatusNoContent)
+}
+
+// bufferPool holds the buffers of response bodies, so that reading them weighs little on the
+// measured latencies.
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func (t *httpTarget) do(ctx context.Context, method string, path string, in any, expected int) error {
+	var body io.Reader
+	if in != nil {
+		encoded, err := json.Marshal(in)
+		if err != nil {
+			return errors.Wrap(err, "failed to encode request")
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.server+path, body)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// responses are read whole, a client waits for them
+	buffer := bufferPool.Get().(*bytes.Buffer)
+	defer bufferPool.Put(buffer)
+	buffer.Reset()
+	if _, err := buffer.ReadFrom(resp.Body); err != nil {
+		return errors.Wrapf(err, "failed to read response of %s %s", method, path)
+	}
+
+	if resp.StatusCode != expected {
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+
+	return nil
+}
+
+// batchInserter is implemented by repositories creating many tenants in a single round trip.
+type batchInserter interface {
+	InsertTenants(ctx context.Context, tenants []*entities.Tenant) error
+}
+
+// seedBatchSize is how many tenants are inserted at once by repositories inserting batches.
+const seedBatchSize = 1000
+
+// Seed creates tenants through target before a run, concurrency creations at once. Repositories
+// inserting batches of tenants insert them in batches instead.
+func Seed(ctx context.Context, target Target, tenants []*entities.Tenant, concurrency int) error {
+	if repo, ok := target.(*repositoryTarget); ok {
+		if inserter, ok := repo.tenants.(batchInserter); ok {
+			for start := 0; start < len(tenants); start += seedBatchSize {
+				end := start + seedBatchSize
+				if end > len(tenants) {
+					end = len(tenants)
+				}
+				if err := inserter.InsertTenants(ctx, tenants[start:end]); err != nil {
+					return errors.Wrap(err, "failed to seed tenants")
+				}
+			}
+			return nil
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		next     = make(chan *entities.Tenant)
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tenant := range next {
+				if err := target.Create(ctx, tenant); err != nil {
+					once.Do(func() {
+						firstErr = errors.Wrapf(err, "failed to seed tenant %s", tenant.ID)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for _, tenant := range tenants {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- tenant
+	}
+	close(next)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
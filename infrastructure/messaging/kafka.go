@@ -0,0 +1,70 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaWriter writes messages to Kafka, *kafka.Writer implements it. Writers must wait for
+// the acknowledgement of the brokers for events to be delivered at least once.
+type KafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type KafkaOption func(*KafkaPublisher)
+
+// WithTopic sets the topic events are written to, for writers without a topic of their own.
+func WithTopic(topic string) KafkaOption {
+	return func(p *KafkaPublisher) {
+		p.topic = topic
+	}
+}
+
+// KafkaPublisher writes events to Kafka keyed by tenant, so the events of a tenant land on
+// the same partition and are consumed in order.
+type KafkaPublisher struct {
+	writer KafkaWriter
+	topic  string
+}
+
+func NewKafkaPublisher(writer KafkaWriter, opts ...KafkaOption) *KafkaPublisher {
+	p := &KafkaPublisher{writer: writer}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event *entities.Event) error {
+	data, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	key := event.TenantID
+	if key == "" {
+		key = event.Subject
+	}
+
+	err = p.writer.WriteMessages(
+		ctx, kafka.Message{
+			Topic: p.topic,
+			Key:   []byte(key),
+			Value: data,
+			Headers: []kafka.Header{
+				{Key: "content-type", Value: []byte(ContentType)},
+				{Key: "event-id", Value: []byte(event.ID)},
+				{Key: "event-type", Value: []byte(event.Type)},
+			},
+			Time: event.OccurredAt,
+		},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to publish event %s to kafka", event.ID)
+	}
+
+	return nil
+}
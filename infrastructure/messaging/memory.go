@@ -0,0 +1,64 @@
+package messaging
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+// Handler reacts to an event. An event is published again when a handler fails, so handlers
+// see events at least once.
+type Handler func(ctx context.Context, event *entities.Event) error
+
+// Publish calls the handler, so handlers can be published to alongside a broker.
+func (h Handler) Publish(ctx context.Context, event *entities.Event) error {
+	return h(ctx, event)
+}
+
+// MemoryPublisher delivers events to the handlers subscribed in the same process. It serves
+// local development and tests, events do not leave the process.
+type MemoryPublisher struct {
+	mu        sync.RWMutex
+	handlers  []Handler
+	published []*entities.Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Subscribe calls handler with every event published from now on.
+func (p *MemoryPublisher) Subscribe(handler Handler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.handlers = append(p.handlers, handler)
+}
+
+// Publish calls the subscribed handlers in order, it stops at the first failing handler.
+func (p *MemoryPublisher) Publish(ctx context.Context, event *entities.Event) error {
+	p.mu.RLock()
+	handlers := p.handlers
+	p.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	p.published = append(p.published, event)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Published returns the events published so far.
+func (p *MemoryPublisher) Published() []*entities.Event {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]*entities.Event(nil), p.published...)
+}
@@ -0,0 +1,248 @@
+package messaging_test
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/messaging"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutbox is an outbox in memory, ignoring leases.
+type fakeOutbox struct {
+	mu        sync.Mutex
+	pending   map[string]*entities.OutboxEvent
+	retryAt   map[string]time.Time
+	published []string
+}
+
+func newFakeOutbox(events ...*entities.Event) *fakeOutbox {
+	o := &fakeOutbox{pending: map[string]*entities.OutboxEvent{}, retryAt: map[string]time.Time{}}
+	for _, event := range events {
+		o.pending[event.ID] = &entities.OutboxEvent{Event: *event}
+	}
+	return o
+}
+
+func (o *fakeOutbox) ClaimEvents(_ context.Context, limit int, _ time.Duration) ([]*entities.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var claimed []*entities.OutboxEvent
+	for _, event := range o.pending {
+		claimed = append(claimed, event)
+	}
+	sort.Slice(claimed, func(i, j int) bool { return claimed[i].ID < claimed[j].ID })
+
+	if len(claimed) > limit {
+		claimed = claimed[:limit]
+	}
+	return claimed, nil
+}
+
+func (o *fakeOutbox) MarkPublished(_ context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	delete(o.pending, id)
+	o.published = append(o.published, id)
+	return nil
+}
+
+func (o *fakeOutbox) MarkFailed(_ context.Context, id string, cause string, retryAt time.Time) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pending[id].Attempts++
+	o.pending[id].LastError = cause
+	o.retryAt[id] = retryAt
+	return nil
+}
+
+// fakeJetStream records the messages published to it.
+type fakeJetStream struct {
+	msgs []*nats.Msg
+	err  error
+}
+
+func (js *fakeJetStream) PublishMsg(m *nats.Msg, _ ...nats.PubOpt) (*nats.PubAck, error) {
+	if js.err != nil {
+		return nil, js.err
+	}
+	js.msgs = append(js.msgs, m)
+	return &nats.PubAck{Stream: "TENANTS", Sequence: uint64(len(js.msgs))}, nil
+}
+
+// fakeKafkaWriter records the messages written to it.
+type fakeKafkaWriter struct {
+	msgs []kafka.Message
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+func newEvent(id string, eventType entities.EventType) *entities.Event {
+	return &entities.Event{
+		ID:         id,
+		Type:       eventType,
+		TenantID:   "tenant-1",
+		Subject:    "tenant-1",
+		Payload:    json.RawMessage(`{"id":"tenant-1"}`),
+		OccurredAt: time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRelay_PublishPending(t *testing.T) {
+	var testCases = []struct {
+		Name              string
+		Failing           map[string]bool
+		ExpectedPublished []string
+		ExpectedPending   []string
+	}{
+		{
+			Name:              "Happy Path: every event is published in order",
+			ExpectedPublished: []string{"event-1", "event-2", "event-3"},
+		},
+		{
+			Name:              "Failing events are retried without holding back the others",
+			Failing:           map[string]bool{"event-2": true},
+			ExpectedPublished: []string{"event-1", "event-3"},
+			ExpectedPending:   []string{"event-2"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				outbox := newFakeOutbox(
+					newEvent("event-1", entities.TenantCreated),
+					newEvent("event-2", entities.TenantUpdated),
+					newEvent("event-3", entities.RoleAssigned),
+				)
+
+				publisher := messaging.NewMemoryPublisher()
+				publisher.Subscribe(
+					func(_ context.Context, event *entities.Event) error {
+						if tt.Failing[event.ID] {
+							return errors.New("broker unavailable")
+						}
+						return nil
+					},
+				)
+
+				relay := messaging.NewRelay(utils.NewLogger(), outbox, publisher)
+				claimed, err := relay.PublishPending(context.Background())
+				require.NoError(t, err)
+				assert.Equal(t, 3, claimed)
+
+				assert.Equal(t, tt.ExpectedPublished, outbox.published)
+
+				var published []string
+				for _, event := range publisher.Published() {
+					published = append(published, event.ID)
+				}
+				assert.Equal(t, tt.ExpectedPublished, published)
+
+				for _, id := range tt.ExpectedPending {
+					require.Contains(t, outbox.pending, id)
+					assert.Equal(t, 1, outbox.pending[id].Attempts)
+					assert.Equal(t, "broker unavailable", outbox.pending[id].LastError)
+					assert.True(t, outbox.retryAt[id].After(time.Now()))
+				}
+			},
+		)
+	}
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	outbox := newFakeOutbox(newEvent("event-1", entities.TenantCreated))
+	publisher := messaging.NewNATSPublisher(&fakeJetStream{err: errors.New("no responders")})
+
+	relay := messaging.NewRelay(
+		utils.NewLogger(), outbox, publisher, messaging.WithBackoff(time.Second, 4*time.Second),
+	)
+
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		before := time.Now()
+		_, err := relay.PublishPending(context.Background())
+		require.NoError(t, err)
+		delays = append(delays, outbox.retryAt["event-1"].Sub(before).Round(time.Second))
+	}
+
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second}, delays)
+	assert.Equal(t, 4, outbox.pending["event-1"].Attempts)
+}
+
+func TestFanout_Publish(t *testing.T) {
+	memory := messaging.NewMemoryPublisher()
+	var handled []string
+	failing := true
+	fanout := messaging.Fanout{
+		memory,
+		messaging.Handler(
+			func(_ context.Context, event *entities.Event) error {
+				if failing {
+					return errors.New("handler failed")
+				}
+				handled = append(handled, event.ID)
+				return nil
+			},
+		),
+	}
+
+	// the event is published to every publisher again once the failing one succeeds
+	event := newEvent("event-1", entities.TenantCreated)
+	assert.Error(t, fanout.Publish(context.Background(), event))
+	failing = false
+	require.NoError(t, fanout.Publish(context.Background(), event))
+
+	assert.Len(t, memory.Published(), 2)
+	assert.Equal(t, []string{"event-1"}, handled)
+}
+
+func TestNATSPublisher_Publish(t *testing.T) {
+	js := &fakeJetStream{}
+	publisher := messaging.NewNATSPublisher(js, messaging.WithSubjectPrefix("events"))
+
+	require.NoError(t, publisher.Publish(context.Background(), newEvent("event-1", entities.SubscriptionUpdated)))
+	require.Len(t, js.msgs, 1)
+
+	msg := js.msgs[0]
+	assert.Equal(t, "events.subscription.updated", msg.Subject)
+	assert.Equal(t, "subscription.updated", msg.Header.Get("Event-Type"))
+	assert.Equal(t, "tenant-1", msg.Header.Get("Tenant-Id"))
+
+	var event entities.Event
+	require.NoError(t, json.Unmarshal(msg.Data, &event))
+	assert.Equal(t, *newEvent("event-1", entities.SubscriptionUpdated), event)
+}
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	writer := &fakeKafkaWriter{}
+	publisher := messaging.NewKafkaPublisher(writer, messaging.WithTopic("tenant-events"))
+
+	require.NoError(t, publisher.Publish(context.Background(), newEvent("event-1", entities.TenantSuspended)))
+	require.Len(t, writer.msgs, 1)
+
+	msg := writer.msgs[0]
+	assert.Equal(t, "tenant-events", msg.Topic)
+	assert.Equal(t, "tenant-1", string(msg.Key), "events of a tenant share a partition")
+	assert.Contains(t, msg.Headers, kafka.Header{Key: "event-id", Value: []byte("event-1")})
+
+	var event entities.Event
+	require.NoError(t, json.Unmarshal(msg.Value, &event))
+	assert.Equal(t, entities.TenantSuspended, event.Type)
+}
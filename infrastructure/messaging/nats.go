@@ -0,0 +1,63 @@
+package messaging
+
+import (
+	"context"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+// DefaultSubjectPrefix prefixes the subjects events are published on.
+const DefaultSubjectPrefix = "tenant-management"
+
+// JetStream publishes messages to a NATS JetStream stream, nats.JetStreamContext implements it.
+type JetStream interface {
+	PublishMsg(m *nats.Msg, opts ...nats.PubOpt) (*nats.PubAck, error)
+}
+
+type NATSOption func(*NATSPublisher)
+
+// WithSubjectPrefix sets the prefix of the subjects, the default is DefaultSubjectPrefix.
+func WithSubjectPrefix(prefix string) NATSOption {
+	return func(p *NATSPublisher) {
+		p.prefix = prefix
+	}
+}
+
+// NATSPublisher publishes events to JetStream on <prefix>.<event type>, for instance
+// tenant-management.tenant.created. The event ID is the message ID, so JetStream drops
+// events published again within the duplicate window of the stream.
+type NATSPublisher struct {
+	js     JetStream
+	prefix string
+}
+
+func NewNATSPublisher(js JetStream, opts ...NATSOption) *NATSPublisher {
+	p := &NATSPublisher{js: js, prefix: DefaultSubjectPrefix}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event *entities.Event) error {
+	data, err := encode(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.prefix + "." + string(event.Type))
+	msg.Data = data
+	msg.Header.Set("Content-Type", ContentType)
+	msg.Header.Set("Event-Type", string(event.Type))
+	if event.TenantID != "" {
+		msg.Header.Set("Tenant-Id", event.TenantID)
+	}
+
+	if _, err := p.js.PublishMsg(msg, nats.MsgId(event.ID), nats.Context(ctx)); err != nil {
+		return errors.Wrapf(err, "failed to publish event %s to nats", event.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// ContentType is the content type of published events, which are encoded as entities.Event.
+const ContentType = "application/json"
+
+// Publisher delivers domain events to other services. Publish returns once the event is
+// durably accepted by the broker, an event failing to publish is published again later, so
+// brokers and consumers see events at least once.
+type Publisher interface {
+	Publish(ctx context.Context, event *entities.Event) error
+}
+
+// Fanout publishes events with every publisher in order, it stops at the first failing publisher.
+// The event is published again to all of them, so publishers behind a failing one see it once
+// it succeeds and the ones before see it again.
+type Fanout []Publisher
+
+func (f Fanout) Publish(ctx context.Context, event *entities.Event) error {
+	for _, publisher := range f {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encode(event *entities.Event) ([]byte, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode event %s", event.ID)
+	}
+
+	return raw, nil
+}
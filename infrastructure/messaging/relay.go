@@ -0,0 +1,159 @@
+package messaging
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+)
+
+const (
+	defaultInterval   = time.Second
+	defaultBatchSize  = 100
+	defaultLease      = 30 * time.Second
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 10 * time.Minute
+)
+
+type RelayOption func(*Relay)
+
+// WithInterval sets how often the outbox is polled when it was drained, the default is a second.
+func WithInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = interval
+	}
+}
+
+// WithBatchSize sets how many events are claimed at once, the default is 100.
+func WithBatchSize(size int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = size
+	}
+}
+
+// WithLease sets how long claimed events are reserved to the relay, the default is 30 seconds.
+// It must be longer than publishing a batch takes, or events are published twice.
+func WithLease(lease time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.lease = lease
+	}
+}
+
+// WithBackoff sets the delay before retrying a failed event, it doubles with every attempt up to max.
+func WithBackoff(min time.Duration, max time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.minBackoff = min
+		r.maxBackoff = max
+	}
+}
+
+// Relay publishes the events of the outbox. An event is marked as published only once the
+// publisher accepted it, so an event is published again when the relay dies in between:
+// delivery is at least once. Several relays can share an outbox, claimed events are leased
+// to a single one. Events are published in the order they occurred, but a failing event is
+// retried later without holding back the following ones.
+type Relay struct {
+	outbox     repository.Outbox
+	publisher  Publisher
+	logger     utils.LoggerInterface
+	interval   time.Duration
+	batchSize  int
+	lease      time.Duration
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	now        func() time.Time
+}
+
+func NewRelay(
+	logger utils.LoggerInterface, outbox repository.Outbox, publisher Publisher, opts ...RelayOption,
+) *Relay {
+	r := &Relay{
+		outbox:     outbox,
+		publisher:  publisher,
+		logger:     logger,
+		interval:   defaultInterval,
+		batchSize:  defaultBatchSize,
+		lease:      defaultLease,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Start publishes the events of the outbox until ctx is cancelled. A full batch is followed
+// by the next one right away, the outbox is polled every interval once it was drained.
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		claimed, err := r.PublishPending(ctx)
+		if err != nil {
+			r.logger.Error(err)
+		}
+
+		if claimed == r.batchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishPending publishes a batch of events from the outbox, it returns how many were claimed.
+// Events failing to publish are scheduled for a retry.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	events, err := r.outbox.ClaimEvents(ctx, r.batchSize, r.lease)
+	if err != nil {
+		return len(events), err
+	}
+
+	for _, event := range events {
+		if err := ctx.Err(); err != nil {
+			// the lease of the remaining events expires, another relay picks them up
+			return len(events), err
+		}
+
+		if err := r.publisher.Publish(ctx, &event.Event); err != nil {
+			retryAt := r.now().Add(r.backoff(event.Attempts))
+			r.logger.Errorf("failed to publish event %s (attempt %d), retrying at %s: %v",
+				event.ID, event.Attempts+1, retryAt.Format(time.RFC3339), err)
+
+			if err := r.outbox.MarkFailed(ctx, event.ID, err.Error(), retryAt); err != nil {
+				r.logger.Error(err)
+			}
+			continue
+		}
+
+		if err := r.outbox.MarkPublished(ctx, event.ID); err != nil {
+			// the event is published again once its lease expires
+			r.logger.Error(err)
+		}
+	}
+
+	return len(events), nil
+}
+
+// backoff returns the delay before the next attempt after the given number of failed attempts.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.minBackoff
+	for i := 0; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > r.maxBackoff {
+		return r.maxBackoff
+	}
+	return delay
+}
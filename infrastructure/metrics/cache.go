@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheStats reports the counters of a cache, *cache.TenantRepository is one.
+type CacheStats interface {
+	Stats() cache.Stats
+}
+
+// RegisterTenantCache exposes the lookups, evictions and entries of the cache of tenant lookups.
+func (m *Metrics) RegisterTenantCache(stats CacheStats) {
+	m.registry.MustRegister(newCacheCollector(stats))
+}
+
+// cacheCollector reads the counters of a cache when metrics are scraped.
+type cacheCollector struct {
+	stats     CacheStats
+	lookups   *prometheus.Desc
+	evictions *prometheus.Desc
+	entries   *prometheus.Desc
+}
+
+func newCacheCollector(stats CacheStats) *cacheCollector {
+	return &cacheCollector{
+		stats: stats,
+		lookups: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cache", "lookups_total"),
+			"Lookups of the tenant cache by result, hit, negative_hit for known unknown subdomains, or miss.",
+			[]string{"result"}, nil,
+		),
+		evictions: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cache", "evictions_total"),
+			"Entries of the tenant cache dropped to make room for others.",
+			nil, nil,
+		),
+		entries: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cache", "entries"),
+			"Entries of the tenant cache by kind, tenant or subdomain.",
+			[]string{"kind"}, nil,
+		),
+	}
+}
+
+func (c *cacheCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.lookups
+	descs <- c.evictions
+	descs <- c.entries
+}
+
+func (c *cacheCollector) Collect(metrics chan<- prometheus.Metric) {
+	stats := c.stats.Stats()
+
+	metrics <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(stats.Hits), "hit")
+	metrics <- prometheus.MustNewConstMetric(
+		c.lookups, prometheus.CounterValue, float64(stats.NegativeHits), "negative_hit",
+	)
+	metrics <- prometheus.MustNewConstMetric(c.lookups, prometheus.CounterValue, float64(stats.Misses), "miss")
+	metrics <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	metrics <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Tenants), "tenant")
+	metrics <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Subdomains), "subdomain")
+}
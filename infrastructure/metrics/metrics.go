@@ -0,0 +1,174 @@
+// Package metrics exposes the metrics of the service to Prometheus: the latencies and errors
+// of the tenant repository, of the tenant service and of the HTTP API, the connection pools of
+// mongo, the cache of tenant lookups and gauges of tenants and their subscriptions.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tenant_management"
+
+// Metrics holds the metrics of the service, registered in a registry of their own.
+type Metrics struct {
+	logger   utils.LoggerInterface
+	registry *prometheus.Registry
+
+	repositoryDuration *prometheus.HistogramVec
+	repositoryErrors   *prometheus.CounterVec
+	serviceDuration    *prometheus.HistogramVec
+	serviceErrors      *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+
+	poolConnections      *prometheus.GaugeVec
+	poolCheckouts        *prometheus.CounterVec
+	poolCheckoutFailures *prometheus.CounterVec
+	poolClears           *prometheus.CounterVec
+
+	tenants         *prometheus.GaugeVec
+	subscriptions   *prometheus.GaugeVec
+	paymentStatuses *prometheus.GaugeVec
+	statsFailures   prometheus.Counter
+}
+
+// New returns the metrics of the service, along with the metrics of the Go runtime and of
+// the process.
+func New(logger utils.LoggerInterface) *Metrics {
+	m := &Metrics{
+		logger:   logger,
+		registry: prometheus.NewRegistry(),
+		repositoryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace, Subsystem: "repository", Name: "operation_duration_seconds",
+				Help:    "Latency of the operations of the tenant repository.",
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+			}, []string{"operation"},
+		),
+		repositoryErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace, Subsystem: "repository", Name: "operation_errors_total",
+				Help: "Failed operations of the tenant repository, by error code.",
+			}, []string{"operation", "code"},
+		),
+		serviceDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace, Subsystem: "service", Name: "operation_duration_seconds",
+				Help:    "Latency of the operations of the tenant service.",
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+			}, []string{"operation"},
+		),
+		serviceErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace, Subsystem: "service", Name: "operation_errors_total",
+				Help: "Failed operations of the tenant service, by error code.",
+			}, []string{"operation", "code"},
+		),
+		httpDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+				Help:    "Latency of the HTTP requests, by route and status.",
+				Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+			}, []string{"method", "route", "status"},
+		),
+		poolConnections: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace, Subsystem: "mongo_pool", Name: "connections",
+				Help: "Connections of the mongo pools by server, open ones and the ones in use.",
+			}, []string{"address", "state"},
+		),
+		poolCheckouts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace, Subsystem: "mongo_pool", Name: "checkouts_total",
+				Help: "Connections checked out of the mongo pools by server.",
+			}, []string{"address"},
+		),
+		poolCheckoutFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace, Subsystem: "mongo_pool", Name: "checkout_failures_total",
+				Help: "Connections that could not be checked out of the mongo pools, by server and reason.",
+			}, []string{"address", "reason"},
+		),
+		poolClears: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace, Subsystem: "mongo_pool", Name: "clears_total",
+				Help: "Times the mongo pools were cleared after a server error, by server.",
+			}, []string{"address"},
+		),
+		tenants: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace, Name: "tenants",
+				Help: "Tenants by state, active or inactive.",
+			}, []string{"state"},
+		),
+		subscriptions: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace, Name: "subscriptions",
+				Help: "Subscriptions of the companies of active tenants, by plan.",
+			}, []string{"plan"},
+		),
+		paymentStatuses: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace, Name: "subscription_payment_statuses",
+				Help: "Subscriptions of the companies of active tenants, by payment status.",
+			}, []string{"status"},
+		),
+		statsFailures: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Namespace: namespace, Name: "tenant_stats_failures_total",
+				Help: "Failed refreshes of the gauges of tenants and subscriptions.",
+			},
+		),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.repositoryDuration, m.repositoryErrors, m.serviceDuration, m.serviceErrors, m.httpDuration,
+		m.poolConnections, m.poolCheckouts, m.poolCheckoutFailures, m.poolClears,
+		m.tenants, m.subscriptions, m.paymentStatuses, m.statsFailures,
+	)
+
+	return m
+}
+
+// Registry returns the registry of the metrics, to register the metrics of other components.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler serves the metrics in the exposition formats of Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorLog: errorLog{m.logger}})
+}
+
+// ObserveRequest records the latency of an HTTP request, it is a rest.Observer.
+func (m *Metrics) ObserveRequest(r *http.Request, route string, status int, duration time.Duration) {
+	m.httpDuration.WithLabelValues(r.Method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// observe records the latency of an operation and its error, by apperrors code.
+func observe(
+	durations *prometheus.HistogramVec, errors *prometheus.CounterVec, operation string, start time.Time, err error,
+) {
+	durations.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		errors.WithLabelValues(operation, apperrors.From(err).Code).Inc()
+	}
+}
+
+// errorLog logs the errors of the metrics handler.
+type errorLog struct {
+	logger utils.LoggerInterface
+}
+
+func (l errorLog) Println(v ...interface{}) {
+	l.logger.Error(v...)
+}
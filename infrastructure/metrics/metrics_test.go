@@ -0,0 +1,309 @@
+package metrics_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/metrics"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/cache"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func quietLogger() utils.LoggerInterface {
+	return utils.NewLogger(utils.Config{
+		Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"},
+	})
+}
+
+// scrape returns the metrics served by the handler of m.
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, res.Code)
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestInstrumentTenantRepository(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New(quietLogger())
+	repo := metrics.InstrumentTenantRepository(memory.NewTenantRepository(quietLogger()), m)
+
+	tenant := tests.NewGenerator(tests.WithSeed(1)).Tenant()
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+	_, err := repo.GetTenantByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	_, err = repo.GetTenantByID(ctx, "unknown")
+	require.Error(t, err)
+
+	body := scrape(t, m)
+	expected := []string{
+		`tenant_management_repository_operation_duration_seconds_count{operation="CreateTenant"} 1`,
+		`tenant_management_repository_operation_duration_seconds_count{operation="GetTenantByID"} 2`,
+		fmt.Sprintf(
+			`tenant_management_repository_operation_errors_total{code="%s",operation="GetTenantByID"} 1`,
+			apperrors.From(err).Code,
+		),
+	}
+	for _, line := range expected {
+		assert.Contains(t, body, line)
+	}
+	assert.NotContains(t, body, `tenant_management_repository_operation_errors_total{code="",operation="CreateTenant"}`)
+}
+
+func TestInstrumentTenantService(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New(quietLogger())
+	tenants := metrics.InstrumentTenantService(
+		service.NewTenantService(quietLogger(), memory.NewTenantRepository(quietLogger())), m,
+	)
+
+	tenant := tests.NewGenerator(tests.WithSeed(1)).Tenant()
+	require.NoError(t, tenants.CreateTenant(ctx, tenant))
+	_, err := tenants.GetTenants(ctx)
+	require.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = tenants.GetTenantByID(canceled, tenant.ID)
+	require.Error(t, err)
+
+	body := scrape(t, m)
+	expected := []string{
+		`tenant_management_service_operation_duration_seconds_count{operation="CreateTenant"} 1`,
+		`tenant_management_service_operation_duration_seconds_count{operation="GetTenants"} 1`,
+		fmt.Sprintf(
+			`tenant_management_service_operation_errors_total{code="%s",operation="GetTenantByID"} 1`,
+			apperrors.From(err).Code,
+		),
+	}
+	for _, line := range expected {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	m := metrics.New(quietLogger())
+	r := httptest.NewRequest(http.MethodGet, "/tenants/1", nil)
+	m.ObserveRequest(r, "/tenants/{tenantId}", http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest(r, "/tenants/{tenantId}", http.StatusNotFound, time.Millisecond)
+
+	body := scrape(t, m)
+	assert.Contains(
+		t, body,
+		`tenant_management_http_request_duration_seconds_count{method="GET",route="/tenants/{tenantId}",status="200"} 1`,
+	)
+	assert.Contains(
+		t, body,
+		`tenant_management_http_request_duration_seconds_count{method="GET",route="/tenants/{tenantId}",status="404"} 1`,
+	)
+}
+
+func TestMetrics_PoolMonitor(t *testing.T) {
+	m := metrics.New(quietLogger())
+	monitor := m.PoolMonitor()
+
+	const address = "mongo:27017"
+	for _, e := range []*event.PoolEvent{
+		{Type: event.ConnectionCreated, Address: address},
+		{Type: event.ConnectionCreated, Address: address},
+		{Type: event.ConnectionCreated, Address: address},
+		{Type: event.ConnectionClosed, Address: address},
+		{Type: event.GetSucceeded, Address: address},
+		{Type: event.GetSucceeded, Address: address},
+		{Type: event.ConnectionReturned, Address: address},
+		{Type: event.GetFailed, Address: address, Reason: event.ReasonTimedOut},
+		{Type: event.PoolCleared, Address: address},
+	} {
+		monitor.Event(e)
+	}
+
+	body := scrape(t, m)
+	expected := []string{
+		`tenant_management_mongo_pool_connections{address="mongo:27017",state="open"} 2`,
+		`tenant_management_mongo_pool_connections{address="mongo:27017",state="in_use"} 1`,
+		`tenant_management_mongo_pool_checkouts_total{address="mongo:27017"} 2`,
+		fmt.Sprintf(
+			`tenant_management_mongo_pool_checkout_failures_total{address="mongo:27017",reason="%s"} 1`,
+			event.ReasonTimedOut,
+		),
+		`tenant_management_mongo_pool_clears_total{address="mongo:27017"} 1`,
+	}
+	for _, line := range expected {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestMetrics_RegisterTenantCache(t *testing.T) {
+	ctx := context.Background()
+	m := metrics.New(quietLogger())
+	repo := cache.NewTenantRepository(memory.NewTenantRepository(quietLogger()), quietLogger())
+	m.RegisterTenantCache(repo)
+
+	tenant := tests.NewGenerator(tests.WithSeed(1)).Tenant()
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+	for i := 0; i < 3; i++ {
+		_, err := repo.GetTenantByID(ctx, tenant.ID)
+		require.NoError(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := repo.SearchTenant(ctx, map[string]any{"subdomain": "unknown"})
+		require.Error(t, err)
+	}
+
+	body := scrape(t, m)
+	expected := []string{
+		`tenant_management_cache_lookups_total{result="hit"} 2`,
+		`tenant_management_cache_lookups_total{result="negative_hit"} 1`,
+		`tenant_management_cache_lookups_total{result="miss"} 2`,
+		`tenant_management_cache_evictions_total 0`,
+		`tenant_management_cache_entries{kind="tenant"} 1`,
+	}
+	for _, line := range expected {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestMetrics_RefreshStats(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Source        metrics.StatsSource
+		Expected      []string
+		Unexpected    []string
+		ExpectedError string
+	}{
+		{
+			Name: "Happy Path: stale plans are dropped",
+			Source: metrics.StatsSourceFunc(
+				func(context.Context) (*entities.TenantStats, error) {
+					return &entities.TenantStats{
+						ActiveTenants:                3,
+						InactiveTenants:              1,
+						SubscriptionsByPlan:          map[string]int{"pro": 2},
+						SubscriptionsByPaymentStatus: map[string]int{"paid": 2},
+					}, nil
+				},
+			),
+			Expected: []string{
+				`tenant_management_tenants{state="active"} 3`,
+				`tenant_management_tenants{state="inactive"} 1`,
+				`tenant_management_subscriptions{plan="pro"} 2`,
+				`tenant_management_subscription_payment_statuses{status="paid"} 2`,
+			},
+			Unexpected: []string{`plan="basic"`, `status="overdue"`},
+		},
+		{
+			Name: "Error Path: failures keep the last stats",
+			Source: metrics.StatsSourceFunc(
+				func(context.Context) (*entities.TenantStats, error) {
+					return nil, errors.New("unreachable")
+				},
+			),
+			Expected: []string{
+				`tenant_management_tenants{state="active"} 1`,
+				`tenant_management_subscriptions{plan="basic"} 1`,
+				`tenant_management_tenant_stats_failures_total 1`,
+			},
+			ExpectedError: "unreachable",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				ctx := context.Background()
+				m := metrics.New(quietLogger())
+				initial := metrics.StatsSourceFunc(
+					func(context.Context) (*entities.TenantStats, error) {
+						return &entities.TenantStats{
+							ActiveTenants:                1,
+							SubscriptionsByPlan:          map[string]int{"basic": 1},
+							SubscriptionsByPaymentStatus: map[string]int{"overdue": 1},
+						}, nil
+					},
+				)
+				require.NoError(t, m.RefreshStats(ctx, initial))
+
+				err := m.RefreshStats(ctx, tt.Source)
+				if tt.ExpectedError != "" {
+					assert.EqualError(t, err, tt.ExpectedError)
+				} else {
+					assert.NoError(t, err)
+				}
+
+				body := scrape(t, m)
+				for _, line := range tt.Expected {
+					assert.Contains(t, body, line)
+				}
+				for _, label := range tt.Unexpected {
+					assert.NotContains(t, body, label)
+				}
+			},
+		)
+	}
+}
+
+func TestScanStats(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewTenantRepository(quietLogger())
+	tenants := tests.NewGenerator(tests.WithSeed(1)).Tenants(20)
+	tenants[0].IsActive = false
+	for _, tenant := range tenants {
+		require.NoError(t, repo.CreateTenant(ctx, tenant))
+	}
+
+	stats, err := metrics.ScanStats(repo).TenantStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.NewTenantStats(tenants), stats)
+}
+
+func TestMetrics_StartStats(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := metrics.New(quietLogger())
+
+	refreshes := make(chan struct{}, 10)
+	source := metrics.StatsSourceFunc(
+		func(context.Context) (*entities.TenantStats, error) {
+			refreshes <- struct{}{}
+			return entities.NewTenantStats(nil), nil
+		},
+	)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		m.StartStats(ctx, source, 10*time.Millisecond)
+	}()
+
+	// stats are refreshed right away, then on every tick
+	for i := 0; i < 2; i++ {
+		select {
+		case <-refreshes:
+		case <-time.After(time.Second):
+			t.Fatal("stats were not refreshed")
+		}
+	}
+	cancel()
+	<-stopped
+
+	assert.Contains(t, scrape(t, m), `tenant_management_tenants{state="active"} 0`)
+}
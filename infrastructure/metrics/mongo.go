@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolMonitor returns a monitor of the connection pools of a mongo client, counting their
+// connections, checkouts and clears by server.
+func (m *Metrics) PoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{Event: m.observePool}
+}
+
+func (m *Metrics) observePool(e *event.PoolEvent) {
+	switch e.Type {
+	case event.ConnectionCreated:
+		m.poolConnections.WithLabelValues(e.Address, "open").Inc()
+	case event.ConnectionClosed:
+		m.poolConnections.WithLabelValues(e.Address, "open").Dec()
+	case event.GetSucceeded:
+		m.poolConnections.WithLabelValues(e.Address, "in_use").Inc()
+		m.poolCheckouts.WithLabelValues(e.Address).Inc()
+	case event.ConnectionReturned:
+		m.poolConnections.WithLabelValues(e.Address, "in_use").Dec()
+	case event.GetFailed:
+		m.poolCheckoutFailures.WithLabelValues(e.Address, e.Reason).Inc()
+	case event.PoolCleared:
+		m.poolClears.WithLabelValues(e.Address).Inc()
+	}
+}
@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+)
+
+// tenantRepository records the latency and the errors of the operations of another repository.
+type tenantRepository struct {
+	next    repository.TenantRepository
+	metrics *Metrics
+}
+
+// InstrumentTenantRepository returns a repository recording the latency and the errors of
+// every operation of next.
+func InstrumentTenantRepository(next repository.TenantRepository, m *Metrics) repository.TenantRepository {
+	return &tenantRepository{next: next, metrics: m}
+}
+
+func (r *tenantRepository) observe(operation string, start time.Time, err error) {
+	observe(r.metrics.repositoryDuration, r.metrics.repositoryErrors, operation, start, err)
+}
+
+func (r *tenantRepository) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	start := time.Now()
+	err := r.next.CreateTenant(ctx, tenant)
+	r.observe("CreateTenant", start, err)
+	return err
+}
+
+func (r *tenantRepository) DeleteTenant(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.DeleteTenant(ctx, id)
+	r.observe("DeleteTenant", start, err)
+	return err
+}
+
+func (r *tenantRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	start := time.Now()
+	tenant, err := r.next.GetTenantByID(ctx, id)
+	r.observe("GetTenantByID", start, err)
+	return tenant, err
+}
+
+func (r *tenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
+	start := time.Now()
+	tenants, err := r.next.GetTenants(ctx)
+	r.observe("GetTenants", start, err)
+	return tenants, err
+}
+
+func (r *tenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	start := time.Now()
+	err := r.next.UpdateTenant(ctx, tenant)
+	r.observe("UpdateTenant", start, err)
+	return err
+}
+
+func (r *tenantRepository) SearchTenant(ctx context.Context, filter map[string]any) (*entities.Tenant, error) {
+	start := time.Now()
+	tenant, err := r.next.SearchTenant(ctx, filter)
+	r.observe("SearchTenant", start, err)
+	return tenant, err
+}
+
+func (r *tenantRepository) SearchTenants(ctx context.Context, filter map[string]any) ([]*entities.Tenant, error) {
+	start := time.Now()
+	tenants, err := r.next.SearchTenants(ctx, filter)
+	r.observe("SearchTenants", start, err)
+	return tenants, err
+}
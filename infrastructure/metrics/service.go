@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/tenant-management/application/service"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+// tenantService records the latency and the errors of the operations of another service.
+type tenantService struct {
+	next    service.TenantService
+	metrics *Metrics
+}
+
+// InstrumentTenantService returns a service recording the latency and the errors of every
+// operation of next.
+func InstrumentTenantService(next service.TenantService, m *Metrics) service.TenantService {
+	return &tenantService{next: next, metrics: m}
+}
+
+func (s *tenantService) observe(operation string, start time.Time, err error) {
+	observe(s.metrics.serviceDuration, s.metrics.serviceErrors, operation, start, err)
+}
+
+func (s *tenantService) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	start := time.Now()
+	err := s.next.CreateTenant(ctx, tenant)
+	s.observe("CreateTenant", start, err)
+	return err
+}
+
+func (s *tenantService) DeleteTenant(ctx context.Context, id string) error {
+	start := time.Now()
+	err := s.next.DeleteTenant(ctx, id)
+	s.observe("DeleteTenant", start, err)
+	return err
+}
+
+func (s *tenantService) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	start := time.Now()
+	tenant, err := s.next.GetTenantByID(ctx, id)
+	s.observe("GetTenantByID", start, err)
+	return tenant, err
+}
+
+func (s *tenantService) GetTenantCompanies(ctx context.Context, id string) ([]*entities.TenantCompanyDetails, error) {
+	start := time.Now()
+	companies, err := s.next.GetTenantCompanies(ctx, id)
+	s.observe("GetTenantCompanies", start, err)
+	return companies, err
+}
+
+func (s *tenantService) GetTenantCompanyByID(
+	ctx context.Context, id string, companyID string,
+) (*entities.TenantCompanyDetails, error) {
+	start := time.Now()
+	company, err := s.next.GetTenantCompanyByID(ctx, id, companyID)
+	s.observe("GetTenantCompanyByID", start, err)
+	return company, err
+}
+
+func (s *tenantService) GetTenantPaymentDetails(
+	ctx context.Context, id string,
+) ([]*entities.TenantPaymentDetails, error) {
+	start := time.Now()
+	payments, err := s.next.GetTenantPaymentDetails(ctx, id)
+	s.observe("GetTenantPaymentDetails", start, err)
+	return payments, err
+}
+
+func (s *tenantService) GetTenantByPaymentID(ctx context.Context, paymentID string) (*entities.Tenant, error) {
+	start := time.Now()
+	tenant, err := s.next.GetTenantByPaymentID(ctx, paymentID)
+	s.observe("GetTenantByPaymentID", start, err)
+	return tenant, err
+}
+
+func (s *tenantService) GetTenantCompaniesSubscriptions(
+	ctx context.Context, id string,
+) ([]*entities.TenantSubscriptionDetails, error) {
+	start := time.Now()
+	subscriptions, err := s.next.GetTenantCompaniesSubscriptions(ctx, id)
+	s.observe("GetTenantCompaniesSubscriptions", start, err)
+	return subscriptions, err
+}
+
+func (s *tenantService) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
+	start := time.Now()
+	tenants, err := s.next.GetTenants(ctx)
+	s.observe("GetTenants", start, err)
+	return tenants, err
+}
+
+func (s *tenantService) UpdateTenant(ctx context.Context, id string, tenant *entities.Tenant) error {
+	start := time.Now()
+	err := s.next.UpdateTenant(ctx, id, tenant)
+	s.observe("UpdateTenant", start, err)
+	return err
+}
+
+func (s *tenantService) UpdateTenantCompany(
+	ctx context.Context, id string, company *entities.TenantCompanyDetails,
+) error {
+	start := time.Now()
+	err := s.next.UpdateTenantCompany(ctx, id, company)
+	s.observe("UpdateTenantCompany", start, err)
+	return err
+}
+
+func (s *tenantService) UpdateTenantSubscription(
+	ctx context.Context, tenantID string, subscription *entities.TenantSubscriptionDetails,
+) error {
+	start := time.Now()
+	err := s.next.UpdateTenantSubscription(ctx, tenantID, subscription)
+	s.observe("UpdateTenantSubscription", start, err)
+	return err
+}
+
+func (s *tenantService) UpdateTenantPaymentDetails(
+	ctx context.Context, id string, paymentDetails *entities.TenantPaymentDetails,
+) error {
+	start := time.Now()
+	err := s.next.UpdateTenantPaymentDetails(ctx, id, paymentDetails)
+	s.observe("UpdateTenantPaymentDetails", start, err)
+	return err
+}
+
+func (s *tenantService) CreateAPIKey(ctx context.Context, tenantID string, key *entities.APIKey) (string, error) {
+	start := time.Now()
+	secret, err := s.next.CreateAPIKey(ctx, tenantID, key)
+	s.observe("CreateAPIKey", start, err)
+	return secret, err
+}
+
+func (s *tenantService) ListAPIKeys(ctx context.Context, tenantID string) ([]*entities.APIKey, error) {
+	start := time.Now()
+	keys, err := s.next.ListAPIKeys(ctx, tenantID)
+	s.observe("ListAPIKeys", start, err)
+	return keys, err
+}
+
+func (s *tenantService) RotateAPIKey(
+	ctx context.Context, tenantID string, keyID string,
+) (*entities.APIKey, string, error) {
+	start := time.Now()
+	key, secret, err := s.next.RotateAPIKey(ctx, tenantID, keyID)
+	s.observe("RotateAPIKey", start, err)
+	return key, secret, err
+}
+
+func (s *tenantService) RevokeAPIKey(ctx context.Context, tenantID string, keyID string) error {
+	start := time.Now()
+	err := s.next.RevokeAPIKey(ctx, tenantID, keyID)
+	s.observe("RevokeAPIKey", start, err)
+	return err
+}
@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+)
+
+// StatsSource counts tenants and their subscriptions.
+type StatsSource interface {
+	TenantStats(ctx context.Context) (*entities.TenantStats, error)
+}
+
+// StatsSourceFunc is a StatsSource of a function.
+type StatsSourceFunc func(ctx context.Context) (*entities.TenantStats, error)
+
+func (f StatsSourceFunc) TenantStats(ctx context.Context) (*entities.TenantStats, error) {
+	return f(ctx)
+}
+
+// ScanStats counts tenants and their subscriptions by reading every tenant of a repository,
+// for the repositories unable to count them on their own.
+func ScanStats(tenants repository.TenantRepository) StatsSource {
+	return StatsSourceFunc(
+		func(ctx context.Context) (*entities.TenantStats, error) {
+			all, err := tenants.SearchTenants(ctx, map[string]any{})
+			if err != nil {
+				return nil, err
+			}
+			return entities.NewTenantStats(all), nil
+		},
+	)
+}
+
+// RefreshStats sets the gauges of tenants and subscriptions to the stats of source. Plans and
+// payment statuses without subscriptions anymore are dropped.
+func (m *Metrics) RefreshStats(ctx context.Context, source StatsSource) error {
+	stats, err := source.TenantStats(ctx)
+	if err != nil {
+		m.statsFailures.Inc()
+		return err
+	}
+
+	m.tenants.WithLabelValues("active").Set(float64(stats.ActiveTenants))
+	m.tenants.WithLabelValues("inactive").Set(float64(stats.InactiveTenants))
+
+	m.subscriptions.Reset()
+	for plan, count := range stats.SubscriptionsByPlan {
+		m.subscriptions.WithLabelValues(plan).Set(float64(count))
+	}
+	m.paymentStatuses.Reset()
+	for status, count := range stats.SubscriptionsByPaymentStatus {
+		m.paymentStatuses.WithLabelValues(status).Set(float64(count))
+	}
+
+	return nil
+}
+
+// StartStats refreshes the gauges of tenants and subscriptions right away, then every interval
+// until ctx is done.
+func (m *Metrics) StartStats(ctx context.Context, source StatsSource, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.RefreshStats(ctx, source); err != nil && ctx.Err() == nil {
+			m.logger.Error(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package migrations upgrades stored tenant documents to the current shape of entities.Tenant.
+//
+// Every document records the schema_version it was written in. Migrations are numbered from 1,
+// migration N upgrades documents of version N-1 to version N, so documents of any version are
+// upgraded by applying the migrations above their version in order. Documents without version
+// were written before versioning and are of version 0.
+package migrations
+
+import (
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// VersionField is the field of documents holding their schema version.
+const VersionField = "schema_version"
+
+// Migration upgrades documents from the version before it to its version. Up changes the
+// document in place, it must not set the version, which is done once it succeeds.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(document bson.M) error
+}
+
+// Registry is an ordered set of migrations.
+type Registry struct {
+	migrations []Migration
+}
+
+// NewRegistry returns the registry of migrations, which must be numbered 1, 2, 3... in order.
+func NewRegistry(migrations ...Migration) (*Registry, error) {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, errors.Errorf("migration %q has version %d, expected %d",
+				migration.Description, migration.Version, i+1)
+		}
+		if migration.Up == nil {
+			return nil, errors.Errorf("migration %d has no Up function", migration.Version)
+		}
+	}
+
+	return &Registry{migrations: migrations}, nil
+}
+
+// MustNewRegistry is like NewRegistry but panics when the migrations are not numbered in order.
+func MustNewRegistry(migrations ...Migration) *Registry {
+	registry, err := NewRegistry(migrations...)
+	if err != nil {
+		panic(err)
+	}
+
+	return registry
+}
+
+// Latest returns the version documents are upgraded to, 0 when there are no migrations.
+func (r *Registry) Latest() int {
+	return len(r.migrations)
+}
+
+// Migrations returns the migrations, in order.
+func (r *Registry) Migrations() []Migration {
+	return append([]Migration(nil), r.migrations...)
+}
+
+// Pending returns the migrations upgrading documents of version to the latest version.
+func (r *Registry) Pending(version int) []Migration {
+	if version < 0 {
+		version = 0
+	}
+	if version >= len(r.migrations) {
+		return nil
+	}
+
+	return append([]Migration(nil), r.migrations[version:]...)
+}
+
+// Upgrade applies the pending migrations of a document, and reports whether it was changed.
+// Documents of versions above the latest one, written by a more recent release, are left as
+// they are.
+func (r *Registry) Upgrade(document bson.M) (bool, error) {
+	version, err := Version(document)
+	if err != nil {
+		return false, err
+	}
+
+	pending := r.Pending(version)
+	for _, migration := range pending {
+		if err := migration.Up(document); err != nil {
+			return false, errors.Wrapf(err, "failed to apply migration %d %q", migration.Version, migration.Description)
+		}
+		document[VersionField] = int32(migration.Version)
+	}
+
+	return len(pending) > 0, nil
+}
+
+// Version returns the schema version of a document.
+func Version(document bson.M) (int, error) {
+	value, ok := document[VersionField]
+	if !ok || value == nil {
+		return 0, nil
+	}
+
+	switch value := value.(type) {
+	case int32:
+		return int(value), nil
+	case int64:
+		return int(value), nil
+	case int:
+		return value, nil
+	case float64:
+		return int(value), nil
+	default:
+		return 0, errors.Errorf("invalid %s %v", VersionField, value)
+	}
+}
+
+// RawVersion returns the schema version of an encoded document.
+func RawVersion(document bson.Raw) (int, error) {
+	value, err := document.LookupErr(VersionField)
+	if errors.Is(err, bsoncore.ErrElementNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read %s", VersionField)
+	}
+
+	switch value.Type {
+	case bson.TypeInt32:
+		return int(value.Int32()), nil
+	case bson.TypeInt64:
+		return int(value.Int64()), nil
+	case bson.TypeDouble:
+		return int(value.Double()), nil
+	case bson.TypeNull:
+		return 0, nil
+	default:
+		return 0, errors.Errorf("invalid %s of type %s", VersionField, value.Type)
+	}
+}
@@ -0,0 +1,211 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func newRegistry(t *testing.T) *migrations.Registry {
+	registry, err := migrations.NewRegistry(
+		migrations.Migration{
+			Version:     1,
+			Description: "rename plan to plan_id",
+			Up: func(document bson.M) error {
+				document["plan_id"] = document["plan"]
+				delete(document, "plan")
+				return nil
+			},
+		},
+		migrations.Migration{
+			Version:     2,
+			Description: "default currency",
+			Up: func(document bson.M) error {
+				if document["currency"] == "invalid" {
+					return errors.New("invalid currency")
+				}
+				if _, ok := document["currency"]; !ok {
+					document["currency"] = "USD"
+				}
+				return nil
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	return registry
+}
+
+func TestNewRegistry(t *testing.T) {
+	up := func(bson.M) error { return nil }
+
+	var testCases = []struct {
+		Name          string
+		Migrations    []migrations.Migration
+		ExpectedError bool
+	}{
+		{
+			Name: "Happy Path: Migrations in order",
+			Migrations: []migrations.Migration{
+				{Version: 1, Description: "first", Up: up},
+				{Version: 2, Description: "second", Up: up},
+			},
+		},
+		{
+			Name: "Happy Path: No migrations",
+		},
+		{
+			Name: "Error Path: Missing version",
+			Migrations: []migrations.Migration{
+				{Version: 1, Description: "first", Up: up},
+				{Version: 3, Description: "third", Up: up},
+			},
+			ExpectedError: true,
+		},
+		{
+			Name: "Error Path: Migrations out of order",
+			Migrations: []migrations.Migration{
+				{Version: 2, Description: "second", Up: up},
+				{Version: 1, Description: "first", Up: up},
+			},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error Path: Migration without Up",
+			Migrations:    []migrations.Migration{{Version: 1, Description: "first"}},
+			ExpectedError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				registry, err := migrations.NewRegistry(tt.Migrations...)
+				if tt.ExpectedError {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, len(tt.Migrations), registry.Latest())
+			},
+		)
+	}
+}
+
+func TestRegistry_Upgrade(t *testing.T) {
+	registry := newRegistry(t)
+
+	var testCases = []struct {
+		Name            string
+		Document        bson.M
+		ExpectedChanged bool
+		Expected        bson.M
+		ExpectedError   bool
+	}{
+		{
+			Name:            "Happy Path: Document written before versioning",
+			Document:        bson.M{"_id": "1", "plan": "gold"},
+			ExpectedChanged: true,
+			Expected:        bson.M{"_id": "1", "plan_id": "gold", "currency": "USD", "schema_version": int32(2)},
+		},
+		{
+			Name:            "Happy Path: Document of an older version",
+			Document:        bson.M{"_id": "1", "plan_id": "gold", "currency": "EUR", "schema_version": int64(1)},
+			ExpectedChanged: true,
+			Expected:        bson.M{"_id": "1", "plan_id": "gold", "currency": "EUR", "schema_version": int32(2)},
+		},
+		{
+			Name:     "Happy Path: Document of the latest version",
+			Document: bson.M{"_id": "1", "plan_id": "gold", "currency": "EUR", "schema_version": int32(2)},
+			Expected: bson.M{"_id": "1", "plan_id": "gold", "currency": "EUR", "schema_version": int32(2)},
+		},
+		{
+			Name:     "Happy Path: Document of a more recent release",
+			Document: bson.M{"_id": "1", "schema_version": int32(7)},
+			Expected: bson.M{"_id": "1", "schema_version": int32(7)},
+		},
+		{
+			Name:          "Error Path: Failing migration",
+			Document:      bson.M{"_id": "1", "currency": "invalid"},
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error Path: Invalid version",
+			Document:      bson.M{"_id": "1", "schema_version": "one"},
+			ExpectedError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				changed, err := registry.Upgrade(tt.Document)
+				if tt.ExpectedError {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedChanged, changed)
+				assert.Equal(t, tt.Expected, tt.Document)
+			},
+		)
+	}
+}
+
+func TestRawVersion(t *testing.T) {
+	var testCases = []struct {
+		Name            string
+		Document        bson.M
+		ExpectedVersion int
+		ExpectedError   bool
+	}{
+		{
+			Name:     "Happy Path: Unversioned document",
+			Document: bson.M{"_id": "1"},
+		},
+		{
+			Name:            "Happy Path: Versioned document",
+			Document:        bson.M{"_id": "1", "schema_version": int32(3)},
+			ExpectedVersion: 3,
+		},
+		{
+			Name:          "Error Path: Invalid version",
+			Document:      bson.M{"_id": "1", "schema_version": "three"},
+			ExpectedError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				raw, err := bson.Marshal(tt.Document)
+				require.NoError(t, err)
+
+				version, err := migrations.RawVersion(raw)
+				if tt.ExpectedError {
+					assert.Error(t, err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedVersion, version)
+			},
+		)
+	}
+}
+
+func TestTenants(t *testing.T) {
+	// the migrations of tenants upgrade documents of any version
+	for version := 0; version <= migrations.Tenants.Latest(); version++ {
+		document := bson.M{"_id": "tenant-1", "schema_version": int32(version)}
+		_, err := migrations.Tenants.Upgrade(document)
+		require.NoError(t, err)
+		assert.Equal(t, int32(migrations.Tenants.Latest()), document["schema_version"])
+	}
+}
@@ -0,0 +1,14 @@
+package migrations
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Tenants are the migrations of tenant documents. Append migrations for new shapes of
+// entities.Tenant, released migrations must never be changed or removed.
+var Tenants = MustNewRegistry(
+	Migration{
+		Version:     1,
+		Description: "version tenant documents",
+		// documents written before versioning already have the shape of version 1
+		Up: func(bson.M) error { return nil },
+	},
+)
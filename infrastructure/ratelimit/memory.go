@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store forgets counters that no longer limit anything.
+const sweepInterval = time.Minute
+
+type counter struct {
+	tokens    float64
+	count     int
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in the memory of the process. Every instance of the service
+// limits requests on its own, use MongoStore to share limits between instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	counters  map[string]*counter
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, mode Mode, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if mode == FixedWindow {
+		start := now.Truncate(limit.Period)
+		windowKey := key + "@" + start.Format(time.RFC3339Nano)
+
+		c, ok := s.counters[windowKey]
+		if !ok {
+			c = &counter{expiresAt: start.Add(limit.Period)}
+			s.counters[windowKey] = c
+		}
+
+		c.count++
+		return fixedWindowResult(limit, c.count, start, now), nil
+	}
+
+	capacity := float64(limit.capacity())
+	c, ok := s.counters[key]
+	if !ok {
+		c = &counter{tokens: capacity, updatedAt: now}
+		s.counters[key] = c
+	}
+
+	if elapsed := now.Sub(c.updatedAt); elapsed > 0 {
+		c.tokens = math.Min(capacity, c.tokens+elapsed.Seconds()*limit.rate())
+		c.updatedAt = now
+	}
+
+	allowed := c.tokens >= 1
+	if allowed {
+		c.tokens--
+	}
+
+	// a full bucket is the same as no bucket
+	c.expiresAt = now.Add(seconds((capacity - c.tokens) / limit.rate()))
+
+	return tokenBucketResult(limit, c.tokens, allowed), nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, c := range s.counters {
+		if !now.Before(c.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Middleware limits the requests of authenticated principals and describes the limit with the
+// RateLimit-* headers. It must run after authentication, anonymous requests are not limited.
+// Requests are let through when the store fails, an outage of the limiter must not take the API down.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			result, ok := l.limit(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for name, value := range headers(result) {
+				w.Header().Set(name, value)
+			}
+
+			if !result.Allowed {
+				apperrors.WriteProblem(w, r, apperrors.ErrRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// UnaryServerInterceptor limits unary gRPC calls like Middleware, the limit is described
+// in the ratelimit-* header metadata.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (any, error) {
+		result, ok := l.limit(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		md := metadata.MD{}
+		for name, value := range headers(result) {
+			md.Set(name, value)
+		}
+		if err := grpc.SetHeader(ctx, md); err != nil {
+			l.logger.Error(err)
+		}
+
+		if !result.Allowed {
+			return nil, apperrors.GRPCStatus(apperrors.ErrRateLimited).Err()
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// limit counts the request of ctx, it reports false when the request is not limited.
+func (l *Limiter) limit(ctx context.Context) (Result, bool) {
+	principal, ok := authn.PrincipalFromContext(ctx)
+	if !ok {
+		return Result{}, false
+	}
+
+	result, err := l.Allow(ctx, principal)
+	if err != nil {
+		l.logger.Error(err)
+		return Result{}, false
+	}
+
+	if result.Policy == "" {
+		return Result{}, false
+	}
+
+	if !result.Allowed {
+		l.logger.Infof("rate limited tenant %s", principal.TenantID)
+	}
+
+	return result, true
+}
+
+func headers(result Result) map[string]string {
+	h := map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(result.Limit),
+		"RateLimit-Remaining": strconv.Itoa(result.Remaining),
+		"RateLimit-Reset":     strconv.Itoa(ceilSeconds(result.Reset)),
+		"RateLimit-Policy":    result.Policy,
+	}
+
+	if !result.Allowed {
+		h["Retry-After"] = strconv.Itoa(ceilSeconds(result.RetryAfter))
+	}
+
+	return h
+}
+
+// ceilSeconds rounds up, so clients waiting for the advertised time are not rejected again.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps counters in a Mongo collection shared by every instance of the service.
+// Counters are updated atomically on the server and removed by a TTL index once they no
+// longer limit anything.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// CreateIndexes creates the TTL index expiring counters.
+func (s *MongoStore) CreateIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(
+		ctx, mongo.IndexModel{
+			Keys:    bson.M{"expires_at": 1},
+			Options: options.Index().SetName("expires_at").SetExpireAfterSeconds(0),
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create rate limit indexes")
+	}
+
+	return nil
+}
+
+func (s *MongoStore) Take(ctx context.Context, key string, limit Limit, mode Mode, now time.Time) (Result, error) {
+	// counters are stored with millisecond precision
+	now = now.Truncate(time.Millisecond)
+
+	var (
+		result Result
+		err    error
+	)
+
+	// concurrent upserts of a new counter can collide, the loser finds the counter on retry
+	for attempt := 0; attempt < 2; attempt++ {
+		if mode == FixedWindow {
+			result, err = s.takeWindow(ctx, key, limit, now)
+		} else {
+			result, err = s.takeToken(ctx, key, limit, now)
+		}
+
+		if !mongo.IsDuplicateKeyError(err) {
+			break
+		}
+	}
+
+	if err != nil {
+		return Result{}, errors.Wrapf(err, "failed to take rate limit %s", key)
+	}
+
+	return result, nil
+}
+
+func (s *MongoStore) takeWindow(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	start := now.Truncate(limit.Period)
+
+	var counter struct {
+		Count int `bson:"count"`
+	}
+
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key + "@" + strconv.FormatInt(start.UnixMilli(), 10)},
+		bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": bson.M{"expires_at": start.Add(limit.Period)},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return fixedWindowResult(limit, counter.Count, start, now), nil
+}
+
+func (s *MongoStore) takeToken(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	capacity := float64(limit.capacity())
+	perMillisecond := limit.rate() / 1000
+
+	// refill the bucket for the time elapsed since its last update, then take a token if there is one
+	pipeline := mongo.Pipeline{
+		{
+			{
+				Key: "$set", Value: bson.M{
+					"tokens": bson.M{
+						"$min": bson.A{
+							capacity,
+							bson.M{
+								"$add": bson.A{
+									bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+									bson.M{
+										"$multiply": bson.A{
+											perMillisecond,
+											bson.M{
+												"$max": bson.A{
+													0, bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					"updated_at": now,
+				},
+			},
+		},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{
+			{
+				Key: "$set", Value: bson.M{
+					"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+					// at the latest, the bucket is full again once every token was regained
+					"expires_at": now.Add(seconds(capacity / limit.rate())),
+				},
+			},
+		},
+	}
+
+	var bucket struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+
+	err := s.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": key},
+		pipeline,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&bucket)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return tokenBucketResult(limit, bucket.Tokens, bucket.Allowed), nil
+}
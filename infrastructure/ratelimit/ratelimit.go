@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// Mode is the algorithm counting requests against a limit.
+type Mode string
+
+const (
+	// TokenBucket refills a bucket of Burst tokens at Requests per Period, every request takes a token.
+	// Bursts are smoothed out, the budget is never reset at once.
+	TokenBucket Mode = "token_bucket"
+	// FixedWindow allows Requests per aligned window of Period, counters reset at the end of the window.
+	FixedWindow Mode = "fixed_window"
+)
+
+func (m Mode) IsValid() bool {
+	switch m {
+	case TokenBucket, FixedWindow:
+		return true
+	default:
+		return false
+	}
+}
+
+// Limit allows Requests per Period. Burst is the capacity of token buckets, it defaults to Requests.
+// The zero Limit does not limit anything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate is the number of tokens a bucket regains per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Quota is the limits of the tenants of a plan.
+type Quota struct {
+	// Tenant limits all requests of a tenant.
+	Tenant Limit
+	// APIKey limits the requests of each API key of a tenant, on top of Tenant.
+	APIKey Limit
+}
+
+// Result is the outcome of counting a request against a limit.
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests that can be made at once.
+	Limit int
+	// Remaining is the number of requests that can still be made at once.
+	Remaining int
+	// Reset is how long until the whole limit is available again.
+	Reset time.Duration
+	// RetryAfter is how long until a request is allowed again, it is zero for allowed requests.
+	RetryAfter time.Duration
+	// Policy describes the limit for the RateLimit-Policy header.
+	Policy string
+}
+
+// Store keeps the counters of rate limits.
+type Store interface {
+	// Take counts a request against the limit of key.
+	Take(ctx context.Context, key string, limit Limit, mode Mode, now time.Time) (Result, error)
+}
+
+type Option func(*Limiter)
+
+// WithMode sets the algorithm of the limiter, the default is TokenBucket.
+func WithMode(mode Mode) Option {
+	return func(l *Limiter) {
+		l.mode = mode
+	}
+}
+
+// WithPlan sets the quota of the tenants subscribed to plan.
+func WithPlan(plan string, quota Quota) Option {
+	return func(l *Limiter) {
+		l.plans[plan] = quota
+	}
+}
+
+// WithDefaultQuota sets the quota of tenants without a known active plan.
+func WithDefaultQuota(quota Quota) Option {
+	return func(l *Limiter) {
+		l.fallback = quota
+	}
+}
+
+// Limiter limits the requests of tenants and of their API keys according to their subscription plan.
+type Limiter struct {
+	store    Store
+	logger   utils.LoggerInterface
+	mode     Mode
+	plans    map[string]Quota
+	fallback Quota
+	now      func() time.Time
+}
+
+func NewLimiter(logger utils.LoggerInterface, store Store, opts ...Option) (*Limiter, error) {
+	l := &Limiter{
+		store:  store,
+		logger: logger,
+		mode:   TokenBucket,
+		plans:  map[string]Quota{},
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if !l.mode.IsValid() {
+		return nil, errors.Errorf("unknown rate limit mode %q", l.mode)
+	}
+
+	return l, nil
+}
+
+// Allow counts a request of principal against the limits of its tenant and API key.
+// The result is the most restrictive of them.
+func (l *Limiter) Allow(ctx context.Context, principal *authn.Principal) (Result, error) {
+	quota := l.quota(principal.Tenant)
+	now := l.now()
+
+	result := Result{Allowed: true, Remaining: math.MaxInt}
+	if !quota.Tenant.IsZero() {
+		tenant, err := l.store.Take(ctx, "tenant:"+principal.TenantID, quota.Tenant, l.mode, now)
+		if err != nil {
+			return Result{}, err
+		}
+		result = tenant
+	}
+
+	// requests rejected for the tenant are not counted against the key
+	if result.Allowed && principal.APIKey != nil && !quota.APIKey.IsZero() {
+		key, err := l.store.Take(ctx, "api_key:"+principal.APIKey.ID, quota.APIKey, l.mode, now)
+		if err != nil {
+			return Result{}, err
+		}
+
+		if !key.Allowed || key.Remaining < result.Remaining {
+			result = key
+		}
+	}
+
+	if result.Remaining == math.MaxInt {
+		// nothing is limited
+		return Result{Allowed: true}, nil
+	}
+
+	return result, nil
+}
+
+// quota returns the quota of the best active plan of a tenant.
+func (l *Limiter) quota(tenant *entities.Tenant) Quota {
+	quota, found := l.fallback, false
+	if tenant == nil {
+		return quota
+	}
+
+	for _, company := range tenant.Companies {
+		for _, subscription := range company.Subscriptions {
+			if subscription == nil || !subscription.Active {
+				continue
+			}
+
+			plan, ok := l.plans[subscription.Plan]
+			if !ok {
+				continue
+			}
+
+			if !found || allowsMore(plan.Tenant, quota.Tenant) {
+				quota, found = plan, true
+			}
+		}
+	}
+
+	return quota
+}
+
+// allowsMore reports whether a allows more requests over time than b.
+func allowsMore(a Limit, b Limit) bool {
+	switch {
+	case a.IsZero():
+		return true
+	case b.IsZero():
+		return false
+	default:
+		return a.rate() > b.rate()
+	}
+}
+
+func tokenBucketResult(limit Limit, tokens float64, allowed bool) Result {
+	capacity := limit.capacity()
+	rate := limit.rate()
+
+	result := Result{
+		Allowed:   allowed,
+		Limit:     capacity,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(capacity) - tokens) / rate),
+		Policy:    fmt.Sprintf("%d;w=%d;burst=%d", limit.Requests, int(limit.Period.Seconds()), capacity),
+	}
+
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+func fixedWindowResult(limit Limit, count int, start time.Time, now time.Time) Result {
+	result := Result{
+		Allowed:   count <= limit.Requests,
+		Limit:     limit.Requests,
+		Remaining: limit.Requests - count,
+		Reset:     start.Add(limit.Period).Sub(now),
+		Policy:    fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds())),
+	}
+
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+
+	if !result.Allowed {
+		result.RetryAfter = result.Reset
+	}
+
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
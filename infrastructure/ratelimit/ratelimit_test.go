@@ -0,0 +1,193 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/infrastructure/ratelimit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStore fails while it is down.
+type flakyStore struct {
+	ratelimit.Store
+	down bool
+}
+
+func (s *flakyStore) Take(
+	ctx context.Context, key string, limit ratelimit.Limit, mode ratelimit.Mode, now time.Time,
+) (ratelimit.Result, error) {
+	if s.down {
+		return ratelimit.Result{}, errors.New("store is down")
+	}
+	return s.Store.Take(ctx, key, limit, mode, now)
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	start := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	limit := ratelimit.Limit{Requests: 2, Period: time.Second}
+
+	var testCases = []struct {
+		Name     string
+		Mode     ratelimit.Mode
+		Offsets  []time.Duration
+		Expected []bool
+	}{
+		{
+			Name:     "Token bucket: burst is exhausted",
+			Mode:     ratelimit.TokenBucket,
+			Offsets:  []time.Duration{0, 0, 0},
+			Expected: []bool{true, true, false},
+		},
+		{
+			Name:     "Token bucket: tokens are regained over time",
+			Mode:     ratelimit.TokenBucket,
+			Offsets:  []time.Duration{0, 0, 0, 500 * time.Millisecond, 500 * time.Millisecond},
+			Expected: []bool{true, true, false, true, false},
+		},
+		{
+			Name:     "Fixed window: limit is reset with the window",
+			Mode:     ratelimit.FixedWindow,
+			Offsets:  []time.Duration{0, 100 * time.Millisecond, 900 * time.Millisecond, time.Second},
+			Expected: []bool{true, true, false, true},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				store := ratelimit.NewMemoryStore()
+				for i, offset := range tt.Offsets {
+					result, err := store.Take(context.Background(), "tenant:1", limit, tt.Mode, start.Add(offset))
+					require.NoError(t, err)
+					assert.Equal(t, tt.Expected[i], result.Allowed, "request %d", i)
+					assert.Equal(t, 2, result.Limit)
+
+					if !result.Allowed {
+						assert.Equal(t, 0, result.Remaining)
+						assert.Positive(t, result.RetryAfter)
+					}
+				}
+			},
+		)
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	premium := ratelimit.Quota{
+		Tenant: ratelimit.Limit{Requests: 3, Period: time.Minute},
+		APIKey: ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}
+
+	newTenant := func(plans ...string) *entities.Tenant {
+		company := &entities.TenantCompanyDetails{}
+		for _, plan := range plans {
+			company.Subscriptions = append(
+				company.Subscriptions, &entities.TenantSubscriptionDetails{Plan: plan, Active: true},
+			)
+		}
+		return &entities.Tenant{ID: "tenant-1", Companies: []*entities.TenantCompanyDetails{company}}
+	}
+
+	var testCases = []struct {
+		Name     string
+		Tenant   *entities.Tenant
+		APIKey   *entities.APIKey
+		Expected []bool
+	}{
+		{
+			Name:     "Default quota without an active plan",
+			Tenant:   newTenant(),
+			Expected: []bool{true, false},
+		},
+		{
+			Name:     "Best active plan",
+			Tenant:   newTenant("starter", "premium"),
+			Expected: []bool{true, true, true, false},
+		},
+		{
+			Name:     "API key limit on top of the tenant limit",
+			Tenant:   newTenant("premium"),
+			APIKey:   &entities.APIKey{ID: "key-1"},
+			Expected: []bool{true, false},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				limiter, err := ratelimit.NewLimiter(
+					utils.NewLogger(), ratelimit.NewMemoryStore(),
+					ratelimit.WithMode(ratelimit.FixedWindow),
+					ratelimit.WithDefaultQuota(ratelimit.Quota{Tenant: ratelimit.Limit{Requests: 1, Period: time.Minute}}),
+					ratelimit.WithPlan("starter", ratelimit.Quota{Tenant: ratelimit.Limit{Requests: 2, Period: time.Minute}}),
+					ratelimit.WithPlan("premium", premium),
+				)
+				require.NoError(t, err)
+
+				principal := &authn.Principal{TenantID: tt.Tenant.ID, Tenant: tt.Tenant, APIKey: tt.APIKey}
+				for i, expected := range tt.Expected {
+					result, err := limiter.Allow(context.Background(), principal)
+					require.NoError(t, err)
+					assert.Equal(t, expected, result.Allowed, "request %d", i)
+				}
+			},
+		)
+	}
+}
+
+func TestLimiter_Middleware(t *testing.T) {
+	store := &flakyStore{Store: ratelimit.NewMemoryStore()}
+	limiter, err := ratelimit.NewLimiter(
+		utils.NewLogger(), store,
+		ratelimit.WithDefaultQuota(ratelimit.Quota{Tenant: ratelimit.Limit{Requests: 1, Period: time.Minute}}),
+	)
+	require.NoError(t, err)
+
+	handler := limiter.Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	)
+
+	serve := func(ctx context.Context) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tenants", nil).WithContext(ctx))
+		return rec
+	}
+
+	ctx := authn.WithPrincipal(context.Background(), &authn.Principal{TenantID: "tenant-1"})
+
+	rec := serve(ctx)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60;burst=1", rec.Header().Get("RateLimit-Policy"))
+
+	rec = serve(ctx)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	var problem apperrors.Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, "rate_limited", problem.Code)
+
+	// anonymous requests are left to the authentication
+	rec = serve(context.Background())
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+
+	// requests are let through when the store fails
+	store.down = true
+	rec = serve(ctx)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+type lruEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// lru is a least recently used cache whose entries expire. It is not safe for concurrent use.
+type lru[V any] struct {
+	capacity int
+	order    *list.List
+	entries  map[string]*list.Element
+	// onEvict is called with the entries dropped to make room.
+	onEvict func(key string, value V)
+}
+
+func newLRU[V any](capacity int, onEvict func(key string, value V)) *lru[V] {
+	return &lru[V]{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		onEvict:  onEvict,
+	}
+}
+
+// get returns the value of key unless it expired at now, and marks it as recently used.
+func (c *lru[V]) get(key string, now time.Time) (V, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	entry := element.Value.(*lruEntry[V])
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set stores the value of key until expiresAt, dropping the least recently used entry when full.
+func (c *lru[V]) set(key string, value V, expiresAt time.Time) {
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry[V])
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expiresAt: expiresAt})
+
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*lruEntry[V])
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		if c.onEvict != nil {
+			c.onEvict(entry.key, entry.value)
+		}
+	}
+}
+
+func (c *lru[V]) remove(key string) {
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// removeIf drops the entries matching match.
+func (c *lru[V]) removeIf(match func(key string, value V) bool) {
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*lruEntry[V])
+		if match(entry.key, entry.value) {
+			c.order.Remove(element)
+			delete(c.entries, entry.key)
+		}
+		element = next
+	}
+}
+
+func (c *lru[V]) clear() {
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+func (c *lru[V]) len() int {
+	return c.order.Len()
+}
@@ -0,0 +1,323 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultCapacity    = 10000
+	defaultTTL         = time.Minute
+	defaultNegativeTTL = 10 * time.Second
+
+	// lookupTimeout bounds the lookups of misses, which do not end with the caller that started them.
+	lookupTimeout = 30 * time.Second
+)
+
+type Option func(*TenantRepository)
+
+// WithCapacity sets how many tenants, and how many subdomains, are kept in cache.
+func WithCapacity(capacity int) Option {
+	return func(r *TenantRepository) {
+		r.capacity = capacity
+	}
+}
+
+// WithTTL sets how long tenants are cached. Changes made by other instances of the service
+// are seen after the ttl at worst, unless the cache is invalidated by a watcher.
+func WithTTL(ttl time.Duration) Option {
+	return func(r *TenantRepository) {
+		r.ttl = ttl
+	}
+}
+
+// WithNegativeTTL sets how long unknown subdomains are remembered as such.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(r *TenantRepository) {
+		r.negativeTTL = ttl
+	}
+}
+
+// Stats are the counters of a cache since it was created.
+type Stats struct {
+	Hits uint64
+	// NegativeHits are the lookups of subdomains known to be unknown.
+	NegativeHits uint64
+	Misses       uint64
+	// Evictions are the entries dropped to make room for others.
+	Evictions uint64
+	// Tenants and Subdomains are the number of cached entries.
+	Tenants    int
+	Subdomains int
+}
+
+// TenantRepository caches the lookups of tenants by ID and by subdomain in front of another
+// repository. Entries are kept in LRU order and expire after a ttl, unknown subdomains are
+// cached as well for a shorter time. Concurrent misses of the same key are collapsed into a
+// single lookup, and writes through the repository invalidate the tenant they change.
+//
+// Tenants are cached encoded, every lookup returns a copy the caller is free to modify.
+type TenantRepository struct {
+	next   repository.TenantRepository
+	logger utils.LoggerInterface
+
+	mu         sync.Mutex
+	tenants    *lru[[]byte]
+	subdomains *lru[string]
+	// generation changes with every invalidation, lookups started before an invalidation
+	// are not cached since they may have read what was invalidated.
+	generation uint64
+	group      singleflight.Group
+
+	hits         atomic.Uint64
+	negativeHits atomic.Uint64
+	misses       atomic.Uint64
+	evictions    atomic.Uint64
+
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+func NewTenantRepository(
+	next repository.TenantRepository, logger utils.LoggerInterface, opts ...Option,
+) *TenantRepository {
+	r := &TenantRepository{
+		next:        next,
+		logger:      logger,
+		capacity:    defaultCapacity,
+		ttl:         defaultTTL,
+		negativeTTL: defaultNegativeTTL,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	r.tenants = newLRU(r.capacity, func(string, []byte) { r.evictions.Add(1) })
+	r.subdomains = newLRU(r.capacity, func(string, string) { r.evictions.Add(1) })
+
+	return r
+}
+
+// Stats returns the counters of the cache.
+func (r *TenantRepository) Stats() Stats {
+	r.mu.Lock()
+	tenants, subdomains := r.tenants.len(), r.subdomains.len()
+	r.mu.Unlock()
+
+	return Stats{
+		Hits:         r.hits.Load(),
+		NegativeHits: r.negativeHits.Load(),
+		Misses:       r.misses.Load(),
+		Evictions:    r.evictions.Load(),
+		Tenants:      tenants,
+		Subdomains:   subdomains,
+	}
+}
+
+func (r *TenantRepository) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	defer r.Invalidate(tenant.ID)
+	return r.next.CreateTenant(ctx, tenant)
+}
+
+func (r *TenantRepository) DeleteTenant(ctx context.Context, id string) error {
+	defer r.Invalidate(id)
+	return r.next.DeleteTenant(ctx, id)
+}
+
+func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	defer r.Invalidate(tenant.ID)
+	return r.next.UpdateTenant(ctx, tenant)
+}
+
+func (r *TenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
+	return r.next.GetTenants(ctx)
+}
+
+func (r *TenantRepository) SearchTenants(ctx context.Context, filter map[string]any) ([]*entities.Tenant, error) {
+	return r.next.SearchTenants(ctx, filter)
+}
+
+// GetTenantByID returns a tenant from cache, or from the next repository on a miss.
+func (r *TenantRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	r.mu.Lock()
+	raw, ok := r.tenants.get(id, r.now())
+	r.mu.Unlock()
+
+	if ok {
+		r.hits.Add(1)
+		return decode(raw)
+	}
+
+	r.misses.Add(1)
+	return r.load(
+		ctx, "id:"+id, "", func(ctx context.Context) (*entities.Tenant, error) {
+			return r.next.GetTenantByID(ctx, id)
+		},
+	)
+}
+
+// SearchTenant caches the lookups by subdomain, other searches go to the next repository.
+func (r *TenantRepository) SearchTenant(ctx context.Context, filter map[string]any) (*entities.Tenant, error) {
+	subdomain, ok := filter["subdomain"].(string)
+	if !ok || len(filter) != 1 {
+		return r.next.SearchTenant(ctx, filter)
+	}
+
+	r.mu.Lock()
+	now := r.now()
+	id, found := r.subdomains.get(subdomain, now)
+	var raw []byte
+	if found && id != "" {
+		raw, found = r.tenants.get(id, now)
+	}
+	r.mu.Unlock()
+
+	switch {
+	case found && id == "":
+		r.negativeHits.Add(1)
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	case found:
+		r.hits.Add(1)
+		return decode(raw)
+	}
+
+	r.misses.Add(1)
+	return r.load(
+		ctx, "subdomain:"+subdomain, subdomain, func(ctx context.Context) (*entities.Tenant, error) {
+			return r.next.SearchTenant(ctx, filter)
+		},
+	)
+}
+
+// Invalidate drops a tenant from cache, together with its subdomain. Unknown subdomains are
+// forgotten as well, since the change may have claimed one of them.
+func (r *TenantRepository) Invalidate(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.tenants.remove(id)
+	r.subdomains.removeIf(
+		func(_ string, tenantID string) bool {
+			return tenantID == id || tenantID == ""
+		},
+	)
+}
+
+// InvalidateAll empties the cache.
+func (r *TenantRepository) InvalidateAll() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	r.tenants.clear()
+	r.subdomains.clear()
+}
+
+// load looks a tenant up once for all the concurrent misses of key and caches the result.
+// Subdomain is the subdomain looked up, unknown subdomains are cached as such. The lookup is
+// not canceled with ctx since other callers may be waiting for it, callers stop waiting for
+// it when their own ctx is done.
+func (r *TenantRepository) load(
+	ctx context.Context, key string, subdomain string, lookup func(ctx context.Context) (*entities.Tenant, error),
+) (*entities.Tenant, error) {
+	results := r.group.DoChan(
+		key, func() (any, error) {
+			r.mu.Lock()
+			generation := r.generation
+			r.mu.Unlock()
+
+			ctx, cancel := context.WithTimeout(withoutCancel{ctx}, lookupTimeout)
+			defer cancel()
+
+			tenant, err := lookup(ctx)
+			if errors.Is(err, apperrors.ErrNoTenantDocumentsFound) && subdomain != "" {
+				r.store(generation, subdomain, "", nil, r.negativeTTL)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			raw, err := bson.Marshal(tenant)
+			if err != nil {
+				r.logger.Error(err)
+				return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+			}
+
+			if subdomain == "" {
+				subdomain = tenant.Subdomain
+			}
+			r.store(generation, subdomain, tenant.ID, raw, r.ttl)
+
+			return raw, nil
+		},
+	)
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return decode(result.Val.([]byte))
+	}
+}
+
+// withoutCancel keeps the values of a context but neither its deadline nor its cancellation.
+type withoutCancel struct {
+	context.Context
+}
+
+func (withoutCancel) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (withoutCancel) Done() <-chan struct{} {
+	return nil
+}
+
+func (withoutCancel) Err() error {
+	return nil
+}
+
+// store caches a lookup made at generation, unless the cache was invalidated since.
+func (r *TenantRepository) store(generation uint64, subdomain string, id string, raw []byte, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if generation != r.generation || ttl <= 0 {
+		return
+	}
+
+	expiresAt := r.now().Add(ttl)
+	if raw != nil {
+		r.tenants.set(id, raw, expiresAt)
+	}
+	if subdomain != "" {
+		r.subdomains.set(subdomain, id, expiresAt)
+	}
+}
+
+func decode(raw []byte) (*entities.Tenant, error) {
+	var tenant entities.Tenant
+	if err := bson.Unmarshal(raw, &tenant); err != nil {
+		return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+	}
+
+	return &tenant, nil
+}
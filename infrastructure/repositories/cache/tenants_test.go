@@ -0,0 +1,346 @@
+package cache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/cache"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepository is a tenant repository in memory counting its lookups. Lookups wait for
+// release when it is set, or for their context to be done.
+type countingRepository struct {
+	mu      sync.Mutex
+	tenants map[string]*entities.Tenant
+	lookups atomic.Int64
+	release chan struct{}
+}
+
+func newCountingRepository(tenants ...*entities.Tenant) *countingRepository {
+	r := &countingRepository{tenants: map[string]*entities.Tenant{}}
+	for _, tenant := range tenants {
+		r.tenants[tenant.ID] = tenant
+	}
+	return r
+}
+
+func (r *countingRepository) lookup(ctx context.Context) error {
+	r.lookups.Add(1)
+	if r.release == nil {
+		return nil
+	}
+
+	select {
+	case <-r.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *countingRepository) CreateTenant(_ context.Context, tenant *entities.Tenant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *tenant
+	r.tenants[tenant.ID] = &stored
+	return nil
+}
+
+func (r *countingRepository) DeleteTenant(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tenants, id)
+	return nil
+}
+
+func (r *countingRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	if err := r.lookup(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenant, ok := r.tenants[id]
+	if !ok {
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+	found := *tenant
+	return &found, nil
+}
+
+func (r *countingRepository) GetTenants(context.Context) ([]*entities.Tenant, error) {
+	return nil, nil
+}
+
+func (r *countingRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	return r.CreateTenant(ctx, tenant)
+}
+
+func (r *countingRepository) SearchTenant(ctx context.Context, filter map[string]any) (*entities.Tenant, error) {
+	if err := r.lookup(ctx); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, tenant := range r.tenants {
+		if tenant.Subdomain == filter["subdomain"] {
+			found := *tenant
+			return &found, nil
+		}
+	}
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (r *countingRepository) SearchTenants(context.Context, map[string]any) ([]*entities.Tenant, error) {
+	return nil, nil
+}
+
+func TestTenantRepository(t *testing.T) {
+	repositorytest.TestTenantRepository(
+		t, func(t *testing.T) repository.TenantRepository {
+			return cache.NewTenantRepository(memory.NewTenantRepository(utils.NewLogger()), utils.NewLogger())
+		},
+	)
+}
+
+func newTenant(id string, subdomain string) *entities.Tenant {
+	return &entities.Tenant{ID: id, Name: "Tenant " + id, Subdomain: subdomain, IsActive: true}
+}
+
+func TestTenantRepository_GetTenantByID(t *testing.T) {
+	next := newCountingRepository(newTenant("tenant-1", "acme"))
+	repo := cache.NewTenantRepository(next, utils.NewLogger())
+	ctx := context.Background()
+
+	first, err := repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+
+	// callers get copies they are free to modify
+	first.Name = "Modified"
+
+	second, err := repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Tenant tenant-1", second.Name)
+
+	// the subdomain of a tenant looked up by ID is cached too
+	bySubdomain, err := repo.SearchTenant(ctx, map[string]any{"subdomain": "acme"})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", bySubdomain.ID)
+
+	_, err = repo.GetTenantByID(ctx, "missing")
+	assert.True(t, errors.Is(err, apperrors.ErrNoTenantDocumentsFound), err)
+
+	assert.Equal(t, int64(2), next.lookups.Load())
+	stats := repo.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(2), stats.Misses)
+	assert.Equal(t, 1, stats.Tenants)
+}
+
+func TestTenantRepository_SearchTenant(t *testing.T) {
+	next := newCountingRepository(newTenant("tenant-1", "acme"))
+	repo := cache.NewTenantRepository(next, utils.NewLogger(), cache.WithNegativeTTL(time.Hour))
+	ctx := context.Background()
+
+	var testCases = []struct {
+		Name            string
+		Filter          map[string]any
+		ExpectedID      string
+		ExpectedError   error
+		ExpectedLookups int64
+	}{
+		{
+			Name:            "Happy Path: first lookup of a subdomain",
+			Filter:          map[string]any{"subdomain": "acme"},
+			ExpectedID:      "tenant-1",
+			ExpectedLookups: 1,
+		},
+		{
+			Name:            "Happy Path: cached subdomain",
+			Filter:          map[string]any{"subdomain": "acme"},
+			ExpectedID:      "tenant-1",
+			ExpectedLookups: 1,
+		},
+		{
+			Name:            "Error Path: unknown subdomain",
+			Filter:          map[string]any{"subdomain": "globex"},
+			ExpectedError:   apperrors.ErrNoTenantDocumentsFound,
+			ExpectedLookups: 2,
+		},
+		{
+			Name:            "Error Path: unknown subdomain is cached",
+			Filter:          map[string]any{"subdomain": "globex"},
+			ExpectedError:   apperrors.ErrNoTenantDocumentsFound,
+			ExpectedLookups: 2,
+		},
+		{
+			Name:            "Happy Path: other searches are not cached",
+			Filter:          map[string]any{"subdomain": "acme", "is_active": true},
+			ExpectedID:      "tenant-1",
+			ExpectedLookups: 3,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				tenant, err := repo.SearchTenant(ctx, tt.Filter)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), err)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, tt.ExpectedID, tenant.ID)
+				}
+				assert.Equal(t, tt.ExpectedLookups, next.lookups.Load())
+			},
+		)
+	}
+
+	assert.Equal(t, uint64(1), repo.Stats().NegativeHits)
+}
+
+func TestTenantRepository_Invalidation(t *testing.T) {
+	next := newCountingRepository(newTenant("tenant-1", "acme"))
+	repo := cache.NewTenantRepository(next, utils.NewLogger(), cache.WithNegativeTTL(time.Hour))
+	ctx := context.Background()
+
+	_, err := repo.SearchTenant(ctx, map[string]any{"subdomain": "globex"})
+	require.Error(t, err)
+	tenant, err := repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+
+	// writes invalidate the tenant and unknown subdomains
+	tenant.Subdomain = "globex"
+	require.NoError(t, repo.UpdateTenant(ctx, tenant))
+
+	found, err := repo.SearchTenant(ctx, map[string]any{"subdomain": "globex"})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", found.ID)
+
+	_, err = repo.SearchTenant(ctx, map[string]any{"subdomain": "acme"})
+	assert.True(t, errors.Is(err, apperrors.ErrNoTenantDocumentsFound), err)
+
+	// changes made elsewhere are seen once invalidated
+	next.tenants["tenant-1"].Name = "Renamed"
+	found, err = repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Tenant tenant-1", found.Name)
+
+	repo.Invalidate("tenant-1")
+	found, err = repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", found.Name)
+
+	repo.InvalidateAll()
+	assert.Zero(t, repo.Stats().Tenants)
+	assert.Zero(t, repo.Stats().Subdomains)
+}
+
+func TestTenantRepository_Expiry(t *testing.T) {
+	next := newCountingRepository(newTenant("tenant-1", "acme"), newTenant("tenant-2", "globex"))
+	repo := cache.NewTenantRepository(
+		next, utils.NewLogger(), cache.WithCapacity(1), cache.WithTTL(50*time.Millisecond),
+	)
+	ctx := context.Background()
+
+	_, err := repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	_, err = repo.GetTenantByID(ctx, "tenant-2")
+	require.NoError(t, err)
+
+	// the least recently used tenant made room for the other
+	_, err = repo.GetTenantByID(ctx, "tenant-2")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), next.lookups.Load())
+	assert.Equal(t, uint64(2), repo.Stats().Evictions)
+
+	_, err = repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), next.lookups.Load())
+
+	time.Sleep(60 * time.Millisecond)
+	_, err = repo.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), next.lookups.Load())
+}
+
+func TestTenantRepository_ConcurrentMisses(t *testing.T) {
+	next := newCountingRepository(newTenant("tenant-1", "acme"))
+	next.release = make(chan struct{})
+	repo := cache.NewTenantRepository(next, utils.NewLogger())
+
+	const callers = 10
+	var wg sync.WaitGroup
+	names := make(chan string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tenant, err := repo.GetTenantByID(context.Background(), "tenant-1")
+			if err == nil {
+				names <- tenant.Name
+			}
+		}()
+	}
+
+	require.Eventually(
+		t, func() bool { return next.lookups.Load() == 1 }, time.Second, time.Millisecond,
+	)
+	// let the callers pile up behind the lookup in flight
+	time.Sleep(20 * time.Millisecond)
+	close(next.release)
+	wg.Wait()
+	close(names)
+
+	assert.Equal(t, int64(1), next.lookups.Load())
+	assert.Len(t, names, callers)
+}
+
+func TestTenantRepository_CanceledMiss(t *testing.T) {
+	next := newCountingRepository(newTenant("tenant-1", "acme"))
+	next.release = make(chan struct{})
+	repo := cache.NewTenantRepository(next, utils.NewLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := repo.GetTenantByID(ctx, "tenant-1")
+		first <- err
+	}()
+	require.Eventually(
+		t, func() bool { return next.lookups.Load() == 1 }, time.Second, time.Millisecond,
+	)
+
+	type result struct {
+		tenant *entities.Tenant
+		err    error
+	}
+	second := make(chan result, 1)
+	go func() {
+		tenant, err := repo.GetTenantByID(context.Background(), "tenant-1")
+		second <- result{tenant: tenant, err: err}
+	}()
+	// let the second caller wait for the lookup in flight
+	time.Sleep(20 * time.Millisecond)
+
+	// the caller that started the lookup gives up, the one waiting for it still gets the tenant
+	cancel()
+	assert.True(t, errors.Is(<-first, context.Canceled))
+	close(next.release)
+
+	got := <-second
+	require.NoError(t, got.err)
+	assert.Equal(t, "tenant-1", got.tenant.ID)
+	assert.Equal(t, int64(1), next.lookups.Load())
+}
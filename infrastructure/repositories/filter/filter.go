@@ -0,0 +1,123 @@
+// Package filter matches documents against the equality filters of tenant searches, for
+// repositories that cannot run the filters as mongo queries.
+package filter
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Match reports whether a document matches a filter the way a mongo query would. Filters
+// are equality conditions on fields, dotted paths reach into nested documents and arrays,
+// and an array matches when one of its elements does. Operators are not supported.
+func Match(document bson.M, filter map[string]any) (bool, error) {
+	for key, value := range filter {
+		if strings.HasPrefix(key, "$") {
+			return false, errors.Errorf("unsupported filter operator %s", key)
+		}
+		if _, ok := value.(map[string]any); ok {
+			return false, errors.Errorf("unsupported filter on %s, only equality is supported", key)
+		}
+		if _, ok := value.(bson.M); ok {
+			return false, errors.Errorf("unsupported filter on %s, only equality is supported", key)
+		}
+
+		expected, err := normalize(value)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid filter on %s", key)
+		}
+
+		matched := false
+		for _, candidate := range lookup(document, strings.Split(key, ".")) {
+			if equal(candidate, expected) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// lookup returns the values found at a path of a document, arrays along the way are traversed
+// element by element. A missing field is returned as nil.
+func lookup(value any, path []string) []any {
+	if len(path) == 0 {
+		return []any{value}
+	}
+
+	switch value := value.(type) {
+	case bson.M:
+		field, ok := value[path[0]]
+		if !ok {
+			return []any{nil}
+		}
+		return lookup(field, path[1:])
+	case bson.A:
+		var found []any
+		for _, element := range value {
+			if _, ok := element.(bson.M); ok {
+				found = append(found, lookup(element, path)...)
+			}
+		}
+		return found
+	default:
+		return []any{nil}
+	}
+}
+
+// equal compares a value of a document with the value of a filter, an array is equal to a
+// value it contains.
+func equal(candidate any, expected any) bool {
+	if array, ok := candidate.(bson.A); ok {
+		if _, ok := expected.(bson.A); !ok {
+			for _, element := range array {
+				if equal(element, expected) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	if a, ok := number(candidate); ok {
+		b, ok := number(expected)
+		return ok && a == b
+	}
+
+	return reflect.DeepEqual(candidate, expected)
+}
+
+func number(value any) (float64, bool) {
+	switch value := value.(type) {
+	case int32:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case float64:
+		return value, true
+	default:
+		return 0, false
+	}
+}
+
+// normalize returns a value of a filter as it reads once stored in a document.
+func normalize(value any) (any, error) {
+	raw, err := bson.Marshal(bson.M{"value": value})
+	if err != nil {
+		return nil, err
+	}
+
+	var document bson.M
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	return document["value"], nil
+}
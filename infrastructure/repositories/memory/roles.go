@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RolesRepository keeps roles in memory with the semantics of the mongo repository, unknown
+// roles are reported as not found. It is safe for concurrent use.
+//
+// Roles are stored encoded, every read returns a copy the caller is free to modify.
+type RolesRepository struct {
+	logger utils.LoggerInterface
+
+	mu    sync.RWMutex
+	roles map[string][]byte
+	// order is the order roles were saved in, FindAllRoles returns roles in that order.
+	order []string
+}
+
+func NewRolesRepository(logger utils.LoggerInterface) *RolesRepository {
+	return &RolesRepository{
+		logger: logger,
+		roles:  map[string][]byte{},
+	}
+}
+
+// SaveRole stores a new role, roles with the ID of another role are refused.
+func (r *RolesRepository) SaveRole(ctx context.Context, role *entities.Role) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrCreatingRoleDocument.Wrap(err)
+	}
+
+	raw, err := bson.Marshal(role)
+	if err != nil {
+		return apperrors.ErrCreatingRoleDocument.Wrap(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[role.ID]; ok {
+		r.logger.Errorf(apperrors.ErrCreatingRole, role.ID)
+		return apperrors.ErrCreatingRoleDocument.Wrap(errors.Errorf("duplicate role id %s", role.ID))
+	}
+
+	r.roles[role.ID] = raw
+	r.order = append(r.order, role.ID)
+
+	return nil
+}
+
+// UpdateRole replaces a role.
+func (r *RolesRepository) UpdateRole(ctx context.Context, role *entities.Role) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrUpdatingRoleDocument.Wrap(err)
+	}
+
+	raw, err := bson.Marshal(role)
+	if err != nil {
+		return apperrors.ErrUpdatingRoleDocument.Wrap(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[role.ID]; !ok {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, role.ID)
+		return apperrors.ErrNoRoleDocumentsFound
+	}
+
+	r.roles[role.ID] = raw
+
+	return nil
+}
+
+// DeleteRole removes a role.
+func (r *RolesRepository) DeleteRole(ctx context.Context, roleID utils.XID) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrDeletingRoleDocument.Wrap(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.roles[roleID.ID]; !ok {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+		return apperrors.ErrNoRoleDocumentsFound
+	}
+
+	delete(r.roles, roleID.ID)
+	for i, id := range r.order {
+		if id == roleID.ID {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+// FindRoleByID returns a role.
+func (r *RolesRepository) FindRoleByID(ctx context.Context, roleID utils.XID) (*entities.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	r.mu.RLock()
+	raw, ok := r.roles[roleID.ID]
+	r.mu.RUnlock()
+
+	if !ok {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+		return nil, apperrors.ErrNoRoleDocumentsFound
+	}
+
+	return decodeRole(raw)
+}
+
+// FindAllRoles returns every role.
+func (r *RolesRepository) FindAllRoles(ctx context.Context) ([]*entities.Role, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := make([]*entities.Role, 0, len(r.order))
+	for _, id := range r.order {
+		role, err := decodeRole(r.roles[id])
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+func decodeRole(raw []byte) (*entities.Role, error) {
+	var role entities.Role
+	if err := bson.Unmarshal(raw, &role); err != nil {
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	return &role, nil
+}
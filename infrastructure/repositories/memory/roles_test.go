@@ -0,0 +1,18 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
+)
+
+func TestRolesRepository(t *testing.T) {
+	repositorytest.TestRolesRepository(
+		t, func(t *testing.T) repository.RolesRepository {
+			return memory.NewRolesRepository(utils.NewLogger())
+		},
+	)
+}
@@ -0,0 +1,283 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/filter"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TenantRepository keeps tenants in memory with the semantics of the mongo repository: tenants
+// are soft deleted, only active tenants are listed, subdomains are unique and unknown tenants
+// are reported as not found. It is safe for concurrent use.
+//
+// Tenants are stored encoded, every read returns a copy the caller is free to modify.
+type TenantRepository struct {
+	logger utils.LoggerInterface
+
+	mu      sync.RWMutex
+	tenants map[string][]byte
+	// order is the order tenants were created in, searches return tenants in that order.
+	order []string
+}
+
+func NewTenantRepository(logger utils.LoggerInterface) *TenantRepository {
+	return &TenantRepository{
+		logger:  logger,
+		tenants: map[string][]byte{},
+	}
+}
+
+// CreateTenant stores a new tenant. Tenants with the ID or the subdomain of another tenant
+// are refused.
+func (r *TenantRepository) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	raw, err := bson.Marshal(tenant)
+	if err != nil {
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenant.ID]; ok {
+		r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
+		return apperrors.ErrCreatingTenantDocument.Wrap(errors.Errorf("duplicate tenant id %s", tenant.ID))
+	}
+	if err := r.checkSubdomain(tenant); err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	r.tenants[tenant.ID] = raw
+	r.order = append(r.order, tenant.ID)
+
+	return nil
+}
+
+// InsertTenants creates tenants at once, checking the subdomains of the stored tenants a single
+// time, for seeding large repositories. Nothing is stored when a tenant is refused.
+func (r *TenantRepository) InsertTenants(ctx context.Context, tenants []*entities.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	raws := make([][]byte, len(tenants))
+	for i, tenant := range tenants {
+		raw, err := bson.Marshal(tenant)
+		if err != nil {
+			return apperrors.ErrCreatingTenantDocument.Wrap(err)
+		}
+		raws[i] = raw
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subdomains := make(map[string]bool, len(r.tenants)+len(tenants))
+	for _, raw := range r.tenants {
+		subdomain, _ := bson.Raw(raw).Lookup("subdomain").StringValueOK()
+		subdomains[subdomain] = true
+	}
+	ids := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		if _, ok := r.tenants[tenant.ID]; ok || ids[tenant.ID] {
+			r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
+			return apperrors.ErrCreatingTenantDocument.Wrap(errors.Errorf("duplicate tenant id %s", tenant.ID))
+		}
+		if subdomains[tenant.Subdomain] {
+			r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
+			return apperrors.ErrCreatingTenantDocument.Wrap(errors.Errorf("duplicate subdomain %s", tenant.Subdomain))
+		}
+		ids[tenant.ID] = true
+		subdomains[tenant.Subdomain] = true
+	}
+
+	for i, tenant := range tenants {
+		r.tenants[tenant.ID] = raws[i]
+		r.order = append(r.order, tenant.ID)
+	}
+
+	return nil
+}
+
+// DeleteTenant soft deletes a tenant, isActive is set to false.
+func (r *TenantRepository) DeleteTenant(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrDeletingTenantDocument.Wrap(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw, ok := r.tenants[id]
+	if !ok {
+		r.logger.Errorf(apperrors.ErrNoTenantFound, id)
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+
+	tenant, err := decode(raw)
+	if err != nil {
+		return err
+	}
+	tenant.IsActive = false
+
+	raw, err = bson.Marshal(tenant)
+	if err != nil {
+		return apperrors.ErrDeletingTenantDocument.Wrap(err)
+	}
+	r.tenants[id] = raw
+
+	return nil
+}
+
+// GetTenantByID returns a tenant, deleted tenants included.
+func (r *TenantRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	r.mu.RLock()
+	raw, ok := r.tenants[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		r.logger.Errorf(apperrors.ErrNoTenantFound, id)
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return decode(raw)
+}
+
+// GetTenants returns the active tenants.
+func (r *TenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
+	return r.SearchTenants(ctx, map[string]any{"is_active": true})
+}
+
+// UpdateTenant replaces a tenant. Like an update matching no document, updating an unknown
+// tenant does nothing.
+func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	if err := ctx.Err(); err != nil {
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	raw, err := bson.Marshal(tenant)
+	if err != nil {
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenant.ID]; !ok {
+		return nil
+	}
+	if err := r.checkSubdomain(tenant); err != nil {
+		r.logger.Errorf(apperrors.ErrUpdatingTenant, tenant.ID)
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	r.tenants[tenant.ID] = raw
+
+	return nil
+}
+
+// SearchTenant returns the first tenant matching a filter, see SearchTenants.
+func (r *TenantRepository) SearchTenant(ctx context.Context, filter map[string]any) (*entities.Tenant, error) {
+	tenants, err := r.search(ctx, filter, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenants) == 0 {
+		r.logger.With(filter).Info(apperrors.ErrNoTenantFound)
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return tenants[0], nil
+}
+
+// SearchTenants returns the tenants matching a filter. Filters are equality conditions on the
+// fields of tenant documents, such as {"subdomain": "acme"} or {"domains.hostname": "acme.com"}.
+func (r *TenantRepository) SearchTenants(ctx context.Context, filter map[string]any) (
+	[]*entities.Tenant, error,
+) {
+	return r.search(ctx, filter, 0)
+}
+
+// search returns up to limit tenants matching a filter query, all of them when limit is 0.
+func (r *TenantRepository) search(ctx context.Context, query map[string]any, limit int) (
+	[]*entities.Tenant, error,
+) {
+	if err := ctx.Err(); err != nil {
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenants := make([]*entities.Tenant, 0)
+	for _, id := range r.order {
+		raw := r.tenants[id]
+
+		var document bson.M
+		if err := bson.Unmarshal(raw, &document); err != nil {
+			return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+		}
+
+		ok, err := filter.Match(document, query)
+		if err != nil {
+			r.logger.With(query).Error(err)
+			return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+		}
+		if !ok {
+			continue
+		}
+
+		tenant, err := decode(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		tenants = append(tenants, tenant)
+		if len(tenants) == limit {
+			break
+		}
+	}
+
+	return tenants, nil
+}
+
+// checkSubdomain fails when another tenant has the subdomain of tenant. It must be called
+// with the lock held.
+func (r *TenantRepository) checkSubdomain(tenant *entities.Tenant) error {
+	for id, raw := range r.tenants {
+		if id == tenant.ID {
+			continue
+		}
+
+		subdomain, _ := bson.Raw(raw).Lookup("subdomain").StringValueOK()
+		if subdomain == tenant.Subdomain {
+			return errors.Errorf("duplicate subdomain %s", tenant.Subdomain)
+		}
+	}
+
+	return nil
+}
+
+func decode(raw []byte) (*entities.Tenant, error) {
+	var tenant entities.Tenant
+	if err := bson.Unmarshal(raw, &tenant); err != nil {
+		return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+	}
+
+	return &tenant, nil
+}
@@ -0,0 +1,115 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestTenantRepository(t *testing.T) {
+	repositorytest.TestTenantRepository(
+		t, func(t *testing.T) repository.TenantRepository {
+			return memory.NewTenantRepository(utils.NewLogger())
+		},
+	)
+}
+
+func BenchmarkTenantRepository(b *testing.B) {
+	repositorytest.BenchmarkTenantRepository(
+		b, func(b *testing.B) repository.TenantRepository {
+			return memory.NewTenantRepository(quietLogger())
+		},
+	)
+}
+
+// quietLogger only logs errors, benchmarks would otherwise mostly measure logging.
+func quietLogger() utils.LoggerInterface {
+	return utils.NewLogger(
+		utils.Config{
+			Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"},
+		},
+	)
+}
+
+func TestTenantRepository_SearchTenants(t *testing.T) {
+	repo := memory.NewTenantRepository(utils.NewLogger())
+	ctx := context.Background()
+	tenant := tests.CreateTenant()
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+
+	var testCases = []struct {
+		Name          string
+		Filter        map[string]any
+		ExpectedCount int
+		ExpectedError error
+	}{
+		{
+			Name:          "Happy Path: Nested documents",
+			Filter:        map[string]any{"tenant_metadata._id": tenant.TenantMetadata.ID},
+			ExpectedCount: 1,
+		},
+		{
+			Name:          "Happy Path: Unknown fields match nothing",
+			Filter:        map[string]any{"unknown": "value"},
+			ExpectedCount: 0,
+		},
+		{
+			Name:          "Error Path: Operators are not supported",
+			Filter:        map[string]any{"subdomain": bson.M{"$ne": "acme"}},
+			ExpectedError: apperrors.ErrRetrievingTenantDocument,
+		},
+		{
+			Name:          "Error Path: Logical operators are not supported",
+			Filter:        map[string]any{"$or": bson.A{bson.M{"subdomain": "acme"}}},
+			ExpectedError: apperrors.ErrRetrievingTenantDocument,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				found, err := repo.SearchTenants(ctx, tt.Filter)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Len(t, found, tt.ExpectedCount)
+			},
+		)
+	}
+}
+
+func TestTenantRepository_InsertTenants(t *testing.T) {
+	repo := memory.NewTenantRepository(utils.NewLogger())
+	ctx := context.Background()
+	tenants := tests.NewGenerator(tests.WithSeed(1)).Tenants(5)
+	require.NoError(t, repo.InsertTenants(ctx, tenants))
+
+	for _, tenant := range tenants {
+		found, err := repo.GetTenantByID(ctx, tenant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tenant.Subdomain, found.Subdomain)
+	}
+
+	// a duplicate subdomain refuses the whole batch
+	duplicate := tests.CreateTenant()
+	duplicate.Subdomain = tenants[0].Subdomain
+	fresh := tests.CreateTenant()
+	err := repo.InsertTenants(ctx, []*entities.Tenant{fresh, duplicate})
+	assert.True(t, errors.Is(err, apperrors.ErrCreatingTenantDocument), "unexpected error: %v", err)
+
+	_, err = repo.GetTenantByID(ctx, fresh.ID)
+	assert.True(t, errors.Is(err, apperrors.ErrNoTenantDocumentsFound), "unexpected error: %v", err)
+}
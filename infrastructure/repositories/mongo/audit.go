@@ -0,0 +1,169 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// appendAttempts is how many times a change is retried when another change took its place in the audit chain.
+const appendAttempts = 5
+
+// verifyBatchSize is how many audit records are verified at once.
+const verifyBatchSize = 500
+
+// AuditRepository is the append-only audit trail of the changes made to tenants and roles.
+type AuditRepository struct {
+	db     *mongo.Collection
+	logger utils.LoggerInterface
+}
+
+func NewAuditRepository(db *mongo.Collection, logger utils.LoggerInterface) *AuditRepository {
+	return &AuditRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateIndexes creates the indexes of the audit trail. The unique sequence keeps the chain linear.
+func (r *AuditRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateMany(
+		ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "sequence", Value: 1}},
+				Options: options.Index().SetName("sequence").SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "sequence", Value: 1}},
+				Options: options.Index().SetName("tenant_id_sequence"),
+			},
+			{
+				Keys:    bson.D{{Key: "actor", Value: 1}, {Key: "sequence", Value: 1}},
+				Options: options.Index().SetName("actor_sequence"),
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create audit indexes")
+	}
+
+	return nil
+}
+
+// QueryAuditRecords returns the records matching filter in sequence order.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *AuditRepository) QueryAuditRecords(ctx context.Context, filter entities.AuditFilter) (
+	[]*entities.AuditRecord, error,
+) {
+	query := bson.M{}
+	if filter.TenantID != "" {
+		query["tenant_id"] = filter.TenantID
+	}
+	if filter.Actor != "" {
+		query["actor"] = filter.Actor
+	}
+	if filter.AfterSequence > 0 {
+		query["sequence"] = bson.M{"$gt": filter.AfterSequence}
+	}
+
+	timestamp := bson.M{}
+	if !filter.From.IsZero() {
+		timestamp["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		timestamp["$lt"] = filter.To
+	}
+	if len(timestamp) > 0 {
+		query["timestamp"] = timestamp
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	r.logger.Infof("retrieving audit records from database with filter: %v", query)
+	cursor, err := r.db.Find(ctx, query, opts)
+	if err != nil {
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingAuditRecords.Wrap(err)
+	}
+
+	defer cursor.Close(ctx)
+
+	var records []*entities.AuditRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingAuditRecords.Wrap(err)
+	}
+
+	return records, nil
+}
+
+// VerifyAuditChain checks the whole audit trail, it returns apperrors.ErrAuditChainBroken
+// at the first record that was altered, removed or inserted.
+func (r *AuditRepository) VerifyAuditChain(ctx context.Context) error {
+	var previous *entities.AuditRecord
+	for {
+		var after int64
+		if previous != nil {
+			after = previous.Sequence
+		}
+
+		cursor, err := r.db.Find(
+			ctx, bson.M{"sequence": bson.M{"$gt": after}},
+			options.Find().SetSort(bson.D{{Key: "sequence", Value: 1}}).SetLimit(verifyBatchSize),
+		)
+		if err != nil {
+			return apperrors.ErrRetrievingAuditRecords.Wrap(err)
+		}
+
+		var records []*entities.AuditRecord
+		if err := cursor.All(ctx, &records); err != nil {
+			return apperrors.ErrRetrievingAuditRecords.Wrap(err)
+		}
+
+		if len(records) == 0 {
+			return nil
+		}
+
+		if err := audit.Verify(records, previous); err != nil {
+			r.logger.Error(err)
+			return err
+		}
+
+		previous = records[len(records)-1]
+	}
+}
+
+// append seals record after the last record of the trail and inserts it.
+// It must run in the transaction of the change the record describes.
+// Nothing is appended without a trail or without a record.
+func (r *AuditRepository) append(ctx context.Context, record *entities.AuditRecord) error {
+	if r == nil || record == nil {
+		return nil
+	}
+
+	var last *entities.AuditRecord
+	err := r.db.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "sequence", Value: -1}})).Decode(&last)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return apperrors.ErrCreatingAuditRecord.Wrap(err)
+	}
+
+	if err := audit.Seal(record, last); err != nil {
+		return apperrors.ErrCreatingAuditRecord.Wrap(err)
+	}
+
+	if _, err := r.db.InsertOne(ctx, record); err != nil {
+		return apperrors.ErrCreatingAuditRecord.Wrap(err)
+	}
+
+	return nil
+}
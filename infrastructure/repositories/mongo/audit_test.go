@@ -0,0 +1,68 @@
+package mongo_test
+
+import (
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAuditRepository(t *testing.T) {
+	collection := storage.DB.Database().Collection("audit")
+	defer func() {
+		if err := collection.Drop(ctx); err != nil {
+			logger.Error(err)
+		}
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	trail := mongo.NewAuditRepository(collection, logger)
+	require.NoError(t, trail.CreateIndexes(ctx))
+	repo := mongo.NewTenantRepository(storage.DB, logger, mongo.WithAuditTrail(trail))
+
+	actorCtx := audit.WithRequestID(audit.WithActor(ctx, audit.Actor{ID: "contact-1"}), "request-1")
+
+	tenant := tests.CreateTenant()
+	require.NoError(t, repo.CreateTenant(actorCtx, tenant))
+
+	name := tenant.Name
+	tenant.Name = "Renamed"
+	require.NoError(t, repo.UpdateTenant(actorCtx, tenant))
+	require.NoError(t, repo.DeleteTenant(ctx, tenant.ID))
+
+	records, err := trail.QueryAuditRecords(ctx, entities.AuditFilter{TenantID: tenant.ID})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, entities.AuditCreate, records[0].Operation)
+	assert.Equal(t, entities.AuditUpdate, records[1].Operation)
+	assert.Equal(t, "contact-1", records[1].Actor)
+	assert.Equal(t, "request-1", records[1].RequestID)
+	assert.Contains(
+		t, records[1].Changes,
+		entities.AuditChange{Field: "name", Before: []byte(`"` + name + `"`), After: []byte(`"Renamed"`)},
+	)
+	assert.Equal(t, entities.AuditDelete, records[2].Operation)
+	assert.Empty(t, records[2].Actor)
+
+	byActor, err := trail.QueryAuditRecords(ctx, entities.AuditFilter{Actor: "contact-1"})
+	require.NoError(t, err)
+	assert.Len(t, byActor, 2)
+
+	require.NoError(t, trail.VerifyAuditChain(ctx))
+
+	_, err = collection.UpdateOne(ctx, bson.M{"sequence": 2}, bson.M{"$set": bson.M{"actor": "someone-else"}})
+	require.NoError(t, err)
+
+	err = trail.VerifyAuditChain(ctx)
+	assert.True(t, errors.Is(err, apperrors.ErrAuditChainBroken), "unexpected error: %v", err)
+}
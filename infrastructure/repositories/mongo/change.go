@@ -0,0 +1,133 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// change describes a mutation made by a repository: the record of the audit trail and the
+// domain events it raises. Either is empty when it is not tracked.
+type change struct {
+	record *entities.AuditRecord
+	events []*entities.Event
+}
+
+// mutate runs a mutation together with appending its record to the audit trail and writing its
+// events to the outbox, in a single transaction when the deployment supports them. Appending is
+// retried when a concurrent change took its place in the chain. Untracked mutations run as is.
+func mutate(
+	ctx context.Context,
+	s settings,
+	collection *mongo.Collection,
+	mutation func(ctx context.Context) (*change, error),
+) error {
+	if !s.tracked() {
+		_, err := mutation(ctx)
+		return err
+	}
+
+	transactional, err := supportsTransactions(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	if !transactional {
+		// the mutation cannot be undone, only tracking it is retried. Events are written first,
+		// an event without record is better than a record without event
+		c, err := mutation(ctx)
+		if err != nil || c == nil {
+			return err
+		}
+
+		if err := s.outbox.enqueue(ctx, c.events); err != nil {
+			return err
+		}
+
+		return retryAppend(
+			s.audit, func() error {
+				return s.audit.append(ctx, c.record)
+			},
+		)
+	}
+
+	return retryAppend(
+		s.audit, func() error {
+			return withTransaction(
+				ctx, collection, func(ctx context.Context) error {
+					c, err := mutation(ctx)
+					if err != nil || c == nil {
+						return err
+					}
+
+					if err := s.outbox.enqueue(ctx, c.events); err != nil {
+						return err
+					}
+
+					return s.audit.append(ctx, c.record)
+				},
+			)
+		},
+	)
+}
+
+func retryAppend(trail *AuditRepository, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < appendAttempts; attempt++ {
+		err = fn()
+		if !errors.Is(err, apperrors.ErrCreatingAuditRecord) || !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		trail.logger.Info("audit chain moved on, retrying")
+	}
+
+	return err
+}
+
+type Option func(*settings)
+
+type settings struct {
+	audit      *AuditRepository
+	outbox     *OutboxRepository
+	migrations *migrations.Registry
+}
+
+// WithAuditTrail records every change made by a repository in the audit trail.
+func WithAuditTrail(trail *AuditRepository) Option {
+	return func(s *settings) {
+		s.audit = trail
+	}
+}
+
+// WithOutbox writes the domain events raised by the changes of a repository to the outbox.
+func WithOutbox(outbox *OutboxRepository) Option {
+	return func(s *settings) {
+		s.outbox = outbox
+	}
+}
+
+// WithMigrations upgrades the tenant documents of older schema versions with the migrations of
+// registry instead of migrations.Tenants. It only applies to tenant repositories.
+func WithMigrations(registry *migrations.Registry) Option {
+	return func(s *settings) {
+		s.migrations = registry
+	}
+}
+
+func newSettings(opts []Option) settings {
+	var s settings
+	for _, opt := range opts {
+		opt(&s)
+	}
+	return s
+}
+
+// tracked reports whether changes are audited or raise events, which needs their previous state.
+func (s settings) tracked() bool {
+	return s.audit != nil || s.outbox != nil
+}
@@ -0,0 +1,424 @@
+package mongo
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	defaultPollInterval = 10 * time.Second
+	defaultRetryDelay   = 5 * time.Second
+
+	// codeChangeStreamNotSupported is returned when change streams are opened on a standalone server.
+	codeChangeStreamNotSupported = 40573
+	// codeChangeStreamHistoryLost is returned when a resume token fell out of the oplog.
+	codeChangeStreamHistoryLost = 286
+)
+
+// errStreamInvalidated ends a change stream whose collection was dropped or renamed.
+var errStreamInvalidated = errors.New("change stream invalidated")
+
+// Operation is the kind of a change to a document.
+type Operation string
+
+const (
+	OperationInsert  Operation = "insert"
+	OperationUpdate  Operation = "update"
+	OperationReplace Operation = "replace"
+	OperationDelete  Operation = "delete"
+	// OperationResync follows the replay of every document of a collection, as insert or replace
+	// changes, when changes may have been missed. Handlers drop the state they derived before Change.Time.
+	OperationResync Operation = "resync"
+)
+
+// Change is a change to a document of a watched collection.
+type Change struct {
+	Collection string
+	Operation  Operation
+	// ID is the _id of the changed document, it is empty for resyncs.
+	ID string
+	// Document is the document after the change, it is nil for deletes and for documents
+	// removed before the change was seen.
+	Document bson.Raw
+	// Time is when the change was seen, for resyncs when the replay started.
+	Time time.Time
+}
+
+// Decode decodes the document after the change into v.
+func (c Change) Decode(v any) error {
+	if c.Document == nil {
+		return errors.Errorf("change %s of %s has no document", c.Operation, c.ID)
+	}
+
+	return errors.Wrapf(bson.Unmarshal(c.Document, v), "failed to decode document %s", c.ID)
+}
+
+// Handler reacts to the changes of a collection. A change is handled again when a handler
+// fails, so handlers see changes at least once.
+type Handler func(ctx context.Context, change Change) error
+
+// Invalidator is an in-process cache of the documents of a collection.
+type Invalidator interface {
+	// Invalidate drops the cached copies of a document.
+	Invalidate(id string)
+	// InvalidateAll drops every cached document.
+	InvalidateAll()
+}
+
+// Invalidate returns a handler dropping changed documents from cache.
+func Invalidate(cache Invalidator) Handler {
+	return func(_ context.Context, change Change) error {
+		if change.Operation == OperationResync {
+			cache.InvalidateAll()
+			return nil
+		}
+
+		cache.Invalidate(change.ID)
+		return nil
+	}
+}
+
+type WatcherOption func(*Watcher)
+
+// WithPollInterval sets how often collections are scanned when change streams are unavailable.
+func WithPollInterval(interval time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.pollInterval = interval
+	}
+}
+
+// WithRetryDelay sets how long the watcher waits before reopening a failed change stream.
+func WithRetryDelay(delay time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.retryDelay = delay
+	}
+}
+
+type watchedCollection struct {
+	collection *mongo.Collection
+	handlers   []Handler
+}
+
+// Watcher follows the changes made to collections, by this service or directly in the
+// database, and hands them to handlers. It follows change streams, which require a replica
+// set, and persists their resume tokens so it continues where it stopped after a restart.
+// Standalone servers have no change streams, their collections are scanned every poll
+// interval instead and changes are told apart by the checksum of the documents.
+type Watcher struct {
+	tokens       *ResumeTokenRepository
+	logger       utils.LoggerInterface
+	watches      []watchedCollection
+	pollInterval time.Duration
+	retryDelay   time.Duration
+	now          func() time.Time
+}
+
+func NewWatcher(logger utils.LoggerInterface, tokens *ResumeTokenRepository, opts ...WatcherOption) *Watcher {
+	w := &Watcher{
+		tokens:       tokens,
+		logger:       logger,
+		pollInterval: defaultPollInterval,
+		retryDelay:   defaultRetryDelay,
+		now:          time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Watch hands the changes of collection to handlers, in order. It must be called before Start.
+func (w *Watcher) Watch(collection *mongo.Collection, handlers ...Handler) {
+	w.watches = append(w.watches, watchedCollection{collection: collection, handlers: handlers})
+}
+
+// Start follows the watched collections until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, watch := range w.watches {
+		wg.Add(1)
+		go func(watch watchedCollection) {
+			defer wg.Done()
+			w.run(ctx, watch)
+		}(watch)
+	}
+
+	wg.Wait()
+}
+
+func (w *Watcher) run(ctx context.Context, watch watchedCollection) {
+	name := watch.collection.Name()
+	for {
+		err := w.follow(ctx, watch)
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case hasErrorCode(err, codeChangeStreamNotSupported):
+			w.logger.Infof("change streams are unavailable, polling %s every %s", name, w.pollInterval)
+			w.poll(ctx, watch)
+			return
+		case hasErrorCode(err, codeChangeStreamHistoryLost), errors.Is(err, errStreamInvalidated):
+			// the stream cannot be resumed, start over with a resync
+			w.logger.Infof("change stream of %s cannot be resumed: %v", name, err)
+			if err := w.tokens.Delete(ctx, name); err != nil {
+				w.logger.Error(err)
+			}
+			continue
+		case err != nil:
+			w.logger.Error(errors.Wrapf(err, "change stream of %s failed", name))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.retryDelay):
+		}
+	}
+}
+
+// changeEvent is the part of change stream events handed to handlers.
+type changeEvent struct {
+	OperationType string   `bson:"operationType"`
+	DocumentKey   bson.Raw `bson:"documentKey"`
+	FullDocument  bson.Raw `bson:"fullDocument"`
+}
+
+// follow hands the events of the change stream of a collection to its handlers, from the
+// persisted resume token. Without a token the collection is resynced once the stream is open,
+// so no change made meanwhile is missed.
+func (w *Watcher) follow(ctx context.Context, watch watchedCollection) error {
+	name := watch.collection.Name()
+
+	token, err := w.tokens.Find(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if token != nil {
+		opts.SetResumeAfter(token)
+	}
+
+	stream, err := watch.collection.Watch(ctx, mongo.Pipeline{}, opts)
+	if err != nil {
+		return errors.Wrap(err, "failed to open change stream")
+	}
+	defer stream.Close(context.Background())
+
+	if token == nil {
+		if err := w.resync(ctx, watch); err != nil {
+			return err
+		}
+		if err := w.tokens.Save(ctx, name, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+
+	w.logger.Infof("following the change stream of %s", name)
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			return errors.Wrap(err, "failed to decode change event")
+		}
+
+		operation := Operation(event.OperationType)
+		switch operation {
+		case OperationInsert, OperationUpdate, OperationReplace, OperationDelete:
+			change := Change{
+				Collection: name,
+				Operation:  operation,
+				ID:         rawDocumentID(event.DocumentKey),
+				Document:   event.FullDocument,
+				Time:       w.now(),
+			}
+			if err := w.handle(ctx, watch, change); err != nil {
+				return err
+			}
+		case "invalidate":
+			return errStreamInvalidated
+		}
+
+		if err := w.tokens.Save(ctx, name, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+
+	return stream.Err()
+}
+
+// resync replays every document of a collection as a replace change, then tells handlers
+// the replay is over.
+func (w *Watcher) resync(ctx context.Context, watch watchedCollection) error {
+	name := watch.collection.Name()
+	started := w.now()
+	w.logger.Infof("resyncing %s", name)
+
+	cursor, err := watch.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to read %s", name)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		change := Change{
+			Collection: name,
+			Operation:  OperationReplace,
+			ID:         rawDocumentID(cursor.Current),
+			Document:   append(bson.Raw(nil), cursor.Current...),
+			Time:       w.now(),
+		}
+		if err := w.handle(ctx, watch, change); err != nil {
+			return err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read %s", name)
+	}
+
+	return w.handle(ctx, watch, Change{Collection: name, Operation: OperationResync, Time: started})
+}
+
+// poll scans a collection every poll interval and hands the differences with the previous
+// scan to the handlers. The first scan replays every document and ends with a resync, changes
+// made while the service was stopped are not known otherwise.
+func (w *Watcher) poll(ctx context.Context, watch watchedCollection) {
+	name := watch.collection.Name()
+	var checksums map[string][sha256.Size]byte
+
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if checksums == nil {
+			started := w.now()
+			scanned, err := w.scan(ctx, watch, map[string][sha256.Size]byte{})
+			if err == nil {
+				err = w.handle(ctx, watch, Change{Collection: name, Operation: OperationResync, Time: started})
+			}
+			if err != nil {
+				w.logger.Error(errors.Wrapf(err, "failed to resync %s", name))
+			} else {
+				checksums = scanned
+			}
+		} else {
+			scanned, err := w.scan(ctx, watch, checksums)
+			if err != nil {
+				w.logger.Error(errors.Wrapf(err, "failed to poll %s", name))
+			}
+			checksums = scanned
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan reads the checksums of the documents of a collection and hands the changes since
+// previous to the handlers. Without previous nothing is handled. Changes failing to be
+// handled keep their previous checksum, so they are handled again by the next scan.
+func (w *Watcher) scan(ctx context.Context, watch watchedCollection, previous map[string][sha256.Size]byte) (
+	map[string][sha256.Size]byte, error,
+) {
+	name := watch.collection.Name()
+	checksums := make(map[string][sha256.Size]byte, len(previous))
+
+	cursor, err := watch.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return previous, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		id := rawDocumentID(cursor.Current)
+		checksum := sha256.Sum256(cursor.Current)
+		checksums[id] = checksum
+
+		before, existed := previous[id]
+		if existed && before == checksum {
+			continue
+		}
+
+		change := Change{
+			Collection: name,
+			Operation:  OperationUpdate,
+			ID:         id,
+			Document:   append(bson.Raw(nil), cursor.Current...),
+			Time:       w.now(),
+		}
+		if !existed {
+			change.Operation = OperationInsert
+		}
+
+		if err := w.handle(ctx, watch, change); err != nil {
+			w.logger.Error(err)
+			if existed {
+				checksums[id] = before
+			} else {
+				delete(checksums, id)
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return previous, err
+	}
+
+	for id, checksum := range previous {
+		if _, ok := checksums[id]; ok {
+			continue
+		}
+
+		change := Change{Collection: name, Operation: OperationDelete, ID: id, Time: w.now()}
+		if err := w.handle(ctx, watch, change); err != nil {
+			w.logger.Error(err)
+			checksums[id] = checksum
+		}
+	}
+
+	return checksums, nil
+}
+
+func (w *Watcher) handle(ctx context.Context, watch watchedCollection, change Change) error {
+	for _, handler := range watch.handlers {
+		if err := handler(ctx, change); err != nil {
+			return errors.Wrapf(err, "failed to handle %s of %s %s", change.Operation, change.Collection, change.ID)
+		}
+	}
+
+	return nil
+}
+
+func hasErrorCode(err error, code int) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(code)
+}
+
+func rawDocumentID(document bson.Raw) string {
+	value, err := document.LookupErr("_id")
+	if err != nil {
+		return ""
+	}
+
+	if id, ok := value.StringValueOK(); ok {
+		return id
+	}
+	if id, ok := value.ObjectIDOK(); ok {
+		return id.Hex()
+	}
+
+	return value.String()
+}
@@ -0,0 +1,134 @@
+package mongo_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// recordingCache records the invalidations it receives.
+type recordingCache struct {
+	mu          sync.Mutex
+	invalidated []string
+	cleared     int
+}
+
+func (c *recordingCache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidated = append(c.invalidated, id)
+}
+
+func (c *recordingCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleared++
+}
+
+func (c *recordingCache) snapshot() ([]string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.invalidated...), c.cleared
+}
+
+// TestWatcher runs against a standalone server, which has no change streams, so the
+// watcher falls back to polling.
+func TestWatcher(t *testing.T) {
+	db := storage.DB.Database()
+	roles := db.Collection("rbac")
+	directoryCollection := db.Collection("tenant_directory")
+	defer func() {
+		for _, name := range []string{"rbac", "tenant_directory", "resume_tokens"} {
+			if err := db.Collection(name).Drop(ctx); err != nil {
+				logger.Error(err)
+			}
+		}
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	existing := tests.CreateTenant()
+	_, err := storage.DB.InsertOne(ctx, existing)
+	require.NoError(t, err)
+
+	directory := mongo.NewTenantDirectoryRepository(directoryCollection, roles, logger)
+	require.NoError(t, directory.CreateIndexes(ctx))
+
+	// a leftover of a tenant removed while the service was stopped
+	_, err = directoryCollection.InsertOne(
+		ctx, entities.TenantDirectoryEntry{ID: "removed", Name: "Removed", ProjectedAt: time.Now().Add(-time.Hour)},
+	)
+	require.NoError(t, err)
+
+	cache := &recordingCache{}
+	watcher := mongo.NewWatcher(
+		logger, mongo.NewResumeTokenRepository(db.Collection("resume_tokens")),
+		mongo.WithPollInterval(50*time.Millisecond),
+	)
+	watcher.Watch(storage.DB, directory.HandleTenant, mongo.Invalidate(cache))
+	watcher.Watch(roles, directory.HandleRole)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		watcher.Start(watchCtx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// the first scan resyncs the directory
+	require.Eventually(
+		t, func() bool {
+			_, err := directory.Find(ctx, existing.ID)
+			return err == nil
+		}, 5*time.Second, 20*time.Millisecond,
+	)
+	_, err = directory.Find(ctx, "removed")
+	assert.ErrorIs(t, err, apperrors.ErrNoTenantDocumentsFound)
+
+	// changes made directly in the database are picked up
+	_, err = storage.DB.UpdateByID(ctx, existing.ID, bson.M{"$set": bson.M{"name": "Renamed"}})
+	require.NoError(t, err)
+	_, err = roles.InsertOne(ctx, entities.Role{ID: "role-1", TenantID: existing.ID, Name: "auditor"})
+	require.NoError(t, err)
+
+	require.Eventually(
+		t, func() bool {
+			entry, err := directory.Find(ctx, existing.ID)
+			return err == nil && entry.Name == "Renamed" && len(entry.CustomRoles) == 1
+		}, 5*time.Second, 20*time.Millisecond,
+	)
+
+	entry, err := directory.FindByHostname(ctx, existing.Subdomain)
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, entry.ID)
+
+	_, err = roles.DeleteOne(ctx, bson.M{"_id": "role-1"})
+	require.NoError(t, err)
+	_, err = storage.DB.DeleteOne(ctx, bson.M{"_id": existing.ID})
+	require.NoError(t, err)
+
+	require.Eventually(
+		t, func() bool {
+			_, err := directory.Find(ctx, existing.ID)
+			return err != nil
+		}, 5*time.Second, 20*time.Millisecond,
+	)
+
+	invalidated, cleared := cache.snapshot()
+	assert.Equal(t, 1, cleared)
+	assert.Contains(t, invalidated, existing.ID)
+}
@@ -0,0 +1,245 @@
+package mongo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TenantDirectoryRepository keeps the tenant directory, the read model summarizing tenants.
+// It is fed by a Watcher with the changes to the tenants and roles collections.
+type TenantDirectoryRepository struct {
+	directory *mongo.Collection
+	roles     *mongo.Collection
+	logger    utils.LoggerInterface
+}
+
+// NewTenantDirectoryRepository returns the directory kept in the directory collection. Roles is the
+// collection of roles, read to rebuild the custom roles of tenants.
+func NewTenantDirectoryRepository(
+	directory *mongo.Collection, roles *mongo.Collection, logger utils.LoggerInterface,
+) *TenantDirectoryRepository {
+	return &TenantDirectoryRepository{
+		directory: directory,
+		roles:     roles,
+		logger:    logger,
+	}
+}
+
+// CreateIndexes creates the indexes looking tenants up by subdomain and custom domain.
+func (r *TenantDirectoryRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.directory.Indexes().CreateMany(
+		ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "subdomain", Value: 1}},
+				Options: options.Index().SetName("subdomain"),
+			},
+			{
+				Keys:    bson.D{{Key: "hostnames", Value: 1}},
+				Options: options.Index().SetName("hostnames"),
+			},
+			{
+				Keys:    bson.D{{Key: "custom_roles", Value: 1}},
+				Options: options.Index().SetName("custom_roles"),
+			},
+		},
+	)
+
+	return errors.Wrap(err, "failed to create tenant directory indexes")
+}
+
+// Find returns the directory entry of a tenant.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *TenantDirectoryRepository) Find(ctx context.Context, tenantID string) (
+	*entities.TenantDirectoryEntry, error,
+) {
+	return r.findOne(ctx, bson.M{"_id": tenantID})
+}
+
+// FindByHostname returns the directory entry of the tenant serving a subdomain or a verified custom domain.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *TenantDirectoryRepository) FindByHostname(ctx context.Context, hostname string) (
+	*entities.TenantDirectoryEntry, error,
+) {
+	hostname = strings.ToLower(hostname)
+	return r.findOne(ctx, bson.M{"$or": bson.A{bson.M{"subdomain": hostname}, bson.M{"hostnames": hostname}}})
+}
+
+func (r *TenantDirectoryRepository) findOne(ctx context.Context, filter bson.M) (
+	*entities.TenantDirectoryEntry, error,
+) {
+	// entries created by roles before their tenant was projected are not listed
+	filter["projected_at"] = bson.M{"$exists": true}
+
+	var entry *entities.TenantDirectoryEntry
+	err := r.directory.FindOne(ctx, filter).Decode(&entry)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	case err != nil:
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	return entry, nil
+}
+
+// HandleTenant projects a change to the tenants collection into the directory.
+func (r *TenantDirectoryRepository) HandleTenant(ctx context.Context, change Change) error {
+	switch {
+	case change.Operation == OperationResync:
+		// entries not replayed belong to tenants removed while changes were missed
+		_, err := r.directory.DeleteMany(
+			ctx, bson.M{
+				"$or": bson.A{
+					bson.M{"projected_at": bson.M{"$lt": change.Time}},
+					bson.M{"projected_at": bson.M{"$exists": false}},
+				},
+			},
+		)
+		return errors.Wrap(err, "failed to prune tenant directory")
+	case change.Document == nil:
+		_, err := r.directory.DeleteOne(ctx, bson.M{"_id": change.ID})
+		return errors.Wrapf(err, "failed to remove tenant %s from directory", change.ID)
+	}
+
+	var tenant entities.Tenant
+	if err := change.Decode(&tenant); err != nil {
+		return err
+	}
+
+	entry := directoryEntry(&tenant)
+	entry.ProjectedAt = change.Time.UTC()
+
+	// custom roles are projected from the roles collection
+	_, err := r.directory.UpdateOne(
+		ctx, bson.M{"_id": tenant.ID}, bson.M{
+			"$set": bson.M{
+				"name":         entry.Name,
+				"subdomain":    entry.Subdomain,
+				"is_active":    entry.IsActive,
+				"hostnames":    entry.Hostnames,
+				"plans":        entry.Plans,
+				"contacts":     entry.Contacts,
+				"updated_at":   entry.UpdatedAt,
+				"projected_at": entry.ProjectedAt,
+			},
+		}, options.Update().SetUpsert(true),
+	)
+
+	return errors.Wrapf(err, "failed to project tenant %s into directory", tenant.ID)
+}
+
+// HandleRole projects a change to the roles collection into the custom roles of the directory.
+func (r *TenantDirectoryRepository) HandleRole(ctx context.Context, change Change) error {
+	if change.Operation == OperationResync {
+		return r.rebuildCustomRoles(ctx)
+	}
+
+	// the tenant of removed roles is unknown, so the role is removed from every entry
+	_, err := r.directory.UpdateMany(
+		ctx, bson.M{"custom_roles": change.ID}, bson.M{"$pull": bson.M{"custom_roles": change.ID}},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove role %s from directory", change.ID)
+	}
+
+	if change.Document == nil {
+		return nil
+	}
+
+	var role entities.Role
+	if err := change.Decode(&role); err != nil {
+		return err
+	}
+
+	if !role.IsCustom() {
+		return nil
+	}
+
+	_, err = r.directory.UpdateOne(
+		ctx, bson.M{"_id": role.TenantID}, bson.M{"$addToSet": bson.M{"custom_roles": role.ID}},
+		options.Update().SetUpsert(true),
+	)
+
+	return errors.Wrapf(err, "failed to add role %s to directory", role.ID)
+}
+
+// rebuildCustomRoles replaces the custom roles of every entry with the ones in the roles collection.
+func (r *TenantDirectoryRepository) rebuildCustomRoles(ctx context.Context) error {
+	cursor, err := r.roles.Aggregate(
+		ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"tenant_id": bson.M{"$exists": true, "$ne": ""}}}},
+			{{Key: "$group", Value: bson.M{"_id": "$tenant_id", "roles": bson.M{"$push": "$_id"}}}},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read custom roles")
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		TenantID string   `bson:"_id"`
+		Roles    []string `bson:"roles"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return errors.Wrap(err, "failed to read custom roles")
+	}
+
+	tenantIDs := make([]string, 0, len(groups))
+	for _, group := range groups {
+		tenantIDs = append(tenantIDs, group.TenantID)
+		_, err := r.directory.UpdateOne(
+			ctx, bson.M{"_id": group.TenantID}, bson.M{"$set": bson.M{"custom_roles": group.Roles}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to project custom roles of tenant %s", group.TenantID)
+		}
+	}
+
+	_, err = r.directory.UpdateMany(
+		ctx, bson.M{"_id": bson.M{"$nin": tenantIDs}}, bson.M{"$unset": bson.M{"custom_roles": ""}},
+	)
+
+	return errors.Wrap(err, "failed to project custom roles")
+}
+
+func directoryEntry(tenant *entities.Tenant) *entities.TenantDirectoryEntry {
+	entry := &entities.TenantDirectoryEntry{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		Subdomain: strings.ToLower(tenant.Subdomain),
+		IsActive:  tenant.IsActive,
+		Contacts:  len(tenant.PrimaryContacts),
+		UpdatedAt: tenant.UpdatedAt,
+	}
+
+	for _, domain := range tenant.Domains {
+		if domain != nil && domain.Status == entities.DomainStatusVerified {
+			entry.Hostnames = append(entry.Hostnames, strings.ToLower(domain.Hostname))
+		}
+	}
+
+	plans := map[string]bool{}
+	for _, company := range tenant.Companies {
+		if company == nil {
+			continue
+		}
+		for _, subscription := range company.Subscriptions {
+			if subscription != nil && subscription.Active && !plans[subscription.Plan] {
+				plans[subscription.Plan] = true
+				entry.Plans = append(entry.Plans, subscription.Plan)
+			}
+		}
+	}
+
+	return entry
+}
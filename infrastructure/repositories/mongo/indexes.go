@@ -0,0 +1,341 @@
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index is an index a collection is declared to have.
+type Index struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+	Sparse bool
+	// ExpireAfter removes documents once the date of their key is older, when it is positive.
+	ExpireAfter time.Duration
+}
+
+// TenantIndexes are the indexes of the tenants collection, by the paths of tenant documents.
+var TenantIndexes = []Index{
+	{Name: "subdomain_1", Keys: bson.D{{Key: "subdomain", Value: 1}}, Unique: true},
+	{Name: "is_active", Keys: bson.D{{Key: "is_active", Value: 1}}},
+	{Name: "created_at", Keys: bson.D{{Key: "created_at", Value: 1}}},
+	{Name: "primary_contacts.email", Keys: bson.D{{Key: "primary_contacts.email", Value: 1}}},
+	{Name: "domains.hostname", Keys: bson.D{{Key: "domains.hostname", Value: 1}}},
+	{Name: "api_keys.prefix", Keys: bson.D{{Key: "api_keys.prefix", Value: 1}}},
+	{Name: "companies._id", Keys: bson.D{{Key: "companies._id", Value: 1}}},
+	{Name: "companies.company_name", Keys: bson.D{{Key: "companies.company_name", Value: 1}}},
+	{Name: "companies.subscriptions.plan", Keys: bson.D{{Key: "companies.subscriptions.plan", Value: 1}}},
+}
+
+// ReconcileIndexes reconciles the indexes of the tenants collection with TenantIndexes.
+func (r *TenantRepository) ReconcileIndexes(ctx context.Context, opts ...IndexOption) (*IndexReport, error) {
+	return ReconcileIndexes(ctx, r.db, TenantIndexes, opts...)
+}
+
+// IndexDrift is a declared index differing from the existing index of its name, or existing
+// under another name.
+type IndexDrift struct {
+	Name     string `json:"name"`
+	Declared string `json:"declared"`
+	Existing string `json:"existing"`
+}
+
+// IndexReport is the outcome of the reconciliation of the indexes of a collection.
+type IndexReport struct {
+	Collection string `json:"collection"`
+	// Missing are the declared indexes that did not exist, Created the ones that were created.
+	Missing   []string `json:"missing,omitempty"`
+	Created   []string `json:"created,omitempty"`
+	Unchanged []string `json:"unchanged,omitempty"`
+	// Drifted are the declared indexes whose keys or options differ from the existing ones,
+	// Rebuilt the ones that were dropped and created again.
+	Drifted []IndexDrift `json:"drifted,omitempty"`
+	Rebuilt []string     `json:"rebuilt,omitempty"`
+	// Stale are the existing indexes that are not declared, Dropped the ones that were dropped.
+	Stale   []string `json:"stale,omitempty"`
+	Dropped []string `json:"dropped,omitempty"`
+}
+
+// String summarizes the report, for logs.
+func (r *IndexReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(
+		&b, "indexes of %s: %d missing, %d created, %d unchanged, %d drifted, %d rebuilt, %d stale, %d dropped",
+		r.Collection, len(r.Missing), len(r.Created), len(r.Unchanged), len(r.Drifted), len(r.Rebuilt),
+		len(r.Stale), len(r.Dropped),
+	)
+	for _, drift := range r.Drifted {
+		fmt.Fprintf(&b, "; %s is declared as %s but exists as %s", drift.Name, drift.Declared, drift.Existing)
+	}
+	for _, name := range r.Stale {
+		fmt.Fprintf(&b, "; %s is not declared", name)
+	}
+
+	return b.String()
+}
+
+type IndexOption func(*indexSettings)
+
+type indexSettings struct {
+	dropStale      bool
+	rebuildDrifted bool
+	reportOnly     bool
+}
+
+// WithDropStale drops the existing indexes that are not declared.
+func WithDropStale() IndexOption {
+	return func(s *indexSettings) {
+		s.dropStale = true
+	}
+}
+
+// WithRebuildDrifted drops the indexes differing from their declaration and creates them again.
+func WithRebuildDrifted() IndexOption {
+	return func(s *indexSettings) {
+		s.rebuildDrifted = true
+	}
+}
+
+// WithReportOnly reports the differences between the declared and the existing indexes without
+// changing anything.
+func WithReportOnly() IndexOption {
+	return func(s *indexSettings) {
+		s.reportOnly = true
+	}
+}
+
+// existingIndex is an index as listed by mongo.
+type existingIndex struct {
+	Name               string `bson:"name"`
+	Keys               bson.D `bson:"key"`
+	Unique             bool   `bson:"unique"`
+	Sparse             bool   `bson:"sparse"`
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+}
+
+// ReconcileIndexes brings the indexes of a collection in line with the declared ones. Missing
+// indexes are created, drifted and stale indexes are reported, and only changed when asked to.
+// A declared index existing under another name is drifted, it cannot be created next to it.
+func ReconcileIndexes(
+	ctx context.Context, collection *mongo.Collection, declared []Index, opts ...IndexOption,
+) (*IndexReport, error) {
+	var s indexSettings
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	existing, err := listIndexes(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &IndexReport{Collection: collection.Name()}
+	isDeclared := map[string]bool{}
+	for _, index := range declared {
+		isDeclared[index.Name] = true
+	}
+
+	var (
+		create []Index
+		// rebuild are the drifted indexes, by the name of the existing index they replace
+		rebuild = map[string]Index{}
+	)
+	for _, index := range declared {
+		current, ok := existing[index.Name]
+		if !ok {
+			current, ok = sameKeys(existing, isDeclared, index)
+		}
+
+		switch {
+		case !ok:
+			report.Missing = append(report.Missing, index.Name)
+			create = append(create, index)
+		case current.Name == index.Name && describe(index) == describeExisting(current):
+			report.Unchanged = append(report.Unchanged, index.Name)
+		default:
+			existingDescription := describeExisting(current)
+			if current.Name != index.Name {
+				existingDescription = current.Name + " " + existingDescription
+			}
+			report.Drifted = append(
+				report.Drifted, IndexDrift{Name: index.Name, Declared: describe(index), Existing: existingDescription},
+			)
+			rebuild[current.Name] = index
+		}
+	}
+
+	for name := range existing {
+		if name != "_id_" && !isDeclared[name] {
+			report.Stale = append(report.Stale, name)
+		}
+	}
+	sort.Strings(report.Stale)
+
+	if s.reportOnly {
+		return report, nil
+	}
+
+	if s.rebuildDrifted {
+		names := make([]string, 0, len(rebuild))
+		for name := range rebuild {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if err := dropIndex(ctx, collection, name); err != nil {
+				return report, err
+			}
+			if !isDeclared[name] {
+				report.Dropped = append(report.Dropped, name)
+			}
+
+			index := rebuild[name]
+			if err := createIndex(ctx, collection, index); err != nil {
+				return report, err
+			}
+			report.Rebuilt = append(report.Rebuilt, index.Name)
+		}
+	}
+
+	for _, index := range create {
+		if err := createIndex(ctx, collection, index); err != nil {
+			return report, err
+		}
+		report.Created = append(report.Created, index.Name)
+	}
+
+	if s.dropStale {
+		for _, name := range report.Stale {
+			if _, ok := rebuild[name]; ok && s.rebuildDrifted {
+				continue
+			}
+
+			if err := dropIndex(ctx, collection, name); err != nil {
+				return report, err
+			}
+			report.Dropped = append(report.Dropped, name)
+		}
+	}
+
+	return report, nil
+}
+
+func createIndex(ctx context.Context, collection *mongo.Collection, index Index) error {
+	_, err := collection.Indexes().CreateOne(ctx, index.model())
+	return errors.Wrapf(err, "failed to create index %s of %s", index.Name, collection.Name())
+}
+
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	return errors.Wrapf(err, "failed to drop index %s of %s", name, collection.Name())
+}
+
+func listIndexes(ctx context.Context, collection *mongo.Collection) (map[string]existingIndex, error) {
+	cursor, err := collection.Indexes().List(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list indexes of %s", collection.Name())
+	}
+
+	var indexes []existingIndex
+	if err := cursor.All(ctx, &indexes); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode indexes of %s", collection.Name())
+	}
+
+	existing := make(map[string]existingIndex, len(indexes))
+	for _, index := range indexes {
+		existing[index.Name] = index
+	}
+
+	return existing, nil
+}
+
+// sameKeys returns the existing index with the keys of a declared index, under a name that is
+// not declared.
+func sameKeys(existing map[string]existingIndex, declared map[string]bool, index Index) (existingIndex, bool) {
+	keys := describeKeys(index.Keys)
+	for name, current := range existing {
+		if !declared[name] && describeKeys(current.Keys) == keys {
+			return current, true
+		}
+	}
+
+	return existingIndex{}, false
+}
+
+func (i Index) model() mongo.IndexModel {
+	opts := options.Index().SetName(i.Name)
+	if i.Unique {
+		opts.SetUnique(true)
+	}
+	if i.Sparse {
+		opts.SetSparse(true)
+	}
+	if i.ExpireAfter > 0 {
+		opts.SetExpireAfterSeconds(int32(i.ExpireAfter.Seconds()))
+	}
+
+	return mongo.IndexModel{Keys: i.Keys, Options: opts}
+}
+
+// describe returns the keys and options of a declared index, comparable to describeExisting.
+func describe(index Index) string {
+	var expireAfter int64
+	if index.ExpireAfter > 0 {
+		expireAfter = int64(index.ExpireAfter.Seconds())
+	}
+
+	return describeOptions(index.Keys, index.Unique, index.Sparse, expireAfter)
+}
+
+func describeExisting(index existingIndex) string {
+	var expireAfter int64
+	if index.ExpireAfterSeconds != nil {
+		expireAfter = *index.ExpireAfterSeconds
+	}
+
+	return describeOptions(index.Keys, index.Unique, index.Sparse, expireAfter)
+}
+
+func describeOptions(keys bson.D, unique bool, sparse bool, expireAfter int64) string {
+	description := describeKeys(keys)
+	if unique {
+		description += " unique"
+	}
+	if sparse {
+		description += " sparse"
+	}
+	if expireAfter > 0 {
+		description += fmt.Sprintf(" expiring after %ds", expireAfter)
+	}
+
+	return description
+}
+
+// describeKeys returns the keys of an index, numeric directions read as mongo lists them.
+func describeKeys(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := key.Value
+		switch direction := value.(type) {
+		case int:
+			value = float64(direction)
+		case int32:
+			value = float64(direction)
+		case int64:
+			value = float64(direction)
+		}
+		parts = append(parts, fmt.Sprintf("%s: %v", key.Key, value))
+	}
+
+	return "{" + strings.Join(parts, ", ") + "}"
+}
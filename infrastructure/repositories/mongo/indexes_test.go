@@ -0,0 +1,124 @@
+package mongo_test
+
+import (
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	mgo "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestReconcileIndexes(t *testing.T) {
+	collection := storage.DB.Database().Collection("indexed")
+	declared := []mongo.Index{
+		{Name: "subdomain_1", Keys: bson.D{{Key: "subdomain", Value: 1}}, Unique: true},
+		{Name: "is_active", Keys: bson.D{{Key: "is_active", Value: 1}}},
+		{Name: "companies.company_name", Keys: bson.D{{Key: "companies.company_name", Value: 1}}},
+	}
+
+	// the indexes created before they were declared
+	setup := func(t *testing.T) {
+		require.NoError(t, collection.Drop(ctx))
+		_, err := collection.Indexes().CreateMany(
+			ctx, []mgo.IndexModel{
+				{Keys: bson.D{{Key: "subdomain", Value: 1}}},
+				{Keys: bson.D{{Key: "company_name", Value: 1}}, Options: options.Index().SetName("company_name")},
+				{
+					Keys:    bson.D{{Key: "is_active", Value: 1}},
+					Options: options.Index().SetName("active"),
+				},
+			},
+		)
+		require.NoError(t, err)
+	}
+	defer func() {
+		if err := collection.Drop(ctx); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	var testCases = []struct {
+		Name             string
+		Options          []mongo.IndexOption
+		ExpectedReport   mongo.IndexReport
+		ExpectedExisting []string
+	}{
+		{
+			Name:    "Happy Path: Report only",
+			Options: []mongo.IndexOption{mongo.WithReportOnly()},
+			ExpectedReport: mongo.IndexReport{
+				Collection: "indexed",
+				Missing:    []string{"companies.company_name"},
+				Drifted: []mongo.IndexDrift{
+					{Name: "subdomain_1", Declared: "{subdomain: 1} unique", Existing: "{subdomain: 1}"},
+					{Name: "is_active", Declared: "{is_active: 1}", Existing: "active {is_active: 1}"},
+				},
+				Stale: []string{"active", "company_name"},
+			},
+			ExpectedExisting: []string{"_id_", "active", "company_name", "subdomain_1"},
+		},
+		{
+			Name: "Happy Path: Create missing indexes",
+			ExpectedReport: mongo.IndexReport{
+				Collection: "indexed",
+				Missing:    []string{"companies.company_name"},
+				Created:    []string{"companies.company_name"},
+				Drifted: []mongo.IndexDrift{
+					{Name: "subdomain_1", Declared: "{subdomain: 1} unique", Existing: "{subdomain: 1}"},
+					{Name: "is_active", Declared: "{is_active: 1}", Existing: "active {is_active: 1}"},
+				},
+				Stale: []string{"active", "company_name"},
+			},
+			ExpectedExisting: []string{"_id_", "active", "companies.company_name", "company_name", "subdomain_1"},
+		},
+		{
+			Name:    "Happy Path: Rebuild drifted and drop stale indexes",
+			Options: []mongo.IndexOption{mongo.WithRebuildDrifted(), mongo.WithDropStale()},
+			ExpectedReport: mongo.IndexReport{
+				Collection: "indexed",
+				Missing:    []string{"companies.company_name"},
+				Created:    []string{"companies.company_name"},
+				Drifted: []mongo.IndexDrift{
+					{Name: "subdomain_1", Declared: "{subdomain: 1} unique", Existing: "{subdomain: 1}"},
+					{Name: "is_active", Declared: "{is_active: 1}", Existing: "active {is_active: 1}"},
+				},
+				Rebuilt: []string{"is_active", "subdomain_1"},
+				Stale:   []string{"active", "company_name"},
+				Dropped: []string{"active", "company_name"},
+			},
+			ExpectedExisting: []string{"_id_", "companies.company_name", "is_active", "subdomain_1"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				setup(t)
+
+				report, err := mongo.ReconcileIndexes(ctx, collection, declared, tt.Options...)
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedReport, *report)
+
+				specifications, err := collection.Indexes().ListSpecifications(ctx)
+				require.NoError(t, err)
+				var names []string
+				for _, specification := range specifications {
+					names = append(names, specification.Name)
+				}
+				assert.ElementsMatch(t, tt.ExpectedExisting, names)
+
+				// reconciled indexes stay as they are
+				if len(report.Dropped) > 0 {
+					again, err := mongo.ReconcileIndexes(ctx, collection, declared)
+					require.NoError(t, err)
+					assert.Empty(t, again.Drifted)
+					assert.Empty(t, again.Stale)
+					assert.ElementsMatch(t, []string{"subdomain_1", "is_active", "companies.company_name"}, again.Unchanged)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,335 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultMigrationBatchSize is how many tenants the migrator upgrades between checkpoints.
+const defaultMigrationBatchSize = 100
+
+// MigrationRecord is the record of a migration in the migrations collection. Runs of the
+// migrator upgrade every tenant to the latest version, the record of the latest version is
+// the checkpoint of the run: an interrupted run resumes after LastID.
+type MigrationRecord struct {
+	Version     int    `json:"version" bson:"_id"`
+	Description string `json:"description" bson:"description"`
+	// LastID is the ID of the last tenant upgraded by the run in progress, tenants are upgraded
+	// in the order of their IDs.
+	LastID    string    `json:"last_id,omitempty" bson:"last_id"`
+	Migrated  int64     `json:"migrated" bson:"migrated"`
+	StartedAt time.Time `json:"started_at" bson:"started_at"`
+	// AppliedAt is when every tenant was upgraded, it is zero while a run is in progress.
+	AppliedAt time.Time `json:"applied_at" bson:"applied_at"`
+}
+
+// MigrationProgress is the progress of a run of the migrator.
+type MigrationProgress struct {
+	// Target is the version tenants are upgraded to.
+	Target int `json:"target"`
+	// Pending is how many tenants of older versions the run upgrades, including the ones upgraded
+	// before it was interrupted.
+	Pending int64 `json:"pending"`
+	// Migrated is how many tenants were upgraded, or would be on a dry run.
+	Migrated int64 `json:"migrated"`
+	// Skipped is how many tenants changed while they were upgraded, they were upgraded on read.
+	Skipped int64 `json:"skipped"`
+	// Failed is how many tenants the migrations failed on, they are left as they are.
+	Failed int64  `json:"failed"`
+	LastID string `json:"last_id,omitempty"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// Migrator upgrades the tenants of older schema versions in batches, rather than waiting for
+// them to be read. Runs are recorded in the migrations collection, and resume where they were
+// interrupted.
+type Migrator struct {
+	tenants   *mongo.Collection
+	records   *mongo.Collection
+	registry  *migrations.Registry
+	logger    utils.LoggerInterface
+	batchSize int
+	dryRun    bool
+	progress  func(MigrationProgress)
+}
+
+type MigratorOption func(*Migrator)
+
+// WithBatchSize sets how many tenants are upgraded between checkpoints.
+func WithBatchSize(size int) MigratorOption {
+	return func(m *Migrator) {
+		if size > 0 {
+			m.batchSize = size
+		}
+	}
+}
+
+// WithDryRun reports what a run would upgrade without writing anything.
+func WithDryRun() MigratorOption {
+	return func(m *Migrator) {
+		m.dryRun = true
+	}
+}
+
+// WithProgress calls report with the progress of runs after every batch.
+func WithProgress(report func(MigrationProgress)) MigratorOption {
+	return func(m *Migrator) {
+		m.progress = report
+	}
+}
+
+func NewMigrator(
+	tenants *mongo.Collection, records *mongo.Collection, registry *migrations.Registry,
+	logger utils.LoggerInterface, opts ...MigratorOption,
+) *Migrator {
+	m := &Migrator{
+		tenants:   tenants,
+		records:   records,
+		registry:  registry,
+		logger:    logger,
+		batchSize: defaultMigrationBatchSize,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Applied returns the records of the migrations, by version.
+func (m *Migrator) Applied(ctx context.Context) ([]*MigrationRecord, error) {
+	cursor, err := m.records.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read migration records")
+	}
+
+	records := make([]*MigrationRecord, 0)
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, errors.Wrap(err, "failed to decode migration records")
+	}
+
+	return records, nil
+}
+
+// Run upgrades every tenant of an older version to the latest version. Tenants the migrations
+// fail on are left as they are and reported by an error once the others are upgraded, the next
+// run tries them again.
+func (m *Migrator) Run(ctx context.Context) (MigrationProgress, error) {
+	progress := MigrationProgress{Target: m.registry.Latest(), DryRun: m.dryRun}
+	if progress.Target == 0 {
+		return progress, nil
+	}
+
+	if !m.dryRun {
+		record, err := m.start(ctx, progress.Target)
+		if err != nil {
+			return progress, err
+		}
+		if record.LastID != "" {
+			m.logger.Infof("resuming migration to version %d after tenant %s", progress.Target, record.LastID)
+		}
+		progress.LastID = record.LastID
+		progress.Migrated = record.Migrated
+	}
+
+	pending := bson.M{migrations.VersionField: bson.M{"$not": bson.M{"$gte": progress.Target}}}
+	count, err := m.tenants.CountDocuments(ctx, after(pending, progress.LastID))
+	if err != nil {
+		return progress, errors.Wrap(err, "failed to count tenants to migrate")
+	}
+	progress.Pending = progress.Migrated + count
+	m.logger.Infof("migrating %d tenants to version %d", count, progress.Target)
+
+	for {
+		batch, err := m.batch(ctx, after(pending, progress.LastID))
+		if err != nil {
+			return progress, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		for _, raw := range batch {
+			if err := m.migrate(ctx, raw, &progress); err != nil {
+				return progress, err
+			}
+		}
+
+		if err := m.checkpoint(ctx, progress); err != nil {
+			return progress, err
+		}
+		m.report(progress)
+	}
+
+	if progress.Failed > 0 {
+		if err := m.restart(ctx, progress); err != nil {
+			return progress, err
+		}
+		return progress, errors.Errorf("%d tenants failed to migrate to version %d", progress.Failed, progress.Target)
+	}
+
+	return progress, m.finish(ctx, progress)
+}
+
+// after restricts a filter to the tenants after lastID.
+func after(filter bson.M, lastID string) bson.M {
+	if lastID == "" {
+		return filter
+	}
+
+	return bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$gt": lastID}}}}
+}
+
+func (m *Migrator) batch(ctx context.Context, filter bson.M) ([]bson.Raw, error) {
+	cursor, err := m.tenants.Find(
+		ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(m.batchSize)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read tenants to migrate")
+	}
+	defer cursor.Close(ctx)
+
+	var batch []bson.Raw
+	for cursor.Next(ctx) {
+		batch = append(batch, append(bson.Raw(nil), cursor.Current...))
+	}
+
+	return batch, errors.Wrap(cursor.Err(), "failed to read tenants to migrate")
+}
+
+// migrate upgrades a tenant, only errors writing it back stop the run.
+func (m *Migrator) migrate(ctx context.Context, raw bson.Raw, progress *MigrationProgress) error {
+	id, ok := raw.Lookup("_id").StringValueOK()
+	if !ok {
+		return errors.New("tenant without string id")
+	}
+	progress.LastID = id
+
+	version, err := migrations.RawVersion(raw)
+	if err != nil {
+		m.logger.With(id).Errorln(err)
+		progress.Failed++
+		return nil
+	}
+
+	var document bson.M
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		m.logger.With(id).Errorln(err)
+		progress.Failed++
+		return nil
+	}
+
+	if _, err := m.registry.Upgrade(document); err != nil {
+		m.logger.With(id).Errorln(err)
+		progress.Failed++
+		return nil
+	}
+
+	if m.dryRun {
+		progress.Migrated++
+		return nil
+	}
+
+	result, err := m.tenants.ReplaceOne(ctx, versionFilter(id, version), document)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write migrated tenant %s", id)
+	}
+
+	if result.MatchedCount == 0 {
+		progress.Skipped++
+		return nil
+	}
+	progress.Migrated++
+
+	return nil
+}
+
+// start returns the record of the run to target, creating it unless a run is in progress.
+func (m *Migrator) start(ctx context.Context, target int) (*MigrationRecord, error) {
+	var record MigrationRecord
+	err := m.records.FindOne(ctx, bson.M{"_id": target}).Decode(&record)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errors.Wrapf(err, "failed to read record of migration %d", target)
+	}
+	if err == nil && record.AppliedAt.IsZero() {
+		return &record, nil
+	}
+
+	record = MigrationRecord{
+		Version:     target,
+		Description: m.registry.Migrations()[target-1].Description,
+		StartedAt:   time.Now().UTC(),
+	}
+	_, err = m.records.ReplaceOne(ctx, bson.M{"_id": target}, record, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to record migration %d", target)
+	}
+
+	return &record, nil
+}
+
+func (m *Migrator) checkpoint(ctx context.Context, progress MigrationProgress) error {
+	if m.dryRun {
+		return nil
+	}
+
+	_, err := m.records.UpdateOne(
+		ctx, bson.M{"_id": progress.Target},
+		bson.M{"$set": bson.M{"last_id": progress.LastID, "migrated": progress.Migrated}},
+	)
+
+	return errors.Wrapf(err, "failed to checkpoint migration %d", progress.Target)
+}
+
+// restart makes the next run start over, to try the tenants that failed again.
+func (m *Migrator) restart(ctx context.Context, progress MigrationProgress) error {
+	progress.LastID = ""
+	return m.checkpoint(ctx, progress)
+}
+
+// finish records every migration up to the target of a run as applied.
+func (m *Migrator) finish(ctx context.Context, progress MigrationProgress) error {
+	if m.dryRun {
+		m.logger.Infof("dry run: %d tenants would be migrated to version %d", progress.Migrated, progress.Target)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, migration := range m.registry.Migrations() {
+		_, err := m.records.UpdateOne(
+			ctx, bson.M{"_id": migration.Version, "applied_at": time.Time{}},
+			bson.M{
+				"$set":         bson.M{"description": migration.Description, "last_id": "", "applied_at": now},
+				"$setOnInsert": bson.M{"migrated": int64(0), "started_at": now},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return errors.Wrapf(err, "failed to record migration %d as applied", migration.Version)
+		}
+	}
+
+	m.logger.Infof(
+		"migrated %d tenants to version %d, %d were upgraded concurrently", progress.Migrated, progress.Target,
+		progress.Skipped,
+	)
+
+	return nil
+}
+
+func (m *Migrator) report(progress MigrationProgress) {
+	m.logger.Infof(
+		"migration to version %d: %d of %d tenants migrated, %d failed", progress.Target, progress.Migrated,
+		progress.Pending, progress.Failed,
+	)
+	if m.progress != nil {
+		m.progress(progress)
+	}
+}
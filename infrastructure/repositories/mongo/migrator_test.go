@@ -0,0 +1,163 @@
+package mongo_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// testMigrations rename the name of tenants to display_name, failing on tenants named "broken".
+var testMigrations = migrations.MustNewRegistry(
+	migrations.Migration{Version: 1, Description: "version tenant documents", Up: func(bson.M) error { return nil }},
+	migrations.Migration{
+		Version:     2,
+		Description: "rename name to display_name",
+		Up: func(document bson.M) error {
+			if document["name"] == "broken" {
+				return errors.New("broken tenant")
+			}
+			document["display_name"] = document["name"]
+			return nil
+		},
+	},
+)
+
+func insertLegacyTenants(t *testing.T, names ...string) {
+	for i, name := range names {
+		_, err := storage.DB.InsertOne(ctx, bson.M{"_id": fmt.Sprintf("tenant-%02d", i), "name": name})
+		require.NoError(t, err)
+	}
+}
+
+func TestTenantRepository_UpgradeOnRead(t *testing.T) {
+	require.NoError(t, dropTestCollections())
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	insertLegacyTenants(t, "Acme")
+	repo := mongo.NewTenantRepository(storage.DB, logger, mongo.WithMigrations(testMigrations))
+
+	tenant, err := repo.GetTenantByID(ctx, "tenant-00")
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", tenant.Name)
+
+	// the upgraded document was written back
+	var document bson.M
+	require.NoError(t, storage.DB.FindOne(ctx, bson.M{"_id": "tenant-00"}).Decode(&document))
+	assert.EqualValues(t, 2, document["schema_version"])
+	assert.Equal(t, "Acme", document["display_name"])
+
+	// writes record the latest version
+	tenant.Name = "Acme Corp"
+	require.NoError(t, repo.UpdateTenant(ctx, tenant))
+	require.NoError(t, storage.DB.FindOne(ctx, bson.M{"_id": "tenant-00"}).Decode(&document))
+	assert.EqualValues(t, 2, document["schema_version"])
+}
+
+func TestMigrator_Run(t *testing.T) {
+	records := storage.DB.Database().Collection("migrations")
+	reset := func() {
+		if err := records.Drop(ctx); err != nil {
+			logger.Error(err)
+		}
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}
+	reset()
+	defer reset()
+
+	insertLegacyTenants(t, "Acme", "Globex", "broken", "Initech", "Umbrella")
+
+	// dry runs write nothing
+	var reports []mongo.MigrationProgress
+	dryRun := mongo.NewMigrator(
+		storage.DB, records, testMigrations, logger, mongo.WithDryRun(), mongo.WithBatchSize(2),
+		mongo.WithProgress(func(p mongo.MigrationProgress) { reports = append(reports, p) }),
+	)
+	progress, err := dryRun.Run(ctx)
+	require.Error(t, err)
+	assert.Equal(t, int64(5), progress.Pending)
+	assert.Equal(t, int64(4), progress.Migrated)
+	assert.Equal(t, int64(1), progress.Failed)
+	assert.Len(t, reports, 3)
+
+	count, err := storage.DB.CountDocuments(ctx, bson.M{"schema_version": 2})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	applied, err := dryRun.Applied(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// failing tenants are reported once the others are migrated
+	migrator := mongo.NewMigrator(storage.DB, records, testMigrations, logger, mongo.WithBatchSize(2))
+	progress, err = migrator.Run(ctx)
+	require.Error(t, err)
+	assert.Equal(t, int64(4), progress.Migrated)
+	assert.Equal(t, int64(1), progress.Failed)
+
+	count, err = storage.DB.CountDocuments(ctx, bson.M{"schema_version": 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	applied, err = migrator.Applied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.True(t, applied[0].AppliedAt.IsZero())
+
+	// the next run tries them again
+	_, err = storage.DB.UpdateOne(ctx, bson.M{"name": "broken"}, bson.M{"$set": bson.M{"name": "Fixed"}})
+	require.NoError(t, err)
+
+	progress, err = migrator.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), progress.Migrated)
+
+	applied, err = migrator.Applied(ctx)
+	require.NoError(t, err)
+	require.Len(t, applied, 2)
+	for _, record := range applied {
+		assert.False(t, record.AppliedAt.IsZero())
+	}
+}
+
+func TestMigrator_Resume(t *testing.T) {
+	records := storage.DB.Database().Collection("migrations")
+	reset := func() {
+		if err := records.Drop(ctx); err != nil {
+			logger.Error(err)
+		}
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}
+	reset()
+	defer reset()
+
+	insertLegacyTenants(t, "Acme", "Globex", "Initech")
+
+	// a run interrupted after the first tenant
+	_, err := records.InsertOne(
+		ctx, mongo.MigrationRecord{Version: 2, Description: "rename name to display_name", LastID: "tenant-00", Migrated: 1},
+	)
+	require.NoError(t, err)
+
+	progress, err := mongo.NewMigrator(storage.DB, records, testMigrations, logger).Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), progress.Pending)
+	assert.Equal(t, int64(3), progress.Migrated)
+
+	// tenants before the checkpoint are left to the next run, or to reads
+	var document bson.M
+	require.NoError(t, storage.DB.FindOne(ctx, bson.M{"_id": "tenant-00"}).Decode(&document))
+	assert.Nil(t, document["schema_version"])
+}
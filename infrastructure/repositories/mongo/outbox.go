@@ -0,0 +1,150 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// publishedRetention is how long published events are kept in the outbox, for troubleshooting.
+const publishedRetention = 7 * 24 * time.Hour
+
+// outboxDocument is an event in the outbox. AvailableAt is when the event can be claimed,
+// it is pushed back by the lease of a relay and by the backoff of failed attempts.
+type outboxDocument struct {
+	entities.OutboxEvent `bson:",inline"`
+	Published            bool      `bson:"published"`
+	AvailableAt          time.Time `bson:"available_at"`
+}
+
+// OutboxRepository is the transactional outbox of domain events. Events are written in the
+// transaction of the change raising them, then published by a relay.
+type OutboxRepository struct {
+	db     *mongo.Collection
+	logger utils.LoggerInterface
+}
+
+func NewOutboxRepository(db *mongo.Collection, logger utils.LoggerInterface) *OutboxRepository {
+	return &OutboxRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateIndexes creates the index of pending events and the TTL index removing published events.
+func (r *OutboxRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.db.Indexes().CreateMany(
+		ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "published", Value: 1}, {Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("published_occurred_at"),
+			},
+			{
+				Keys: bson.D{{Key: "published_at", Value: 1}},
+				Options: options.Index().SetName("published_at").
+					SetExpireAfterSeconds(int32(publishedRetention.Seconds())),
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create outbox indexes")
+	}
+
+	return nil
+}
+
+// ClaimEvents leases up to limit events available for publishing, oldest first.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *OutboxRepository) ClaimEvents(ctx context.Context, limit int, lease time.Duration) (
+	[]*entities.OutboxEvent, error,
+) {
+	now := time.Now().UTC()
+
+	var events []*entities.OutboxEvent
+	for len(events) < limit {
+		var event *entities.OutboxEvent
+		err := r.db.FindOneAndUpdate(
+			ctx,
+			bson.M{"published": false, "available_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"available_at": now.Add(lease)}},
+			options.FindOneAndUpdate().
+				// events raised together occur at the same time, their IDs keep them in order
+				SetSort(bson.D{{Key: "occurred_at", Value: 1}, {Key: "_id", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(&event)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			r.logger.Error(err)
+			return events, errors.Wrap(err, "failed to claim outbox events")
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// MarkPublished marks an event as published, it is not claimed anymore.
+func (r *OutboxRepository) MarkPublished(ctx context.Context, id string) error {
+	_, err := r.db.UpdateOne(
+		ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"published": true, "published_at": time.Now().UTC()}},
+	)
+	if err != nil {
+		r.logger.Error(err)
+		return errors.Wrapf(err, "failed to mark outbox event %s as published", id)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt to publish an event, it is claimed again at retryAt.
+func (r *OutboxRepository) MarkFailed(ctx context.Context, id string, cause string, retryAt time.Time) error {
+	_, err := r.db.UpdateOne(
+		ctx, bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"attempts": 1},
+			"$set": bson.M{"last_error": cause, "available_at": retryAt.UTC()},
+		},
+	)
+	if err != nil {
+		r.logger.Error(err)
+		return errors.Wrapf(err, "failed to mark outbox event %s as failed", id)
+	}
+
+	return nil
+}
+
+// enqueue writes events to the outbox, it must run in the transaction of the change raising them.
+// Nothing is written without an outbox or without events.
+func (r *OutboxRepository) enqueue(ctx context.Context, events []*entities.Event) error {
+	if r == nil || len(events) == 0 {
+		return nil
+	}
+
+	documents := make([]any, 0, len(events))
+	for _, event := range events {
+		documents = append(
+			documents, outboxDocument{
+				OutboxEvent: entities.OutboxEvent{Event: *event},
+				AvailableAt: event.OccurredAt,
+			},
+		)
+	}
+
+	if _, err := r.db.InsertMany(ctx, documents); err != nil {
+		r.logger.Error(err)
+		return apperrors.ErrCreatingOutboxEvent.Wrap(err)
+	}
+
+	return nil
+}
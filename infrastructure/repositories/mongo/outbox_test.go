@@ -0,0 +1,57 @@
+package mongo_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRepository(t *testing.T) {
+	collection := storage.DB.Database().Collection("outbox")
+	defer func() {
+		if err := collection.Drop(ctx); err != nil {
+			logger.Error(err)
+		}
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	outbox := mongo.NewOutboxRepository(collection, logger)
+	require.NoError(t, outbox.CreateIndexes(ctx))
+	repo := mongo.NewTenantRepository(storage.DB, logger, mongo.WithOutbox(outbox))
+
+	tenant := tests.CreateTenant()
+	tenant.IsActive = true
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+
+	tenant.IsActive = false
+	require.NoError(t, repo.UpdateTenant(ctx, tenant))
+
+	claimed, err := outbox.ClaimEvents(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, claimed)
+	assert.Equal(t, entities.TenantCreated, claimed[0].Type)
+	assert.Equal(t, entities.TenantSuspended, claimed[len(claimed)-1].Type)
+
+	// claimed events are leased
+	again, err := outbox.ClaimEvents(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, again)
+
+	first, last := claimed[0], claimed[len(claimed)-1]
+	require.NoError(t, outbox.MarkPublished(ctx, first.ID))
+	require.NoError(t, outbox.MarkFailed(ctx, last.ID, "broker unavailable", time.Now().Add(-time.Second)))
+
+	retried, err := outbox.ClaimEvents(ctx, 100, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, retried, 1)
+	assert.Equal(t, last.ID, retried[0].ID)
+	assert.Equal(t, 1, retried[0].Attempts)
+	assert.Equal(t, "broker unavailable", retried[0].LastError)
+}
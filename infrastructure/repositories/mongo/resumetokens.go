@@ -0,0 +1,61 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResumeTokenRepository persists the resume tokens of change streams, by name of the watched collection.
+type ResumeTokenRepository struct {
+	collection *mongo.Collection
+}
+
+func NewResumeTokenRepository(collection *mongo.Collection) *ResumeTokenRepository {
+	return &ResumeTokenRepository{collection: collection}
+}
+
+type resumeTokenDocument struct {
+	Name      string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// Find returns the resume token of a change stream, it returns nil when no token was saved.
+func (r *ResumeTokenRepository) Find(ctx context.Context, name string) (bson.Raw, error) {
+	var document resumeTokenDocument
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&document)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to read resume token of %s", name)
+	}
+
+	return document.Token, nil
+}
+
+// Save replaces the resume token of a change stream.
+func (r *ResumeTokenRepository) Save(ctx context.Context, name string, token bson.Raw) error {
+	if token == nil {
+		return nil
+	}
+
+	_, err := r.collection.ReplaceOne(
+		ctx, bson.M{"_id": name},
+		resumeTokenDocument{Name: name, Token: token, UpdatedAt: time.Now().UTC()},
+		options.Replace().SetUpsert(true),
+	)
+
+	return errors.Wrapf(err, "failed to save resume token of %s", name)
+}
+
+// Delete forgets the resume token of a change stream, the stream starts over from the current changes.
+func (r *ResumeTokenRepository) Delete(ctx context.Context, name string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+	return errors.Wrapf(err, "failed to delete resume token of %s", name)
+}
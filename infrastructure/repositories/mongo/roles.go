@@ -0,0 +1,199 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/events"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RolesRepository struct {
+	db       *mongo.Collection
+	logger   utils.LoggerInterface
+	settings settings
+}
+
+func NewRolesRepository(db *mongo.Collection, logger utils.LoggerInterface, opts ...Option) *RolesRepository {
+	return &RolesRepository{
+		db:       db,
+		logger:   logger,
+		settings: newSettings(opts),
+	}
+}
+
+// SaveRole creates a new role in the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// Role is the role to be created.
+func (r *RolesRepository) SaveRole(ctx context.Context, role *entities.Role) error {
+	r.logger.Infof("inserting role into database: %v", role.ID)
+	err := mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			if _, err := r.db.InsertOne(ctx, role); err != nil {
+				return nil, apperrors.ErrCreatingRoleDocument.Wrap(err)
+			}
+
+			return r.describe(ctx, entities.AuditCreate, nil, role)
+		},
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingRole, role.ID)
+		r.logger.Error(err)
+		return err
+	}
+
+	r.logger.Infof("successfully inserted role into database: %v", role.ID)
+	return nil
+}
+
+// UpdateRole replaces a role in the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// Role is the role to be updated.
+func (r *RolesRepository) UpdateRole(ctx context.Context, role *entities.Role) error {
+	r.logger.Infof("updating role in database: %v", role.ID)
+	return mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			var before *entities.Role
+			if r.settings.tracked() {
+				found, err := r.FindRoleByID(ctx, utils.XID{ID: role.ID})
+				if err != nil {
+					return nil, err
+				}
+				before = found
+			}
+
+			result, err := r.db.ReplaceOne(ctx, bson.M{"_id": role.ID}, role)
+			if err != nil {
+				r.logger.Errorf(apperrors.ErrUpdatingRole, role.ID)
+				r.logger.Error(err)
+				return nil, apperrors.ErrUpdatingRoleDocument.Wrap(err)
+			}
+
+			if result.MatchedCount == 0 {
+				r.logger.Errorf(apperrors.ErrNoRoleFound, role.ID)
+				return nil, apperrors.ErrNoRoleDocumentsFound
+			}
+
+			r.logger.Infof("updated %v documents", result.ModifiedCount)
+			return r.describe(ctx, entities.AuditUpdate, before, role)
+		},
+	)
+}
+
+// DeleteRole removes a role from the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// RoleID is the id of the role to be deleted.
+func (r *RolesRepository) DeleteRole(ctx context.Context, roleID utils.XID) error {
+	r.logger.Infof("deleting role from database: %v", roleID.ID)
+	return mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			var before *entities.Role
+			if r.settings.tracked() {
+				found, err := r.FindRoleByID(ctx, roleID)
+				if err != nil {
+					return nil, err
+				}
+				before = found
+			}
+
+			result, err := r.db.DeleteOne(ctx, bson.M{"_id": roleID.ID})
+			if err != nil {
+				r.logger.Errorf(apperrors.ErrDeletingRole, roleID.ID)
+				r.logger.Error(err)
+				return nil, apperrors.ErrDeletingRoleDocument.Wrap(err)
+			}
+
+			if result.DeletedCount == 0 {
+				r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+				return nil, apperrors.ErrNoRoleDocumentsFound
+			}
+
+			if before == nil {
+				return nil, nil
+			}
+			return r.describe(ctx, entities.AuditDelete, before, nil)
+		},
+	)
+}
+
+// describe returns the audit record and the domain events of an operation changing a role
+// from before to after, as far as they are tracked.
+func (r *RolesRepository) describe(
+	ctx context.Context, operation entities.AuditOperation, before *entities.Role, after *entities.Role,
+) (*change, error) {
+	c := &change{}
+	role := after
+	if role == nil {
+		role = before
+	}
+
+	if r.settings.audit != nil {
+		record, err := audit.NewRecord(ctx, entities.AuditRole, role.ID, role.TenantID, operation, before, after)
+		if err != nil {
+			return nil, err
+		}
+		c.record = record
+	}
+
+	if r.settings.outbox != nil {
+		raised, err := events.RoleEvents(ctx, operation, before, after)
+		if err != nil {
+			return nil, err
+		}
+		c.events = raised
+	}
+
+	return c, nil
+}
+
+// FindRoleByID returns a role from the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// RoleID is the id of the role to be retrieved.
+func (r *RolesRepository) FindRoleByID(ctx context.Context, roleID utils.XID) (*entities.Role, error) {
+	var role *entities.Role
+
+	r.logger.Infof("retrieving role from database: %v", roleID.ID)
+	if err := r.db.FindOne(ctx, bson.M{"_id": roleID.ID}).Decode(&role); err != nil {
+		switch err {
+		case mongo.ErrNoDocuments:
+			r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+			return nil, apperrors.ErrNoRoleDocumentsFound
+		default:
+			r.logger.Errorf(apperrors.ErrRetrievingRole, roleID.ID)
+			r.logger.Error(err)
+			return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+		}
+	}
+
+	return role, nil
+}
+
+// FindAllRoles returns every role from the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *RolesRepository) FindAllRoles(ctx context.Context) ([]*entities.Role, error) {
+	var roles []*entities.Role
+
+	r.logger.Info("retrieving roles from database")
+	cursor, err := r.db.Find(ctx, bson.M{})
+	if err != nil {
+		r.logger.Error(apperrors.ErrRetrievingRoles)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, &roles); err != nil {
+		r.logger.Error(apperrors.ErrRetrievingRoles)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	r.logger.Infof("found %d roles", len(roles))
+
+	return roles, nil
+}
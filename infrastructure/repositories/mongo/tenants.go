@@ -5,20 +5,35 @@ import (
 
 	"github.com/hebecoding/digital-dash-commons/utils"
 	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/migrations"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/events"
+	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TenantRepository struct {
-	db     *mongo.Collection
-	logger utils.LoggerInterface
+	db       *mongo.Collection
+	logger   utils.LoggerInterface
+	settings settings
 }
 
-func NewTenantRepository(db *mongo.Collection, logger utils.LoggerInterface) *TenantRepository {
+// NewTenantRepository returns the repository of the tenants of a collection. Tenant documents
+// record the schema version they are written in, documents of older versions are upgraded by
+// the migrations of tenants when they are read, see WithMigrations.
+func NewTenantRepository(db *mongo.Collection, logger utils.LoggerInterface, opts ...Option) *TenantRepository {
+	s := newSettings(opts)
+	if s.migrations == nil {
+		s.migrations = migrations.Tenants
+	}
+
 	return &TenantRepository{
-		db:     db,
-		logger: logger,
+		db:       db,
+		logger:   logger,
+		settings: s,
 	}
 }
 
@@ -27,55 +42,132 @@ func NewTenantRepository(db *mongo.Collection, logger utils.LoggerInterface) *Te
 // Tenants is the tenant to be created.
 func (r *TenantRepository) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
 	r.logger.Infof("inserting tenant into database: %v", tenant.ID)
-	_, err := r.db.InsertOne(ctx, tenant)
+	err := mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			if _, err := r.db.InsertOne(ctx, r.document(tenant)); err != nil {
+				return nil, err
+			}
+
+			return r.describe(ctx, entities.AuditCreate, nil, tenant)
+		},
+	)
 	if err != nil {
 		r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
 		r.logger.Error(err)
 		r.logger.Error(apperrors.ErrRollingBackTransaction)
 
-		return apperrors.ErrCreatingTenantDocument
+		if errors.Is(err, apperrors.ErrCreatingAuditRecord) || errors.Is(err, apperrors.ErrCreatingOutboxEvent) {
+			return err
+		}
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
 	}
 	r.logger.Infof("successfully inserted tenant into database: %v", tenant.ID)
 	return nil
 }
 
+// InsertTenants creates tenants in a single round trip, for seeding and restoring databases.
+// Repositories tracking their changes create the tenants one at a time instead, so that each
+// one is audited and raises its events.
+func (r *TenantRepository) InsertTenants(ctx context.Context, tenants []*entities.Tenant) error {
+	if len(tenants) == 0 {
+		return nil
+	}
+	if r.settings.tracked() {
+		for _, tenant := range tenants {
+			if err := r.CreateTenant(ctx, tenant); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	r.logger.Infof("inserting %d tenants into database", len(tenants))
+	documents := make([]interface{}, 0, len(tenants))
+	for _, tenant := range tenants {
+		documents = append(documents, r.document(tenant))
+	}
+
+	if _, err := r.db.InsertMany(ctx, documents); err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingTenant, tenants[0].ID)
+		r.logger.Error(err)
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	return nil
+}
+
 // DeleteTenant deletes a tenant from the database.
 // Ctx is used to cancel the operation if the context is cancelled.
 // ID is the id of the tenant to be deleted.
 // This is a soft delete, isActive is set to false.
 func (r *TenantRepository) DeleteTenant(ctx context.Context, id string) error {
-	var tenant *entities.Tenant
-
 	r.logger.Infof("deleting tenant from database: %v", id)
-	tenant, err := r.GetTenantByID(ctx, id)
-	if err != nil {
-		return err
-	}
+	return mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			tenant, err := r.GetTenantByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
 
-	// set isActive to false
-	tenant.IsActive = false
+			before := *tenant
 
-	// update tenant in database
-	if err := r.UpdateTenant(ctx, tenant); err != nil {
-		r.logger.With(tenant.ID).Error(err)
-		return apperrors.ErrDeletingTenantDocument
-	}
+			// set isActive to false
+			tenant.IsActive = false
 
-	return nil
+			// update tenant in database
+			if err := r.update(ctx, tenant); err != nil {
+				r.logger.With(tenant.ID).Error(err)
+				return nil, apperrors.ErrDeletingTenantDocument.Wrap(err)
+			}
+
+			return r.describe(ctx, entities.AuditDelete, &before, tenant)
+		},
+	)
+}
+
+// PurgeTenant removes a tenant from the database for good, unlike DeleteTenant.
+// Ctx is used to cancel the operation if the context is cancelled.
+// ID is the id of the tenant to be purged.
+func (r *TenantRepository) PurgeTenant(ctx context.Context, id string) error {
+	r.logger.Infof("purging tenant from database: %v", id)
+	return mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			var before *entities.Tenant
+			if r.settings.tracked() {
+				found, err := r.GetTenantByID(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+				before = found
+			}
+
+			result, err := r.db.DeleteOne(ctx, bson.M{"_id": id})
+			if err != nil {
+				r.logger.With(id).Error(err)
+				return nil, apperrors.ErrDeletingTenantDocument.Wrap(err)
+			}
+
+			if result.DeletedCount == 0 {
+				r.logger.Infof(apperrors.ErrNoTenantFound, id)
+				return nil, apperrors.ErrNoTenantDocumentsFound
+			}
+
+			if before == nil {
+				return nil, nil
+			}
+			return r.describe(ctx, entities.AuditDelete, before, nil)
+		},
+	)
 }
 
 // GetTenantByID returns a tenant from the database.
 // Ctx is used to cancel the operation if the context is cancelled.
 // ID is the id of the tenant to be retrieved.
 func (r *TenantRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
-	var tenant *entities.Tenant
-
 	// get tenant from database
 	r.logger.Infof("retrieving tenant from database: %v", id)
-	if err := r.db.FindOne(
-		ctx, bson.M{"_id": id},
-	).
-		Decode(&tenant); err != nil {
+	raw, err := r.db.FindOne(ctx, bson.M{"_id": id}).DecodeBytes()
+	if err != nil {
 		switch err {
 		case mongo.ErrNoDocuments:
 			r.logger.Errorf(apperrors.ErrNoTenantFound, id)
@@ -83,32 +175,31 @@ func (r *TenantRepository) GetTenantByID(ctx context.Context, id string) (*entit
 		default:
 			r.logger.Errorf(apperrors.ErrRetrievingTenant, id)
 			r.logger.Error(err)
-			return nil, apperrors.ErrRetrievingTenantDocument
+			return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
 		}
 	}
 
-	return tenant, nil
+	return r.decode(ctx, raw)
 }
 
 // GetTenants returns a list of tenants from the database.
 // Ctx is used to cancel the operation if the context is cancelled.
 func (r *TenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
-	var tenants []*entities.Tenant
-
 	// get all tenants from database
 	r.logger.Info("retrieving tenants from database")
-	cursor, err := r.db.Find(ctx, bson.D{{"is_active", true}})
+	cursor, err := r.db.Find(ctx, bson.D{{Key: "is_active", Value: true}})
 	if err != nil {
 		r.logger.Error(apperrors.ErrRetrievingTenants)
 		r.logger.Error(err)
-		return nil, apperrors.ErrRetrievingTenantDocument
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
 	}
 
 	// unmarshal all tenants into a slice
-	if err := cursor.All(ctx, &tenants); err != nil {
+	tenants, err := r.decodeAll(ctx, cursor)
+	if err != nil {
 		r.logger.Error(apperrors.ErrUnmarshallingTenant)
 		r.logger.Error(err)
-		return nil, apperrors.ErrUnmarshallingTenantDocument
+		return nil, err
 	}
 
 	r.logger.Infof("found %d tenants", len(tenants))
@@ -121,16 +212,167 @@ func (r *TenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant,
 // Tenant is the tenant to be updated.
 // Only included fields will be updated.
 func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	if !r.settings.tracked() {
+		return r.update(ctx, tenant)
+	}
+
+	return mutate(
+		ctx, r.settings, r.db, func(ctx context.Context) (*change, error) {
+			before, err := r.GetTenantByID(ctx, tenant.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := r.update(ctx, tenant); err != nil {
+				return nil, err
+			}
+
+			return r.describe(ctx, entities.AuditUpdate, before, tenant)
+		},
+	)
+}
+
+// EachTenant calls fn with the tenants matching filter one at a time, in the order of their IDs,
+// without holding them all in memory. It stops at the first error of fn.
+func (r *TenantRepository) EachTenant(
+	ctx context.Context, filter map[string]interface{}, fn func(tenant *entities.Tenant) error,
+) error {
+	if filter == nil {
+		filter = map[string]interface{}{}
+	}
+
+	cursor, err := r.db.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		r.logger.With(filter).With(apperrors.ErrRetrievingTenants).Errorln(err)
+		return apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		tenant, err := r.decode(ctx, cursor.Current)
+		if err != nil {
+			return err
+		}
+		if err := fn(tenant); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	return nil
+}
+
+// TenantStats counts tenants and the subscriptions of active tenants in a single aggregation,
+// without decoding tenants.
+func (r *TenantRepository) TenantStats(ctx context.Context) (*entities.TenantStats, error) {
+	subscriptions := func(field string) mongo.Pipeline {
+		return mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"is_active": true}}},
+			{{Key: "$unwind", Value: "$companies"}},
+			{{Key: "$unwind", Value: "$companies.subscriptions"}},
+			{{Key: "$group", Value: bson.M{"_id": "$companies.subscriptions." + field, "count": bson.M{"$sum": 1}}}},
+		}
+	}
+
+	cursor, err := r.db.Aggregate(
+		ctx, mongo.Pipeline{
+			{{
+				Key: "$facet", Value: bson.M{
+					"tenants":  mongo.Pipeline{{{Key: "$group", Value: bson.M{"_id": "$is_active", "count": bson.M{"$sum": 1}}}}},
+					"plans":    subscriptions("plan"),
+					"payments": subscriptions("payment_status"),
+				},
+			}},
+		},
+	)
+	if err != nil {
+		r.logger.With(apperrors.ErrRetrievingTenants).Errorln(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+	defer cursor.Close(ctx)
+
+	type count struct {
+		Key   any `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	var facets []struct {
+		Tenants  []count `bson:"tenants"`
+		Plans    []count `bson:"plans"`
+		Payments []count `bson:"payments"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil {
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	stats := &entities.TenantStats{SubscriptionsByPlan: map[string]int{}, SubscriptionsByPaymentStatus: map[string]int{}}
+	if len(facets) == 0 {
+		return stats, nil
+	}
+	for _, c := range facets[0].Tenants {
+		if active, _ := c.Key.(bool); active {
+			stats.ActiveTenants += c.Count
+		} else {
+			stats.InactiveTenants += c.Count
+		}
+	}
+	// subscriptions without a plan or a status are counted under the empty one
+	for _, c := range facets[0].Plans {
+		plan, _ := c.Key.(string)
+		stats.SubscriptionsByPlan[plan] += c.Count
+	}
+	for _, c := range facets[0].Payments {
+		status, _ := c.Key.(string)
+		stats.SubscriptionsByPaymentStatus[status] += c.Count
+	}
+
+	return stats, nil
+}
+
+// describe returns the audit record and the domain events of an operation changing a tenant
+// from before to after, as far as they are tracked.
+func (r *TenantRepository) describe(
+	ctx context.Context, operation entities.AuditOperation, before *entities.Tenant, after *entities.Tenant,
+) (*change, error) {
+	c := &change{}
+	tenant := after
+	if tenant == nil {
+		tenant = before
+	}
+	id := tenant.ID
+
+	if r.settings.audit != nil {
+		record, err := audit.NewRecord(ctx, entities.AuditTenant, id, id, operation, before, after)
+		if err != nil {
+			return nil, err
+		}
+		c.record = record
+	}
+
+	if r.settings.outbox != nil {
+		raised, err := events.TenantEvents(ctx, operation, before, after)
+		if err != nil {
+			return nil, err
+		}
+		c.events = raised
+	}
+
+	return c, nil
+}
+
+func (r *TenantRepository) update(ctx context.Context, tenant *entities.Tenant) error {
 	r.logger.Infof("updating tenant in database: %v", tenant.ID)
 	result, err := r.db.UpdateOne(
 		ctx,
 		bson.M{"_id": tenant.ID},
-		bson.M{"$set": tenant},
+		bson.M{"$set": r.document(tenant)},
 	)
 	if err != nil {
 		r.logger.Errorf(apperrors.ErrUpdatingTenant, tenant.ID)
 		r.logger.Error(err)
-		return apperrors.ErrUpdatingTenantDocument
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
 	}
 
 	r.logger.Infof("updated %v documents", result.ModifiedCount)
@@ -142,25 +384,21 @@ func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Te
 // Ctx is used to cancel the operation if the context is cancelled.
 // Filter is the filter to be applied to the search.
 func (r *TenantRepository) SearchTenant(ctx context.Context, filter map[string]interface{}) (*entities.Tenant, error) {
-	var tenant *entities.Tenant
-
 	// get tenant from database
 	r.logger.Infof("retrieving tenant document from database with filter: %v", filter)
-	if err := r.db.FindOne(
-		ctx, filter,
-	).
-		Decode(&tenant); err != nil {
+	raw, err := r.db.FindOne(ctx, filter).DecodeBytes()
+	if err != nil {
 		switch err {
 		case mongo.ErrNoDocuments:
 			r.logger.With(filter).Info(apperrors.ErrNoTenantFound)
 			return nil, apperrors.ErrNoTenantDocumentsFound
 		default:
 			r.logger.With(filter).Error(err)
-			return nil, apperrors.ErrRetrievingTenantDocument
+			return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
 		}
 	}
 
-	return tenant, nil
+	return r.decode(ctx, raw)
 }
 
 // SearchTenants returns a list of tenants from the database.
@@ -169,25 +407,112 @@ func (r *TenantRepository) SearchTenant(ctx context.Context, filter map[string]i
 func (r *TenantRepository) SearchTenants(ctx context.Context, filter map[string]interface{}) (
 	[]*entities.Tenant, error,
 ) {
-	var tenants []*entities.Tenant
-
 	// get all tenants from database
 	r.logger.Infof("retrieving tenants from database with filter: %v", filter)
 	cursor, err := r.db.Find(ctx, filter)
 	if err != nil {
 		r.logger.With(filter).With(apperrors.ErrRetrievingTenants).Errorln(err)
-		return nil, apperrors.ErrRetrievingTenantDocument
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
 	}
 
 	defer cursor.Close(ctx)
 
 	// unmarshal all tenants into a slice
-	if err := cursor.All(ctx, &tenants); err != nil {
+	tenants, err := r.decodeAll(ctx, cursor)
+	if err != nil {
 		r.logger.With(filter).With(apperrors.ErrUnmarshallingTenant).Errorln(err)
-		return nil, apperrors.ErrUnmarshallingTenantDocument
+		return nil, err
 	}
 
 	r.logger.Infof("found %d tenants", len(tenants))
 
 	return tenants, nil
 }
+
+// tenantDocument is a tenant as stored, with the schema version it is written in.
+type tenantDocument struct {
+	entities.Tenant `bson:",inline"`
+	SchemaVersion   int `bson:"schema_version"`
+}
+
+// document returns the document a tenant is written as, of the latest schema version.
+func (r *TenantRepository) document(tenant *entities.Tenant) *tenantDocument {
+	return &tenantDocument{Tenant: *tenant, SchemaVersion: r.settings.migrations.Latest()}
+}
+
+// decode returns the tenant of a stored document. Documents of older schema versions are
+// upgraded first, and written back so that they are only upgraded once.
+func (r *TenantRepository) decode(ctx context.Context, raw bson.Raw) (*entities.Tenant, error) {
+	version, err := migrations.RawVersion(raw)
+	if err != nil {
+		return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+	}
+
+	if version < r.settings.migrations.Latest() {
+		if raw, err = r.upgrade(ctx, raw, version); err != nil {
+			return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+		}
+	}
+
+	var tenant entities.Tenant
+	if err := bson.Unmarshal(raw, &tenant); err != nil {
+		return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+	}
+
+	return &tenant, nil
+}
+
+func (r *TenantRepository) decodeAll(ctx context.Context, cursor *mongo.Cursor) ([]*entities.Tenant, error) {
+	defer cursor.Close(ctx)
+
+	tenants := make([]*entities.Tenant, 0)
+	for cursor.Next(ctx) {
+		tenant, err := r.decode(ctx, cursor.Current)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err := cursor.Err(); err != nil {
+		return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+	}
+
+	return tenants, nil
+}
+
+// upgrade applies the pending migrations of a document of version. The upgraded document is
+// written back unless it changed since it was read, failing to write it back is not an error,
+// the document is upgraded again on its next read.
+func (r *TenantRepository) upgrade(ctx context.Context, raw bson.Raw, version int) (bson.Raw, error) {
+	var document bson.M
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.settings.migrations.Upgrade(document); err != nil {
+		return nil, err
+	}
+
+	upgraded, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Infof("upgrading tenant %v from schema version %d", document["_id"], version)
+	if _, err := r.db.ReplaceOne(ctx, versionFilter(document["_id"], version), upgraded); err != nil {
+		r.logger.With(document["_id"]).Errorln(errors.Wrap(err, "failed to write back upgraded tenant"))
+	}
+
+	return upgraded, nil
+}
+
+// versionFilter matches a document as long as it is of version, documents written before
+// versioning have no version.
+func versionFilter(id any, version int) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, migrations.VersionField: bson.M{"$in": bson.A{nil, 0}}}
+	}
+
+	return bson.M{"_id": id, migrations.VersionField: version}
+}
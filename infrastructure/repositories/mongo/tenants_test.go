@@ -2,380 +2,210 @@ package mongo_test
 
 import (
 	"context"
+	"sort"
 	"testing"
 
 	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
 	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
 	"github.com/hebecoding/tenant-management/tests"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	mgo "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type TestTenantRepository struct {
+type TestStorage struct {
 	DB   *mgo.Collection
 	Repo *mongo.TenantRepository
 }
 
-var storage = &TestTenantRepository{}
+var storage = &TestStorage{}
 var logger utils.LoggerInterface
 var ctx = context.Background()
 
-func TestTenantRepository_Create(t *testing.T) {
-	var testCases = []struct {
-		Name          string
-		Tenant        *entities.Tenant
-		ExpectedError string
-		CancelContext bool
-	}{
-		{
-			Name:          "Happy Path: Create Tenant successfully",
-			Tenant:        tests.CreateTenant(),
-			ExpectedError: "",
-			CancelContext: false,
-		},
-	}
-
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
+func TestTenantRepository(t *testing.T) {
+	repositorytest.TestTenantRepository(
+		t, func(t *testing.T) repository.TenantRepository {
+			require.NoError(t, dropTestCollections())
+			t.Cleanup(
+				func() {
+					if err := dropTestCollections(); err != nil {
 						logger.Error(err)
 					}
-				}()
-
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
-
-				ctx, cancel := context.WithCancel(context.Background())
-				defer cancel()
-
-				if tt.CancelContext {
-					expectedErr = context.Canceled
-					cancel()
-				}
-
-				// when
-				if gotErr := storage.Repo.CreateTenant(ctx, tt.Tenant); gotErr != expectedErr {
-					assert.Equal(t, expectedErr, gotErr)
-				}
-			},
-		)
-	}
-}
-
-func TestTenantRepository_GetTenants(t *testing.T) {
-	var testCases = []struct {
-		Name          string
-		ExpectedError string
-		Tenants       []*entities.Tenant
-	}{
-		{
-			Name:          "Happy Path: Get Tenants successfully",
-			ExpectedError: "",
-			Tenants: func() []*entities.Tenant {
-				tenantSlice := []*entities.Tenant{}
-
-				for i := 0; i < 10; i++ {
-					tenant := tests.CreateTenant()
-					_ = storage.Repo.CreateTenant(ctx, tenant)
-					tenantSlice = append(tenantSlice, tenant)
-				}
-
-				return tenantSlice
-			}(),
+				},
+			)
+
+			// subdomains are unique by index
+			_, err := storage.DB.Indexes().CreateOne(
+				ctx, mgo.IndexModel{
+					Keys:    bson.D{{Key: "subdomain", Value: 1}},
+					Options: options.Index().SetUnique(true),
+				},
+			)
+			require.NoError(t, err)
+
+			return storage.Repo
 		},
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
-						logger.Error(err)
-					}
-				}()
-
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
-
-				tenants, gotErr := storage.Repo.GetTenants(ctx)
-				if gotErr != expectedErr {
-					assert.Fail(t, "expected error", expectedErr, "got error", gotErr)
-				}
-				assert.Len(t, tenants, len(tt.Tenants))
-			},
-		)
-	}
+	)
 }
 
-func TestTenantRepository_GetTenantByID(t *testing.T) {
-	var testCases = []struct {
-		Name          string
-		Tenant        *entities.Tenant
-		ExpectedError string
-	}{
-		{
-			Name: "Happy Path: Get Tenant by ID successfully",
-			Tenant: func() *entities.Tenant {
-				tenant := tests.CreateTenant()
-				tenant.IsActive = true
-				_ = storage.Repo.CreateTenant(ctx, tenant)
-				return tenant
-			}(),
-		},
-	}
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
+func BenchmarkTenantRepository(b *testing.B) {
+	repositorytest.BenchmarkTenantRepository(
+		b, func(b *testing.B) repository.TenantRepository {
+			require.NoError(b, dropTestCollections())
+			b.Cleanup(
+				func() {
+					if err := dropTestCollections(); err != nil {
 						logger.Error(err)
 					}
-				}()
-
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
-
-				tenant, gotErr := storage.Repo.GetTenantByID(ctx, tt.Tenant.ID)
-				if gotErr != nil {
-					assert.EqualError(t, gotErr, expectedErr.Error())
-				}
-
-				assert.EqualValues(t, tt.Tenant, tenant)
-
-			},
-		)
-	}
-}
-
-func TestTenantRepository_UpdateTenant(t *testing.T) {
-	var testCases = []struct {
-		Name          string
-		Tenant        entities.Tenant
-		UpdatedTenant entities.Tenant
-		ExpectedError string
-	}{
-		{
-			Name: "Happy Path: Update Tenant successfully",
-			Tenant: func() entities.Tenant {
-				tenant := tests.CreateTenant()
-				_ = storage.Repo.CreateTenant(ctx, tenant)
-
-				payDetailsID := tenant.PaymentDetails[0].ID
-				newPaymentDetails := tests.GeneratePaymentDetails()
-				newPaymentDetails.ID = payDetailsID
-
-				newTenant := entities.Tenant{}
-				newTenant.ID = tenant.ID
-				newTenant.PaymentDetails = append(newTenant.PaymentDetails, newPaymentDetails)
-				return newTenant
-			}(),
+				},
+			)
+
+			// benchmarks would otherwise mostly measure logging
+			quiet := utils.NewLogger(
+				utils.Config{
+					Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"},
+				},
+			)
+			repo := mongo.NewTenantRepository(storage.DB, quiet)
+
+			// the indexes of the service, searches by contact email use one
+			_, err := repo.ReconcileIndexes(ctx)
+			require.NoError(b, err)
+
+			return repo
 		},
-	}
+	)
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+func TestRolesRepository(t *testing.T) {
+	collection := storage.DB.Database().Collection("rbac")
 
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
+	repositorytest.TestRolesRepository(
+		t, func(t *testing.T) repository.RolesRepository {
+			require.NoError(t, collection.Drop(ctx))
+			t.Cleanup(
+				func() {
+					if err := collection.Drop(ctx); err != nil {
 						logger.Error(err)
 					}
-				}()
-
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
-
-				gotErr := storage.Repo.UpdateTenant(ctx, &tt.Tenant)
-				if gotErr != expectedErr {
-					assert.Fail(t, "expected error", expectedErr, "got error", gotErr)
-				}
-			},
-		)
-	}
-}
+				},
+			)
 
-func TestTenantRepository_DeleteTenant(t *testing.T) {
-	var testCases = []struct {
-		Name          string
-		TenantID      string
-		ExpectedError string
-	}{
-		{
-			Name: "Happy Path: Delete Tenant successfully",
-			TenantID: func() string {
-				tenant := tests.CreateTenant()
-				_ = storage.Repo.CreateTenant(ctx, tenant)
-				return tenant.ID
-			}(),
-			ExpectedError: "",
+			return mongo.NewRolesRepository(collection, logger)
 		},
-	}
+	)
+}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+func TestTenantRepository_PurgeTenant(t *testing.T) {
+	collection := storage.DB.Database().Collection("audit")
+	defer func() {
+		if err := collection.Drop(ctx); err != nil {
+			logger.Error(err)
+		}
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
 
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
-						logger.Error(err)
-					}
-				}()
+	trail := mongo.NewAuditRepository(collection, logger)
+	repo := mongo.NewTenantRepository(storage.DB, logger, mongo.WithAuditTrail(trail))
 
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
+	tenant := tests.CreateTenant()
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+	require.NoError(t, repo.PurgeTenant(ctx, tenant.ID))
 
-				if gotErr := storage.Repo.DeleteTenant(ctx, tt.TenantID); gotErr != expectedErr {
-					assert.ErrorContains(t, gotErr, expectedErr.Error())
-				}
+	count, err := storage.DB.CountDocuments(ctx, bson.M{"_id": tenant.ID})
+	require.NoError(t, err)
+	assert.Zero(t, count)
 
-				tenant, err := storage.Repo.GetTenantByID(ctx, tt.TenantID)
-				assert.NoError(t, err)
+	records, err := trail.QueryAuditRecords(ctx, entities.AuditFilter{TenantID: tenant.ID})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, entities.AuditDelete, records[1].Operation)
 
-				assert.False(t, tenant.IsActive)
-			},
-		)
-	}
+	err = repo.PurgeTenant(ctx, tenant.ID)
+	assert.True(t, errors.Is(err, apperrors.ErrNoTenantDocumentsFound), "unexpected error: %v", err)
 }
 
-func TestTenantRepository_SearchTenant(t *testing.T) {
-
-	tenant := tests.CreateTenant()
-	_ = storage.Repo.CreateTenant(ctx, tenant)
-
-	var testCases = []struct {
-		Name          string
-		TenantID      string
-		SearchParams  map[string]any
-		ExpectedError string
-	}{
-		{
-			Name:     "Happy Path: Search Tenant successfully",
-			TenantID: tenant.ID,
-			SearchParams: map[string]any{
-				"payment_details._id": tenant.PaymentDetails[0].ID,
-			},
-			ExpectedError: "",
-		},
+func TestTenantRepository_EachTenant(t *testing.T) {
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	repo := mongo.NewTenantRepository(storage.DB, logger)
+
+	var created []string
+	for i := 0; i < 3; i++ {
+		tenant := tests.CreateTenant()
+		tenant.IsActive = i != 1
+		require.NoError(t, repo.CreateTenant(ctx, tenant))
+		if tenant.IsActive {
+			created = append(created, tenant.ID)
+		}
 	}
+	sort.Strings(created)
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	var listed []string
+	err := repo.EachTenant(
+		ctx, map[string]interface{}{"is_active": true}, func(tenant *entities.Tenant) error {
+			listed = append(listed, tenant.ID)
+			return nil
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, created, listed)
 
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
-						logger.Error(err)
-					}
-				}()
-
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
-
-				expectedTenant, err := storage.Repo.GetTenantByID(ctx, tt.TenantID)
-				assert.Nil(t, err)
-
-				gotTenant, gotErr := storage.Repo.SearchTenant(ctx, tt.SearchParams)
-				if gotErr != expectedErr {
-					assert.Nil(t, gotErr)
-				}
-				assert.EqualValues(t, expectedTenant, gotTenant)
-			},
-		)
-	}
+	stop := errors.New("stop")
+	err = repo.EachTenant(ctx, nil, func(*entities.Tenant) error { return stop })
+	assert.Equal(t, stop, err)
 }
 
-func TestTenantRepository_SearchTenants(t *testing.T) {
-	var testCases = []struct {
-		Name          string
-		SearchParams  map[string]any
-		ExpectedError string
-		Tenants       []*entities.Tenant
-	}{
-		{
-			Name: "Happy Path: Search Tenants successfully",
-			SearchParams: map[string]any{
-				"is_active": true,
-			},
-			Tenants: func() []*entities.Tenant {
-				var tenants []*entities.Tenant
-				for i := 0; i < 5; i++ {
-					tenant := tests.CreateTenant()
-					tenant.IsActive = true
-					_ = storage.Repo.CreateTenant(ctx, tenant)
-					tenants = append(tenants, tenant)
-				}
-				return tenants
-			}(),
-		},
-	}
+func TestTenantRepository_TenantStats(t *testing.T) {
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	repo := mongo.NewTenantRepository(storage.DB, logger)
+	stats, err := repo.TenantStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.NewTenantStats(nil), stats)
 
-	// run test cases
-	for _, tt := range testCases {
-		t.Run(
-			tt.Name, func(t *testing.T) {
-				defer func() {
-					err := dropTestCollections()
-					if err != nil {
-						logger.Error(err)
-					}
-				}()
-
-				var expectedErr error
-				if tt.ExpectedError != "" {
-					expectedErr = errors.New(tt.ExpectedError)
-				}
+	tenants := tests.NewGenerator(tests.WithSeed(1)).Tenants(20)
+	require.NoError(t, repo.InsertTenants(ctx, tenants))
 
-				tenants, gotErr := storage.Repo.SearchTenants(ctx, tt.SearchParams)
-				if gotErr != expectedErr {
-					assert.NoError(t, gotErr)
-				}
+	stats, err = repo.TenantStats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.NewTenantStats(tenants), stats)
+}
 
-				assert.Len(t, tenants, len(tt.Tenants))
-			},
-		)
+func TestTenantRepository_InsertTenants(t *testing.T) {
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	repo := mongo.NewTenantRepository(storage.DB, logger)
+	tenants := tests.CreateTenantList(5)
+	require.NoError(t, repo.InsertTenants(ctx, tenants))
+
+	for _, tenant := range tenants {
+		found, err := repo.GetTenantByID(ctx, tenant.ID)
+		require.NoError(t, err)
+		assert.Equal(t, tenant.Subdomain, found.Subdomain)
 	}
+
+	err := repo.InsertTenants(ctx, tenants[:1])
+	assert.True(t, errors.Is(err, apperrors.ErrCreatingTenantDocument), "unexpected error: %v", err)
 }
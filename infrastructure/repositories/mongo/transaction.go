@@ -0,0 +1,65 @@
+package mongo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transactionSupport caches whether the deployment of a client supports transactions.
+var transactionSupport sync.Map
+
+// withTransaction runs fn in a transaction when the deployment of collection supports them,
+// which requires a replica set or a sharded cluster. Standalone servers run fn as is, so its
+// writes are not atomic there.
+func withTransaction(ctx context.Context, collection *mongo.Collection, fn func(ctx context.Context) error) error {
+	client := collection.Database().Client()
+
+	supported, err := supportsTransactions(ctx, collection)
+	if err != nil {
+		return err
+	}
+
+	if !supported {
+		return fn(ctx)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return errors.Wrap(err, "failed to start session")
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(
+		ctx, func(sessionCtx mongo.SessionContext) (any, error) {
+			return nil, fn(sessionCtx)
+		},
+	)
+
+	return err
+}
+
+func supportsTransactions(ctx context.Context, collection *mongo.Collection) (bool, error) {
+	client := collection.Database().Client()
+	if supported, ok := transactionSupport.Load(client); ok {
+		return supported.(bool), nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := collection.Database().RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to describe mongo deployment")
+	}
+
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	transactionSupport.Store(client, supported)
+
+	return supported, nil
+}
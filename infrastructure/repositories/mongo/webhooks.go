@@ -0,0 +1,280 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// succeededDeliveryRetention is how long the logs of succeeded deliveries are kept.
+// Dead deliveries are kept until they are redelivered.
+const succeededDeliveryRetention = 30 * 24 * time.Hour
+
+// WebhookRepository stores the webhook endpoints of tenants and the deliveries of events to them.
+type WebhookRepository struct {
+	endpoints  *mongo.Collection
+	deliveries *mongo.Collection
+	logger     utils.LoggerInterface
+}
+
+func NewWebhookRepository(
+	endpoints *mongo.Collection, deliveries *mongo.Collection, logger utils.LoggerInterface,
+) *WebhookRepository {
+	return &WebhookRepository{
+		endpoints:  endpoints,
+		deliveries: deliveries,
+		logger:     logger,
+	}
+}
+
+// CreateIndexes creates the indexes of endpoints and deliveries, and the TTL index removing
+// the logs of succeeded deliveries.
+func (r *WebhookRepository) CreateIndexes(ctx context.Context) error {
+	_, err := r.endpoints.Indexes().CreateOne(
+		ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "tenant_id", Value: 1}},
+			Options: options.Index().SetName("tenant_id"),
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook endpoint indexes")
+	}
+
+	_, err = r.deliveries.Indexes().CreateMany(
+		ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+				Options: options.Index().SetName("status_next_attempt_at"),
+			},
+			{
+				Keys:    bson.D{{Key: "tenant_id", Value: 1}, {Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("tenant_id_created_at"),
+			},
+			{
+				Keys: bson.D{{Key: "completed_at", Value: 1}},
+				Options: options.Index().SetName("succeeded_completed_at").
+					SetExpireAfterSeconds(int32(succeededDeliveryRetention.Seconds())).
+					SetPartialFilterExpression(bson.M{"status": entities.WebhookSucceeded}),
+			},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create webhook delivery indexes")
+	}
+
+	return nil
+}
+
+// SaveEndpoint creates a webhook endpoint.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) SaveEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	r.logger.Infof("inserting webhook into database: %v", endpoint.ID)
+	if _, err := r.endpoints.InsertOne(ctx, endpoint); err != nil {
+		r.logger.Error(err)
+		return apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	return nil
+}
+
+// UpdateEndpoint replaces a webhook endpoint.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) UpdateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	r.logger.Infof("updating webhook in database: %v", endpoint.ID)
+	result, err := r.endpoints.ReplaceOne(
+		ctx, bson.M{"_id": endpoint.ID, "tenant_id": endpoint.TenantID}, endpoint,
+	)
+	if err != nil {
+		r.logger.Error(err)
+		return apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperrors.ErrNoWebhookFound
+	}
+
+	return nil
+}
+
+// DeleteEndpoint removes a webhook endpoint of a tenant.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) DeleteEndpoint(ctx context.Context, tenantID string, endpointID string) error {
+	r.logger.Infof("deleting webhook from database: %v", endpointID)
+	result, err := r.endpoints.DeleteOne(ctx, bson.M{"_id": endpointID, "tenant_id": tenantID})
+	if err != nil {
+		r.logger.Error(err)
+		return apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	if result.DeletedCount == 0 {
+		return apperrors.ErrNoWebhookFound
+	}
+
+	return nil
+}
+
+// FindEndpoint returns a webhook endpoint of a tenant.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) FindEndpoint(ctx context.Context, tenantID string, endpointID string) (
+	*entities.WebhookEndpoint, error,
+) {
+	var endpoint *entities.WebhookEndpoint
+	err := r.endpoints.FindOne(ctx, bson.M{"_id": endpointID, "tenant_id": tenantID}).Decode(&endpoint)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return nil, apperrors.ErrNoWebhookFound
+	case err != nil:
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingWebhooks.Wrap(err)
+	}
+
+	return endpoint, nil
+}
+
+// FindEndpoints returns the webhook endpoints of a tenant.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) FindEndpoints(ctx context.Context, tenantID string) (
+	[]*entities.WebhookEndpoint, error,
+) {
+	cursor, err := r.endpoints.Find(
+		ctx, bson.M{"tenant_id": tenantID}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingWebhooks.Wrap(err)
+	}
+
+	defer cursor.Close(ctx)
+
+	var endpoints []*entities.WebhookEndpoint
+	if err := cursor.All(ctx, &endpoints); err != nil {
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingWebhooks.Wrap(err)
+	}
+
+	return endpoints, nil
+}
+
+// SaveDelivery creates a delivery, saving a delivery again does nothing.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) SaveDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	if _, err := r.deliveries.InsertOne(ctx, delivery); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			r.logger.Infof("webhook delivery %s already exists", delivery.ID)
+			return nil
+		}
+
+		r.logger.Error(err)
+		return apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	return nil
+}
+
+// UpdateDelivery replaces a delivery.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	result, err := r.deliveries.ReplaceOne(ctx, bson.M{"_id": delivery.ID}, delivery)
+	if err != nil {
+		r.logger.Error(err)
+		return apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return apperrors.ErrNoWebhookDeliveryFound
+	}
+
+	return nil
+}
+
+// FindDelivery returns a delivery of a tenant.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) FindDelivery(ctx context.Context, tenantID string, deliveryID string) (
+	*entities.WebhookDelivery, error,
+) {
+	var delivery *entities.WebhookDelivery
+	err := r.deliveries.FindOne(ctx, bson.M{"_id": deliveryID, "tenant_id": tenantID}).Decode(&delivery)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return nil, apperrors.ErrNoWebhookDeliveryFound
+	case err != nil:
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingWebhooks.Wrap(err)
+	}
+
+	return delivery, nil
+}
+
+// FindDeliveries returns the deliveries matching filter, newest first.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) FindDeliveries(ctx context.Context, filter entities.WebhookDeliveryFilter) (
+	[]*entities.WebhookDelivery, error,
+) {
+	query := bson.M{"tenant_id": filter.TenantID}
+	if filter.EndpointID != "" {
+		query["endpoint_id"] = filter.EndpointID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if filter.Limit > 0 {
+		opts.SetLimit(int64(filter.Limit))
+	}
+
+	cursor, err := r.deliveries.Find(ctx, query, opts)
+	if err != nil {
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingWebhooks.Wrap(err)
+	}
+
+	defer cursor.Close(ctx)
+
+	var deliveries []*entities.WebhookDelivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingWebhooks.Wrap(err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDeliveries leases up to limit pending deliveries due for an attempt, oldest first.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) (
+	[]*entities.WebhookDelivery, error,
+) {
+	now := time.Now().UTC()
+
+	var deliveries []*entities.WebhookDelivery
+	for len(deliveries) < limit {
+		var delivery *entities.WebhookDelivery
+		err := r.deliveries.FindOneAndUpdate(
+			ctx,
+			bson.M{"status": entities.WebhookPending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}},
+			options.FindOneAndUpdate().
+				SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+				SetReturnDocument(options.After),
+		).Decode(&delivery)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+		if err != nil {
+			r.logger.Error(err)
+			return deliveries, apperrors.ErrRetrievingWebhooks.Wrap(err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
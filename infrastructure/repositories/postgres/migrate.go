@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the key of the advisory lock taken while migrating, so instances starting
+// together apply migrations once.
+const migrationLock = 0x7461626c65
+
+// Migration is a versioned change of the schema, read from migrations/<version>_<name>.sql.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Migrations returns the migrations of the schema in order.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read migrations")
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		version, description, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, errors.Errorf("migration %s is not named <version>_<name>.sql", entry.Name())
+		}
+
+		number, err := strconv.Atoi(version)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid version of migration %s", entry.Name())
+		}
+
+		content, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read migration %s", entry.Name())
+		}
+
+		migrations = append(migrations, Migration{Version: number, Name: description, SQL: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, errors.Errorf("migrations %s and %s share version %d",
+				migrations[i-1].Name, migrations[i].Name, migrations[i].Version)
+		}
+	}
+
+	return migrations, nil
+}
+
+// Migrate applies the migrations not applied yet, recording them in the schema_migrations table.
+// Migrations are applied in a single transaction, either all of them are or none is.
+func Migrate(ctx context.Context, pool *pgxpool.Pool, logger utils.LoggerInterface) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	return pgx.BeginFunc(
+		ctx, pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLock); err != nil {
+				return errors.Wrap(err, "failed to lock schema migrations")
+			}
+
+			_, err := tx.Exec(
+				ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+					version    INTEGER PRIMARY KEY,
+					name       TEXT        NOT NULL,
+					applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+				)`,
+			)
+			if err != nil {
+				return errors.Wrap(err, "failed to create schema migrations table")
+			}
+
+			var current int
+			if err := tx.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").
+				Scan(&current); err != nil {
+				return errors.Wrap(err, "failed to read schema version")
+			}
+
+			for _, migration := range migrations {
+				if migration.Version <= current {
+					continue
+				}
+
+				logger.Infof("applying migration %d %s", migration.Version, migration.Name)
+				if _, err := tx.Exec(ctx, migration.SQL); err != nil {
+					return errors.Wrapf(err, "failed to apply migration %d %s", migration.Version, migration.Name)
+				}
+
+				_, err := tx.Exec(
+					ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+					migration.Version, migration.Name,
+				)
+				if err != nil {
+					return errors.Wrapf(err, "failed to record migration %d %s", migration.Version, migration.Name)
+				}
+			}
+
+			return nil
+		},
+	)
+}
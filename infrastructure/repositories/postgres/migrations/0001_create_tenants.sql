@@ -0,0 +1,97 @@
+-- tenants keep their scalar fields in columns, custom domains, api keys and metadata are
+-- documents of their own kept as jsonb
+CREATE TABLE tenants (
+    id         TEXT PRIMARY KEY,
+    name       TEXT        NOT NULL,
+    subdomain  TEXT        NOT NULL,
+    updated_by TEXT        NOT NULL,
+    is_active  BOOLEAN     NOT NULL,
+    metadata   JSONB,
+    domains    JSONB,
+    api_keys   JSONB,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX tenants_subdomain ON tenants (subdomain);
+CREATE INDEX tenants_is_active ON tenants (is_active);
+CREATE INDEX tenants_domains ON tenants USING GIN (domains jsonb_path_ops);
+CREATE INDEX tenants_api_keys ON tenants USING GIN (api_keys jsonb_path_ops);
+
+-- rows of the collections of a tenant keep their position in the tenant, the IDs of the
+-- entities are not required to be set, hence the surrogate keys
+CREATE TABLE companies (
+    key                 BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    tenant_id           TEXT    NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    position            INTEGER NOT NULL,
+    id                  TEXT    NOT NULL,
+    name                TEXT    NOT NULL,
+    website_url         TEXT    NOT NULL,
+    logo_url            TEXT    NOT NULL,
+    industry            TEXT    NOT NULL,
+    registration_number TEXT    NOT NULL,
+    is_active           BOOLEAN NOT NULL,
+    address             JSONB,
+    UNIQUE (tenant_id, position)
+);
+
+CREATE INDEX companies_id ON companies (id);
+
+CREATE TABLE subscriptions (
+    company_key       BIGINT           NOT NULL REFERENCES companies (key) ON DELETE CASCADE,
+    position          INTEGER          NOT NULL,
+    id                TEXT             NOT NULL,
+    plan              TEXT             NOT NULL,
+    billing_cycle     TEXT             NOT NULL,
+    payment_status    TEXT             NOT NULL,
+    payment_gateway   TEXT             NOT NULL,
+    discount_rate     DOUBLE PRECISION NOT NULL,
+    discount          BOOLEAN          NOT NULL,
+    active            BOOLEAN          NOT NULL,
+    auto_renew        BOOLEAN          NOT NULL,
+    start_date        TIMESTAMPTZ      NOT NULL,
+    end_date          TIMESTAMPTZ      NOT NULL,
+    next_billing_date TIMESTAMPTZ      NOT NULL,
+    last_payment_date TIMESTAMPTZ      NOT NULL,
+    PRIMARY KEY (company_key, position)
+);
+
+CREATE INDEX subscriptions_id ON subscriptions (id);
+CREATE INDEX subscriptions_plan ON subscriptions (plan);
+
+CREATE TABLE payment_methods (
+    tenant_id       TEXT    NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    position        INTEGER NOT NULL,
+    id              TEXT    NOT NULL,
+    billing_address JSONB,
+    card_type       TEXT    NOT NULL,
+    card_number     TEXT    NOT NULL,
+    security_code   TEXT    NOT NULL,
+    exp_month       INTEGER NOT NULL,
+    exp_year        INTEGER NOT NULL,
+    is_active       BOOLEAN NOT NULL,
+    PRIMARY KEY (tenant_id, position)
+);
+
+CREATE INDEX payment_methods_id ON payment_methods (id);
+
+CREATE TABLE contacts (
+    key                BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    tenant_id          TEXT    NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    position           INTEGER NOT NULL,
+    id                 TEXT    NOT NULL,
+    first_name         TEXT    NOT NULL,
+    last_name          TEXT    NOT NULL,
+    email              TEXT    NOT NULL,
+    phone_number       TEXT    NOT NULL,
+    avatar_url         TEXT    NOT NULL,
+    job_title          TEXT    NOT NULL,
+    preferred_language TEXT    NOT NULL,
+    timezone           TEXT    NOT NULL,
+    is_active          BOOLEAN NOT NULL,
+    UNIQUE (tenant_id, position)
+);
+
+CREATE INDEX contacts_id ON contacts (id);
+CREATE INDEX contacts_email ON contacts (email);
@@ -0,0 +1,19 @@
+-- roles are defined once and assigned to contacts, platform roles have no tenant
+CREATE TABLE roles (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT   NOT NULL,
+    name        TEXT   NOT NULL,
+    description TEXT   NOT NULL,
+    permissions TEXT[]
+);
+
+CREATE INDEX roles_tenant_id ON roles (tenant_id);
+
+CREATE TABLE contact_roles (
+    contact_key BIGINT  NOT NULL REFERENCES contacts (key) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    role_id     TEXT    NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    PRIMARY KEY (contact_key, position)
+);
+
+CREATE INDEX contact_roles_role_id ON contact_roles (role_id);
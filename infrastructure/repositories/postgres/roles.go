@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// RolesRepository stores roles in the roles table, contacts are assigned roles from it.
+type RolesRepository struct {
+	db     *pgxpool.Pool
+	logger utils.LoggerInterface
+}
+
+func NewRolesRepository(db *pgxpool.Pool, logger utils.LoggerInterface) *RolesRepository {
+	return &RolesRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveRole stores a new role, roles with the ID of another role are refused.
+func (r *RolesRepository) SaveRole(ctx context.Context, role *entities.Role) error {
+	_, err := r.db.Exec(
+		ctx, "INSERT INTO roles (id, tenant_id, name, description, permissions) VALUES ($1, $2, $3, $4, $5)",
+		role.ID, role.TenantID, role.Name, role.Description, permissionNames(role.Permissions),
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingRole, role.ID)
+		r.logger.Error(err)
+		return apperrors.ErrCreatingRoleDocument.Wrap(err)
+	}
+
+	return nil
+}
+
+// UpdateRole replaces a role.
+func (r *RolesRepository) UpdateRole(ctx context.Context, role *entities.Role) error {
+	result, err := r.db.Exec(
+		ctx, "UPDATE roles SET tenant_id = $2, name = $3, description = $4, permissions = $5 WHERE id = $1",
+		role.ID, role.TenantID, role.Name, role.Description, permissionNames(role.Permissions),
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrUpdatingRole, role.ID)
+		r.logger.Error(err)
+		return apperrors.ErrUpdatingRoleDocument.Wrap(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, role.ID)
+		return apperrors.ErrNoRoleDocumentsFound
+	}
+
+	return nil
+}
+
+// DeleteRole removes a role, and unassigns it from contacts.
+func (r *RolesRepository) DeleteRole(ctx context.Context, roleID utils.XID) error {
+	result, err := r.db.Exec(ctx, "DELETE FROM roles WHERE id = $1", roleID.ID)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrDeletingRole, roleID.ID)
+		r.logger.Error(err)
+		return apperrors.ErrDeletingRoleDocument.Wrap(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+		return apperrors.ErrNoRoleDocumentsFound
+	}
+
+	return nil
+}
+
+// FindRoleByID returns a role.
+func (r *RolesRepository) FindRoleByID(ctx context.Context, roleID utils.XID) (*entities.Role, error) {
+	role, err := scanRole(
+		r.db.QueryRow(
+			ctx, "SELECT id, tenant_id, name, description, permissions FROM roles WHERE id = $1", roleID.ID,
+		),
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+		return nil, apperrors.ErrNoRoleDocumentsFound
+	}
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrRetrievingRole, roleID.ID)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	return role, nil
+}
+
+// FindAllRoles returns every role, ordered by ID.
+func (r *RolesRepository) FindAllRoles(ctx context.Context) ([]*entities.Role, error) {
+	rows, err := r.db.Query(ctx, "SELECT id, tenant_id, name, description, permissions FROM roles ORDER BY id")
+	if err != nil {
+		r.logger.Error(apperrors.ErrRetrievingRoles)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+	defer rows.Close()
+
+	roles := make([]*entities.Role, 0)
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error(apperrors.ErrRetrievingRoles)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	return roles, nil
+}
@@ -0,0 +1,547 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+)
+
+// tenantRow is a row of the tenants table.
+type tenantRow struct {
+	ID        string
+	Name      string
+	Subdomain string
+	UpdatedBy string
+	IsActive  bool
+	Metadata  []byte
+	Domains   []byte
+	APIKeys   []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt time.Time
+}
+
+func newTenantRow(tenant *entities.Tenant) (*tenantRow, error) {
+	metadata, err := json.Marshal(tenant.TenantMetadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode tenant metadata")
+	}
+
+	domains, err := json.Marshal(tenant.Domains)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode tenant domains")
+	}
+
+	apiKeys, err := json.Marshal(newAPIKeyDocuments(tenant.APIKeys))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode tenant api keys")
+	}
+
+	return &tenantRow{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		Subdomain: tenant.Subdomain,
+		UpdatedBy: tenant.UpdatedBy,
+		IsActive:  tenant.IsActive,
+		Metadata:  metadata,
+		Domains:   domains,
+		APIKeys:   apiKeys,
+		CreatedAt: tenant.CreatedAt,
+		UpdatedAt: tenant.UpdatedAt,
+		DeletedAt: tenant.DeletedAt,
+	}, nil
+}
+
+// args returns the values of the row in the order of its columns.
+func (r *tenantRow) args() []any {
+	return []any{
+		r.ID, r.Name, r.Subdomain, r.UpdatedBy, r.IsActive, r.Metadata, r.Domains, r.APIKeys,
+		r.CreatedAt, r.UpdatedAt, r.DeletedAt,
+	}
+}
+
+// fields returns the destinations of the columns of the row, in order.
+func (r *tenantRow) fields() []any {
+	return []any{
+		&r.ID, &r.Name, &r.Subdomain, &r.UpdatedBy, &r.IsActive, &r.Metadata, &r.Domains, &r.APIKeys,
+		&r.CreatedAt, &r.UpdatedAt, &r.DeletedAt,
+	}
+}
+
+func (r *tenantRow) tenant() (*entities.Tenant, error) {
+	tenant := &entities.Tenant{
+		ID:              r.ID,
+		Name:            r.Name,
+		Subdomain:       r.Subdomain,
+		UpdatedBy:       r.UpdatedBy,
+		IsActive:        r.IsActive,
+		Companies:       []*entities.TenantCompanyDetails{},
+		PaymentDetails:  []*entities.TenantPaymentDetails{},
+		PrimaryContacts: []*entities.TenantContactDetails{},
+		CreatedAt:       r.CreatedAt.UTC(),
+		UpdatedAt:       r.UpdatedAt.UTC(),
+		DeletedAt:       r.DeletedAt.UTC(),
+	}
+
+	if err := decodeJSON(r.Metadata, &tenant.TenantMetadata); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode metadata of tenant %s", r.ID)
+	}
+
+	if err := decodeJSON(r.Domains, &tenant.Domains); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode domains of tenant %s", r.ID)
+	}
+
+	var apiKeys []*apiKeyDocument
+	if err := decodeJSON(r.APIKeys, &apiKeys); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode api keys of tenant %s", r.ID)
+	}
+	tenant.APIKeys = apiKeyEntities(apiKeys)
+
+	return tenant, nil
+}
+
+func decodeJSON(data []byte, v any) error {
+	if data == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// apiKeyDocument is an api key as kept in the api_keys column. The json encoding of
+// entities.APIKey leaves the salt and hash out, and validates permissions.
+type apiKeyDocument struct {
+	ID         string                `json:"_id"`
+	Name       string                `json:"name"`
+	Prefix     string                `json:"prefix"`
+	Salt       string                `json:"salt"`
+	Hash       string                `json:"hash"`
+	Scopes     []apiKeyScopeDocument `json:"scopes"`
+	CreatedBy  string                `json:"created_by"`
+	CreatedAt  time.Time             `json:"created_at"`
+	ExpiresAt  time.Time             `json:"expires_at"`
+	LastUsedAt time.Time             `json:"last_used_at"`
+	RevokedAt  time.Time             `json:"revoked_at"`
+}
+
+type apiKeyScopeDocument struct {
+	Resource    string   `json:"resource"`
+	Permissions []string `json:"permissions"`
+}
+
+func newAPIKeyDocuments(keys []*entities.APIKey) []*apiKeyDocument {
+	if keys == nil {
+		return nil
+	}
+
+	documents := make([]*apiKeyDocument, 0, len(keys))
+	for _, key := range keys {
+		if key == nil {
+			continue
+		}
+
+		var scopes []apiKeyScopeDocument
+		if key.Scopes != nil {
+			scopes = make([]apiKeyScopeDocument, 0, len(key.Scopes))
+		}
+		for _, scope := range key.Scopes {
+			scopes = append(
+				scopes, apiKeyScopeDocument{Resource: string(scope.Resource), Permissions: permissionNames(scope.Permissions)},
+			)
+		}
+
+		documents = append(
+			documents, &apiKeyDocument{
+				ID:         key.ID,
+				Name:       key.Name,
+				Prefix:     key.Prefix,
+				Salt:       key.Salt,
+				Hash:       key.Hash,
+				Scopes:     scopes,
+				CreatedBy:  key.CreatedBy,
+				CreatedAt:  key.CreatedAt,
+				ExpiresAt:  key.ExpiresAt,
+				LastUsedAt: key.LastUsedAt,
+				RevokedAt:  key.RevokedAt,
+			},
+		)
+	}
+
+	return documents
+}
+
+func apiKeyEntities(documents []*apiKeyDocument) []*entities.APIKey {
+	if documents == nil {
+		return nil
+	}
+
+	keys := make([]*entities.APIKey, 0, len(documents))
+	for _, document := range documents {
+		var scopes []entities.APIKeyScope
+		if document.Scopes != nil {
+			scopes = make([]entities.APIKeyScope, 0, len(document.Scopes))
+		}
+		for _, scope := range document.Scopes {
+			scopes = append(
+				scopes, entities.APIKeyScope{
+					Resource: entities.Resource(scope.Resource), Permissions: permissions(scope.Permissions),
+				},
+			)
+		}
+
+		keys = append(
+			keys, &entities.APIKey{
+				ID:         document.ID,
+				Name:       document.Name,
+				Prefix:     document.Prefix,
+				Salt:       document.Salt,
+				Hash:       document.Hash,
+				Scopes:     scopes,
+				CreatedBy:  document.CreatedBy,
+				CreatedAt:  document.CreatedAt,
+				ExpiresAt:  document.ExpiresAt,
+				LastUsedAt: document.LastUsedAt,
+				RevokedAt:  document.RevokedAt,
+			},
+		)
+	}
+
+	return keys
+}
+
+func permissionNames(permissions []entities.Permission) []string {
+	if permissions == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		names = append(names, string(permission))
+	}
+
+	return names
+}
+
+func permissions(names []string) []entities.Permission {
+	if names == nil {
+		return nil
+	}
+
+	permissions := make([]entities.Permission, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, entities.Permission(name))
+	}
+
+	return permissions
+}
+
+// insertChildren inserts the companies, payment methods and contacts of a tenant. The roles
+// of contacts unknown to the roles table are created from the copies held by the contacts.
+func insertChildren(ctx context.Context, tx pgx.Tx, tenant *entities.Tenant) error {
+	for position, company := range tenant.Companies {
+		if company == nil {
+			continue
+		}
+
+		address, err := json.Marshal(company.Address)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode address of company %s", company.ID)
+		}
+
+		var key int64
+		err = tx.QueryRow(
+			ctx, `INSERT INTO companies (
+				tenant_id, position, id, name, website_url, logo_url, industry, registration_number,
+				is_active, address
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING key`,
+			tenant.ID, position, company.ID, company.Name, company.WebsiteURL, company.LogoURL,
+			company.Industry, company.RegistrationNumber, company.IsActive, address,
+		).Scan(&key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert company %s", company.ID)
+		}
+
+		for position, subscription := range company.Subscriptions {
+			if subscription == nil {
+				continue
+			}
+
+			_, err := tx.Exec(
+				ctx, `INSERT INTO subscriptions (
+					company_key, position, id, plan, billing_cycle, payment_status, payment_gateway,
+					discount_rate, discount, active, auto_renew, start_date, end_date, next_billing_date,
+					last_payment_date
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+				key, position, subscription.ID, subscription.Plan, subscription.BillingCycle,
+				subscription.PaymentStatus, subscription.PaymentGateway, subscription.DiscountRate,
+				subscription.Discount, subscription.Active, subscription.AutoRenew, subscription.StartDate,
+				subscription.EndDate, subscription.NextBillingDate, subscription.LastPaymentDate,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to insert subscription %s", subscription.ID)
+			}
+		}
+	}
+
+	for position, payment := range tenant.PaymentDetails {
+		if payment == nil {
+			continue
+		}
+
+		address, err := json.Marshal(payment.Address)
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode billing address of payment method %s", payment.ID)
+		}
+
+		_, err = tx.Exec(
+			ctx, `INSERT INTO payment_methods (
+				tenant_id, position, id, billing_address, card_type, card_number, security_code,
+				exp_month, exp_year, is_active
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			tenant.ID, position, payment.ID, address, payment.CardType, payment.CardNumber,
+			payment.SecurityCode, payment.ExpMonth, payment.ExpYear, payment.IsActive,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert payment method %s", payment.ID)
+		}
+	}
+
+	for position, contact := range tenant.PrimaryContacts {
+		if contact == nil {
+			continue
+		}
+
+		var key int64
+		err := tx.QueryRow(
+			ctx, `INSERT INTO contacts (
+				tenant_id, position, id, first_name, last_name, email, phone_number, avatar_url,
+				job_title, preferred_language, timezone, is_active
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING key`,
+			tenant.ID, position, contact.ID, contact.FirstName, contact.LastName, contact.Email,
+			contact.PhoneNumber, contact.AvatarURL, contact.JobTitle, contact.PreferredLanguage,
+			contact.Timezone, contact.IsActive,
+		).Scan(&key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to insert contact %s", contact.ID)
+		}
+
+		for position, role := range contact.Roles {
+			if role == nil {
+				continue
+			}
+
+			_, err := tx.Exec(
+				ctx, `INSERT INTO roles (id, tenant_id, name, description, permissions)
+				VALUES ($1, $2, $3, $4, $5) ON CONFLICT (id) DO NOTHING`,
+				role.ID, role.TenantID, role.Name, role.Description, permissionNames(role.Permissions),
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to insert role %s", role.ID)
+			}
+
+			_, err = tx.Exec(
+				ctx, "INSERT INTO contact_roles (contact_key, position, role_id) VALUES ($1, $2, $3)",
+				key, position, role.ID,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "failed to assign role %s to contact %s", role.ID, contact.ID)
+			}
+		}
+	}
+
+	return nil
+}
+
+// loadChildren reads the companies, payment methods and contacts of tenants, by ID.
+func loadChildren(ctx context.Context, tx pgx.Tx, tenants map[string]*entities.Tenant) error {
+	ids := make([]string, 0, len(tenants))
+	for id := range tenants {
+		ids = append(ids, id)
+	}
+
+	companies := map[int64]*entities.TenantCompanyDetails{}
+	err := each(
+		ctx, tx, `SELECT key, tenant_id, id, name, website_url, logo_url, industry, registration_number,
+			is_active, address
+		FROM companies WHERE tenant_id = ANY($1) ORDER BY tenant_id, position`,
+		ids, func(rows pgx.Rows) error {
+			var (
+				key      int64
+				tenantID string
+				address  []byte
+				company  = &entities.TenantCompanyDetails{Subscriptions: []*entities.TenantSubscriptionDetails{}}
+			)
+			err := rows.Scan(
+				&key, &tenantID, &company.ID, &company.Name, &company.WebsiteURL, &company.LogoURL,
+				&company.Industry, &company.RegistrationNumber, &company.IsActive, &address,
+			)
+			if err != nil {
+				return err
+			}
+			if err := decodeJSON(address, &company.Address); err != nil {
+				return errors.Wrapf(err, "failed to decode address of company %s", company.ID)
+			}
+
+			companies[key] = company
+			tenant := tenants[tenantID]
+			tenant.Companies = append(tenant.Companies, company)
+			return nil
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read companies")
+	}
+
+	err = each(
+		ctx, tx, `SELECT s.company_key, s.id, s.plan, s.billing_cycle, s.payment_status, s.payment_gateway,
+			s.discount_rate, s.discount, s.active, s.auto_renew, s.start_date, s.end_date,
+			s.next_billing_date, s.last_payment_date
+		FROM subscriptions s JOIN companies c ON c.key = s.company_key
+		WHERE c.tenant_id = ANY($1) ORDER BY s.company_key, s.position`,
+		ids, func(rows pgx.Rows) error {
+			var (
+				companyKey   int64
+				subscription = &entities.TenantSubscriptionDetails{}
+			)
+			err := rows.Scan(
+				&companyKey, &subscription.ID, &subscription.Plan, &subscription.BillingCycle,
+				&subscription.PaymentStatus, &subscription.PaymentGateway, &subscription.DiscountRate,
+				&subscription.Discount, &subscription.Active, &subscription.AutoRenew, &subscription.StartDate,
+				&subscription.EndDate, &subscription.NextBillingDate, &subscription.LastPaymentDate,
+			)
+			if err != nil {
+				return err
+			}
+			subscription.StartDate = subscription.StartDate.UTC()
+			subscription.EndDate = subscription.EndDate.UTC()
+			subscription.NextBillingDate = subscription.NextBillingDate.UTC()
+			subscription.LastPaymentDate = subscription.LastPaymentDate.UTC()
+
+			company := companies[companyKey]
+			company.Subscriptions = append(company.Subscriptions, subscription)
+			return nil
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read subscriptions")
+	}
+
+	err = each(
+		ctx, tx, `SELECT tenant_id, id, billing_address, card_type, card_number, security_code, exp_month,
+			exp_year, is_active
+		FROM payment_methods WHERE tenant_id = ANY($1) ORDER BY tenant_id, position`,
+		ids, func(rows pgx.Rows) error {
+			var (
+				tenantID string
+				address  []byte
+				payment  = &entities.TenantPaymentDetails{}
+			)
+			err := rows.Scan(
+				&tenantID, &payment.ID, &address, &payment.CardType, &payment.CardNumber,
+				&payment.SecurityCode, &payment.ExpMonth, &payment.ExpYear, &payment.IsActive,
+			)
+			if err != nil {
+				return err
+			}
+			if err := decodeJSON(address, &payment.Address); err != nil {
+				return errors.Wrapf(err, "failed to decode billing address of payment method %s", payment.ID)
+			}
+
+			tenant := tenants[tenantID]
+			tenant.PaymentDetails = append(tenant.PaymentDetails, payment)
+			return nil
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read payment methods")
+	}
+
+	contacts := map[int64]*entities.TenantContactDetails{}
+	err = each(
+		ctx, tx, `SELECT key, tenant_id, id, first_name, last_name, email, phone_number, avatar_url,
+			job_title, preferred_language, timezone, is_active
+		FROM contacts WHERE tenant_id = ANY($1) ORDER BY tenant_id, position`,
+		ids, func(rows pgx.Rows) error {
+			var (
+				key      int64
+				tenantID string
+				contact  = &entities.TenantContactDetails{Roles: []*entities.Role{}}
+			)
+			err := rows.Scan(
+				&key, &tenantID, &contact.ID, &contact.FirstName, &contact.LastName, &contact.Email,
+				&contact.PhoneNumber, &contact.AvatarURL, &contact.JobTitle, &contact.PreferredLanguage,
+				&contact.Timezone, &contact.IsActive,
+			)
+			if err != nil {
+				return err
+			}
+
+			contacts[key] = contact
+			tenant := tenants[tenantID]
+			tenant.PrimaryContacts = append(tenant.PrimaryContacts, contact)
+			return nil
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to read contacts")
+	}
+
+	err = each(
+		ctx, tx, `SELECT cr.contact_key, r.id, r.tenant_id, r.name, r.description, r.permissions
+		FROM contact_roles cr
+			JOIN roles r ON r.id = cr.role_id
+			JOIN contacts c ON c.key = cr.contact_key
+		WHERE c.tenant_id = ANY($1) ORDER BY cr.contact_key, cr.position`,
+		ids, func(rows pgx.Rows) error {
+			var (
+				contactKey int64
+				role       *entities.Role
+			)
+			role, err := scanRole(rows, &contactKey)
+			if err != nil {
+				return err
+			}
+
+			contact := contacts[contactKey]
+			contact.Roles = append(contact.Roles, role)
+			return nil
+		},
+	)
+
+	return errors.Wrap(err, "failed to read roles of contacts")
+}
+
+// each calls fn with every row of a query.
+func each(ctx context.Context, tx pgx.Tx, query string, arg any, fn func(rows pgx.Rows) error) error {
+	rows, err := tx.Query(ctx, query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanRole scans a row of the roles table, prefixed by the columns of prefix.
+func scanRole(row pgx.Row, prefix ...any) (*entities.Role, error) {
+	var (
+		role  entities.Role
+		names []string
+	)
+	if err := row.Scan(append(prefix, &role.ID, &role.TenantID, &role.Name, &role.Description, &names)...); err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions(names)
+
+	return &role, nil
+}
@@ -0,0 +1,114 @@
+package postgres_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+var (
+	logger utils.LoggerInterface
+	db     *pgxpool.Pool
+)
+
+// TestMain migrates the database at TEST_POSTGRES_URL, or a postgres test container when it is
+// not set, before running the tests.
+func TestMain(m *testing.M) {
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+	logger = utils.NewLogger()
+
+	url := os.Getenv("TEST_POSTGRES_URL")
+	if url == "" {
+		logger.Info("Starting postgres test container")
+		container, err := NewPostgresTestContainer(ctx)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		defer func() {
+			if err := container.Terminate(ctx); err != nil {
+				logger.Error(err)
+			}
+		}()
+
+		if url, err = container.URL(ctx); err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	var err error
+	db, err = pgxpool.New(ctx, url)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer db.Close()
+
+	if err := postgres.Migrate(ctx, db, logger); err != nil {
+		logger.Fatal(err)
+	}
+
+	return m.Run()
+}
+
+type PostgresTestContainer struct {
+	testcontainers.Container
+}
+
+func NewPostgresTestContainer(ctx context.Context) (*PostgresTestContainer, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "postgres:15-alpine",
+		ExposedPorts: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     "postgres",
+			"POSTGRES_PASSWORD": "postgres",
+			"POSTGRES_DB":       "test_tenants",
+		},
+		WaitingFor: wait.ForAll(
+			wait.ForLog("database system is ready to accept connections").WithOccurrence(2),
+			wait.ForListeningPort("5432/tcp"),
+		),
+	}
+
+	container, err := testcontainers.GenericContainer(
+		ctx, testcontainers.GenericContainerRequest{
+			ContainerRequest: req,
+			Started:          true,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresTestContainer{
+		Container: container,
+	}, nil
+}
+
+// URL returns the connection string of the test database.
+func (c *PostgresTestContainer) URL(ctx context.Context) (string, error) {
+	endpoint, err := c.Endpoint(ctx, "")
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("postgres://postgres:postgres@%s/test_tenants?sslmode=disable", endpoint), nil
+}
+
+// truncateTables empties the tables of the schema between tests.
+func truncateTables(t testing.TB) {
+	t.Helper()
+
+	if _, err := db.Exec(context.Background(), "TRUNCATE tenants, roles CASCADE"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,365 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+)
+
+// TenantRepository stores tenants in postgres, in the normalized schema of the migrations:
+// companies, subscriptions, payment methods and contacts have tables of their own, the roles
+// of contacts are assigned from the roles table.
+type TenantRepository struct {
+	db     *pgxpool.Pool
+	logger utils.LoggerInterface
+}
+
+func NewTenantRepository(db *pgxpool.Pool, logger utils.LoggerInterface) *TenantRepository {
+	return &TenantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateTenant creates a new tenant in the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// Tenant is the tenant to be created.
+func (r *TenantRepository) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	r.logger.Infof("inserting tenant into database: %v", tenant.ID)
+	err := pgx.BeginFunc(
+		ctx, r.db, func(tx pgx.Tx) error {
+			row, err := newTenantRow(tenant)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(
+				ctx, `INSERT INTO tenants (
+					id, name, subdomain, updated_by, is_active, metadata, domains, api_keys,
+					created_at, updated_at, deleted_at
+				) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+				row.args()...,
+			)
+			if err != nil {
+				return err
+			}
+
+			return insertChildren(ctx, tx, tenant)
+		},
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
+		r.logger.Error(err)
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	r.logger.Infof("successfully inserted tenant into database: %v", tenant.ID)
+	return nil
+}
+
+// DeleteTenant deletes a tenant from the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// ID is the id of the tenant to be deleted.
+// This is a soft delete, isActive is set to false.
+func (r *TenantRepository) DeleteTenant(ctx context.Context, id string) error {
+	r.logger.Infof("deleting tenant from database: %v", id)
+	result, err := r.db.Exec(ctx, "UPDATE tenants SET is_active = false WHERE id = $1", id)
+	if err != nil {
+		r.logger.With(id).Error(err)
+		return apperrors.ErrDeletingTenantDocument.Wrap(err)
+	}
+
+	if result.RowsAffected() == 0 {
+		r.logger.Errorf(apperrors.ErrNoTenantFound, id)
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return nil
+}
+
+// GetTenantByID returns a tenant from the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+// ID is the id of the tenant to be retrieved.
+func (r *TenantRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	r.logger.Infof("retrieving tenant from database: %v", id)
+	tenants, err := r.find(ctx, "t.id = $1", []any{id}, 1)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrRetrievingTenant, id)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	if len(tenants) == 0 {
+		r.logger.Errorf(apperrors.ErrNoTenantFound, id)
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return tenants[0], nil
+}
+
+// GetTenants returns a list of tenants from the database.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *TenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
+	r.logger.Info("retrieving tenants from database")
+	tenants, err := r.find(ctx, "t.is_active", nil, 0)
+	if err != nil {
+		r.logger.Error(apperrors.ErrRetrievingTenants)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	r.logger.Infof("found %d tenants", len(tenants))
+
+	return tenants, nil
+}
+
+// UpdateTenant replaces a tenant in the database, the collections of the tenant are replaced
+// as a whole. Updating an unknown tenant does nothing.
+// Ctx is used to cancel the operation if the context is cancelled.
+// Tenant is the tenant to be updated.
+func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	r.logger.Infof("updating tenant in database: %v", tenant.ID)
+	err := pgx.BeginFunc(
+		ctx, r.db, func(tx pgx.Tx) error {
+			row, err := newTenantRow(tenant)
+			if err != nil {
+				return err
+			}
+
+			result, err := tx.Exec(
+				ctx, `UPDATE tenants SET
+					name = $2, subdomain = $3, updated_by = $4, is_active = $5, metadata = $6,
+					domains = $7, api_keys = $8, created_at = $9, updated_at = $10, deleted_at = $11
+				WHERE id = $1`,
+				row.args()...,
+			)
+			if err != nil {
+				return err
+			}
+
+			if result.RowsAffected() == 0 {
+				return nil
+			}
+
+			for _, table := range []string{"companies", "payment_methods", "contacts"} {
+				if _, err := tx.Exec(ctx, "DELETE FROM "+table+" WHERE tenant_id = $1", tenant.ID); err != nil {
+					return err
+				}
+			}
+
+			return insertChildren(ctx, tx, tenant)
+		},
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrUpdatingTenant, tenant.ID)
+		r.logger.Error(err)
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	return nil
+}
+
+// SearchTenant returns the first tenant matching a filter, see SearchTenants.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *TenantRepository) SearchTenant(ctx context.Context, filter map[string]any) (*entities.Tenant, error) {
+	r.logger.Infof("retrieving tenant document from database with filter: %v", filter)
+	tenants, err := r.search(ctx, filter, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenants) == 0 {
+		r.logger.With(filter).Info(apperrors.ErrNoTenantFound)
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return tenants[0], nil
+}
+
+// SearchTenants returns the tenants matching a filter. Filters are equality conditions on the
+// paths of tenant documents, as they would be in mongo, such as {"subdomain": "acme"} or
+// {"domains.hostname": "acme.com"}. Only the paths listed in searchable are supported.
+// Ctx is used to cancel the operation if the context is cancelled.
+func (r *TenantRepository) SearchTenants(ctx context.Context, filter map[string]any) (
+	[]*entities.Tenant, error,
+) {
+	r.logger.Infof("retrieving tenants from database with filter: %v", filter)
+	tenants, err := r.search(ctx, filter, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Infof("found %d tenants", len(tenants))
+
+	return tenants, nil
+}
+
+func (r *TenantRepository) search(ctx context.Context, filter map[string]any, limit int) (
+	[]*entities.Tenant, error,
+) {
+	where, args, err := conditions(filter)
+	if err != nil {
+		r.logger.With(filter).Error(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	tenants, err := r.find(ctx, where, args, limit)
+	if err != nil {
+		r.logger.With(filter).With(apperrors.ErrRetrievingTenants).Errorln(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	return tenants, nil
+}
+
+// searchable are the paths of tenant documents searches can filter on, with the condition
+// comparing the field at the path to a parameter.
+var searchable = map[string]func(param string) string{
+	"_id":                                    column("t.id"),
+	"name":                                   column("t.name"),
+	"subdomain":                              column("t.subdomain"),
+	"updated_by":                             column("t.updated_by"),
+	"is_active":                              column("t.is_active"),
+	"tenant_metadata._id":                    field("t.metadata", "_id"),
+	"domains._id":                            element("t.domains", "_id"),
+	"domains.hostname":                       element("t.domains", "hostname"),
+	"domains.status":                         element("t.domains", "status"),
+	"api_keys._id":                           element("t.api_keys", "_id"),
+	"api_keys.prefix":                        element("t.api_keys", "prefix"),
+	"companies._id":                          child("companies", "id"),
+	"companies.company_name":                 child("companies", "name"),
+	"companies.is_active":                    child("companies", "is_active"),
+	"companies.subscriptions._id":            subscription("id"),
+	"companies.subscriptions.plan":           subscription("plan"),
+	"companies.subscriptions.payment_status": subscription("payment_status"),
+	"companies.subscriptions.active":         subscription("active"),
+	"payment_details._id":                    child("payment_methods", "id"),
+	"payment_details.is_active":              child("payment_methods", "is_active"),
+	"primary_contacts._id":                   child("contacts", "id"),
+	"primary_contacts.email":                 child("contacts", "email"),
+	"primary_contacts.is_active":             child("contacts", "is_active"),
+}
+
+func column(name string) func(string) string {
+	return func(param string) string {
+		return fmt.Sprintf("%s = %s", name, param)
+	}
+}
+
+func field(document string, name string) func(string) string {
+	return func(param string) string {
+		return fmt.Sprintf("%s ->> '%s' = %s::text", document, name, param)
+	}
+}
+
+func element(documents string, name string) func(string) string {
+	return func(param string) string {
+		return fmt.Sprintf("%s @> jsonb_build_array(jsonb_build_object('%s', %s::text))", documents, name, param)
+	}
+}
+
+func child(table string, name string) func(string) string {
+	return func(param string) string {
+		return fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM %s c WHERE c.tenant_id = t.id AND c.%s = %s)", table, name, param,
+		)
+	}
+}
+
+func subscription(name string) func(string) string {
+	return func(param string) string {
+		return fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM companies c JOIN subscriptions s ON s.company_key = c.key "+
+				"WHERE c.tenant_id = t.id AND s.%s = %s)", name, param,
+		)
+	}
+}
+
+// conditions returns the where clause of a filter, with its parameters.
+func conditions(filter map[string]any) (string, []any, error) {
+	paths := make([]string, 0, len(filter))
+	for path := range filter {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	where := []string{"true"}
+	args := make([]any, 0, len(filter))
+	for _, path := range paths {
+		condition, ok := searchable[path]
+		if !ok {
+			return "", nil, errors.Errorf("unsupported filter on %s", path)
+		}
+
+		args = append(args, filter[path])
+		where = append(where, condition(fmt.Sprintf("$%d", len(args))))
+	}
+
+	return strings.Join(where, " AND "), args, nil
+}
+
+// find returns up to limit tenants matching a where clause on the tenants table aliased t,
+// all of them when limit is 0. Tenants are returned in the order they were created.
+func (r *TenantRepository) find(ctx context.Context, where string, args []any, limit int) (
+	[]*entities.Tenant, error,
+) {
+	query := `SELECT t.id, t.name, t.subdomain, t.updated_by, t.is_active, t.metadata, t.domains, t.api_keys,
+		t.created_at, t.updated_at, t.deleted_at
+	FROM tenants t WHERE ` + where + ` ORDER BY t.created_at, t.id`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := make([]*entities.Tenant, 0)
+	byID := map[string]*entities.Tenant{}
+	for rows.Next() {
+		var row tenantRow
+		if err := rows.Scan(row.fields()...); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		tenant, err := row.tenant()
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		tenants = append(tenants, tenant)
+		byID[tenant.ID] = tenant
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(tenants) == 0 {
+		return tenants, nil
+	}
+
+	if err := loadChildren(ctx, tx, byID); err != nil {
+		return nil, err
+	}
+
+	return tenants, tx.Commit(ctx)
+}
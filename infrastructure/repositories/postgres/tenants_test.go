@@ -0,0 +1,53 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/postgres"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRepository(t *testing.T) {
+	repositorytest.TestTenantRepository(
+		t, func(t *testing.T) repository.TenantRepository {
+			truncateTables(t)
+			return postgres.NewTenantRepository(db, logger)
+		},
+	)
+}
+
+func BenchmarkTenantRepository(b *testing.B) {
+	repositorytest.BenchmarkTenantRepository(
+		b, func(b *testing.B) repository.TenantRepository {
+			truncateTables(b)
+			return postgres.NewTenantRepository(db, logger)
+		},
+	)
+}
+
+func TestRolesRepository(t *testing.T) {
+	repositorytest.TestRolesRepository(
+		t, func(t *testing.T) repository.RolesRepository {
+			truncateTables(t)
+			return postgres.NewRolesRepository(db, logger)
+		},
+	)
+}
+
+func TestMigrate(t *testing.T) {
+	migrations, err := postgres.Migrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	// migrating an up to date schema does nothing
+	require.NoError(t, postgres.Migrate(context.Background(), db, logger))
+
+	var version int
+	err = db.QueryRow(context.Background(), "SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	require.NoError(t, err)
+	assert.Equal(t, migrations[len(migrations)-1].Version, version)
+}
@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// RolesRepository stores roles in sqlite as JSON documents, unknown roles are reported as not
+// found.
+type RolesRepository struct {
+	db     *sql.DB
+	logger utils.LoggerInterface
+}
+
+func NewRolesRepository(db *sql.DB, logger utils.LoggerInterface) *RolesRepository {
+	return &RolesRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveRole stores a new role, roles with the ID of another role are refused.
+func (r *RolesRepository) SaveRole(ctx context.Context, role *entities.Role) error {
+	document, err := encode(role)
+	if err != nil {
+		return apperrors.ErrCreatingRoleDocument.Wrap(err)
+	}
+
+	_, err = r.db.ExecContext(
+		ctx, "INSERT INTO roles (id, tenant_id, document) VALUES (?, ?, ?)", role.ID, role.TenantID, document,
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingRole, role.ID)
+		r.logger.Error(err)
+		return apperrors.ErrCreatingRoleDocument.Wrap(err)
+	}
+
+	return nil
+}
+
+// UpdateRole replaces a role.
+func (r *RolesRepository) UpdateRole(ctx context.Context, role *entities.Role) error {
+	document, err := encode(role)
+	if err != nil {
+		return apperrors.ErrUpdatingRoleDocument.Wrap(err)
+	}
+
+	result, err := r.db.ExecContext(
+		ctx, "UPDATE roles SET tenant_id = ?, document = ? WHERE id = ?", role.TenantID, document, role.ID,
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrUpdatingRole, role.ID)
+		r.logger.Error(err)
+		return apperrors.ErrUpdatingRoleDocument.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return apperrors.ErrUpdatingRoleDocument.Wrap(err)
+	}
+	if affected == 0 {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, role.ID)
+		return apperrors.ErrNoRoleDocumentsFound
+	}
+
+	return nil
+}
+
+// DeleteRole removes a role.
+func (r *RolesRepository) DeleteRole(ctx context.Context, roleID utils.XID) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM roles WHERE id = ?", roleID.ID)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrDeletingRole, roleID.ID)
+		r.logger.Error(err)
+		return apperrors.ErrDeletingRoleDocument.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return apperrors.ErrDeletingRoleDocument.Wrap(err)
+	}
+	if affected == 0 {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+		return apperrors.ErrNoRoleDocumentsFound
+	}
+
+	return nil
+}
+
+// FindRoleByID returns a role.
+func (r *RolesRepository) FindRoleByID(ctx context.Context, roleID utils.XID) (*entities.Role, error) {
+	var document []byte
+	err := r.db.QueryRowContext(ctx, "SELECT document FROM roles WHERE id = ?", roleID.ID).Scan(&document)
+	if errors.Is(err, sql.ErrNoRows) {
+		r.logger.Errorf(apperrors.ErrNoRoleFound, roleID.ID)
+		return nil, apperrors.ErrNoRoleDocumentsFound
+	}
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrRetrievingRole, roleID.ID)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	return decodeRole(document)
+}
+
+// FindAllRoles returns every role, in the order they were saved in.
+func (r *RolesRepository) FindAllRoles(ctx context.Context) ([]*entities.Role, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT document FROM roles ORDER BY seq")
+	if err != nil {
+		r.logger.Error(apperrors.ErrRetrievingRoles)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+	defer rows.Close()
+
+	roles := make([]*entities.Role, 0)
+	for rows.Next() {
+		var document []byte
+		if err := rows.Scan(&document); err != nil {
+			return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+		}
+
+		role, err := decodeRole(document)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Error(apperrors.ErrRetrievingRoles)
+		r.logger.Error(err)
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	return roles, nil
+}
+
+func decodeRole(document []byte) (*entities.Role, error) {
+	var role entities.Role
+	if err := bson.UnmarshalExtJSON(document, false, &role); err != nil {
+		return nil, apperrors.ErrRetrievingRoleDocument.Wrap(err)
+	}
+
+	return &role, nil
+}
@@ -0,0 +1,18 @@
+package sqlite_test
+
+import (
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/sqlite"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
+)
+
+func TestRolesRepository(t *testing.T) {
+	repositorytest.TestRolesRepository(
+		t, func(t *testing.T) repository.RolesRepository {
+			return sqlite.NewRolesRepository(newDB(t), utils.NewLogger())
+		},
+	)
+}
@@ -0,0 +1,21 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed schema.sql
+var schema string
+
+// CreateSchema creates the tables and indexes of the repositories that do not exist yet.
+func CreateSchema(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return errors.Wrap(err, "failed to create sqlite schema")
+	}
+
+	return nil
+}
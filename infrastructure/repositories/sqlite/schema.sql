@@ -0,0 +1,28 @@
+-- Tenants and roles are kept as the relaxed extended JSON of their mongo documents, the columns
+-- next to the documents are copies of the fields lookups are made on.
+CREATE TABLE IF NOT EXISTS tenants (
+    seq       INTEGER PRIMARY KEY AUTOINCREMENT,
+    id        TEXT    NOT NULL UNIQUE,
+    subdomain TEXT    NOT NULL UNIQUE,
+    is_active INTEGER NOT NULL,
+    document  TEXT    NOT NULL CHECK (json_valid(document))
+);
+
+CREATE INDEX IF NOT EXISTS tenants_is_active ON tenants (is_active);
+
+CREATE TABLE IF NOT EXISTS tenant_contact_emails (
+    tenant_id TEXT NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    email     TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS tenant_contact_emails_email ON tenant_contact_emails (email);
+CREATE INDEX IF NOT EXISTS tenant_contact_emails_tenant_id ON tenant_contact_emails (tenant_id);
+
+CREATE TABLE IF NOT EXISTS roles (
+    seq       INTEGER PRIMARY KEY AUTOINCREMENT,
+    id        TEXT NOT NULL UNIQUE,
+    tenant_id TEXT NOT NULL,
+    document  TEXT NOT NULL CHECK (json_valid(document))
+);
+
+CREATE INDEX IF NOT EXISTS roles_tenant_id ON roles (tenant_id);
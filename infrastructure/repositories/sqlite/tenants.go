@@ -0,0 +1,285 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/filter"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TenantRepository stores tenants in sqlite with the semantics of the mongo repository: tenants
+// are soft deleted, only active tenants are listed, subdomains are unique and unknown tenants
+// are reported as not found.
+//
+// Tenants are stored as JSON documents, subdomains, activity and the emails of contacts are
+// copied to indexed columns lookups are made on.
+type TenantRepository struct {
+	db     *sql.DB
+	logger utils.LoggerInterface
+}
+
+func NewTenantRepository(db *sql.DB, logger utils.LoggerInterface) *TenantRepository {
+	return &TenantRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// CreateTenant stores a new tenant. Tenants with the ID or the subdomain of another tenant
+// are refused.
+func (r *TenantRepository) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	r.logger.Infof("inserting tenant into database: %v", tenant.ID)
+	document, err := encode(tenant)
+	if err != nil {
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	err = transaction(
+		ctx, r.db, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(
+				ctx, "INSERT INTO tenants (id, subdomain, is_active, document) VALUES (?, ?, ?, ?)",
+				tenant.ID, tenant.Subdomain, tenant.IsActive, document,
+			)
+			if err != nil {
+				return err
+			}
+
+			return insertContactEmails(ctx, tx, tenant)
+		},
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrCreatingTenant, tenant.ID)
+		r.logger.Error(err)
+		return apperrors.ErrCreatingTenantDocument.Wrap(err)
+	}
+
+	r.logger.Infof("successfully inserted tenant into database: %v", tenant.ID)
+	return nil
+}
+
+// DeleteTenant soft deletes a tenant, isActive is set to false.
+func (r *TenantRepository) DeleteTenant(ctx context.Context, id string) error {
+	r.logger.Infof("deleting tenant from database: %v", id)
+	result, err := r.db.ExecContext(
+		ctx, `UPDATE tenants SET is_active = 0, document = json_set(document, '$.is_active', json('false'))
+		WHERE id = ?`, id,
+	)
+	if err != nil {
+		r.logger.With(id).Error(err)
+		return apperrors.ErrDeletingTenantDocument.Wrap(err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return apperrors.ErrDeletingTenantDocument.Wrap(err)
+	}
+	if affected == 0 {
+		r.logger.Errorf(apperrors.ErrNoTenantFound, id)
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return nil
+}
+
+// GetTenantByID returns a tenant, deleted tenants included.
+func (r *TenantRepository) GetTenantByID(ctx context.Context, id string) (*entities.Tenant, error) {
+	r.logger.Infof("retrieving tenant from database: %v", id)
+	return r.SearchTenant(ctx, map[string]any{"_id": id})
+}
+
+// GetTenants returns the active tenants.
+func (r *TenantRepository) GetTenants(ctx context.Context) ([]*entities.Tenant, error) {
+	return r.SearchTenants(ctx, map[string]any{"is_active": true})
+}
+
+// UpdateTenant replaces a tenant. Like an update matching no document, updating an unknown
+// tenant does nothing.
+func (r *TenantRepository) UpdateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	r.logger.Infof("updating tenant in database: %v", tenant.ID)
+	document, err := encode(tenant)
+	if err != nil {
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	err = transaction(
+		ctx, r.db, func(tx *sql.Tx) error {
+			result, err := tx.ExecContext(
+				ctx, "UPDATE tenants SET subdomain = ?, is_active = ?, document = ? WHERE id = ?",
+				tenant.Subdomain, tenant.IsActive, document, tenant.ID,
+			)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil || affected == 0 {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, "DELETE FROM tenant_contact_emails WHERE tenant_id = ?", tenant.ID); err != nil {
+				return err
+			}
+
+			return insertContactEmails(ctx, tx, tenant)
+		},
+	)
+	if err != nil {
+		r.logger.Errorf(apperrors.ErrUpdatingTenant, tenant.ID)
+		r.logger.Error(err)
+		return apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	return nil
+}
+
+// SearchTenant returns the first tenant matching a filter, see SearchTenants.
+func (r *TenantRepository) SearchTenant(ctx context.Context, filter map[string]any) (*entities.Tenant, error) {
+	tenants, err := r.search(ctx, filter, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenants) == 0 {
+		r.logger.With(filter).Info(apperrors.ErrNoTenantFound)
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return tenants[0], nil
+}
+
+// SearchTenants returns the tenants matching a filter. Filters are equality conditions on the
+// fields of tenant documents, as they would be in mongo. Conditions on the ID, the subdomain,
+// the activity or the emails of contacts are looked up in indexes, the others are checked
+// against the documents.
+func (r *TenantRepository) SearchTenants(ctx context.Context, filter map[string]any) (
+	[]*entities.Tenant, error,
+) {
+	return r.search(ctx, filter, 0)
+}
+
+// indexed are the fields of tenant documents with a copy in an indexed column, with the
+// condition comparing the column to a parameter.
+var indexed = map[string]string{
+	"_id":                    "id = ?",
+	"subdomain":              "subdomain = ?",
+	"is_active":              "is_active = ?",
+	"primary_contacts.email": "id IN (SELECT tenant_id FROM tenant_contact_emails WHERE email = ?)",
+}
+
+// search returns up to limit tenants matching a filter, all of them when limit is 0. Tenants
+// are returned in the order they were created.
+func (r *TenantRepository) search(ctx context.Context, query map[string]any, limit int) (
+	[]*entities.Tenant, error,
+) {
+	where := []string{"1 = 1"}
+	var args []any
+	for path, value := range query {
+		condition, ok := indexed[path]
+		if !ok {
+			continue
+		}
+
+		// other types are compared as mongo would by the filter
+		switch value.(type) {
+		case string, bool:
+			where = append(where, condition)
+			args = append(args, value)
+		}
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx, "SELECT document FROM tenants WHERE "+strings.Join(where, " AND ")+" ORDER BY seq", args...,
+	)
+	if err != nil {
+		r.logger.With(query).With(apperrors.ErrRetrievingTenants).Errorln(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+	defer rows.Close()
+
+	tenants := make([]*entities.Tenant, 0)
+	for rows.Next() {
+		var document []byte
+		if err := rows.Scan(&document); err != nil {
+			return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+		}
+
+		var fields bson.M
+		if err := bson.UnmarshalExtJSON(document, false, &fields); err != nil {
+			return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+		}
+
+		ok, err := filter.Match(fields, query)
+		if err != nil {
+			r.logger.With(query).Error(err)
+			return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+		}
+		if !ok {
+			continue
+		}
+
+		var tenant entities.Tenant
+		if err := bson.UnmarshalExtJSON(document, false, &tenant); err != nil {
+			return nil, apperrors.ErrUnmarshallingTenantDocument.Wrap(err)
+		}
+
+		tenants = append(tenants, &tenant)
+		if len(tenants) == limit {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.With(query).With(apperrors.ErrRetrievingTenants).Errorln(err)
+		return nil, apperrors.ErrRetrievingTenantDocument.Wrap(err)
+	}
+
+	return tenants, nil
+}
+
+// encode returns the document of a tenant or a role, the relaxed extended JSON of its mongo
+// document.
+func encode(v any) ([]byte, error) {
+	document, err := bson.MarshalExtJSON(v, false, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode document")
+	}
+
+	return document, nil
+}
+
+func insertContactEmails(ctx context.Context, tx *sql.Tx, tenant *entities.Tenant) error {
+	for _, contact := range tenant.PrimaryContacts {
+		if contact == nil {
+			continue
+		}
+
+		_, err := tx.ExecContext(
+			ctx, "INSERT INTO tenant_contact_emails (tenant_id, email) VALUES (?, ?)", tenant.ID, contact.Email,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// transaction runs fn in a transaction, committed when fn succeeds and rolled back otherwise.
+func transaction(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
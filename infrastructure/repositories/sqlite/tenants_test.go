@@ -0,0 +1,99 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	database "github.com/hebecoding/tenant-management/infrastructure/database/sqlite"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/sqlite"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/internal/domain/repository/repositorytest"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDB returns an empty database, removed once the test ends.
+func newDB(t testing.TB) *sql.DB {
+	db, err := database.NewSQLiteDB(context.Background(), utils.NewLogger(), filepath.Join(t.TempDir(), "tenants.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+func TestTenantRepository(t *testing.T) {
+	repositorytest.TestTenantRepository(
+		t, func(t *testing.T) repository.TenantRepository {
+			return sqlite.NewTenantRepository(newDB(t), utils.NewLogger())
+		},
+	)
+}
+
+func BenchmarkTenantRepository(b *testing.B) {
+	repositorytest.BenchmarkTenantRepository(
+		b, func(b *testing.B) repository.TenantRepository {
+			// benchmarks would otherwise mostly measure logging
+			logger := utils.NewLogger(
+				utils.Config{
+					Level: "error", Encoding: "console", OutputPaths: []string{"stderr"}, ErrorOutputPaths: []string{"stderr"},
+				},
+			)
+			return sqlite.NewTenantRepository(newDB(b), logger)
+		},
+	)
+}
+
+func TestTenantRepository_SearchTenants(t *testing.T) {
+	repo := sqlite.NewTenantRepository(newDB(t), utils.NewLogger())
+	ctx := context.Background()
+
+	tenant := tests.CreateTenant()
+	require.NoError(t, repo.CreateTenant(ctx, tenant))
+
+	// contacts are replaced, the emails of the former ones are no longer found
+	updated := *tenant
+	updated.PrimaryContacts = []*entities.TenantContactDetails{tests.GenerateContactDetails()}
+	require.NoError(t, repo.UpdateTenant(ctx, &updated))
+
+	var testCases = []struct {
+		Name     string
+		Filter   map[string]any
+		Expected int
+	}{
+		{
+			Name:     "Happy Path: Indexed contact email",
+			Filter:   map[string]any{"primary_contacts.email": updated.PrimaryContacts[0].Email},
+			Expected: 1,
+		},
+		{
+			Name:     "Happy Path: Email of a removed contact",
+			Filter:   map[string]any{"primary_contacts.email": tenant.PrimaryContacts[0].Email},
+			Expected: 0,
+		},
+		{
+			Name:     "Happy Path: Indexed and document conditions",
+			Filter:   map[string]any{"subdomain": tenant.Subdomain, "name": tenant.Name},
+			Expected: 1,
+		},
+		{
+			Name:     "Happy Path: Document condition of another tenant",
+			Filter:   map[string]any{"subdomain": tenant.Subdomain, "name": "another"},
+			Expected: 0,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				tenants, err := repo.SearchTenants(ctx, tt.Filter)
+				require.NoError(t, err)
+				assert.Len(t, tenants, tt.Expected)
+			},
+		)
+	}
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+type newAPIKeyRequest struct {
+	Name      string                 `json:"name"`
+	Scopes    []entities.APIKeyScope `json:"scopes"`
+	ExpiresAt time.Time              `json:"expires_at"`
+	CreatedBy string                 `json:"created_by"`
+}
+
+// issuedAPIKey is an API key together with its secret, only sent when the secret is generated.
+type issuedAPIKey struct {
+	*entities.APIKey
+	Key string `json:"key"`
+}
+
+func (s *Server) listTenantAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.tenants.ListAPIKeys(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if keys == nil {
+		keys = []*entities.APIKey{}
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (s *Server) createTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req newAPIKeyRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	key := &entities.APIKey{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: req.CreatedBy,
+	}
+
+	// authenticated callers issue keys on their own behalf, and an API key cannot
+	// issue a key with more access than it has itself
+	if principal, ok := authn.PrincipalFromContext(r.Context()); ok {
+		key.CreatedBy = principal.Subject
+
+		var fields []apperrors.FieldError
+		for i, scope := range key.Scopes {
+			for j, permission := range scope.Permissions {
+				if !principal.Allows(scope.Resource, permission) {
+					fields = append(
+						fields, apperrors.FieldError{
+							Field:   apperrors.FieldPath("scopes", i, "permissions", j),
+							Message: "exceeds the scopes of the calling api key",
+						},
+					)
+				}
+			}
+		}
+
+		if len(fields) > 0 {
+			writeError(w, r, s.logger, apperrors.ErrForbidden.WithFields(fields...))
+			return
+		}
+	}
+
+	secret, err := s.tenants.CreateAPIKey(r.Context(), pathParam(r, "tenantId"), key)
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, issuedAPIKey{APIKey: key, Key: secret})
+}
+
+func (s *Server) rotateTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	key, secret, err := s.tenants.RotateAPIKey(r.Context(), pathParam(r, "tenantId"), pathParam(r, "apiKeyId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, issuedAPIKey{APIKey: key, Key: secret})
+}
+
+func (s *Server) revokeTenantAPIKey(w http.ResponseWriter, r *http.Request) {
+	if err := s.tenants.RevokeAPIKey(r.Context(), pathParam(r, "tenantId"), pathParam(r, "apiKeyId")); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
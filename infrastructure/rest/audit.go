@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+func (s *Server) listTenantAuditRecords(w http.ResponseWriter, r *http.Request) {
+	// the query was validated against the OpenAPI document, so it parses
+	query := r.URL.Query()
+	filter := entities.AuditFilter{
+		TenantID: pathParam(r, "tenantId"),
+		Actor:    query.Get("actor"),
+	}
+
+	if from := query.Get("from"); from != "" {
+		filter.From, _ = time.Parse(time.RFC3339, from)
+	}
+	if to := query.Get("to"); to != "" {
+		filter.To, _ = time.Parse(time.RFC3339, to)
+	}
+	if after := query.Get("after"); after != "" {
+		filter.AfterSequence, _ = strconv.ParseInt(after, 10, 64)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, _ = strconv.Atoi(limit)
+	}
+
+	records, err := s.audits.QueryAuditRecords(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if records == nil {
+		records = []*entities.AuditRecord{}
+	}
+
+	writeJSON(w, http.StatusOK, records)
+}
@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+type newDomainRequest struct {
+	Hostname           string                      `json:"hostname"`
+	VerificationMethod entities.VerificationMethod `json:"verification_method"`
+}
+
+func (s *Server) listTenantDomains(w http.ResponseWriter, r *http.Request) {
+	domains, err := s.domains.GetDomains(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if domains == nil {
+		domains = []*entities.TenantDomain{}
+	}
+
+	writeJSON(w, http.StatusOK, domains)
+}
+
+func (s *Server) addTenantDomain(w http.ResponseWriter, r *http.Request) {
+	var req newDomainRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	domain, err := s.domains.AddDomain(r.Context(), pathParam(r, "tenantId"), req.Hostname, req.VerificationMethod)
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain)
+}
+
+func (s *Server) removeTenantDomain(w http.ResponseWriter, r *http.Request) {
+	if err := s.domains.RemoveDomain(r.Context(), pathParam(r, "tenantId"), pathParam(r, "domainId")); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) verifyTenantDomain(w http.ResponseWriter, r *http.Request) {
+	domain, err := s.domains.VerifyDomain(r.Context(), pathParam(r, "tenantId"), pathParam(r, "domainId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, domain)
+}
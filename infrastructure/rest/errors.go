@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+)
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError reports err as problem details. Errors that are not known to the
+// application are reported as internal errors without leaking their details to the client.
+func writeError(w http.ResponseWriter, r *http.Request, logger utils.LoggerInterface, err error) {
+	if apperrors.From(err).Status >= http.StatusInternalServerError {
+		logger.Error(err)
+	}
+
+	apperrors.WriteProblem(w, r, err)
+}
+
+// unavailable answers the operations of the features the database backend does not support.
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	apperrors.WriteProblem(w, r, apperrors.ErrFeatureUnavailable)
+}
+
+// fieldErrors flattens the errors returned by the OpenAPI request validator
+// into one entry per offending field.
+func fieldErrors(err error) []apperrors.FieldError {
+	var fields []apperrors.FieldError
+
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, inner := range e {
+			fields = append(fields, fieldErrors(inner)...)
+		}
+	case *openapi3filter.RequestError:
+		inner := fieldErrors(e.Err)
+		if e.Parameter != nil {
+			for i := range inner {
+				inner[i].Field = apperrors.FieldPath(e.Parameter.Name, inner[i].Field)
+			}
+			if len(inner) == 0 {
+				inner = []apperrors.FieldError{{Field: e.Parameter.Name, Message: e.Reason}}
+			}
+		}
+		if len(inner) == 0 {
+			inner = []apperrors.FieldError{{Field: "body", Message: e.Error()}}
+		}
+		fields = append(fields, inner...)
+	case *openapi3.SchemaError:
+		segments := make([]any, 0, len(e.JSONPointer()))
+		for _, segment := range e.JSONPointer() {
+			segments = append(segments, segment)
+		}
+		field := apperrors.FieldPath(segments...)
+
+		// composed schemas (allOf, oneOf, ...) report the failures of their parts as origin,
+		// relative to the value being validated
+		switch e.Origin.(type) {
+		case openapi3.MultiError, *openapi3.SchemaError:
+			inner := fieldErrors(e.Origin)
+			for i := range inner {
+				inner[i].Field = apperrors.FieldPath(field, inner[i].Field)
+			}
+			fields = append(fields, inner...)
+		default:
+			fields = append(fields, apperrors.FieldError{Field: field, Message: e.Reason})
+		}
+	case *openapi3filter.ParseError:
+		fields = append(fields, apperrors.FieldError{Field: "body", Message: e.Error()})
+	case nil:
+	default:
+		fields = append(fields, apperrors.FieldError{Field: "body", Message: e.Error()})
+	}
+
+	return fields
+}
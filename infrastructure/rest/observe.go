@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer is told of every request served, with the path template of its route, such as
+// /tenants/{tenantId}. Requests matching no route are reported with the unmatched route.
+type Observer func(r *http.Request, route string, status int, duration time.Duration)
+
+// UnmatchedRoute is the route of requests matching no route, so that unknown paths do not
+// make up routes of their own.
+const UnmatchedRoute = "unmatched"
+
+// WithObserver tells observer of every request served, from the moment it is received until
+// its response is written.
+func WithObserver(observer Observer) Option {
+	return func(s *Server) {
+		s.observer = observer
+	}
+}
+
+func (s *Server) observe(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			s.observer(r, s.route(r), recorder.status, time.Since(start))
+		},
+	)
+}
+
+// route returns the path template of the route of r.
+func (s *Server) route(r *http.Request) string {
+	if r.URL.Path == "/openapi.json" {
+		return r.URL.Path
+	}
+	if _, ok := s.operational[r.URL.Path]; ok {
+		return r.URL.Path
+	}
+	if route, _, err := s.router.FindRoute(r); err == nil {
+		return route.Path
+	}
+
+	return UnmatchedRoute
+}
+
+// statusRecorder records the status of the response written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
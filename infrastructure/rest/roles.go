@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+func (s *Server) listRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := s.roles.GetRoles(r.Context())
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if roles == nil {
+		roles = []*entities.Role{}
+	}
+
+	writeJSON(w, http.StatusOK, roles)
+}
+
+func (s *Server) createRole(w http.ResponseWriter, r *http.Request) {
+	var role entities.Role
+	if !decode(w, r, &role) {
+		return
+	}
+
+	if err := s.roles.CreateRole(r.Context(), &role); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, role)
+}
+
+func (s *Server) createCustomRole(w http.ResponseWriter, r *http.Request) {
+	var role entities.Role
+	if !decode(w, r, &role) {
+		return
+	}
+
+	role.TenantID = pathParam(r, "tenantId")
+
+	// custom roles can only be created for existing tenants
+	if _, err := s.tenants.GetTenantByID(r.Context(), role.TenantID); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if err := s.roles.CreateCustomRole(r.Context(), &role); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, role)
+}
+
+func (s *Server) getRole(w http.ResponseWriter, r *http.Request) {
+	role, ok := s.authorizedRole(w, r, entities.ReadPermission)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, role)
+}
+
+func (s *Server) updateRole(w http.ResponseWriter, r *http.Request) {
+	var role entities.Role
+	if !decode(w, r, &role) {
+		return
+	}
+
+	current, ok := s.authorizedRole(w, r, entities.EditPermission)
+	if !ok {
+		return
+	}
+
+	// a role cannot be moved between tenants
+	role.ID = current.ID
+	role.TenantID = current.TenantID
+
+	if err := s.roles.UpdateRole(r.Context(), &role); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteRole(w http.ResponseWriter, r *http.Request) {
+	role, ok := s.authorizedRole(w, r, entities.DeletePermission)
+	if !ok {
+		return
+	}
+
+	if err := s.roles.DeleteRole(r.Context(), utils.XID{ID: role.ID}); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorizedRole returns the role of the request once the caller is authorized to exercise
+// permission on it. Custom roles belong to their tenant, platform roles to the platform.
+func (s *Server) authorizedRole(w http.ResponseWriter, r *http.Request, permission entities.Permission) (
+	*entities.Role, bool,
+) {
+	role, err := s.roles.GetRole(r.Context(), utils.XID{ID: pathParam(r, "roleId")})
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return nil, false
+	}
+
+	if err := s.authorized(r.Context(), entities.RolesResource, permission, role.TenantID); err != nil {
+		writeError(w, r, s.logger, err)
+		return nil, false
+	}
+
+	return role, true
+}
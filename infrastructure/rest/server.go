@@ -0,0 +1,364 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/application/service"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	domain "github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/pkg/errors"
+)
+
+// DomainService manages the custom domains of tenants.
+type DomainService interface {
+	AddDomain(
+		ctx context.Context, tenantID string, hostname string, method entities.VerificationMethod,
+	) (*entities.TenantDomain, error)
+	GetDomains(ctx context.Context, tenantID string) ([]*entities.TenantDomain, error)
+	VerifyDomain(ctx context.Context, tenantID string, domainID string) (*entities.TenantDomain, error)
+	RemoveDomain(ctx context.Context, tenantID string, domainID string) error
+}
+
+// Authorizer checks that the caller of ctx may exercise permission on resource of a tenant.
+// tenantID is empty for operations outside of a tenant.
+type Authorizer func(
+	ctx context.Context, resource entities.Resource, permission entities.Permission, tenantID string,
+) error
+
+// AuditService reads the audit trail.
+type AuditService interface {
+	QueryAuditRecords(ctx context.Context, filter entities.AuditFilter) ([]*entities.AuditRecord, error)
+}
+
+// WebhookService manages the webhook endpoints of tenants and the deliveries of events to them.
+type WebhookService interface {
+	CreateEndpoint(
+		ctx context.Context, tenantID string, endpoint *entities.WebhookEndpoint,
+	) (*entities.WebhookEndpoint, error)
+	GetEndpoints(ctx context.Context, tenantID string) ([]*entities.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, tenantID string, endpointID string) (*entities.WebhookEndpoint, error)
+	UpdateEndpoint(
+		ctx context.Context, tenantID string, endpoint *entities.WebhookEndpoint,
+	) (*entities.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, tenantID string, endpointID string) error
+	SendTestEvent(ctx context.Context, tenantID string, endpointID string) (*entities.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, filter entities.WebhookDeliveryFilter) ([]*entities.WebhookDelivery, error)
+	Redeliver(ctx context.Context, tenantID string, deliveryID string) (*entities.WebhookDelivery, error)
+}
+
+type Option func(*Server)
+
+// WithMiddleware wraps the API routes with middlewares, applied in the given order.
+// The OpenAPI document is served without them.
+func WithMiddleware(middlewares ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.middlewares = append(s.middlewares, middlewares...)
+	}
+}
+
+// WithAuthorizer checks every request against the x-authorization extension of its operation.
+// Requests are not authorized without it.
+func WithAuthorizer(authorize Authorizer) Option {
+	return func(s *Server) {
+		s.authorize = authorize
+	}
+}
+
+// WithHandler serves handler at pattern besides the API, without its middlewares, for
+// endpoints meant for operators and orchestrators such as health checks.
+func WithHandler(pattern string, handler http.Handler) Option {
+	return func(s *Server) {
+		if s.operational == nil {
+			s.operational = map[string]http.Handler{}
+		}
+		s.operational[pattern] = handler
+	}
+}
+
+// resolvedTenant marks the operations whose tenant is only known to their handler, such as the
+// tenant of a role, the handler authorizes them once it is known.
+const resolvedTenant = "resolved"
+
+// authorization is the x-authorization extension of an operation.
+type authorization struct {
+	Resource   entities.Resource   `json:"resource"`
+	Permission entities.Permission `json:"permission"`
+	Tenant     string              `json:"tenant,omitempty"`
+}
+
+// webhookOperations are the operations of the webhook service.
+var webhookOperations = []string{
+	"listTenantWebhooks", "createTenantWebhook", "getTenantWebhook", "updateTenantWebhook", "deleteTenantWebhook",
+	"testTenantWebhook", "listTenantWebhookDeliveries", "redeliverTenantWebhookDelivery",
+}
+
+// Server routes HTTP requests to the application services. Routes are taken from the
+// OpenAPI document, and every request is validated against it before reaching a service.
+type Server struct {
+	tenants     service.TenantService
+	roles       domain.RoleService
+	domains     DomainService
+	audits      AuditService
+	webhooks    WebhookService
+	logger      utils.LoggerInterface
+	doc         *openapi3.T
+	router      routers.Router
+	spec        []byte
+	handlers    map[string]http.HandlerFunc
+	access      map[string]authorization
+	authorize   Authorizer
+	middlewares []func(http.Handler) http.Handler
+	// operational are the handlers served besides the API, by pattern.
+	operational map[string]http.Handler
+	observer    Observer
+}
+
+// NewServer returns the server of the API. audits and webhooks are nil with the database backends
+// keeping neither, their operations then fail with apperrors.ErrFeatureUnavailable.
+func NewServer(
+	logger utils.LoggerInterface,
+	tenants service.TenantService,
+	roles domain.RoleService,
+	domains DomainService,
+	audits AuditService,
+	webhooks WebhookService,
+	opts ...Option,
+) (*Server, error) {
+	doc, err := LoadSpecification()
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := newRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode openapi specification")
+	}
+
+	s := &Server{
+		tenants:  tenants,
+		roles:    roles,
+		domains:  domains,
+		audits:   audits,
+		webhooks: webhooks,
+		logger:   logger,
+		doc:      doc,
+		router:   router,
+		spec:     spec,
+	}
+
+	s.handlers = map[string]http.HandlerFunc{
+		"listTenants":                    s.listTenants,
+		"createTenant":                   s.createTenant,
+		"getTenant":                      s.getTenant,
+		"updateTenant":                   s.updateTenant,
+		"deleteTenant":                   s.deleteTenant,
+		"listTenantCompanies":            s.listTenantCompanies,
+		"getTenantCompany":               s.getTenantCompany,
+		"updateTenantCompany":            s.updateTenantCompany,
+		"listTenantSubscriptions":        s.listTenantSubscriptions,
+		"updateTenantSubscription":       s.updateTenantSubscription,
+		"listTenantPaymentDetails":       s.listTenantPaymentDetails,
+		"updateTenantPaymentDetails":     s.updateTenantPaymentDetails,
+		"getTenantByPaymentID":           s.getTenantByPaymentID,
+		"listTenantDomains":              s.listTenantDomains,
+		"addTenantDomain":                s.addTenantDomain,
+		"removeTenantDomain":             s.removeTenantDomain,
+		"verifyTenantDomain":             s.verifyTenantDomain,
+		"listTenantAPIKeys":              s.listTenantAPIKeys,
+		"createTenantAPIKey":             s.createTenantAPIKey,
+		"rotateTenantAPIKey":             s.rotateTenantAPIKey,
+		"revokeTenantAPIKey":             s.revokeTenantAPIKey,
+		"listTenantAuditRecords":         s.listTenantAuditRecords,
+		"listTenantWebhooks":             s.listTenantWebhooks,
+		"createTenantWebhook":            s.createTenantWebhook,
+		"getTenantWebhook":               s.getTenantWebhook,
+		"updateTenantWebhook":            s.updateTenantWebhook,
+		"deleteTenantWebhook":            s.deleteTenantWebhook,
+		"testTenantWebhook":              s.testTenantWebhook,
+		"listTenantWebhookDeliveries":    s.listTenantWebhookDeliveries,
+		"redeliverTenantWebhookDelivery": s.redeliverTenantWebhookDelivery,
+		"listRoles":                      s.listRoles,
+		"createRole":                     s.createRole,
+		"createCustomRole":               s.createCustomRole,
+		"getRole":                        s.getRole,
+		"updateRole":                     s.updateRole,
+		"deleteRole":                     s.deleteRole,
+	}
+
+	// the audit trail and webhooks are not kept by every database backend
+	if audits == nil {
+		s.handlers["listTenantAuditRecords"] = unavailable
+	}
+	if webhooks == nil {
+		for _, operation := range webhookOperations {
+			s.handlers[operation] = unavailable
+		}
+	}
+
+	// every operation of the document must be implemented and declare what it accesses
+	s.access = make(map[string]authorization, len(s.handlers))
+	for path, item := range doc.Paths {
+		for method, operation := range item.Operations() {
+			if _, ok := s.handlers[operation.OperationID]; !ok {
+				return nil, errors.Errorf("no handler for operation %s %s", method, path)
+			}
+
+			access, err := operationAuthorization(operation)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid operation %s %s", method, path)
+			}
+			s.access[operation.OperationID] = access
+		}
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Handler returns the HTTP handler serving the API, its OpenAPI document and the handlers given
+// with WithHandler. Every response carries the X-Request-ID of its request, and every request
+// is reported to the observer of the server, if any.
+func (s *Server) Handler() http.Handler {
+	var api http.Handler = http.HandlerFunc(s.serveAPI)
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		api = s.middlewares[i](api)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/openapi.json", s.serveSpecification)
+	for pattern, handler := range s.operational {
+		mux.Handle(pattern, handler)
+	}
+	mux.Handle("/", api)
+
+	handler := requestID(mux)
+	if s.observer != nil {
+		handler = s.observe(handler)
+	}
+
+	return handler
+}
+
+func (s *Server) serveSpecification(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		apperrors.WriteProblem(w, r, apperrors.ErrMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(s.spec)
+}
+
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	route, params, err := s.router.FindRoute(r)
+	if err != nil {
+		// the router returns copies of its sentinel errors, so they are told apart by reason
+		var routeErr *routers.RouteError
+		if errors.As(err, &routeErr) && routeErr.Reason == routers.ErrMethodNotAllowed.Error() {
+			apperrors.WriteProblem(w, r, apperrors.ErrMethodNotAllowed)
+			return
+		}
+
+		apperrors.WriteProblem(w, r, apperrors.ErrRouteNotFound)
+		return
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: params,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			MultiError:         true,
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+
+	if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+		s.logger.Infof("rejected invalid request to %s: %v", route.Operation.OperationID, err)
+		apperrors.WriteProblem(w, r, apperrors.ErrValidation.WithFields(fieldErrors(err)...))
+		return
+	}
+
+	access := s.access[route.Operation.OperationID]
+	if access.Tenant != resolvedTenant {
+		if err := s.authorized(r.Context(), access.Resource, access.Permission, params["tenantId"]); err != nil {
+			writeError(w, r, s.logger, err)
+			return
+		}
+	}
+
+	ctx := context.WithValue(r.Context(), pathParamsKey{}, params)
+	s.handlers[route.Operation.OperationID](w, r.WithContext(ctx))
+}
+
+// authorized runs the authorizer of the server, if any.
+func (s *Server) authorized(
+	ctx context.Context, resource entities.Resource, permission entities.Permission, tenantID string,
+) error {
+	if s.authorize == nil {
+		return nil
+	}
+
+	return s.authorize(ctx, resource, permission, tenantID)
+}
+
+func operationAuthorization(operation *openapi3.Operation) (authorization, error) {
+	var access authorization
+
+	extension, ok := operation.Extensions["x-authorization"]
+	if !ok {
+		return access, errors.New("missing x-authorization extension")
+	}
+
+	// the extension is decoded generically, round trip it to get a typed value
+	raw, err := json.Marshal(extension)
+	if err != nil {
+		return access, errors.Wrap(err, "failed to encode x-authorization extension")
+	}
+
+	if err := json.Unmarshal(raw, &access); err != nil {
+		return access, errors.Wrap(err, "failed to decode x-authorization extension")
+	}
+
+	if !access.Resource.IsValid() || !access.Permission.IsValid() ||
+		(access.Tenant != "" && access.Tenant != resolvedTenant) {
+		return access, errors.Errorf("invalid x-authorization extension %s", raw)
+	}
+
+	return access, nil
+}
+
+type pathParamsKey struct{}
+
+func pathParam(r *http.Request, name string) string {
+	params, _ := r.Context().Value(pathParamsKey{}).(map[string]string)
+	return params[name]
+}
+
+// decode reads the JSON request body into v. The body was already validated against
+// the OpenAPI document, so decoding errors are reported as validation errors.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		field := apperrors.FieldError{Field: "body", Message: err.Error()}
+		apperrors.WriteProblem(w, r, apperrors.ErrValidation.WithFields(field))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,1005 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/infrastructure/rest"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTenantRepository struct {
+	mu      sync.Mutex
+	tenants map[string]*entities.Tenant
+}
+
+func (f *fakeTenantRepository) CreateTenant(_ context.Context, tenant *entities.Tenant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (f *fakeTenantRepository) DeleteTenant(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tenant, ok := f.tenants[id]
+	if !ok {
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+	tenant.IsActive = false
+	return nil
+}
+
+func (f *fakeTenantRepository) GetTenantByID(_ context.Context, id string) (*entities.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tenant, ok := f.tenants[id]
+	if !ok {
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+	return tenant, nil
+}
+
+func (f *fakeTenantRepository) GetTenants(_ context.Context) ([]*entities.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var tenants []*entities.Tenant
+	for _, tenant := range f.tenants {
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+func (f *fakeTenantRepository) UpdateTenant(_ context.Context, tenant *entities.Tenant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (f *fakeTenantRepository) SearchTenant(_ context.Context, _ map[string]any) (*entities.Tenant, error) {
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (f *fakeTenantRepository) SearchTenants(_ context.Context, _ map[string]any) ([]*entities.Tenant, error) {
+	return nil, nil
+}
+
+type fakeRolesRepository struct {
+	roles map[string]*entities.Role
+}
+
+func (f *fakeRolesRepository) SaveRole(_ context.Context, role *entities.Role) error {
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeRolesRepository) UpdateRole(_ context.Context, role *entities.Role) error {
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeRolesRepository) DeleteRole(_ context.Context, roleID utils.XID) error {
+	delete(f.roles, roleID.ID)
+	return nil
+}
+
+func (f *fakeRolesRepository) FindRoleByID(_ context.Context, roleID utils.XID) (*entities.Role, error) {
+	role, ok := f.roles[roleID.ID]
+	if !ok {
+		return nil, apperrors.ErrNoRoleDocumentsFound
+	}
+	return role, nil
+}
+
+func (f *fakeRolesRepository) FindAllRoles(_ context.Context) ([]*entities.Role, error) {
+	var roles []*entities.Role
+	for _, role := range f.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+type fakeAuditRepository struct {
+	records []*entities.AuditRecord
+}
+
+func (f *fakeAuditRepository) QueryAuditRecords(_ context.Context, filter entities.AuditFilter) (
+	[]*entities.AuditRecord, error,
+) {
+	var records []*entities.AuditRecord
+	for _, record := range f.records {
+		if record.TenantID == filter.TenantID && (filter.Actor == "" || record.Actor == filter.Actor) &&
+			record.Sequence > filter.AfterSequence && len(records) < filter.Limit {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (f *fakeAuditRepository) VerifyAuditChain(context.Context) error {
+	return nil
+}
+
+type fakeWebhookRepository struct {
+	mu         sync.Mutex
+	endpoints  map[string]*entities.WebhookEndpoint
+	deliveries map[string]*entities.WebhookDelivery
+}
+
+func (f *fakeWebhookRepository) SaveEndpoint(_ context.Context, endpoint *entities.WebhookEndpoint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stored := *endpoint
+	f.endpoints[endpoint.ID] = &stored
+	return nil
+}
+
+func (f *fakeWebhookRepository) UpdateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error {
+	return f.SaveEndpoint(ctx, endpoint)
+}
+
+func (f *fakeWebhookRepository) DeleteEndpoint(_ context.Context, tenantID string, endpointID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if endpoint, ok := f.endpoints[endpointID]; !ok || endpoint.TenantID != tenantID {
+		return apperrors.ErrNoWebhookFound
+	}
+	delete(f.endpoints, endpointID)
+	return nil
+}
+
+func (f *fakeWebhookRepository) FindEndpoint(_ context.Context, tenantID string, endpointID string) (
+	*entities.WebhookEndpoint, error,
+) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	endpoint, ok := f.endpoints[endpointID]
+	if !ok || endpoint.TenantID != tenantID {
+		return nil, apperrors.ErrNoWebhookFound
+	}
+	found := *endpoint
+	return &found, nil
+}
+
+func (f *fakeWebhookRepository) FindEndpoints(_ context.Context, tenantID string) (
+	[]*entities.WebhookEndpoint, error,
+) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var endpoints []*entities.WebhookEndpoint
+	for _, endpoint := range f.endpoints {
+		if endpoint.TenantID == tenantID {
+			found := *endpoint
+			endpoints = append(endpoints, &found)
+		}
+	}
+	return endpoints, nil
+}
+
+func (f *fakeWebhookRepository) SaveDelivery(_ context.Context, delivery *entities.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.deliveries[delivery.ID]; !ok {
+		f.deliveries[delivery.ID] = delivery
+	}
+	return nil
+}
+
+func (f *fakeWebhookRepository) UpdateDelivery(_ context.Context, delivery *entities.WebhookDelivery) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (f *fakeWebhookRepository) FindDelivery(_ context.Context, tenantID string, deliveryID string) (
+	*entities.WebhookDelivery, error,
+) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delivery, ok := f.deliveries[deliveryID]
+	if !ok || delivery.TenantID != tenantID {
+		return nil, apperrors.ErrNoWebhookDeliveryFound
+	}
+	return delivery, nil
+}
+
+func (f *fakeWebhookRepository) FindDeliveries(_ context.Context, filter entities.WebhookDeliveryFilter) (
+	[]*entities.WebhookDelivery, error,
+) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deliveries []*entities.WebhookDelivery
+	for _, delivery := range f.deliveries {
+		if delivery.TenantID == filter.TenantID && (filter.Status == "" || delivery.Status == filter.Status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (f *fakeWebhookRepository) ClaimDeliveries(context.Context, int, time.Duration) (
+	[]*entities.WebhookDelivery, error,
+) {
+	return nil, nil
+}
+
+// fakeWebhookSender rejects every delivery.
+type fakeWebhookSender struct{}
+
+func (fakeWebhookSender) Send(
+	context.Context, *entities.WebhookEndpoint, *entities.WebhookDelivery,
+) entities.WebhookAttempt {
+	return entities.WebhookAttempt{At: time.Now().UTC(), StatusCode: http.StatusServiceUnavailable}
+}
+
+type fakeVerifier struct{}
+
+func (fakeVerifier) Verify(_ context.Context, _ *entities.TenantDomain) error {
+	return nil
+}
+
+func newTestServer(t *testing.T, opts ...rest.Option) *httptest.Server {
+	logger := utils.NewLogger()
+	repository := &fakeTenantRepository{tenants: map[string]*entities.Tenant{}}
+	tenants := service.NewTenantService(logger, repository)
+	roles := service.NewRoleService(logger, &fakeRolesRepository{roles: map[string]*entities.Role{}})
+	domains := service.NewDomainService(logger, repository, fakeVerifier{})
+	audits := service.NewAuditService(
+		logger, &fakeAuditRepository{
+			records: []*entities.AuditRecord{
+				{Sequence: 1, TenantID: "tenant-1", Actor: "contact-1", Operation: entities.AuditCreate},
+				{Sequence: 2, TenantID: "tenant-2", Actor: "contact-2", Operation: entities.AuditCreate},
+				{Sequence: 3, TenantID: "tenant-1", Actor: "contact-2", Operation: entities.AuditUpdate},
+				{Sequence: 4, TenantID: "tenant-1", Actor: "contact-1", Operation: entities.AuditDelete},
+			},
+		},
+	)
+
+	webhooks := service.NewWebhookService(
+		logger, &fakeWebhookRepository{
+			endpoints:  map[string]*entities.WebhookEndpoint{},
+			deliveries: map[string]*entities.WebhookDelivery{},
+		}, repository, fakeWebhookSender{},
+	)
+
+	server, err := rest.NewServer(logger, tenants, roles, domains, audits, webhooks, opts...)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func do(t *testing.T, ts *httptest.Server, method, path, body string) (*http.Response, map[string]any) {
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var decoded map[string]any
+	_ = json.NewDecoder(res.Body).Decode(&decoded)
+
+	return res, decoded
+}
+
+func TestServer_Tenants(t *testing.T) {
+	ts := newTestServer(t)
+
+	res, created := do(t, ts, http.MethodPost, "/tenants", `{"name": "Acme", "subdomain": "acme"}`)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	require.NotEmpty(t, created["_id"])
+
+	res, tenant := do(t, ts, http.MethodGet, "/tenants/"+created["_id"].(string), "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Acme", tenant["name"])
+
+	res, problem := do(t, ts, http.MethodGet, "/tenants/missing", "")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "application/problem+json", res.Header.Get("Content-Type"))
+	assert.Equal(t, "tenant_not_found", problem["code"])
+	assert.Equal(t, "/tenants/missing", problem["instance"])
+}
+
+func TestServer_UpdateTenantSubscription(t *testing.T) {
+	ts := newTestServer(t)
+
+	res, created := do(
+		t, ts, http.MethodPost, "/tenants", `{
+			"name": "Acme", "subdomain": "acme", "is_active": true,
+			"primary_contacts": [{"_id": "contact-1", "email": "ada@acme.io"}],
+			"companies": [
+				{"_id": "company-1", "name": "Acme Inc", "subscriptions": [
+					{"_id": "subscription-1", "plan": "starter"}
+				]},
+				{"_id": "company-2", "name": "Acme Labs", "subscriptions": [
+					{"_id": "subscription-2", "plan": "starter"}, {"_id": "subscription-3", "plan": "pro"}
+				]}
+			]
+		}`,
+	)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	path := "/tenants/" + created["_id"].(string)
+
+	body := `{"_id": "subscription-2", "plan": "premium"}`
+	res, _ = do(t, ts, http.MethodPut, path+"/subscriptions/subscription-2", body)
+	require.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	// only the subscription changed, the rest of the tenant is kept
+	res, tenant := do(t, ts, http.MethodGet, path, "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Acme", tenant["name"])
+	assert.Equal(t, "acme", tenant["subdomain"])
+	assert.Equal(t, true, tenant["is_active"])
+	assert.Len(t, tenant["primary_contacts"], 1)
+
+	plans := map[string]string{}
+	companies := map[string]string{}
+	for _, company := range tenant["companies"].([]any) {
+		company := company.(map[string]any)
+		companies[company["_id"].(string)] = company["name"].(string)
+		for _, subscription := range company["subscriptions"].([]any) {
+			subscription := subscription.(map[string]any)
+			plans[subscription["_id"].(string)] = subscription["plan"].(string)
+		}
+	}
+	assert.Equal(t, map[string]string{"company-1": "Acme Inc", "company-2": "Acme Labs"}, companies)
+	assert.Equal(
+		t, map[string]string{"subscription-1": "starter", "subscription-2": "premium", "subscription-3": "pro"}, plans,
+	)
+}
+
+func TestServer_RequestValidation(t *testing.T) {
+	ts := newTestServer(t)
+
+	type testCase struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+		ExpectedCode   string
+		ExpectedFields []string
+	}
+
+	testCases := []testCase{
+		{
+			Name:           "Missing required fields",
+			Method:         http.MethodPost,
+			Path:           "/tenants",
+			Body:           `{}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedCode:   "validation_failed",
+			ExpectedFields: []string{"name", "subdomain"},
+		},
+		{
+			Name:           "Invalid subdomain and contact email",
+			Method:         http.MethodPost,
+			Path:           "/tenants",
+			Body:           `{"name": "Acme", "subdomain": "Not Valid", "primary_contacts": [{"email": "nope"}]}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedCode:   "validation_failed",
+			ExpectedFields: []string{"subdomain", "primary_contacts.0.email"},
+		},
+		{
+			Name:           "Invalid permission",
+			Method:         http.MethodPost,
+			Path:           "/roles",
+			Body:           `{"name": "admin", "permissions": ["read", "launch"]}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedCode:   "validation_failed",
+			ExpectedFields: []string{"permissions.1"},
+		},
+		{
+			Name:           "Invalid subscription date",
+			Method:         http.MethodPut,
+			Path:           "/tenants/t1/subscriptions/s1",
+			Body:           `{"_id": "s1", "plan": "gold", "start_date": "yesterday"}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedCode:   "validation_failed",
+			ExpectedFields: []string{"start_date"},
+		},
+		{
+			Name:           "Malformed body",
+			Method:         http.MethodPost,
+			Path:           "/tenants",
+			Body:           `{"name":`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedCode:   "validation_failed",
+			ExpectedFields: []string{"body"},
+		},
+		{
+			Name:           "Unknown route",
+			Method:         http.MethodGet,
+			Path:           "/unknown",
+			ExpectedStatus: http.StatusNotFound,
+			ExpectedCode:   "route_not_found",
+		},
+		{
+			Name:           "Method not allowed",
+			Method:         http.MethodPatch,
+			Path:           "/tenants",
+			Body:           `{}`,
+			ExpectedStatus: http.StatusMethodNotAllowed,
+			ExpectedCode:   "method_not_allowed",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(
+			tc.Name, func(t *testing.T) {
+				res, body := do(t, ts, tc.Method, tc.Path, tc.Body)
+				assert.Equal(t, tc.ExpectedStatus, res.StatusCode)
+				assert.Equal(t, tc.ExpectedCode, body["code"])
+
+				var fields []string
+				rawFields, _ := body["errors"].([]any)
+				for _, f := range rawFields {
+					fields = append(fields, f.(map[string]any)["field"].(string))
+				}
+				for _, expected := range tc.ExpectedFields {
+					assert.Contains(t, fields, expected)
+				}
+			},
+		)
+	}
+}
+
+func TestServer_Specification(t *testing.T) {
+	ts := newTestServer(t)
+
+	res, doc := do(t, ts, http.MethodGet, "/openapi.json", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.Equal(t, "3.0.3", doc["openapi"])
+	assert.Contains(t, doc["paths"], "/tenants/{tenantId}")
+}
+
+func TestServer_WithHandler(t *testing.T) {
+	// the handler is served without the middlewares of the API
+	ts := newTestServer(
+		t,
+		rest.WithMiddleware(
+			func(http.Handler) http.Handler {
+				return http.HandlerFunc(
+					func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusUnauthorized)
+					},
+				)
+			},
+		),
+		rest.WithHandler(
+			"/healthz", http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write([]byte(`{"status": "pass"}`))
+				},
+			),
+		),
+	)
+
+	res, body := do(t, ts, http.MethodGet, "/healthz", "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "pass", body["status"])
+	assert.NotEmpty(t, res.Header.Get("X-Request-ID"))
+
+	res, _ = do(t, ts, http.MethodGet, "/tenants", "")
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+func TestServer_WithObserver(t *testing.T) {
+	type observation struct {
+		Method string
+		Route  string
+		Status int
+	}
+
+	var (
+		mu           sync.Mutex
+		observations []observation
+	)
+	ts := newTestServer(
+		t, rest.WithObserver(
+			func(r *http.Request, route string, status int, duration time.Duration) {
+				mu.Lock()
+				defer mu.Unlock()
+				observations = append(observations, observation{Method: r.Method, Route: route, Status: status})
+			},
+		),
+	)
+
+	res, tenant := do(t, ts, http.MethodPost, "/tenants", `{"name": "Acme", "subdomain": "acme"}`)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	do(t, ts, http.MethodGet, "/tenants/"+tenant["_id"].(string), "")
+	do(t, ts, http.MethodDelete, "/tenants/unknown", "")
+	do(t, ts, http.MethodGet, "/unknown/path", "")
+	do(t, ts, http.MethodGet, "/openapi.json", "")
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(
+		t, []observation{
+			{Method: http.MethodPost, Route: "/tenants", Status: http.StatusCreated},
+			{Method: http.MethodGet, Route: "/tenants/{tenantId}", Status: http.StatusOK},
+			{Method: http.MethodDelete, Route: "/tenants/{tenantId}", Status: http.StatusNotFound},
+			{Method: http.MethodGet, Route: rest.UnmatchedRoute, Status: http.StatusNotFound},
+			{Method: http.MethodGet, Route: "/openapi.json", Status: http.StatusOK},
+		}, observations,
+	)
+}
+
+// adminRole grants every permission.
+var adminRole = &entities.Role{
+	Name: "admin",
+	Permissions: []entities.Permission{
+		entities.ReadPermission, entities.WritePermission, entities.EditPermission, entities.DeletePermission,
+	},
+}
+
+// withPrincipal authenticates every request as the principal *principal points to.
+func withPrincipal(principal **authn.Principal) rest.Option {
+	return rest.WithMiddleware(
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					next.ServeHTTP(w, r.WithContext(authn.WithPrincipal(r.Context(), *principal)))
+				},
+			)
+		},
+	)
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	var principal *authn.Principal
+	ts := newTestServer(t, withPrincipal(&principal), rest.WithAuthorizer(authn.Authorize))
+
+	principal = &authn.Principal{
+		Subject: "contact-1", Method: authn.MethodJWT, Roles: []*entities.Role{adminRole}, Platform: true,
+	}
+	res, tenant := do(
+		t, ts, http.MethodPost, "/tenants",
+		`{"name": "Acme", "subdomain": "acme", "primary_contacts": [{"_id": "contact-1", "email": "ada@example.com"}]}`,
+	)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	tenantID := tenant["_id"].(string)
+	principal.TenantID, principal.Platform = tenantID, false
+
+	res, created := do(
+		t, ts, http.MethodPost, "/tenants/"+tenantID+"/api-keys",
+		`{"name": "ci", "scopes": [{"resource": "domains", "permissions": ["read"]}]}`,
+	)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "contact-1", created["created_by"])
+	assert.NotContains(t, created, "hash")
+	key := created["key"].(string)
+	assert.True(t, strings.HasPrefix(key, created["prefix"].(string)+"_"))
+
+	res, listed := doList(t, ts, "/tenants/"+tenantID+"/api-keys")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, listed, 1)
+	assert.NotContains(t, listed[0], "key")
+	assert.NotContains(t, listed[0], "salt")
+
+	keyID := created["_id"].(string)
+	res, rotated := do(t, ts, http.MethodPost, "/tenants/"+tenantID+"/api-keys/"+keyID+"/rotate", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NotEqual(t, key, rotated["key"])
+	assert.NotEqual(t, created["prefix"], rotated["prefix"])
+
+	// the key is restricted to its scopes and its tenant
+	principal = &authn.Principal{
+		Subject:  "contact-1",
+		TenantID: tenantID,
+		Method:   authn.MethodAPIKey,
+		Roles:    []*entities.Role{adminRole},
+		APIKey: &entities.APIKey{
+			Scopes: []entities.APIKeyScope{
+				{Resource: entities.DomainsResource, Permissions: []entities.Permission{entities.ReadPermission}},
+				{Resource: entities.APIKeysResource, Permissions: []entities.Permission{entities.WritePermission}},
+			},
+		},
+	}
+
+	res, _ = doList(t, ts, "/tenants/"+tenantID+"/domains")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var testCases = []struct {
+		Name           string
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+		ExpectedCode   string
+	}{
+		{
+			Name:           "permission outside of the scopes",
+			Method:         http.MethodPost,
+			Path:           "/tenants/" + tenantID + "/domains",
+			Body:           `{"hostname": "acme.example.com", "verification_method": "dns_txt"}`,
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedCode:   "forbidden",
+		},
+		{
+			Name:           "resource outside of the scopes",
+			Method:         http.MethodGet,
+			Path:           "/tenants/" + tenantID,
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedCode:   "forbidden",
+		},
+		{
+			Name:           "other tenant",
+			Method:         http.MethodGet,
+			Path:           "/tenants/other/domains",
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedCode:   "forbidden",
+		},
+		{
+			Name:           "issuing a key with more access",
+			Method:         http.MethodPost,
+			Path:           "/tenants/" + tenantID + "/api-keys",
+			Body:           `{"name": "admin", "scopes": [{"resource": "roles", "permissions": ["write"]}]}`,
+			ExpectedStatus: http.StatusForbidden,
+			ExpectedCode:   "forbidden",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				res, problem := do(t, ts, tt.Method, tt.Path, tt.Body)
+				assert.Equal(t, tt.ExpectedStatus, res.StatusCode)
+				assert.Equal(t, tt.ExpectedCode, problem["code"])
+			},
+		)
+	}
+
+	principal.Method, principal.APIKey = authn.MethodJWT, nil
+	res, _ = do(t, ts, http.MethodDelete, "/tenants/"+tenantID+"/api-keys/"+keyID, "")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res, problem := do(t, ts, http.MethodPost, "/tenants/"+tenantID+"/api-keys/"+keyID+"/rotate", "")
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid_api_key", problem["code"])
+}
+
+func TestServer_Authorization(t *testing.T) {
+	var principal *authn.Principal
+	ts := newTestServer(t, withPrincipal(&principal), rest.WithAuthorizer(authn.Authorize))
+
+	operator := &authn.Principal{
+		Subject: "operator", TenantID: "platform", Method: authn.MethodJWT, Roles: []*entities.Role{adminRole},
+		Platform: true,
+	}
+	principal = operator
+
+	tenantIDs := make([]string, 2)
+	for i, subdomain := range []string{"acme", "globex"} {
+		body := fmt.Sprintf(`{"name": "%s", "subdomain": "%s"}`, subdomain, subdomain)
+		res, tenant := do(t, ts, http.MethodPost, "/tenants", body)
+		require.Equal(t, http.StatusCreated, res.StatusCode)
+		tenantIDs[i] = tenant["_id"].(string)
+	}
+
+	res, platformRole := do(t, ts, http.MethodPost, "/roles", `{"name": "support", "permissions": ["read"]}`)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	res, otherRole := do(
+		t, ts, http.MethodPost, "/tenants/"+tenantIDs[1]+"/roles", `{"name": "billing", "permissions": ["read"]}`,
+	)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	member := &authn.Principal{
+		Subject: "contact-1", TenantID: tenantIDs[0], Method: authn.MethodJWT, Roles: []*entities.Role{adminRole},
+	}
+	reader := &authn.Principal{
+		Subject: "contact-2", TenantID: tenantIDs[0], Method: authn.MethodJWT,
+		Roles: []*entities.Role{{Name: "viewer", Permissions: []entities.Permission{entities.ReadPermission}}},
+	}
+
+	principal = member
+	res, ownRole := do(
+		t, ts, http.MethodPost, "/tenants/"+tenantIDs[0]+"/roles", `{"name": "billing", "permissions": ["read"]}`,
+	)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	var testCases = []struct {
+		Name           string
+		Principal      *authn.Principal
+		Method         string
+		Path           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{
+			Name:           "Happy Path: platform lists tenants",
+			Principal:      operator,
+			Method:         http.MethodGet,
+			Path:           "/tenants",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Happy Path: platform reads another tenant",
+			Principal:      operator,
+			Method:         http.MethodGet,
+			Path:           "/tenants/" + tenantIDs[1],
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Happy Path: member reads a custom role of its tenant",
+			Principal:      member,
+			Method:         http.MethodGet,
+			Path:           "/roles/" + ownRole["_id"].(string),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "Happy Path: member edits a custom role of its tenant",
+			Principal:      member,
+			Method:         http.MethodPut,
+			Path:           "/roles/" + ownRole["_id"].(string),
+			Body:           `{"name": "billing", "permissions": ["read", "edit"]}`,
+			ExpectedStatus: http.StatusNoContent,
+		},
+		{
+			Name:           "Error Path: member lists tenants",
+			Principal:      member,
+			Method:         http.MethodGet,
+			Path:           "/tenants",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: member creates a tenant",
+			Principal:      member,
+			Method:         http.MethodPost,
+			Path:           "/tenants",
+			Body:           `{"name": "Initech", "subdomain": "initech"}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: member creates a platform role",
+			Principal:      member,
+			Method:         http.MethodPost,
+			Path:           "/roles",
+			Body:           `{"name": "owner", "permissions": ["read"]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: member lists roles",
+			Principal:      member,
+			Method:         http.MethodGet,
+			Path:           "/roles",
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: member edits a platform role",
+			Principal:      member,
+			Method:         http.MethodPut,
+			Path:           "/roles/" + platformRole["_id"].(string),
+			Body:           `{"name": "support", "permissions": ["read", "delete"]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: member deletes a custom role of another tenant",
+			Principal:      member,
+			Method:         http.MethodDelete,
+			Path:           "/roles/" + otherRole["_id"].(string),
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: member creates a custom role in another tenant",
+			Principal:      member,
+			Method:         http.MethodPost,
+			Path:           "/tenants/" + tenantIDs[1] + "/roles",
+			Body:           `{"name": "owner", "permissions": ["read"]}`,
+			ExpectedStatus: http.StatusForbidden,
+		},
+		{
+			Name:           "Error Path: user outside of its roles",
+			Principal:      reader,
+			Method:         http.MethodDelete,
+			Path:           "/roles/" + ownRole["_id"].(string),
+			ExpectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				principal = tt.Principal
+				res, _ := do(t, ts, tt.Method, tt.Path, tt.Body)
+				assert.Equal(t, tt.ExpectedStatus, res.StatusCode)
+			},
+		)
+	}
+
+	// the role of another tenant is untouched
+	principal = operator
+	res, _ = do(t, ts, http.MethodGet, "/roles/"+otherRole["_id"].(string), "")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
+
+func doList(t *testing.T, ts *httptest.Server, path string) (*http.Response, []map[string]any) {
+	res, err := http.Get(ts.URL + path)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	var decoded []map[string]any
+	_ = json.NewDecoder(res.Body).Decode(&decoded)
+
+	return res, decoded
+}
+
+func TestServer_AuditRecords(t *testing.T) {
+	ts := newTestServer(t)
+
+	var testCases = []struct {
+		Name              string
+		Query             string
+		ExpectedStatus    int
+		ExpectedSequences []float64
+	}{
+		{
+			Name:              "Records of the tenant",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedSequences: []float64{1, 3, 4},
+		},
+		{
+			Name:              "Records of an actor",
+			Query:             "?actor=contact-1",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedSequences: []float64{1, 4},
+		},
+		{
+			Name:              "Next page",
+			Query:             "?after=1&limit=1",
+			ExpectedStatus:    http.StatusOK,
+			ExpectedSequences: []float64{3},
+		},
+		{
+			Name:           "Inverted time range",
+			Query:          "?from=2023-06-02T00:00:00Z&to=2023-06-01T00:00:00Z",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+		{
+			Name:           "Limit out of range",
+			Query:          "?limit=5000",
+			ExpectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				res, records := doList(t, ts, "/tenants/tenant-1/audit-records"+tt.Query)
+				require.Equal(t, tt.ExpectedStatus, res.StatusCode)
+				if tt.ExpectedStatus != http.StatusOK {
+					return
+				}
+
+				var sequences []float64
+				for _, record := range records {
+					sequences = append(sequences, record["sequence"].(float64))
+				}
+				assert.Equal(t, tt.ExpectedSequences, sequences)
+			},
+		)
+	}
+}
+
+func TestServer_Webhooks(t *testing.T) {
+	ts := newTestServer(t)
+
+	res, tenant := do(t, ts, http.MethodPost, "/tenants", `{"name": "Acme", "subdomain": "acme"}`)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	webhooks := "/tenants/" + tenant["_id"].(string) + "/webhooks"
+
+	res, problem := do(t, ts, http.MethodPost, webhooks, `{"url": "https://hooks.example.com", "event_types": ["x"]}`)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "validation_failed", problem["code"])
+
+	res, problem = do(t, ts, http.MethodPost, webhooks, `{"url": "http://hooks.example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid_webhook", problem["code"])
+
+	res, created := do(
+		t, ts, http.MethodPost, webhooks, `{"url": "https://hooks.example.com", "event_types": ["tenant.updated"]}`,
+	)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, true, created["active"])
+	assert.True(t, strings.HasPrefix(created["secret"].(string), service.WebhookSecretPrefix))
+	webhook := webhooks + "/" + created["_id"].(string)
+
+	// the secret is only returned when the endpoint is registered
+	res, listed := doList(t, ts, webhooks)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, listed, 1)
+	assert.NotContains(t, listed[0], "secret")
+
+	res, updated := do(t, ts, http.MethodPut, webhook, `{"url": "https://hooks.example.com/v2", "active": false}`)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, false, updated["active"])
+	assert.NotContains(t, updated, "secret")
+
+	res, delivery := do(t, ts, http.MethodPost, webhook+"/test", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "webhook.test", delivery["event_type"])
+	assert.Equal(t, "dead", delivery["status"])
+
+	deliveries := "/tenants/" + tenant["_id"].(string) + "/webhook-deliveries"
+	res, dead := doList(t, ts, deliveries+"?status=dead")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, dead, 1)
+
+	res, redelivered := do(t, ts, http.MethodPost, deliveries+"/"+dead[0]["_id"].(string)+"/redeliver", "")
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "pending", redelivered["status"])
+
+	res, _ = doList(t, ts, deliveries+"?status=lost")
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, _ = do(t, ts, http.MethodDelete, webhook, "")
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res, problem = do(t, ts, http.MethodGet, webhook, "")
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "webhook_not_found", problem["code"])
+}
+
+func TestServer_UnavailableFeatures(t *testing.T) {
+	logger := utils.NewLogger()
+	repository := &fakeTenantRepository{tenants: map[string]*entities.Tenant{}}
+	server, err := rest.NewServer(
+		logger, service.NewTenantService(logger, repository),
+		service.NewRoleService(logger, &fakeRolesRepository{roles: map[string]*entities.Role{}}),
+		service.NewDomainService(logger, repository, fakeVerifier{}), nil, nil,
+	)
+	require.NoError(t, err)
+	ts := httptest.NewServer(server.Handler())
+	t.Cleanup(ts.Close)
+
+	res, tenant := do(t, ts, http.MethodPost, "/tenants", `{"name": "Acme", "subdomain": "acme"}`)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+
+	// the audit trail and webhooks are not kept by the database backend
+	for _, path := range []string{"/audit-records", "/webhooks"} {
+		res, problem := do(t, ts, http.MethodGet, "/tenants/"+tenant["_id"].(string)+path, "")
+		assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+		assert.Equal(t, "feature_unavailable", problem["code"])
+	}
+}
+
+func TestServer_RequestID(t *testing.T) {
+	ts := newTestServer(t)
+
+	res, _ := do(t, ts, http.MethodGet, "/tenants", "")
+	assert.NotEmpty(t, res.Header.Get(rest.RequestIDHeader))
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/tenants", nil)
+	require.NoError(t, err)
+	req.Header.Set(rest.RequestIDHeader, "request-1")
+
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "request-1", res.Header.Get(rest.RequestIDHeader))
+}
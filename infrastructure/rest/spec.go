@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"context"
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/pkg/errors"
+)
+
+//go:embed openapi.yaml
+var specification []byte
+
+func init() {
+	// contact emails are declared with the email format, which is not validated by default
+	openapi3.DefineStringFormat("email", openapi3.FormatOfStringForEmail)
+}
+
+// LoadSpecification parses and validates the OpenAPI document describing the API.
+func LoadSpecification() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(specification)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load openapi specification")
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, errors.Wrap(err, "invalid openapi specification")
+	}
+
+	return doc, nil
+}
+
+func newRouter(doc *openapi3.T) (routers.Router, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build router from openapi specification")
+	}
+
+	return router, nil
+}
@@ -0,0 +1,174 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+func (s *Server) listTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := s.tenants.GetTenants(r.Context())
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if tenants == nil {
+		tenants = []*entities.Tenant{}
+	}
+
+	writeJSON(w, http.StatusOK, tenants)
+}
+
+func (s *Server) createTenant(w http.ResponseWriter, r *http.Request) {
+	var tenant entities.Tenant
+	if !decode(w, r, &tenant) {
+		return
+	}
+
+	// domains and api keys are managed through their own endpoints only
+	tenant.Domains = nil
+	tenant.APIKeys = nil
+
+	if err := s.tenants.CreateTenant(r.Context(), &tenant); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, tenant)
+}
+
+func (s *Server) getTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, err := s.tenants.GetTenantByID(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tenant)
+}
+
+func (s *Server) updateTenant(w http.ResponseWriter, r *http.Request) {
+	var tenant entities.Tenant
+	if !decode(w, r, &tenant) {
+		return
+	}
+
+	if err := s.tenants.UpdateTenant(r.Context(), pathParam(r, "tenantId"), &tenant); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteTenant(w http.ResponseWriter, r *http.Request) {
+	if err := s.tenants.DeleteTenant(r.Context(), pathParam(r, "tenantId")); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTenantCompanies(w http.ResponseWriter, r *http.Request) {
+	companies, err := s.tenants.GetTenantCompanies(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, companies)
+}
+
+func (s *Server) getTenantCompany(w http.ResponseWriter, r *http.Request) {
+	company, err := s.tenants.GetTenantCompanyByID(r.Context(), pathParam(r, "tenantId"), pathParam(r, "companyId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, company)
+}
+
+func (s *Server) updateTenantCompany(w http.ResponseWriter, r *http.Request) {
+	var company entities.TenantCompanyDetails
+	if !decode(w, r, &company) {
+		return
+	}
+
+	company.ID = pathParam(r, "companyId")
+	if err := s.tenants.UpdateTenantCompany(r.Context(), pathParam(r, "tenantId"), &company); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTenantSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := s.tenants.GetTenantCompaniesSubscriptions(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, subscriptions)
+}
+
+func (s *Server) updateTenantSubscription(w http.ResponseWriter, r *http.Request) {
+	var subscription entities.TenantSubscriptionDetails
+	if !decode(w, r, &subscription) {
+		return
+	}
+
+	subscription.ID = pathParam(r, "subscriptionId")
+	if err := s.tenants.UpdateTenantSubscription(r.Context(), pathParam(r, "tenantId"), &subscription); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listTenantPaymentDetails(w http.ResponseWriter, r *http.Request) {
+	payments, err := s.tenants.GetTenantPaymentDetails(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, payments)
+}
+
+func (s *Server) updateTenantPaymentDetails(w http.ResponseWriter, r *http.Request) {
+	var payment entities.TenantPaymentDetails
+	if !decode(w, r, &payment) {
+		return
+	}
+
+	payment.ID = pathParam(r, "paymentId")
+	if err := s.tenants.UpdateTenantPaymentDetails(r.Context(), pathParam(r, "tenantId"), &payment); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getTenantByPaymentID(w http.ResponseWriter, r *http.Request) {
+	tenant, err := s.tenants.GetTenantByPaymentID(r.Context(), pathParam(r, "paymentId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	// the tenant is only known once found, so its access is checked afterwards
+	err = s.authorized(r.Context(), entities.PaymentDetailsResource, entities.ReadPermission, tenant.ID)
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tenant)
+}
@@ -0,0 +1,151 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+type webhookRequest struct {
+	URL         string               `json:"url"`
+	Description string               `json:"description"`
+	EventTypes  []entities.EventType `json:"event_types"`
+	Active      *bool                `json:"active"`
+}
+
+// endpoint returns the endpoint described by the request, endpoints are active unless stated otherwise.
+func (req webhookRequest) endpoint() *entities.WebhookEndpoint {
+	endpoint := &entities.WebhookEndpoint{
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  req.EventTypes,
+		Active:      true,
+	}
+	if req.Active != nil {
+		endpoint.Active = *req.Active
+	}
+
+	return endpoint
+}
+
+// withoutSecret returns a copy of an endpoint without its secret, which is only sent when the
+// endpoint is registered.
+func withoutSecret(endpoint *entities.WebhookEndpoint) *entities.WebhookEndpoint {
+	redacted := *endpoint
+	redacted.Secret = ""
+	return &redacted
+}
+
+func (s *Server) listTenantWebhooks(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := s.webhooks.GetEndpoints(r.Context(), pathParam(r, "tenantId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	redacted := make([]*entities.WebhookEndpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		redacted = append(redacted, withoutSecret(endpoint))
+	}
+
+	writeJSON(w, http.StatusOK, redacted)
+}
+
+func (s *Server) createTenantWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	endpoint, err := s.webhooks.CreateEndpoint(r.Context(), pathParam(r, "tenantId"), req.endpoint())
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, endpoint)
+}
+
+func (s *Server) getTenantWebhook(w http.ResponseWriter, r *http.Request) {
+	endpoint, err := s.webhooks.GetEndpoint(r.Context(), pathParam(r, "tenantId"), pathParam(r, "webhookId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, withoutSecret(endpoint))
+}
+
+func (s *Server) updateTenantWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	endpoint := req.endpoint()
+	endpoint.ID = pathParam(r, "webhookId")
+
+	endpoint, err := s.webhooks.UpdateEndpoint(r.Context(), pathParam(r, "tenantId"), endpoint)
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, withoutSecret(endpoint))
+}
+
+func (s *Server) deleteTenantWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := s.webhooks.DeleteEndpoint(r.Context(), pathParam(r, "tenantId"), pathParam(r, "webhookId")); err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) testTenantWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, err := s.webhooks.SendTestEvent(r.Context(), pathParam(r, "tenantId"), pathParam(r, "webhookId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+func (s *Server) listTenantWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	// the query was validated against the OpenAPI document, so it parses
+	query := r.URL.Query()
+	filter := entities.WebhookDeliveryFilter{
+		TenantID:   pathParam(r, "tenantId"),
+		EndpointID: query.Get("webhook_id"),
+		Status:     entities.WebhookDeliveryStatus(query.Get("status")),
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		filter.Limit, _ = strconv.Atoi(limit)
+	}
+
+	deliveries, err := s.webhooks.GetDeliveries(r.Context(), filter)
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*entities.WebhookDelivery{}
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+func (s *Server) redeliverTenantWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	delivery, err := s.webhooks.Redeliver(r.Context(), pathParam(r, "tenantId"), pathParam(r, "deliveryId"))
+	if err != nil {
+		writeError(w, r, s.logger, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	tenantv1 "github.com/hebecoding/tenant-management/api/tenant/v1"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// Authorizer checks that the caller of ctx may exercise permission on resource of a tenant.
+// tenantID is empty for calls outside of a tenant.
+type Authorizer func(
+	ctx context.Context, resource entities.Resource, permission entities.Permission, tenantID string,
+) error
+
+// access is what a method requires. tenantID returns the tenant a request is about, it is nil
+// for methods outside of tenants, which only platform principals may call.
+type access struct {
+	resource   entities.Resource
+	permission entities.Permission
+	tenantID   func(req any) string
+}
+
+type tenantIDGetter interface {
+	GetTenantId() string
+}
+
+type idGetter interface {
+	GetId() string
+}
+
+func requestTenantID(req any) string {
+	if r, ok := req.(tenantIDGetter); ok {
+		return r.GetTenantId()
+	}
+	return ""
+}
+
+type roleGetter interface {
+	GetRole() *tenantv1.Role
+}
+
+// roleTenantID is the tenant of the requests carrying a role.
+func roleTenantID(req any) string {
+	if r, ok := req.(roleGetter); ok {
+		return r.GetRole().GetTenantId()
+	}
+	return ""
+}
+
+// requestID is the tenant of the requests of the tenant service that address tenants by ID.
+func requestID(req any) string {
+	if r, ok := req.(idGetter); ok {
+		return r.GetId()
+	}
+	return ""
+}
+
+// servicesPrefix matches the methods of the services of this package, they must all have an access.
+const servicesPrefix = "/tenant.v1."
+
+var methodAccess = map[string]access{
+	tenantv1.TenantService_CreateTenant_FullMethodName:               {entities.TenantsResource, entities.WritePermission, nil},
+	tenantv1.TenantService_GetTenant_FullMethodName:                  {entities.TenantsResource, entities.ReadPermission, requestID},
+	tenantv1.TenantService_GetTenantByPaymentID_FullMethodName:       {entities.PaymentDetailsResource, entities.ReadPermission, nil},
+	tenantv1.TenantService_ListTenants_FullMethodName:                {entities.TenantsResource, entities.ReadPermission, nil},
+	tenantv1.TenantService_UpdateTenant_FullMethodName:               {entities.TenantsResource, entities.EditPermission, requestID},
+	tenantv1.TenantService_DeleteTenant_FullMethodName:               {entities.TenantsResource, entities.DeletePermission, requestID},
+	tenantv1.TenantService_ListTenantCompanies_FullMethodName:        {entities.CompaniesResource, entities.ReadPermission, requestTenantID},
+	tenantv1.TenantService_GetTenantCompany_FullMethodName:           {entities.CompaniesResource, entities.ReadPermission, requestTenantID},
+	tenantv1.TenantService_UpdateTenantCompany_FullMethodName:        {entities.CompaniesResource, entities.EditPermission, requestTenantID},
+	tenantv1.TenantService_ListTenantSubscriptions_FullMethodName:    {entities.SubscriptionsResource, entities.ReadPermission, requestTenantID},
+	tenantv1.TenantService_UpdateTenantSubscription_FullMethodName:   {entities.SubscriptionsResource, entities.EditPermission, requestTenantID},
+	tenantv1.TenantService_ListTenantPaymentDetails_FullMethodName:   {entities.PaymentDetailsResource, entities.ReadPermission, requestTenantID},
+	tenantv1.TenantService_UpdateTenantPaymentDetails_FullMethodName: {entities.PaymentDetailsResource, entities.EditPermission, requestTenantID},
+	tenantv1.RoleService_CreateRole_FullMethodName:                   {entities.RolesResource, entities.WritePermission, nil},
+	tenantv1.RoleService_CreateCustomRole_FullMethodName:             {entities.RolesResource, entities.WritePermission, roleTenantID},
+	tenantv1.RoleService_GetRole_FullMethodName:                      {entities.RolesResource, entities.ReadPermission, nil},
+	tenantv1.RoleService_ListRoles_FullMethodName:                    {entities.RolesResource, entities.ReadPermission, nil},
+	tenantv1.RoleService_UpdateRole_FullMethodName:                   {entities.RolesResource, entities.EditPermission, nil},
+	tenantv1.RoleService_DeleteRole_FullMethodName:                   {entities.RolesResource, entities.DeletePermission, nil},
+}
+
+// resolvedMethods only learn the tenant a request is about from their response, they are
+// authorized against it.
+var resolvedMethods = map[string]bool{
+	tenantv1.TenantService_GetTenantByPaymentID_FullMethodName: true,
+}
+
+// AuthorizationInterceptor checks unary calls of the tenant and role services with authorize.
+// It must run after the authentication interceptor. Calls of other services, such as health
+// checks, are not checked.
+func AuthorizationInterceptor(authorize Authorizer) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, servicesPrefix) {
+			return handler(ctx, req)
+		}
+
+		method, ok := methodAccess[info.FullMethod]
+		if !ok {
+			return nil, toStatus(errors.Wrapf(apperrors.ErrForbidden, "no access defined for %s", info.FullMethod))
+		}
+
+		resolved := resolvedMethods[info.FullMethod]
+		if !resolved {
+			var tenantID string
+			if method.tenantID != nil {
+				tenantID = method.tenantID(req)
+			}
+
+			if err := authorize(ctx, method.resource, method.permission, tenantID); err != nil {
+				return nil, toStatus(err)
+			}
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// tenants looked up by payment are only known once found
+		if r, ok := resp.(*tenantv1.GetTenantResponse); ok && resolved {
+			if err := authorize(ctx, method.resource, method.permission, r.GetTenant().GetId()); err != nil {
+				return nil, toStatus(err)
+			}
+		}
+
+		return resp, nil
+	}
+}
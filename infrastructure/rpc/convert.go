@@ -0,0 +1,399 @@
+package rpc
+
+import (
+	"time"
+
+	tenantv1 "github.com/hebecoding/tenant-management/api/tenant/v1"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var permissionsToProto = map[entities.Permission]tenantv1.Permission{
+	entities.ReadPermission:   tenantv1.Permission_PERMISSION_READ,
+	entities.WritePermission:  tenantv1.Permission_PERMISSION_WRITE,
+	entities.EditPermission:   tenantv1.Permission_PERMISSION_EDIT,
+	entities.DeletePermission: tenantv1.Permission_PERMISSION_DELETE,
+}
+
+var permissionsFromProto = map[tenantv1.Permission]entities.Permission{
+	tenantv1.Permission_PERMISSION_READ:   entities.ReadPermission,
+	tenantv1.Permission_PERMISSION_WRITE:  entities.WritePermission,
+	tenantv1.Permission_PERMISSION_EDIT:   entities.EditPermission,
+	tenantv1.Permission_PERMISSION_DELETE: entities.DeletePermission,
+}
+
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+func timeFromProto(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+
+	return t.AsTime()
+}
+
+func tenantToProto(t *entities.Tenant) *tenantv1.Tenant {
+	if t == nil {
+		return nil
+	}
+
+	tenant := &tenantv1.Tenant{
+		Id:             t.ID,
+		Name:           t.Name,
+		Subdomain:      t.Subdomain,
+		UpdatedBy:      t.UpdatedBy,
+		IsActive:       t.IsActive,
+		TenantMetadata: metadataToProto(t.TenantMetadata),
+		CreatedAt:      timeToProto(t.CreatedAt),
+		UpdatedAt:      timeToProto(t.UpdatedAt),
+		DeletedAt:      timeToProto(t.DeletedAt),
+	}
+
+	for _, company := range t.Companies {
+		tenant.Companies = append(tenant.Companies, companyToProto(company))
+	}
+	for _, payment := range t.PaymentDetails {
+		tenant.PaymentDetails = append(tenant.PaymentDetails, paymentToProto(payment))
+	}
+	for _, contact := range t.PrimaryContacts {
+		tenant.PrimaryContacts = append(tenant.PrimaryContacts, contactToProto(contact))
+	}
+	for _, domain := range t.Domains {
+		tenant.Domains = append(tenant.Domains, domainToProto(domain))
+	}
+
+	return tenant
+}
+
+func tenantFromProto(t *tenantv1.Tenant) *entities.Tenant {
+	if t == nil {
+		return nil
+	}
+
+	tenant := &entities.Tenant{
+		ID:             t.GetId(),
+		Name:           t.GetName(),
+		Subdomain:      t.GetSubdomain(),
+		UpdatedBy:      t.GetUpdatedBy(),
+		IsActive:       t.GetIsActive(),
+		TenantMetadata: metadataFromProto(t.GetTenantMetadata()),
+		CreatedAt:      timeFromProto(t.GetCreatedAt()),
+		UpdatedAt:      timeFromProto(t.GetUpdatedAt()),
+		DeletedAt:      timeFromProto(t.GetDeletedAt()),
+	}
+
+	for _, company := range t.GetCompanies() {
+		tenant.Companies = append(tenant.Companies, companyFromProto(company))
+	}
+	for _, payment := range t.GetPaymentDetails() {
+		tenant.PaymentDetails = append(tenant.PaymentDetails, paymentFromProto(payment))
+	}
+	for _, contact := range t.GetPrimaryContacts() {
+		tenant.PrimaryContacts = append(tenant.PrimaryContacts, contactFromProto(contact))
+	}
+	// domains are read-only here, they are managed through the domain verification flow
+
+	return tenant
+}
+
+func addressToProto(a *entities.Address) *tenantv1.Address {
+	if a == nil {
+		return nil
+	}
+
+	return &tenantv1.Address{
+		Id:       a.ID,
+		Address:  a.Address,
+		Address2: a.Address2,
+		City:     a.City,
+		State:    a.State,
+		ZipCode:  a.ZipCode,
+		Country:  a.Country,
+	}
+}
+
+func addressFromProto(a *tenantv1.Address) *entities.Address {
+	if a == nil {
+		return nil
+	}
+
+	return &entities.Address{
+		ID:       a.GetId(),
+		Address:  a.GetAddress(),
+		Address2: a.GetAddress2(),
+		City:     a.GetCity(),
+		State:    a.GetState(),
+		ZipCode:  a.GetZipCode(),
+		Country:  a.GetCountry(),
+	}
+}
+
+func paymentToProto(p *entities.TenantPaymentDetails) *tenantv1.TenantPaymentDetails {
+	if p == nil {
+		return nil
+	}
+
+	return &tenantv1.TenantPaymentDetails{
+		Id:             p.ID,
+		BillingAddress: addressToProto(p.Address),
+		CardType:       p.CardType,
+		CardNumber:     p.CardNumber,
+		SecurityCode:   p.SecurityCode,
+		ExpMonth:       int32(p.ExpMonth),
+		ExpYear:        int32(p.ExpYear),
+		IsActive:       p.IsActive,
+	}
+}
+
+func paymentFromProto(p *tenantv1.TenantPaymentDetails) *entities.TenantPaymentDetails {
+	if p == nil {
+		return nil
+	}
+
+	return &entities.TenantPaymentDetails{
+		ID:           p.GetId(),
+		Address:      addressFromProto(p.GetBillingAddress()),
+		CardType:     p.GetCardType(),
+		CardNumber:   p.GetCardNumber(),
+		SecurityCode: p.GetSecurityCode(),
+		ExpMonth:     int(p.GetExpMonth()),
+		ExpYear:      int(p.GetExpYear()),
+		IsActive:     p.GetIsActive(),
+	}
+}
+
+func companyToProto(c *entities.TenantCompanyDetails) *tenantv1.TenantCompanyDetails {
+	if c == nil {
+		return nil
+	}
+
+	company := &tenantv1.TenantCompanyDetails{
+		Id:                 c.ID,
+		Name:               c.Name,
+		WebsiteUrl:         c.WebsiteURL,
+		LogoUrl:            c.LogoURL,
+		Industry:           c.Industry,
+		RegistrationNumber: c.RegistrationNumber,
+		IsActive:           c.IsActive,
+		Address:            addressToProto(c.Address),
+	}
+
+	for _, subscription := range c.Subscriptions {
+		company.Subscriptions = append(company.Subscriptions, subscriptionToProto(subscription))
+	}
+
+	return company
+}
+
+func companyFromProto(c *tenantv1.TenantCompanyDetails) *entities.TenantCompanyDetails {
+	if c == nil {
+		return nil
+	}
+
+	company := &entities.TenantCompanyDetails{
+		ID:                 c.GetId(),
+		Name:               c.GetName(),
+		WebsiteURL:         c.GetWebsiteUrl(),
+		LogoURL:            c.GetLogoUrl(),
+		Industry:           c.GetIndustry(),
+		RegistrationNumber: c.GetRegistrationNumber(),
+		IsActive:           c.GetIsActive(),
+		Address:            addressFromProto(c.GetAddress()),
+	}
+
+	for _, subscription := range c.GetSubscriptions() {
+		company.Subscriptions = append(company.Subscriptions, subscriptionFromProto(subscription))
+	}
+
+	return company
+}
+
+func contactToProto(c *entities.TenantContactDetails) *tenantv1.TenantContactDetails {
+	if c == nil {
+		return nil
+	}
+
+	contact := &tenantv1.TenantContactDetails{
+		Id:                c.ID,
+		FirstName:         c.FirstName,
+		LastName:          c.LastName,
+		Email:             c.Email,
+		PhoneNumber:       c.PhoneNumber,
+		AvatarUrl:         c.AvatarURL,
+		JobTitle:          c.JobTitle,
+		PreferredLanguage: c.PreferredLanguage,
+		Timezone:          c.Timezone,
+		IsActive:          c.IsActive,
+	}
+
+	for _, role := range c.Roles {
+		contact.Roles = append(contact.Roles, roleToProto(role))
+	}
+
+	return contact
+}
+
+func contactFromProto(c *tenantv1.TenantContactDetails) *entities.TenantContactDetails {
+	if c == nil {
+		return nil
+	}
+
+	contact := &entities.TenantContactDetails{
+		ID:                c.GetId(),
+		FirstName:         c.GetFirstName(),
+		LastName:          c.GetLastName(),
+		Email:             c.GetEmail(),
+		PhoneNumber:       c.GetPhoneNumber(),
+		AvatarURL:         c.GetAvatarUrl(),
+		JobTitle:          c.GetJobTitle(),
+		PreferredLanguage: c.GetPreferredLanguage(),
+		Timezone:          c.GetTimezone(),
+		IsActive:          c.GetIsActive(),
+	}
+
+	for _, role := range c.GetRoles() {
+		contact.Roles = append(contact.Roles, roleFromProto(role))
+	}
+
+	return contact
+}
+
+func subscriptionToProto(s *entities.TenantSubscriptionDetails) *tenantv1.TenantSubscriptionDetails {
+	if s == nil {
+		return nil
+	}
+
+	return &tenantv1.TenantSubscriptionDetails{
+		Id:              s.ID,
+		Plan:            s.Plan,
+		BillingCycle:    s.BillingCycle,
+		PaymentStatus:   s.PaymentStatus,
+		PaymentGateway:  s.PaymentGateway,
+		DiscountRate:    s.DiscountRate,
+		Discount:        s.Discount,
+		Active:          s.Active,
+		AutoRenew:       s.AutoRenew,
+		StartDate:       timeToProto(s.StartDate),
+		EndDate:         timeToProto(s.EndDate),
+		NextBillingDate: timeToProto(s.NextBillingDate),
+		LastPaymentDate: timeToProto(s.LastPaymentDate),
+	}
+}
+
+func subscriptionFromProto(s *tenantv1.TenantSubscriptionDetails) *entities.TenantSubscriptionDetails {
+	if s == nil {
+		return nil
+	}
+
+	return &entities.TenantSubscriptionDetails{
+		ID:              s.GetId(),
+		Plan:            s.GetPlan(),
+		BillingCycle:    s.GetBillingCycle(),
+		PaymentStatus:   s.GetPaymentStatus(),
+		PaymentGateway:  s.GetPaymentGateway(),
+		DiscountRate:    s.GetDiscountRate(),
+		Discount:        s.GetDiscount(),
+		Active:          s.GetActive(),
+		AutoRenew:       s.GetAutoRenew(),
+		StartDate:       timeFromProto(s.GetStartDate()),
+		EndDate:         timeFromProto(s.GetEndDate()),
+		NextBillingDate: timeFromProto(s.GetNextBillingDate()),
+		LastPaymentDate: timeFromProto(s.GetLastPaymentDate()),
+	}
+}
+
+func metadataToProto(m *entities.TenantMetadata) *tenantv1.TenantMetadata {
+	if m == nil {
+		return nil
+	}
+
+	return &tenantv1.TenantMetadata{
+		Id:           m.ID,
+		DatabaseName: m.DatabaseName,
+		TimeZone:     m.TimeZone,
+		StorageQuota: m.StorageQuota,
+		StorageUsed:  m.StorageUsed,
+		CreatedAt:    timeToProto(m.CreatedAt),
+		UpdatedAt:    timeToProto(m.UpdatedAt),
+	}
+}
+
+func metadataFromProto(m *tenantv1.TenantMetadata) *entities.TenantMetadata {
+	if m == nil {
+		return nil
+	}
+
+	return &entities.TenantMetadata{
+		ID:           m.GetId(),
+		DatabaseName: m.GetDatabaseName(),
+		TimeZone:     m.GetTimeZone(),
+		StorageQuota: m.GetStorageQuota(),
+		StorageUsed:  m.GetStorageUsed(),
+		CreatedAt:    timeFromProto(m.GetCreatedAt()),
+		UpdatedAt:    timeFromProto(m.GetUpdatedAt()),
+	}
+}
+
+func domainToProto(d *entities.TenantDomain) *tenantv1.TenantDomain {
+	if d == nil {
+		return nil
+	}
+
+	// the verification token is only handed out by the domain endpoints
+	return &tenantv1.TenantDomain{
+		Id:                 d.ID,
+		Hostname:           d.Hostname,
+		VerificationMethod: string(d.VerificationMethod),
+		Status:             string(d.Status),
+		FailureReason:      d.FailureReason,
+		CreatedAt:          timeToProto(d.CreatedAt),
+		VerifiedAt:         timeToProto(d.VerifiedAt),
+		LastCheckedAt:      timeToProto(d.LastCheckedAt),
+	}
+}
+
+func roleToProto(r *entities.Role) *tenantv1.Role {
+	if r == nil {
+		return nil
+	}
+
+	role := &tenantv1.Role{
+		Id:          r.ID,
+		TenantId:    r.TenantID,
+		Name:        r.Name,
+		Description: r.Description,
+	}
+
+	for _, permission := range r.Permissions {
+		role.Permissions = append(role.Permissions, permissionsToProto[permission])
+	}
+
+	return role
+}
+
+// roleFromProto converts a role. Unknown permissions map to an empty permission so that
+// the role service rejects the role.
+func roleFromProto(r *tenantv1.Role) *entities.Role {
+	if r == nil {
+		return nil
+	}
+
+	role := &entities.Role{
+		ID:          r.GetId(),
+		TenantID:    r.GetTenantId(),
+		Name:        r.GetName(),
+		Description: r.GetDescription(),
+	}
+
+	for _, permission := range r.GetPermissions() {
+		role.Permissions = append(role.Permissions, permissionsFromProto[permission])
+	}
+
+	return role
+}
@@ -0,0 +1,19 @@
+package rpc
+
+import (
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+)
+
+// toStatus maps an application error to a gRPC status error.
+// Errors that are not known to the application are reported as internal errors.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return apperrors.GRPCStatus(err).Err()
+}
+
+func errRequired(field string) error {
+	return apperrors.ErrValidation.WithFields(apperrors.FieldError{Field: field, Message: "is required"})
+}
@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDMetadata identifies a call across services and in the audit trail.
+// Clients may send their own ID, one is generated otherwise.
+const RequestIDMetadata = "x-request-id"
+
+const maxRequestIDLength = 128
+
+func requestIDInterceptor(
+	ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadata); len(values) > 0 && len(values[0]) <= maxRequestIDLength {
+			id = values[0]
+		}
+	}
+
+	if id == "" {
+		id = utils.NewXID().ID
+	}
+
+	// the ID is returned even when the call fails
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDMetadata, id))
+
+	return handler(audit.WithRequestID(ctx, id), req)
+}
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	tenantv1 "github.com/hebecoding/tenant-management/api/tenant/v1"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+)
+
+// RoleServer exposes the role service over gRPC.
+type RoleServer struct {
+	tenantv1.UnimplementedRoleServiceServer
+
+	service service.RoleService
+}
+
+func NewRoleServer(service service.RoleService) *RoleServer {
+	return &RoleServer{service: service}
+}
+
+func (s *RoleServer) CreateRole(ctx context.Context, req *tenantv1.CreateRoleRequest) (
+	*tenantv1.CreateRoleResponse, error,
+) {
+	if req.GetRole() == nil {
+		return nil, toStatus(errRequired("role"))
+	}
+
+	role := roleFromProto(req.GetRole())
+	if err := s.service.CreateRole(ctx, role); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.CreateRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *RoleServer) CreateCustomRole(ctx context.Context, req *tenantv1.CreateRoleRequest) (
+	*tenantv1.CreateRoleResponse, error,
+) {
+	if req.GetRole() == nil {
+		return nil, toStatus(errRequired("role"))
+	}
+
+	role := roleFromProto(req.GetRole())
+	if err := s.service.CreateCustomRole(ctx, role); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.CreateRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *RoleServer) GetRole(ctx context.Context, req *tenantv1.GetRoleRequest) (*tenantv1.GetRoleResponse, error) {
+	role, err := s.service.GetRole(ctx, utils.XID{ID: req.GetId()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.GetRoleResponse{Role: roleToProto(role)}, nil
+}
+
+func (s *RoleServer) ListRoles(ctx context.Context, _ *tenantv1.ListRolesRequest) (
+	*tenantv1.ListRolesResponse, error,
+) {
+	roles, err := s.service.GetRoles(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &tenantv1.ListRolesResponse{}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, roleToProto(role))
+	}
+
+	return resp, nil
+}
+
+func (s *RoleServer) UpdateRole(ctx context.Context, req *tenantv1.UpdateRoleRequest) (
+	*tenantv1.UpdateRoleResponse, error,
+) {
+	if req.GetRole() == nil {
+		return nil, toStatus(errRequired("role"))
+	}
+
+	if err := s.service.UpdateRole(ctx, roleFromProto(req.GetRole())); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.UpdateRoleResponse{}, nil
+}
+
+func (s *RoleServer) DeleteRole(ctx context.Context, req *tenantv1.DeleteRoleRequest) (
+	*tenantv1.DeleteRoleResponse, error,
+) {
+	if err := s.service.DeleteRole(ctx, utils.XID{ID: req.GetId()}); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.DeleteRoleResponse{}, nil
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"net"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	tenantv1 "github.com/hebecoding/tenant-management/api/tenant/v1"
+	"github.com/hebecoding/tenant-management/application/service"
+	domain "github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+type Server struct {
+	*grpc.Server
+
+	Health *health.Server
+	logger utils.LoggerInterface
+}
+
+// NewServer registers the tenant and role services on a gRPC server,
+// together with the standard health checking and server reflection services.
+// Every call is identified by the x-request-id metadata, before opts interceptors run.
+func NewServer(
+	logger utils.LoggerInterface,
+	tenants service.TenantService,
+	roles domain.RoleService,
+	opts ...grpc.ServerOption,
+) *Server {
+	opts = append([]grpc.ServerOption{grpc.ChainUnaryInterceptor(requestIDInterceptor)}, opts...)
+	server := grpc.NewServer(opts...)
+
+	tenantv1.RegisterTenantServiceServer(server, NewTenantServer(tenants))
+	tenantv1.RegisterRoleServiceServer(server, NewRoleServer(roles))
+
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	healthServer.SetServingStatus(tenantv1.TenantService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(tenantv1.RoleService_ServiceDesc.ServiceName, grpc_health_v1.HealthCheckResponse_SERVING)
+
+	reflection.Register(server)
+
+	return &Server{
+		Server: server,
+		Health: healthServer,
+		logger: logger,
+	}
+}
+
+// ListenAndServe serves gRPC requests on addr until the server is stopped.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen for grpc connections")
+	}
+
+	s.logger.Infof("serving grpc on %s", listener.Addr())
+	return s.Serve(listener)
+}
+
+// GracefulStop marks every service as not serving before draining in-flight requests.
+func (s *Server) GracefulStop() {
+	s.Health.Shutdown()
+	s.Server.GracefulStop()
+}
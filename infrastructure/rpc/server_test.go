@@ -0,0 +1,365 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	tenantv1 "github.com/hebecoding/tenant-management/api/tenant/v1"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/authn"
+	"github.com/hebecoding/tenant-management/infrastructure/rpc"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeTenantRepository struct {
+	mu      sync.Mutex
+	tenants map[string]*entities.Tenant
+}
+
+func (f *fakeTenantRepository) CreateTenant(_ context.Context, tenant *entities.Tenant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (f *fakeTenantRepository) DeleteTenant(_ context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tenant, ok := f.tenants[id]
+	if !ok {
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+	tenant.IsActive = false
+	return nil
+}
+
+func (f *fakeTenantRepository) GetTenantByID(_ context.Context, id string) (*entities.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tenant, ok := f.tenants[id]
+	if !ok {
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+	return tenant, nil
+}
+
+func (f *fakeTenantRepository) GetTenants(_ context.Context) ([]*entities.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var tenants []*entities.Tenant
+	for _, tenant := range f.tenants {
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+func (f *fakeTenantRepository) UpdateTenant(_ context.Context, tenant *entities.Tenant) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tenants[tenant.ID] = tenant
+	return nil
+}
+
+func (f *fakeTenantRepository) SearchTenant(_ context.Context, _ map[string]any) (*entities.Tenant, error) {
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (f *fakeTenantRepository) SearchTenants(_ context.Context, _ map[string]any) ([]*entities.Tenant, error) {
+	return nil, nil
+}
+
+type fakeRolesRepository struct {
+	roles map[string]*entities.Role
+}
+
+func (f *fakeRolesRepository) SaveRole(_ context.Context, role *entities.Role) error {
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeRolesRepository) UpdateRole(_ context.Context, role *entities.Role) error {
+	f.roles[role.ID] = role
+	return nil
+}
+
+func (f *fakeRolesRepository) DeleteRole(_ context.Context, roleID utils.XID) error {
+	delete(f.roles, roleID.ID)
+	return nil
+}
+
+func (f *fakeRolesRepository) FindRoleByID(_ context.Context, roleID utils.XID) (*entities.Role, error) {
+	role, ok := f.roles[roleID.ID]
+	if !ok {
+		return nil, apperrors.ErrNoRoleDocumentsFound
+	}
+	return role, nil
+}
+
+func (f *fakeRolesRepository) FindAllRoles(_ context.Context) ([]*entities.Role, error) {
+	var roles []*entities.Role
+	for _, role := range f.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func newTestConn(t *testing.T, opts ...grpc.ServerOption) *grpc.ClientConn {
+	logger := utils.NewLogger()
+	tenants := service.NewTenantService(logger, &fakeTenantRepository{tenants: map[string]*entities.Tenant{}})
+	roles := service.NewRoleService(logger, &fakeRolesRepository{roles: map[string]*entities.Role{}})
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := rpc.NewServer(logger, tenants, roles, opts...)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.GracefulStop)
+
+	conn, err := grpc.DialContext(
+		context.Background(), "bufnet",
+		grpc.WithContextDialer(
+			func(ctx context.Context, _ string) (net.Conn, error) {
+				return listener.DialContext(ctx)
+			},
+		),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func TestTenantServer(t *testing.T) {
+	ctx := context.Background()
+	client := tenantv1.NewTenantServiceClient(newTestConn(t))
+
+	created, err := client.CreateTenant(
+		ctx, &tenantv1.CreateTenantRequest{
+			Tenant: &tenantv1.Tenant{
+				Name:      "Acme",
+				Subdomain: "acme",
+				IsActive:  true,
+				Companies: []*tenantv1.TenantCompanyDetails{{Id: "company-1", Name: "Acme Inc"}},
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.GetTenant().GetId())
+	assert.NotNil(t, created.GetTenant().GetCreatedAt())
+
+	got, err := client.GetTenant(ctx, &tenantv1.GetTenantRequest{Id: created.GetTenant().GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", got.GetTenant().GetName())
+
+	company, err := client.GetTenantCompany(
+		ctx, &tenantv1.GetTenantCompanyRequest{TenantId: created.GetTenant().GetId(), CompanyId: "company-1"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "Acme Inc", company.GetCompany().GetName())
+
+	_, err = client.GetTenant(ctx, &tenantv1.GetTenantRequest{Id: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.UpdateTenantSubscription(ctx, &tenantv1.UpdateTenantSubscriptionRequest{TenantId: "missing"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRoleServer(t *testing.T) {
+	ctx := context.Background()
+	client := tenantv1.NewRoleServiceClient(newTestConn(t))
+
+	created, err := client.CreateRole(
+		ctx, &tenantv1.CreateRoleRequest{
+			Role: &tenantv1.Role{
+				Name:        "Admin",
+				Permissions: []tenantv1.Permission{tenantv1.Permission_PERMISSION_READ},
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	got, err := client.GetRole(ctx, &tenantv1.GetRoleRequest{Id: created.GetRole().GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, []tenantv1.Permission{tenantv1.Permission_PERMISSION_READ}, got.GetRole().GetPermissions())
+
+	_, err = client.CreateRole(
+		ctx, &tenantv1.CreateRoleRequest{
+			Role: &tenantv1.Role{Name: "Broken", Permissions: []tenantv1.Permission{tenantv1.Permission(42)}},
+		},
+	)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateRole(ctx, &tenantv1.CreateRoleRequest{Role: &tenantv1.Role{Name: "Custom", TenantId: "t"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestHealth(t *testing.T) {
+	client := grpc_health_v1.NewHealthClient(newTestConn(t))
+
+	resp, err := client.Check(
+		context.Background(),
+		&grpc_health_v1.HealthCheckRequest{Service: tenantv1.TenantService_ServiceDesc.ServiceName},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestAuthentication(t *testing.T) {
+	logger := utils.NewLogger()
+	tenants := service.NewTenantService(logger, &fakeTenantRepository{tenants: map[string]*entities.Tenant{}})
+	authenticator := authn.NewAuthenticator(logger, authn.WithAPIKeys(authn.NewAPIKeyAuthenticator(logger, tenants)))
+
+	conn := newTestConn(
+		t,
+		grpc.ChainUnaryInterceptor(authenticator.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(authenticator.StreamServerInterceptor()),
+	)
+
+	_, err := tenantv1.NewTenantServiceClient(conn).GetTenant(context.Background(), &tenantv1.GetTenantRequest{Id: "t"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "tm_000000000000_secret")
+	_, err = tenantv1.NewTenantServiceClient(conn).GetTenant(ctx, &tenantv1.GetTenantRequest{Id: "t"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// health checks are served without credentials
+	_, err = grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
+
+func TestAuthorizationInterceptor(t *testing.T) {
+	principal := &authn.Principal{
+		Subject:  "contact-1",
+		TenantID: "tenant-1",
+		Method:   authn.MethodAPIKey,
+		APIKey: &entities.APIKey{
+			Scopes: []entities.APIKeyScope{
+				{Resource: entities.TenantsResource, Permissions: []entities.Permission{entities.ReadPermission}},
+				{
+					Resource:    entities.RolesResource,
+					Permissions: []entities.Permission{entities.ReadPermission, entities.WritePermission},
+				},
+			},
+		},
+	}
+
+	conn := newTestConn(
+		t,
+		grpc.ChainUnaryInterceptor(
+			func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				return handler(authn.WithPrincipal(ctx, principal), req)
+			},
+			rpc.AuthorizationInterceptor(authn.Authorize),
+		),
+	)
+	client := tenantv1.NewTenantServiceClient(conn)
+	roles := tenantv1.NewRoleServiceClient(conn)
+
+	var testCases = []struct {
+		Name         string
+		Call         func(ctx context.Context) error
+		ExpectedCode codes.Code
+	}{
+		{
+			Name: "Happy Path: within the scopes",
+			Call: func(ctx context.Context) error {
+				_, err := client.GetTenant(ctx, &tenantv1.GetTenantRequest{Id: "tenant-1"})
+				return err
+			},
+			ExpectedCode: codes.NotFound,
+		},
+		{
+			Name: "Error Path: other tenant",
+			Call: func(ctx context.Context) error {
+				_, err := client.GetTenant(ctx, &tenantv1.GetTenantRequest{Id: "tenant-2"})
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+		{
+			Name: "Error Path: permission outside of the scopes",
+			Call: func(ctx context.Context) error {
+				_, err := client.DeleteTenant(ctx, &tenantv1.DeleteTenantRequest{Id: "tenant-1"})
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+		{
+			Name: "Happy Path: custom role of its tenant",
+			Call: func(ctx context.Context) error {
+				_, err := roles.CreateCustomRole(
+					ctx, &tenantv1.CreateRoleRequest{Role: &tenantv1.Role{Name: "billing", TenantId: "tenant-1"}},
+				)
+				return err
+			},
+			ExpectedCode: codes.OK,
+		},
+		{
+			Name: "Error Path: custom role of another tenant",
+			Call: func(ctx context.Context) error {
+				_, err := roles.CreateCustomRole(
+					ctx, &tenantv1.CreateRoleRequest{Role: &tenantv1.Role{Name: "billing", TenantId: "tenant-2"}},
+				)
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+		{
+			Name: "Error Path: platform role",
+			Call: func(ctx context.Context) error {
+				_, err := roles.CreateRole(ctx, &tenantv1.CreateRoleRequest{Role: &tenantv1.Role{Name: "support"}})
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+		{
+			Name: "Error Path: listing roles",
+			Call: func(ctx context.Context) error {
+				_, err := roles.ListRoles(ctx, &tenantv1.ListRolesRequest{})
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+		{
+			Name: "Error Path: listing tenants",
+			Call: func(ctx context.Context) error {
+				_, err := client.ListTenants(ctx, &tenantv1.ListTenantsRequest{})
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+		{
+			Name: "Error Path: resource outside of the scopes",
+			Call: func(ctx context.Context) error {
+				_, err := client.ListTenantCompanies(ctx, &tenantv1.ListTenantCompaniesRequest{TenantId: "tenant-1"})
+				return err
+			},
+			ExpectedCode: codes.PermissionDenied,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				assert.Equal(t, tt.ExpectedCode, status.Code(tt.Call(context.Background())))
+			},
+		)
+	}
+
+	// health checks are not authorized
+	_, err := grpc_health_v1.NewHealthClient(conn).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
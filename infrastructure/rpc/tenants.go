@@ -0,0 +1,195 @@
+package rpc
+
+import (
+	"context"
+
+	tenantv1 "github.com/hebecoding/tenant-management/api/tenant/v1"
+	"github.com/hebecoding/tenant-management/application/service"
+)
+
+// TenantServer exposes the tenant service over gRPC.
+type TenantServer struct {
+	tenantv1.UnimplementedTenantServiceServer
+
+	service service.TenantService
+}
+
+func NewTenantServer(service service.TenantService) *TenantServer {
+	return &TenantServer{service: service}
+}
+
+func (s *TenantServer) CreateTenant(ctx context.Context, req *tenantv1.CreateTenantRequest) (
+	*tenantv1.CreateTenantResponse, error,
+) {
+	if req.GetTenant() == nil {
+		return nil, toStatus(errRequired("tenant"))
+	}
+
+	tenant := tenantFromProto(req.GetTenant())
+	if err := s.service.CreateTenant(ctx, tenant); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.CreateTenantResponse{Tenant: tenantToProto(tenant)}, nil
+}
+
+func (s *TenantServer) GetTenant(ctx context.Context, req *tenantv1.GetTenantRequest) (
+	*tenantv1.GetTenantResponse, error,
+) {
+	tenant, err := s.service.GetTenantByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.GetTenantResponse{Tenant: tenantToProto(tenant)}, nil
+}
+
+func (s *TenantServer) GetTenantByPaymentID(ctx context.Context, req *tenantv1.GetTenantByPaymentIDRequest) (
+	*tenantv1.GetTenantResponse, error,
+) {
+	tenant, err := s.service.GetTenantByPaymentID(ctx, req.GetPaymentId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.GetTenantResponse{Tenant: tenantToProto(tenant)}, nil
+}
+
+func (s *TenantServer) ListTenants(ctx context.Context, _ *tenantv1.ListTenantsRequest) (
+	*tenantv1.ListTenantsResponse, error,
+) {
+	tenants, err := s.service.GetTenants(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &tenantv1.ListTenantsResponse{}
+	for _, tenant := range tenants {
+		resp.Tenants = append(resp.Tenants, tenantToProto(tenant))
+	}
+
+	return resp, nil
+}
+
+func (s *TenantServer) UpdateTenant(ctx context.Context, req *tenantv1.UpdateTenantRequest) (
+	*tenantv1.UpdateTenantResponse, error,
+) {
+	if req.GetTenant() == nil {
+		return nil, toStatus(errRequired("tenant"))
+	}
+
+	if err := s.service.UpdateTenant(ctx, req.GetId(), tenantFromProto(req.GetTenant())); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.UpdateTenantResponse{}, nil
+}
+
+func (s *TenantServer) DeleteTenant(ctx context.Context, req *tenantv1.DeleteTenantRequest) (
+	*tenantv1.DeleteTenantResponse, error,
+) {
+	if err := s.service.DeleteTenant(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.DeleteTenantResponse{}, nil
+}
+
+func (s *TenantServer) ListTenantCompanies(ctx context.Context, req *tenantv1.ListTenantCompaniesRequest) (
+	*tenantv1.ListTenantCompaniesResponse, error,
+) {
+	companies, err := s.service.GetTenantCompanies(ctx, req.GetTenantId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &tenantv1.ListTenantCompaniesResponse{}
+	for _, company := range companies {
+		resp.Companies = append(resp.Companies, companyToProto(company))
+	}
+
+	return resp, nil
+}
+
+func (s *TenantServer) GetTenantCompany(ctx context.Context, req *tenantv1.GetTenantCompanyRequest) (
+	*tenantv1.GetTenantCompanyResponse, error,
+) {
+	company, err := s.service.GetTenantCompanyByID(ctx, req.GetTenantId(), req.GetCompanyId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.GetTenantCompanyResponse{Company: companyToProto(company)}, nil
+}
+
+func (s *TenantServer) UpdateTenantCompany(ctx context.Context, req *tenantv1.UpdateTenantCompanyRequest) (
+	*tenantv1.UpdateTenantCompanyResponse, error,
+) {
+	if req.GetCompany() == nil {
+		return nil, toStatus(errRequired("company"))
+	}
+
+	if err := s.service.UpdateTenantCompany(ctx, req.GetTenantId(), companyFromProto(req.GetCompany())); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.UpdateTenantCompanyResponse{}, nil
+}
+
+func (s *TenantServer) ListTenantSubscriptions(ctx context.Context, req *tenantv1.ListTenantSubscriptionsRequest) (
+	*tenantv1.ListTenantSubscriptionsResponse, error,
+) {
+	subscriptions, err := s.service.GetTenantCompaniesSubscriptions(ctx, req.GetTenantId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &tenantv1.ListTenantSubscriptionsResponse{}
+	for _, subscription := range subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, subscriptionToProto(subscription))
+	}
+
+	return resp, nil
+}
+
+func (s *TenantServer) UpdateTenantSubscription(
+	ctx context.Context, req *tenantv1.UpdateTenantSubscriptionRequest,
+) (*tenantv1.UpdateTenantSubscriptionResponse, error) {
+	err := s.service.UpdateTenantSubscription(ctx, req.GetTenantId(), subscriptionFromProto(req.GetSubscription()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.UpdateTenantSubscriptionResponse{}, nil
+}
+
+func (s *TenantServer) ListTenantPaymentDetails(
+	ctx context.Context, req *tenantv1.ListTenantPaymentDetailsRequest,
+) (*tenantv1.ListTenantPaymentDetailsResponse, error) {
+	payments, err := s.service.GetTenantPaymentDetails(ctx, req.GetTenantId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &tenantv1.ListTenantPaymentDetailsResponse{}
+	for _, payment := range payments {
+		resp.PaymentDetails = append(resp.PaymentDetails, paymentToProto(payment))
+	}
+
+	return resp, nil
+}
+
+func (s *TenantServer) UpdateTenantPaymentDetails(
+	ctx context.Context, req *tenantv1.UpdateTenantPaymentDetailsRequest,
+) (*tenantv1.UpdateTenantPaymentDetailsResponse, error) {
+	if req.GetPaymentDetails() == nil {
+		return nil, toStatus(errRequired("payment_details"))
+	}
+
+	err := s.service.UpdateTenantPaymentDetails(ctx, req.GetTenantId(), paymentFromProto(req.GetPaymentDetails()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &tenantv1.UpdateTenantPaymentDetailsResponse{}, nil
+}
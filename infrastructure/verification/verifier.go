@@ -0,0 +1,163 @@
+package verification
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DNSRecordPrefix is prepended to the hostname to build the name of the TXT record
+	// holding the verification token, e.g. _tenant-verification.example.com.
+	DNSRecordPrefix = "_tenant-verification"
+	// DNSRecordValuePrefix is prepended to the token in the TXT record value.
+	DNSRecordValuePrefix = "tenant-verification="
+	// WellKnownPath is the path that must serve the verification token over HTTP.
+	WellKnownPath = "/.well-known/tenant-verification.txt"
+
+	maxTokenResponseSize = 1024
+)
+
+// Resolver looks up DNS TXT records. *net.Resolver satisfies this interface.
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+type Verifier struct {
+	resolver Resolver
+	client   *http.Client
+	scheme   string
+	logger   utils.LoggerInterface
+}
+
+type Option func(*Verifier)
+
+// WithResolver overrides the resolver used for DNS TXT challenges.
+func WithResolver(resolver Resolver) Option {
+	return func(v *Verifier) {
+		v.resolver = resolver
+	}
+}
+
+// WithHTTPClient overrides the client used for HTTP well-known challenges.
+func WithHTTPClient(client *http.Client) Option {
+	return func(v *Verifier) {
+		v.client = client
+	}
+}
+
+// WithScheme overrides the scheme used for HTTP well-known challenges. Defaults to http.
+func WithScheme(scheme string) Option {
+	return func(v *Verifier) {
+		v.scheme = scheme
+	}
+}
+
+func NewVerifier(logger utils.LoggerInterface, opts ...Option) *Verifier {
+	v := &Verifier{
+		resolver: net.DefaultResolver,
+		client:   http.DefaultClient,
+		scheme:   "http",
+		logger:   logger,
+	}
+
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return v
+}
+
+// Verify checks that the owner of the domain has published its verification token
+// using the domain's verification method.
+// A nil error means the ownership was proven. Challenges that could not be checked, such as on
+// DNS timeouts or server errors, fail with apperrors.ErrDomainVerificationUnavailable.
+func (v *Verifier) Verify(ctx context.Context, domain *entities.TenantDomain) error {
+	switch domain.VerificationMethod {
+	case entities.DNSTXTVerification:
+		return v.verifyDNS(ctx, domain)
+	case entities.HTTPVerification:
+		return v.verifyHTTP(ctx, domain)
+	default:
+		return errors.Wrapf(
+			apperrors.ErrDomainVerificationFailed, "unsupported verification method %q", domain.VerificationMethod,
+		)
+	}
+}
+
+// DNSRecordName returns the name of the TXT record that must hold the token for hostname.
+func DNSRecordName(hostname string) string {
+	return fmt.Sprintf("%s.%s", DNSRecordPrefix, hostname)
+}
+
+func (v *Verifier) verifyDNS(ctx context.Context, domain *entities.TenantDomain) error {
+	name := DNSRecordName(domain.Hostname)
+
+	v.logger.Infof("looking up verification record: %v", name)
+	records, err := v.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return errors.Wrapf(apperrors.ErrDomainVerificationFailed, "no TXT record found at %s", name)
+		}
+
+		v.logger.Errorf(apperrors.ErrVerifyingDomain, domain.Hostname)
+		v.logger.Error(err)
+		return errors.Wrapf(apperrors.ErrDomainVerificationUnavailable, "looking up %s: %v", name, err)
+	}
+
+	expected := DNSRecordValuePrefix + domain.VerificationToken
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(apperrors.ErrDomainVerificationFailed, "no matching TXT record found at %s", name)
+}
+
+func (v *Verifier) verifyHTTP(ctx context.Context, domain *entities.TenantDomain) error {
+	url := fmt.Sprintf("%s://%s%s", v.scheme, domain.Hostname, WellKnownPath)
+
+	v.logger.Infof("fetching verification token: %v", url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(apperrors.ErrDomainVerificationFailed, "building request: %v", err)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		v.logger.Errorf(apperrors.ErrVerifyingDomain, domain.Hostname)
+		v.logger.Error(err)
+		return errors.Wrapf(apperrors.ErrDomainVerificationUnavailable, "fetching %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+		return errors.Wrapf(
+			apperrors.ErrDomainVerificationUnavailable, "unexpected status %d from %s", resp.StatusCode, url,
+		)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrapf(apperrors.ErrDomainVerificationFailed, "unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if err != nil {
+		return errors.Wrapf(apperrors.ErrDomainVerificationUnavailable, "reading %s: %v", url, err)
+	}
+
+	if strings.TrimSpace(string(body)) != domain.VerificationToken {
+		return errors.Wrapf(apperrors.ErrDomainVerificationFailed, "token served at %s does not match", url)
+	}
+
+	return nil
+}
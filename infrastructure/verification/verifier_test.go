@@ -0,0 +1,285 @@
+package verification_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/infrastructure/verification"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var logger = utils.NewLogger()
+
+// fakeDNSServer answers TXT queries from an in-memory zone over UDP.
+type fakeDNSServer struct {
+	conn net.PacketConn
+	zone map[string][]string
+}
+
+func newFakeDNSServer(t *testing.T, zone map[string][]string) *fakeDNSServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeDNSServer{conn: conn, zone: zone}
+	go server.serve()
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return server
+}
+
+// Resolver returns a pure Go resolver that sends every query to the fake server.
+func (s *fakeDNSServer) Resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", s.conn.LocalAddr().String())
+		},
+	}
+}
+
+func (s *fakeDNSServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		if resp := s.answer(buf[:n]); resp != nil {
+			_, _ = s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+func (s *fakeDNSServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+
+	// walk the question name
+	var labels []string
+	offset := 12
+	for offset < len(query) && query[offset] != 0 {
+		length := int(query[offset])
+		labels = append(labels, string(query[offset+1:offset+1+length]))
+		offset += length + 1
+	}
+	questionEnd := offset + 5 // terminating zero, qtype and qclass
+	if questionEnd > len(query) {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[offset+1:])
+
+	records, found := s.zone[strings.ToLower(strings.Join(labels, "."))]
+
+	resp := make([]byte, 12, 512)
+	copy(resp, query[:2])
+	flags := uint16(0x8180) // response, recursion desired and available
+	if !found {
+		flags |= 3 // NXDOMAIN
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	resp = append(resp, query[12:questionEnd]...)
+
+	answers := 0
+	if found && qtype == 16 {
+		for _, record := range records {
+			rdata := append([]byte{byte(len(record))}, record...)
+			rr := []byte{0xc0, 0x0c, 0x00, 0x10, 0x00, 0x01, 0, 0, 0x00, 0x3c}
+			rr = binary.BigEndian.AppendUint16(rr, uint16(len(rdata)))
+			resp = append(resp, rr...)
+			resp = append(resp, rdata...)
+			answers++
+		}
+	}
+	binary.BigEndian.PutUint16(resp[6:], uint16(answers))
+
+	return resp
+}
+
+// resolverFunc is a Resolver of a function.
+type resolverFunc func(ctx context.Context, name string) ([]string, error)
+
+func (f resolverFunc) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return f(ctx, name)
+}
+
+func TestVerifier_VerifyDNS_Unavailable(t *testing.T) {
+	verifier := verification.NewVerifier(
+		logger, verification.WithResolver(
+			resolverFunc(
+				func(_ context.Context, name string) ([]string, error) {
+					return nil, &net.DNSError{Err: "i/o timeout", Name: name, IsTimeout: true}
+				},
+			),
+		),
+	)
+
+	err := verifier.Verify(
+		context.Background(), &entities.TenantDomain{
+			Hostname:           "acme.example",
+			VerificationMethod: entities.DNSTXTVerification,
+			VerificationToken:  "valid-token",
+		},
+	)
+	assert.True(t, errors.Is(err, apperrors.ErrDomainVerificationUnavailable), "unexpected error: %v", err)
+}
+
+func TestVerifier_VerifyDNS(t *testing.T) {
+	dns := newFakeDNSServer(
+		t, map[string][]string{
+			"_tenant-verification.acme.example":  {"v=spf1 -all", "tenant-verification=valid-token"},
+			"_tenant-verification.other.example": {"tenant-verification=someone-else"},
+		},
+	)
+
+	verifier := verification.NewVerifier(logger, verification.WithResolver(dns.Resolver()))
+
+	var testCases = []struct {
+		Name          string
+		Domain        *entities.TenantDomain
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: TXT record holds the token",
+			Domain: &entities.TenantDomain{
+				Hostname:           "acme.example",
+				VerificationMethod: entities.DNSTXTVerification,
+				VerificationToken:  "valid-token",
+			},
+		},
+		{
+			Name: "Error Path: TXT record holds a different token",
+			Domain: &entities.TenantDomain{
+				Hostname:           "other.example",
+				VerificationMethod: entities.DNSTXTVerification,
+				VerificationToken:  "valid-token",
+			},
+			ExpectedError: apperrors.ErrDomainVerificationFailed,
+		},
+		{
+			Name: "Error Path: TXT record does not exist",
+			Domain: &entities.TenantDomain{
+				Hostname:           "missing.example",
+				VerificationMethod: entities.DNSTXTVerification,
+				VerificationToken:  "valid-token",
+			},
+			ExpectedError: apperrors.ErrDomainVerificationFailed,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+
+				err := verifier.Verify(ctx, tt.Domain)
+				if tt.ExpectedError == nil {
+					assert.NoError(t, err)
+					return
+				}
+
+				assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+			},
+		)
+	}
+}
+
+func TestVerifier_VerifyHTTP(t *testing.T) {
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != verification.WellKnownPath {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = fmt.Fprintln(w, "valid-token")
+			},
+		),
+	)
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	verifier := verification.NewVerifier(logger, verification.WithHTTPClient(server.Client()))
+
+	unavailable := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		),
+	)
+	defer unavailable.Close()
+
+	var testCases = []struct {
+		Name          string
+		Domain        *entities.TenantDomain
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: well-known file serves the token",
+			Domain: &entities.TenantDomain{
+				Hostname:           host,
+				VerificationMethod: entities.HTTPVerification,
+				VerificationToken:  "valid-token",
+			},
+		},
+		{
+			Name: "Error Path: well-known file serves a different token",
+			Domain: &entities.TenantDomain{
+				Hostname:           host,
+				VerificationMethod: entities.HTTPVerification,
+				VerificationToken:  "another-token",
+			},
+			ExpectedError: apperrors.ErrDomainVerificationFailed,
+		},
+		{
+			Name: "Error Path: server unavailable",
+			Domain: &entities.TenantDomain{
+				Hostname:           strings.TrimPrefix(unavailable.URL, "http://"),
+				VerificationMethod: entities.HTTPVerification,
+				VerificationToken:  "valid-token",
+			},
+			ExpectedError: apperrors.ErrDomainVerificationUnavailable,
+		},
+		{
+			Name: "Error Path: unsupported verification method",
+			Domain: &entities.TenantDomain{
+				Hostname:           host,
+				VerificationMethod: "email",
+				VerificationToken:  "valid-token",
+			},
+			ExpectedError: apperrors.ErrDomainVerificationFailed,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := verifier.Verify(context.Background(), tt.Domain)
+				if tt.ExpectedError == nil {
+					assert.NoError(t, err)
+					return
+				}
+
+				assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+			},
+		)
+	}
+}
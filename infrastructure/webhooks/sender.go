@@ -0,0 +1,199 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ContentType is the content type of CloudEvents in structured mode.
+	ContentType = "application/cloudevents+json"
+	// IDHeader carries the ID of the event, receivers deduplicate deliveries with it.
+	IDHeader = "Webhook-Id"
+	// SignatureHeader carries the signature of the payload as t=<unix timestamp>,v1=<hex HMAC-SHA256>.
+	// The HMAC covers "<timestamp>.<payload>" and is keyed with the secret of the endpoint.
+	SignatureHeader = "Webhook-Signature"
+
+	defaultTimeout = 10 * time.Second
+	// maxErrorBodySize is how much of an error response is kept in the delivery log.
+	maxErrorBodySize = 256
+)
+
+// ErrInvalidSignature is returned by VerifySignature for payloads that were not signed with the secret.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// errBlockedAddress rejects connections to addresses of the private network of the service.
+var errBlockedAddress = errors.New("webhook address is not publicly routable")
+
+type Option func(*Sender)
+
+// WithHTTPClient overrides the client posting payloads, it bypasses the address checks of the default client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// WithPrivateNetworks allows endpoints on loopback and private addresses, for local development.
+func WithPrivateNetworks() Option {
+	return func(s *Sender) {
+		s.client = newClient(nil)
+	}
+}
+
+// Sender posts signed CloudEvents to webhook endpoints. The default client refuses to connect
+// to loopback, private and link-local addresses, so tenants cannot reach the internal network
+// of the service through their endpoints, and it does not follow redirects.
+type Sender struct {
+	client *http.Client
+	logger utils.LoggerInterface
+	now    func() time.Time
+}
+
+func NewSender(logger utils.LoggerInterface, opts ...Option) *Sender {
+	s := &Sender{
+		client: newClient(publicOnly),
+		logger: logger,
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Send posts the payload of a delivery to an endpoint. Responses other than 2xx are failures.
+func (s *Sender) Send(
+	ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery,
+) entities.WebhookAttempt {
+	start := s.now()
+	attempt := entities.WebhookAttempt{At: start.UTC().Truncate(time.Millisecond)}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", ContentType)
+	req.Header.Set("User-Agent", "tenant-management-webhooks/1.0")
+	req.Header.Set(IDHeader, delivery.EventID)
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, start, delivery.Payload))
+
+	res, err := s.client.Do(req)
+	attempt.DurationMS = s.now().Sub(start).Milliseconds()
+	if err != nil {
+		s.logger.Infof("webhook delivery %s failed: %v", delivery.ID, err)
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer res.Body.Close()
+
+	attempt.StatusCode = res.StatusCode
+	if !attempt.Succeeded() {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		attempt.Error = strings.TrimSpace(fmt.Sprintf("%s %s", res.Status, body))
+	}
+
+	// drain the body so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	return attempt
+}
+
+// Sign returns the signature header of a payload sent at timestamp.
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + signature(secret, unix, payload)
+}
+
+// VerifySignature checks the signature header of a payload received at now. Signatures older
+// than tolerance are rejected, so captured payloads cannot be replayed later.
+func VerifySignature(secret string, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	var unix string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return errors.Wrap(ErrInvalidSignature, "malformed signature header")
+	}
+
+	if age := now.Sub(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return errors.Wrap(ErrInvalidSignature, "signature timestamp is outside of the tolerance")
+	}
+
+	expected := signature(secret, unix, payload)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func signature(secret string, unix string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newClient(control func(network, address string, c syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   defaultTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicOnly refuses connections to addresses that are not publicly routable. It runs once the
+// hostname is resolved, so hostnames resolving to internal addresses are refused as well.
+func publicOnly(_ string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return errors.Wrapf(errBlockedAddress, "refusing to connect to %s", host)
+	}
+
+	return nil
+}
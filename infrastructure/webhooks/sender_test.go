@@ -0,0 +1,182 @@
+package webhooks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/webhooks"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_Send(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"specversion":"1.0","id":"event-1","type":"tenant.updated"}`)
+
+	var testCases = []struct {
+		Name            string
+		Status          int
+		Opts            []webhooks.Option
+		ExpectedStatus  int
+		ExpectedSuccess bool
+		ExpectedError   bool
+	}{
+		{
+			Name:            "Happy Path: accepted delivery",
+			Status:          http.StatusNoContent,
+			Opts:            []webhooks.Option{webhooks.WithPrivateNetworks()},
+			ExpectedStatus:  http.StatusNoContent,
+			ExpectedSuccess: true,
+		},
+		{
+			Name:           "Error Path: rejected delivery",
+			Status:         http.StatusInternalServerError,
+			Opts:           []webhooks.Option{webhooks.WithPrivateNetworks()},
+			ExpectedStatus: http.StatusInternalServerError,
+			ExpectedError:  true,
+		},
+		{
+			Name:           "Error Path: redirects are not followed",
+			Status:         http.StatusFound,
+			Opts:           []webhooks.Option{webhooks.WithPrivateNetworks()},
+			ExpectedStatus: http.StatusFound,
+			ExpectedError:  true,
+		},
+		{
+			Name:          "Error Path: loopback address is refused by default",
+			Status:        http.StatusOK,
+			ExpectedError: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				var received *http.Request
+				var body []byte
+				receiver := httptest.NewServer(
+					http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							received = r
+							body, _ = io.ReadAll(r.Body)
+							if tt.Status == http.StatusFound {
+								w.Header().Set("Location", "/elsewhere")
+							}
+							w.WriteHeader(tt.Status)
+						},
+					),
+				)
+				defer receiver.Close()
+
+				sender := webhooks.NewSender(utils.NewLogger(), tt.Opts...)
+				attempt := sender.Send(
+					context.Background(),
+					&entities.WebhookEndpoint{ID: "endpoint-1", URL: receiver.URL, Secret: secret},
+					&entities.WebhookDelivery{ID: "endpoint-1-event-1", EventID: "event-1", Payload: payload},
+				)
+
+				assert.Equal(t, tt.ExpectedStatus, attempt.StatusCode)
+				assert.Equal(t, tt.ExpectedSuccess, attempt.Succeeded())
+				assert.Equal(t, tt.ExpectedError, attempt.Error != "")
+				if tt.ExpectedStatus == 0 {
+					assert.Nil(t, received)
+					return
+				}
+
+				require.NotNil(t, received)
+				assert.Equal(t, webhooks.ContentType, received.Header.Get("Content-Type"))
+				assert.Equal(t, "event-1", received.Header.Get(webhooks.IDHeader))
+				assert.Equal(t, payload, body)
+				assert.NoError(
+					t, webhooks.VerifySignature(
+						secret, received.Header.Get(webhooks.SignatureHeader), body, time.Minute, time.Now(),
+					),
+				)
+			},
+		)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"event-1"}`)
+	signedAt := time.Unix(1700000000, 0)
+	header := webhooks.Sign(secret, signedAt, payload)
+
+	var testCases = []struct {
+		Name          string
+		Secret        string
+		Header        string
+		Payload       []byte
+		Now           time.Time
+		ExpectedError error
+	}{
+		{
+			Name:    "Happy Path: valid signature",
+			Secret:  secret,
+			Header:  header,
+			Payload: payload,
+			Now:     signedAt.Add(time.Minute),
+		},
+		{
+			Name:    "Happy Path: one of several signatures is valid",
+			Secret:  secret,
+			Header:  header + ",v1=0000",
+			Payload: payload,
+			Now:     signedAt,
+		},
+		{
+			Name:          "Error Path: other secret",
+			Secret:        "whsec_other",
+			Header:        header,
+			Payload:       payload,
+			Now:           signedAt,
+			ExpectedError: webhooks.ErrInvalidSignature,
+		},
+		{
+			Name:          "Error Path: tampered payload",
+			Secret:        secret,
+			Header:        header,
+			Payload:       []byte(`{"id":"event-2"}`),
+			Now:           signedAt,
+			ExpectedError: webhooks.ErrInvalidSignature,
+		},
+		{
+			Name:          "Error Path: replayed signature",
+			Secret:        secret,
+			Header:        header,
+			Payload:       payload,
+			Now:           signedAt.Add(time.Hour),
+			ExpectedError: webhooks.ErrInvalidSignature,
+		},
+		{
+			Name:          "Error Path: malformed header",
+			Secret:        secret,
+			Header:        "v1=abc",
+			Payload:       payload,
+			Now:           signedAt,
+			ExpectedError: webhooks.ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := webhooks.VerifySignature(tt.Secret, tt.Header, tt.Payload, 5*time.Minute, tt.Now)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), err)
+					return
+				}
+
+				assert.NoError(t, err)
+			},
+		)
+	}
+}
@@ -0,0 +1,173 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before := &entities.Tenant{
+		ID:   "tenant-1",
+		Name: "Acme",
+		PrimaryContacts: []*entities.TenantContactDetails{
+			{ID: "contact-1", Email: "ada@example.com"},
+			{ID: "contact-2", Email: "alan@example.com"},
+		},
+		PaymentDetails: []*entities.TenantPaymentDetails{
+			{ID: "payment-1", CardNumber: "4242424242424242", SecurityCode: "123"},
+		},
+		APIKeys: []*entities.APIKey{{ID: "key-1", Salt: "salt", Hash: "hash"}},
+	}
+
+	var testCases = []struct {
+		Name            string
+		Before          any
+		After           any
+		ExpectedChanges []entities.AuditChange
+	}{
+		{
+			Name:   "Changed and removed fields",
+			Before: before,
+			After: &entities.Tenant{
+				ID:   "tenant-1",
+				Name: "Acme Inc",
+				// reordered, without the email of contact-1
+				PrimaryContacts: []*entities.TenantContactDetails{
+					{ID: "contact-2", Email: "alan@example.com"},
+					{ID: "contact-1"},
+				},
+				PaymentDetails: before.PaymentDetails,
+				APIKeys:        before.APIKeys,
+			},
+			ExpectedChanges: []entities.AuditChange{
+				{Field: "name", Before: json.RawMessage(`"Acme"`), After: json.RawMessage(`"Acme Inc"`)},
+				{Field: "primary_contacts.contact-1.email", Before: json.RawMessage(`"ada@example.com"`)},
+			},
+		},
+		{
+			Name:   "Sensitive fields are redacted",
+			Before: nil,
+			After: &entities.Tenant{
+				ID:             "tenant-1",
+				PaymentDetails: before.PaymentDetails,
+			},
+			ExpectedChanges: []entities.AuditChange{
+				{Field: "_id", After: json.RawMessage(`"tenant-1"`)},
+				{Field: "created_at", After: json.RawMessage(`"0001-01-01T00:00:00Z"`)},
+				{Field: "deleted_at", After: json.RawMessage(`"0001-01-01T00:00:00Z"`)},
+				{Field: "payment_details.payment-1._id", After: json.RawMessage(`"payment-1"`)},
+				{Field: "payment_details.payment-1.card_number", After: json.RawMessage(`"************4242"`)},
+				{Field: "payment_details.payment-1.security_code", After: json.RawMessage(`"[redacted]"`)},
+				{Field: "updated_at", After: json.RawMessage(`"0001-01-01T00:00:00Z"`)},
+			},
+		},
+		{
+			Name:   "Usage is not a change",
+			Before: &entities.APIKey{ID: "key-1"},
+			After:  &entities.APIKey{ID: "key-1", LastUsedAt: before.APIKeys[0].CreatedAt.AddDate(1, 0, 0)},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				changes, err := audit.Diff(tt.Before, tt.After)
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedChanges, changes)
+			},
+		)
+	}
+}
+
+func TestNewRecord(t *testing.T) {
+	ctx := audit.WithRequestID(audit.WithActor(context.Background(), audit.Actor{ID: "contact-1", APIKey: "tm_1"}), "r-1")
+	role := &entities.Role{ID: "role-1", TenantID: "tenant-1", Name: "editor"}
+
+	record, err := audit.NewRecord(ctx, entities.AuditRole, role.ID, role.TenantID, entities.AuditUpdate, role, role)
+	require.NoError(t, err)
+	assert.Nil(t, record, "unchanged entities are not recorded")
+
+	record, err = audit.NewRecord(ctx, entities.AuditRole, role.ID, role.TenantID, entities.AuditDelete, role, nil)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "contact-1", record.Actor)
+	assert.Equal(t, "tm_1", record.APIKey)
+	assert.Equal(t, "r-1", record.RequestID)
+	assert.Equal(t, "tenant-1", record.TenantID)
+	assert.NotEmpty(t, record.Changes)
+}
+
+func TestVerify(t *testing.T) {
+	newChain := func(t *testing.T) []*entities.AuditRecord {
+		var chain []*entities.AuditRecord
+		var last *entities.AuditRecord
+		for _, name := range []string{"a", "b", "c"} {
+			record, err := audit.NewRecord(
+				context.Background(), entities.AuditRole, name, "tenant-1", entities.AuditCreate,
+				nil, &entities.Role{ID: name},
+			)
+			require.NoError(t, err)
+			require.NoError(t, audit.Seal(record, last))
+			chain = append(chain, record)
+			last = record
+		}
+		return chain
+	}
+
+	var testCases = []struct {
+		Name          string
+		Tamper        func(chain []*entities.AuditRecord) []*entities.AuditRecord
+		ExpectedError error
+	}{
+		{
+			Name:   "Happy Path: intact chain",
+			Tamper: func(chain []*entities.AuditRecord) []*entities.AuditRecord { return chain },
+		},
+		{
+			Name: "Error Path: altered record",
+			Tamper: func(chain []*entities.AuditRecord) []*entities.AuditRecord {
+				chain[1].Actor = "someone-else"
+				return chain
+			},
+			ExpectedError: apperrors.ErrAuditChainBroken,
+		},
+		{
+			Name: "Error Path: removed record",
+			Tamper: func(chain []*entities.AuditRecord) []*entities.AuditRecord {
+				return append(chain[:1], chain[2:]...)
+			},
+			ExpectedError: apperrors.ErrAuditChainBroken,
+		},
+		{
+			Name: "Error Path: rehashed record",
+			Tamper: func(chain []*entities.AuditRecord) []*entities.AuditRecord {
+				chain[1].Actor = "someone-else"
+				hash, _ := audit.Hash(chain[1])
+				chain[1].Hash = hash
+				return chain
+			},
+			ExpectedError: apperrors.ErrAuditChainBroken,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := audit.Verify(tt.Tamper(newChain(t)), nil)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+					return
+				}
+				assert.NoError(t, err)
+			},
+		)
+	}
+}
@@ -0,0 +1,35 @@
+package audit
+
+import "context"
+
+// Actor is who makes changes: a contact of a tenant, possibly through one of the API keys of the tenant.
+type Actor struct {
+	ID     string
+	APIKey string
+}
+
+type actorKey struct{}
+
+type requestIDKey struct{}
+
+// WithActor returns a copy of ctx attributing the changes made with it to actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of ctx. Changes without an actor are made by the system.
+func ActorFromContext(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request being served.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the ID of the request being served, if any.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
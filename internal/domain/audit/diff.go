@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// redacted replaces the values of fields too sensitive to be kept in the audit trail.
+const redacted = `"[redacted]"`
+
+// ignoredFields are not changes made by anyone, they are not audited.
+var ignoredFields = map[string]bool{
+	"last_used_at": true,
+}
+
+// Diff returns the fields that differ between two versions of an entity, in field order.
+// Fields are named by their dotted JSON path, elements of lists are named by their ID when
+// they have one so that reordering a list is not reported as a change. before is nil for
+// created entities and after is nil for deleted ones.
+func Diff(before any, after any) ([]entities.AuditChange, error) {
+	previous, err := flatten(before)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := flatten(after)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(previous)+len(current))
+	for field := range previous {
+		fields = append(fields, field)
+	}
+	for field := range current {
+		if _, ok := previous[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []entities.AuditChange
+	for _, field := range fields {
+		if bytes.Equal(previous[field], current[field]) {
+			continue
+		}
+
+		changes = append(changes, entities.AuditChange{Field: field, Before: previous[field], After: current[field]})
+	}
+
+	return changes, nil
+}
+
+func flatten(v any) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if v == nil {
+		return fields, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode audited entity")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var tree any
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, errors.Wrap(err, "failed to decode audited entity")
+	}
+
+	if tree == nil {
+		return fields, nil
+	}
+
+	if err := walk("", "", tree, fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func walk(path string, name string, node any, fields map[string]json.RawMessage) error {
+	if ignoredFields[name] {
+		return nil
+	}
+
+	switch value := node.(type) {
+	case map[string]any:
+		if len(value) == 0 && path != "" {
+			fields[path] = json.RawMessage(`{}`)
+		}
+		for key, child := range value {
+			if err := walk(join(path, key), key, child, fields); err != nil {
+				return err
+			}
+		}
+	case []any:
+		if len(value) == 0 {
+			fields[path] = json.RawMessage(`[]`)
+		}
+		for i, child := range value {
+			if err := walk(join(path, elementKey(i, child)), name, child, fields); err != nil {
+				return err
+			}
+		}
+	default:
+		raw, err := json.Marshal(redact(name, value))
+		if err != nil {
+			return errors.Wrapf(err, "failed to encode audited field %s", path)
+		}
+		fields[path] = raw
+	}
+
+	return nil
+}
+
+func elementKey(i int, element any) string {
+	if object, ok := element.(map[string]any); ok {
+		if id, ok := object["_id"].(string); ok && id != "" {
+			return id
+		}
+	}
+
+	return strconv.Itoa(i)
+}
+
+func redact(name string, value any) any {
+	switch name {
+	case "security_code":
+		return json.RawMessage(redacted)
+	case "card_number":
+		number, _ := value.(string)
+		if len(number) <= 4 {
+			return json.RawMessage(redacted)
+		}
+		return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+	default:
+		return value
+	}
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// NewRecord describes a mutation of an entity made with ctx. before is nil for created
+// entities and after is nil for deleted ones. It returns nil when nothing changed.
+// The record is sealed into the chain when it is appended to the trail.
+func NewRecord(
+	ctx context.Context,
+	entity entities.AuditEntity,
+	entityID string,
+	tenantID string,
+	operation entities.AuditOperation,
+	before any,
+	after any,
+) (*entities.AuditRecord, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 && operation == entities.AuditUpdate {
+		return nil, nil
+	}
+
+	actor, _ := ActorFromContext(ctx)
+
+	return &entities.AuditRecord{
+		ID:        utils.NewXID().ID,
+		Actor:     actor.ID,
+		APIKey:    actor.APIKey,
+		TenantID:  tenantID,
+		Entity:    entity,
+		EntityID:  entityID,
+		Operation: operation,
+		Changes:   changes,
+		RequestID: RequestIDFromContext(ctx),
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+	}, nil
+}
+
+// Seal links record to the last record of the trail, which is nil for the first record.
+func Seal(record *entities.AuditRecord, last *entities.AuditRecord) error {
+	record.Sequence = 1
+	record.PreviousHash = ""
+	if last != nil {
+		record.Sequence = last.Sequence + 1
+		record.PreviousHash = last.Hash
+	}
+
+	hash, err := Hash(record)
+	if err != nil {
+		return err
+	}
+
+	record.Hash = hash
+	return nil
+}
+
+// Hash returns the hash of a record, covering every field but the hash itself.
+func Hash(record *entities.AuditRecord) (string, error) {
+	sealed := *record
+	sealed.Hash = ""
+	// stores keep timestamps with millisecond precision
+	sealed.Timestamp = sealed.Timestamp.UTC().Truncate(time.Millisecond)
+
+	raw, err := json.Marshal(sealed)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to encode audit record")
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Verify checks that records follow each other in the chain, starting after previous.
+// previous is nil when records start at the beginning of the trail.
+func Verify(records []*entities.AuditRecord, previous *entities.AuditRecord) error {
+	for _, record := range records {
+		expectedSequence, expectedPrevious := int64(1), ""
+		if previous != nil {
+			expectedSequence, expectedPrevious = previous.Sequence+1, previous.Hash
+		}
+
+		if record.Sequence != expectedSequence {
+			return errors.Wrapf(
+				apperrors.ErrAuditChainBroken, "expected audit record %d, found %d", expectedSequence, record.Sequence,
+			)
+		}
+
+		if record.PreviousHash != expectedPrevious {
+			return errors.Wrapf(apperrors.ErrAuditChainBroken, "audit record %d is not linked", record.Sequence)
+		}
+
+		hash, err := Hash(record)
+		if err != nil {
+			return err
+		}
+
+		if hash != record.Hash {
+			return errors.Wrapf(apperrors.ErrAuditChainBroken, "audit record %d was altered", record.Sequence)
+		}
+
+		previous = record
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package entities
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Resource is a kind of object of the API that permissions are granted on.
+type Resource string
+
+const (
+	TenantsResource        Resource = "tenants"
+	CompaniesResource      Resource = "companies"
+	SubscriptionsResource  Resource = "subscriptions"
+	PaymentDetailsResource Resource = "payment_details"
+	DomainsResource        Resource = "domains"
+	RolesResource          Resource = "roles"
+	APIKeysResource        Resource = "api_keys"
+	AuditRecordsResource   Resource = "audit_records"
+	WebhooksResource       Resource = "webhooks"
+)
+
+func (r Resource) IsValid() bool {
+	switch r {
+	case TenantsResource, CompaniesResource, SubscriptionsResource, PaymentDetailsResource,
+		DomainsResource, RolesResource, APIKeysResource, AuditRecordsResource, WebhooksResource:
+		return true
+	default:
+		return false
+	}
+}
+
+// APIKeyScope grants permissions on a resource to an API key.
+type APIKeyScope struct {
+	Resource    Resource     `json:"resource" bson:"resource"`
+	Permissions []Permission `json:"permissions" bson:"permissions"`
+}
+
+// APIKey is a machine credential issued to a tenant. Only a salted hash of the key is stored,
+// the prefix identifies the key without revealing it. The key acts on behalf of the contact
+// that created it, restricted to its scopes.
+type APIKey struct {
+	ID         string        `json:"_id,omitempty" bson:"_id"`
+	Name       string        `json:"name,omitempty" bson:"name"`
+	Prefix     string        `json:"prefix,omitempty" bson:"prefix"`
+	Salt       string        `json:"-" bson:"salt"`
+	Hash       string        `json:"-" bson:"hash"`
+	Scopes     []APIKeyScope `json:"scopes,omitempty" bson:"scopes"`
+	CreatedBy  string        `json:"created_by,omitempty" bson:"created_by"`
+	CreatedAt  time.Time     `json:"created_at,omitempty" bson:"created_at"`
+	ExpiresAt  time.Time     `json:"expires_at,omitempty" bson:"expires_at"`
+	LastUsedAt time.Time     `json:"last_used_at,omitempty" bson:"last_used_at"`
+	RevokedAt  time.Time     `json:"revoked_at,omitempty" bson:"revoked_at"`
+}
+
+// IsRevoked reports whether the key can no longer be used.
+func (k *APIKey) IsRevoked() bool {
+	return !k.RevokedAt.IsZero()
+}
+
+// IsExpired reports whether the key expired at the given time. Keys without expiry never expire.
+func (k *APIKey) IsExpired(at time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !at.Before(k.ExpiresAt)
+}
+
+// Allows reports whether the scopes of the key grant permission on resource.
+func (k *APIKey) Allows(resource Resource, permission Permission) bool {
+	for _, scope := range k.Scopes {
+		if scope.Resource != resource {
+			continue
+		}
+
+		for _, granted := range scope.Permissions {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// SetSecret stores a salted hash of the secret, replacing the previous one.
+func (k *APIKey) SetSecret(secret string) error {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return errors.Wrap(err, "failed to generate api key salt")
+	}
+
+	k.Salt = hex.EncodeToString(salt)
+	k.Hash = hashAPIKeySecret(k.Salt, secret)
+
+	return nil
+}
+
+// Matches reports whether secret is the secret of the key, in constant time.
+func (k *APIKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.Hash), []byte(hashAPIKeySecret(k.Salt, secret))) == 1
+}
+
+func hashAPIKeySecret(salt string, secret string) string {
+	sum := sha256.Sum256([]byte(salt + secret))
+	return hex.EncodeToString(sum[:])
+}
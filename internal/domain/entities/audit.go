@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditUpdate AuditOperation = "update"
+	AuditDelete AuditOperation = "delete"
+)
+
+// AuditEntity is the kind of entity an audit record is about.
+type AuditEntity string
+
+const (
+	AuditTenant AuditEntity = "tenant"
+	AuditRole   AuditEntity = "role"
+)
+
+// AuditChange is the value of a field before and after a mutation, encoded as JSON.
+// Before is empty for created fields and After is empty for removed fields.
+type AuditChange struct {
+	Field  string          `json:"field" bson:"field"`
+	Before json.RawMessage `json:"before,omitempty" bson:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty" bson:"after,omitempty"`
+}
+
+// AuditRecord is an entry of the append-only audit trail. Records are chained in sequence,
+// each hash covers the record and the hash of the previous one, so altering or removing a
+// record breaks the chain from there on.
+type AuditRecord struct {
+	ID       string `json:"_id,omitempty" bson:"_id"`
+	Sequence int64  `json:"sequence" bson:"sequence"`
+	// Actor is the ID of the contact that made the change, it is empty for changes made by the system.
+	Actor string `json:"actor,omitempty" bson:"actor,omitempty"`
+	// APIKey is the prefix of the API key the actor made the change with.
+	APIKey    string         `json:"api_key,omitempty" bson:"api_key,omitempty"`
+	TenantID  string         `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	Entity    AuditEntity    `json:"entity" bson:"entity"`
+	EntityID  string         `json:"entity_id" bson:"entity_id"`
+	Operation AuditOperation `json:"operation" bson:"operation"`
+	Changes   []AuditChange  `json:"changes,omitempty" bson:"changes,omitempty"`
+	RequestID string         `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	Timestamp time.Time      `json:"timestamp" bson:"timestamp"`
+	// PreviousHash is the hash of the record preceding this one, it is empty for the first record.
+	PreviousHash string `json:"previous_hash,omitempty" bson:"previous_hash,omitempty"`
+	Hash         string `json:"hash" bson:"hash"`
+}
+
+// AuditFilter selects audit records. Zero fields do not filter.
+type AuditFilter struct {
+	TenantID string
+	Actor    string
+	From     time.Time
+	To       time.Time
+	// AfterSequence pages through records, only records after this sequence are returned.
+	AfterSequence int64
+	Limit         int
+}
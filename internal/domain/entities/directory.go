@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// TenantDirectoryEntry is the read model of a tenant, a summary kept up to date from the
+// changes to tenants and roles. It lags behind the tenant by the time the change takes to
+// reach the watcher.
+type TenantDirectoryEntry struct {
+	ID        string `json:"_id" bson:"_id"`
+	Name      string `json:"name" bson:"name"`
+	Subdomain string `json:"subdomain" bson:"subdomain"`
+	IsActive  bool   `json:"is_active" bson:"is_active"`
+	// Hostnames are the verified custom domains of the tenant.
+	Hostnames []string `json:"hostnames,omitempty" bson:"hostnames,omitempty"`
+	// Plans are the plans of the active subscriptions of the tenant.
+	Plans    []string `json:"plans,omitempty" bson:"plans,omitempty"`
+	Contacts int      `json:"contacts" bson:"contacts"`
+	// CustomRoles are the IDs of the roles defined by the tenant.
+	CustomRoles []string  `json:"custom_roles,omitempty" bson:"custom_roles,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	// ProjectedAt is when the entry was last updated from the tenant.
+	ProjectedAt time.Time `json:"projected_at" bson:"projected_at"`
+}
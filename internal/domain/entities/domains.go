@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+)
+
+type DomainStatus string
+
+const (
+	DomainStatusPending  DomainStatus = "pending"
+	DomainStatusVerified DomainStatus = "verified"
+	DomainStatusFailed   DomainStatus = "failed"
+)
+
+type VerificationMethod string
+
+const (
+	DNSTXTVerification VerificationMethod = "dns_txt"
+	HTTPVerification   VerificationMethod = "http"
+)
+
+// TenantDomain is a custom domain attached to a tenant. The domain can only be
+// used to address the tenant once its ownership has been verified.
+type TenantDomain struct {
+	ID                 string             `json:"_id,omitempty" bson:"_id"`
+	Hostname           string             `json:"hostname,omitempty" bson:"hostname"`
+	VerificationMethod VerificationMethod `json:"verification_method,omitempty" bson:"verification_method"`
+	VerificationToken  string             `json:"verification_token,omitempty" bson:"verification_token"`
+	Status             DomainStatus       `json:"status,omitempty" bson:"status"`
+	FailureReason      string             `json:"failure_reason,omitempty" bson:"failure_reason"`
+	CreatedAt          time.Time          `json:"created_at,omitempty" bson:"created_at"`
+	VerifiedAt         time.Time          `json:"verified_at,omitempty" bson:"verified_at"`
+	LastCheckedAt      time.Time          `json:"last_checked_at,omitempty" bson:"last_checked_at"`
+}
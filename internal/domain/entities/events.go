@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType names what happened, as <entity>.<past tense verb>.
+type EventType string
+
+const (
+	TenantCreated       EventType = "tenant.created"
+	TenantUpdated       EventType = "tenant.updated"
+	TenantSuspended     EventType = "tenant.suspended"
+	TenantReactivated   EventType = "tenant.reactivated"
+	TenantDeleted       EventType = "tenant.deleted"
+	SubscriptionUpdated EventType = "subscription.updated"
+	RoleCreated         EventType = "role.created"
+	RoleUpdated         EventType = "role.updated"
+	RoleDeleted         EventType = "role.deleted"
+	RoleAssigned        EventType = "role.assigned"
+	RoleUnassigned      EventType = "role.unassigned"
+)
+
+func (t EventType) IsValid() bool {
+	switch t {
+	case TenantCreated, TenantUpdated, TenantSuspended, TenantReactivated, TenantDeleted, SubscriptionUpdated,
+		RoleCreated, RoleUpdated, RoleDeleted, RoleAssigned, RoleUnassigned:
+		return true
+	default:
+		return false
+	}
+}
+
+// Event is a domain event, a fact other services can react to. Events are written to the
+// outbox together with the change raising them and published from there at least once,
+// consumers deduplicate them by ID.
+type Event struct {
+	ID   string    `json:"id" bson:"_id"`
+	Type EventType `json:"type" bson:"type"`
+	// TenantID is the tenant the event is about.
+	TenantID string `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
+	// Subject is the ID of the entity the event is about.
+	Subject string `json:"subject" bson:"subject"`
+	// Payload is the JSON encoded details of the event, its schema depends on the type.
+	Payload    json.RawMessage `json:"payload,omitempty" bson:"payload,omitempty"`
+	Actor      string          `json:"actor,omitempty" bson:"actor,omitempty"`
+	RequestID  string          `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at" bson:"occurred_at"`
+}
+
+// OutboxEvent is an event waiting in the outbox to be published.
+type OutboxEvent struct {
+	Event `bson:",inline"`
+	// Attempts is how many times publishing the event failed.
+	Attempts  int    `json:"attempts" bson:"attempts"`
+	LastError string `json:"last_error,omitempty" bson:"last_error,omitempty"`
+}
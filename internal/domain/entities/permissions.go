@@ -39,3 +39,13 @@ func (p *Permission) UnmarshalJSON(b []byte) error {
 func (p Permission) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(p))
 }
+
+// IsValid reports whether p is one of the known permissions.
+func (p Permission) IsValid() bool {
+	switch p {
+	case ReadPermission, WritePermission, EditPermission, DeletePermission:
+		return true
+	default:
+		return false
+	}
+}
@@ -2,7 +2,13 @@ package entities
 
 type Role struct {
 	ID          string       `json:"_id" bson:"_id"`
+	TenantID    string       `json:"tenant_id,omitempty" bson:"tenant_id,omitempty"`
 	Name        string       `json:"name" bson:"name"`
 	Description string       `json:"description" bson:"description"`
 	Permissions []Permission `json:"permissions" bson:"permissions"`
 }
+
+// IsCustom reports whether the role was defined by a tenant rather than by the platform.
+func (r *Role) IsCustom() bool {
+	return r.TenantID != ""
+}
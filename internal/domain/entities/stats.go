@@ -0,0 +1,32 @@
+package entities
+
+// TenantStats counts tenants, and the subscriptions of the companies of active tenants.
+type TenantStats struct {
+	ActiveTenants   int
+	InactiveTenants int
+	// SubscriptionsByPlan and SubscriptionsByPaymentStatus count the subscriptions of active
+	// tenants by plan and by payment status.
+	SubscriptionsByPlan          map[string]int
+	SubscriptionsByPaymentStatus map[string]int
+}
+
+// NewTenantStats returns the stats of tenants.
+func NewTenantStats(tenants []*Tenant) *TenantStats {
+	stats := &TenantStats{SubscriptionsByPlan: map[string]int{}, SubscriptionsByPaymentStatus: map[string]int{}}
+	for _, tenant := range tenants {
+		if !tenant.IsActive {
+			stats.InactiveTenants++
+			continue
+		}
+
+		stats.ActiveTenants++
+		for _, company := range tenant.Companies {
+			for _, subscription := range company.Subscriptions {
+				stats.SubscriptionsByPlan[subscription.Plan]++
+				stats.SubscriptionsByPaymentStatus[subscription.PaymentStatus]++
+			}
+		}
+	}
+
+	return stats
+}
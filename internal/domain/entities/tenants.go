@@ -14,6 +14,8 @@ type Tenant struct {
 	PaymentDetails  []*TenantPaymentDetails `json:"payment_details,omitempty" bson:"payment_details"`
 	TenantMetadata  *TenantMetadata         `json:"tenant_metadata,omitempty" bson:"tenant_metadata"`
 	PrimaryContacts []*TenantContactDetails `json:"primary_contacts,omitempty" bson:"primary_contacts"`
+	Domains         []*TenantDomain         `json:"domains,omitempty" bson:"domains"`
+	APIKeys         []*APIKey               `json:"api_keys,omitempty" bson:"api_keys"`
 	CreatedAt       time.Time               `json:"created_at,omitempty" bson:"created_at"`
 	UpdatedAt       time.Time               `json:"updated_at,omitempty" bson:"updated_at"`
 	DeletedAt       time.Time               `json:"deleted_at,omitempty" bson:"deleted_at"`
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookTest is the type of the events sent to try out an endpoint.
+const WebhookTest EventType = "webhook.test"
+
+// WebhookEndpoint is a URL of a tenant receiving the domain events of the tenant.
+// Payloads are signed with the secret of the endpoint, which is only shown once.
+type WebhookEndpoint struct {
+	ID          string `json:"_id" bson:"_id"`
+	TenantID    string `json:"tenant_id" bson:"tenant_id"`
+	URL         string `json:"url" bson:"url"`
+	Description string `json:"description,omitempty" bson:"description,omitempty"`
+	// EventTypes are the events sent to the endpoint, every event is sent when it is empty.
+	EventTypes []EventType `json:"event_types,omitempty" bson:"event_types,omitempty"`
+	Secret     string      `json:"secret,omitempty" bson:"secret"`
+	Active     bool        `json:"active" bson:"active"`
+	CreatedAt  time.Time   `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at,omitempty" bson:"updated_at,omitempty"`
+}
+
+// Subscribes reports whether events of type t are sent to the endpoint.
+func (e *WebhookEndpoint) Subscribes(t EventType) bool {
+	if len(e.EventTypes) == 0 {
+		return true
+	}
+
+	for _, eventType := range e.EventTypes {
+		if eventType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookPending deliveries are waiting for their next attempt.
+	WebhookPending WebhookDeliveryStatus = "pending"
+	// WebhookSucceeded deliveries were accepted by the endpoint.
+	WebhookSucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDead deliveries ran out of attempts, they are kept until they are redelivered.
+	WebhookDead WebhookDeliveryStatus = "dead"
+)
+
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WebhookPending, WebhookSucceeded, WebhookDead:
+		return true
+	default:
+		return false
+	}
+}
+
+// WebhookAttempt is the log of an attempt to deliver an event to an endpoint.
+type WebhookAttempt struct {
+	At time.Time `json:"at" bson:"at"`
+	// StatusCode is the status of the response, it is zero when no response was received.
+	StatusCode int    `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Error      string `json:"error,omitempty" bson:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms" bson:"duration_ms"`
+}
+
+// Succeeded reports whether the endpoint accepted the event.
+func (a WebhookAttempt) Succeeded() bool {
+	return a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// WebhookDelivery is an event to deliver to an endpoint, with the log of its attempts.
+type WebhookDelivery struct {
+	ID         string    `json:"_id" bson:"_id"`
+	TenantID   string    `json:"tenant_id" bson:"tenant_id"`
+	EndpointID string    `json:"endpoint_id" bson:"endpoint_id"`
+	EventID    string    `json:"event_id" bson:"event_id"`
+	EventType  EventType `json:"event_type" bson:"event_type"`
+	// Payload is the CloudEvent posted to the endpoint.
+	Payload  json.RawMessage       `json:"payload" bson:"payload"`
+	Status   WebhookDeliveryStatus `json:"status" bson:"status"`
+	Attempts []WebhookAttempt      `json:"attempts,omitempty" bson:"attempts,omitempty"`
+	// Failures is the number of failed attempts since the delivery was created or redelivered.
+	Failures      int       `json:"failures" bson:"failures"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitempty" bson:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	CompletedAt   time.Time `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
+
+// CloudEvent is the payload posted to webhook endpoints, a CloudEvents 1.0 event in structured mode.
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            EventType       `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data,omitempty"`
+	// TenantID is an extension attribute, the tenant the event is about.
+	TenantID string `json:"tenantid,omitempty"`
+}
+
+// WebhookDeliveryFilter selects the deliveries of a tenant, newest first. Zero fields do not filter.
+type WebhookDeliveryFilter struct {
+	TenantID   string
+	EndpointID string
+	Status     WebhookDeliveryStatus
+	Limit      int
+}
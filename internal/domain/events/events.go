@@ -0,0 +1,326 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/pkg/errors"
+)
+
+// bookkeepingFields change with every update, they alone do not make a tenant.updated event.
+var bookkeepingFields = map[string]bool{
+	"updated_at": true,
+	"updated_by": true,
+	"is_active":  true,
+}
+
+// TenantPayload is the payload of the tenant.* events. Payment details and API keys are
+// never part of events.
+type TenantPayload struct {
+	ID        string `json:"id"`
+	Name      string `json:"name,omitempty"`
+	Subdomain string `json:"subdomain,omitempty"`
+	IsActive  bool   `json:"is_active"`
+	// Fields are the top level fields that changed, for tenant.updated.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// SubscriptionPayload is the payload of subscription.updated. Subscription is nil for removed
+// subscriptions and Previous is nil for added ones.
+type SubscriptionPayload struct {
+	CompanyID    string                              `json:"company_id,omitempty"`
+	Subscription *entities.TenantSubscriptionDetails `json:"subscription,omitempty"`
+	Previous     *entities.TenantSubscriptionDetails `json:"previous,omitempty"`
+}
+
+// RolePayload is the payload of the role.created, role.updated and role.deleted events.
+type RolePayload struct {
+	Role *entities.Role `json:"role"`
+}
+
+// RoleAssignmentPayload is the payload of role.assigned and role.unassigned.
+type RoleAssignmentPayload struct {
+	ContactID string `json:"contact_id"`
+	RoleID    string `json:"role_id"`
+	RoleName  string `json:"role_name,omitempty"`
+}
+
+// New returns an event about subject raised with ctx, which carries the actor and the request ID.
+func New(
+	ctx context.Context, eventType entities.EventType, tenantID string, subject string, payload any,
+) (*entities.Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode %s event", eventType)
+	}
+
+	actor, _ := audit.ActorFromContext(ctx)
+
+	return &entities.Event{
+		ID:         utils.NewXID().ID,
+		Type:       eventType,
+		TenantID:   tenantID,
+		Subject:    subject,
+		Payload:    raw,
+		Actor:      actor.ID,
+		RequestID:  audit.RequestIDFromContext(ctx),
+		OccurredAt: time.Now().UTC().Truncate(time.Millisecond),
+	}, nil
+}
+
+// TenantEvents returns the events raised by an operation changing a tenant from before to after.
+// before is nil for created tenants. Deleting a tenant only raises tenant.deleted.
+func TenantEvents(
+	ctx context.Context, operation entities.AuditOperation, before *entities.Tenant, after *entities.Tenant,
+) ([]*entities.Event, error) {
+	r := raiser{ctx: ctx}
+
+	switch operation {
+	case entities.AuditCreate:
+		r.raise(entities.TenantCreated, after.ID, after.ID, tenantPayload(after, nil))
+		r.assignments(after.ID, nil, after)
+	case entities.AuditDelete:
+		r.raise(entities.TenantDeleted, before.ID, before.ID, tenantPayload(before, nil))
+	case entities.AuditUpdate:
+		switch {
+		case before.IsActive && !after.IsActive:
+			r.raise(entities.TenantSuspended, after.ID, after.ID, tenantPayload(after, nil))
+		case !before.IsActive && after.IsActive:
+			r.raise(entities.TenantReactivated, after.ID, after.ID, tenantPayload(after, nil))
+		}
+
+		fields, err := changedFields(before, after)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			r.raise(entities.TenantUpdated, after.ID, after.ID, tenantPayload(after, fields))
+		}
+
+		r.subscriptions(after.ID, before, after)
+		r.assignments(after.ID, before, after)
+	}
+
+	return r.events, r.err
+}
+
+// RoleEvents returns the events raised by an operation changing a role from before to after.
+// before is nil for created roles and after is nil for deleted ones.
+func RoleEvents(
+	ctx context.Context, operation entities.AuditOperation, before *entities.Role, after *entities.Role,
+) ([]*entities.Event, error) {
+	r := raiser{ctx: ctx}
+
+	switch operation {
+	case entities.AuditCreate:
+		r.raise(entities.RoleCreated, after.TenantID, after.ID, RolePayload{Role: after})
+	case entities.AuditDelete:
+		r.raise(entities.RoleDeleted, before.TenantID, before.ID, RolePayload{Role: before})
+	case entities.AuditUpdate:
+		changes, err := audit.Diff(before, after)
+		if err != nil {
+			return nil, err
+		}
+		if len(changes) > 0 {
+			r.raise(entities.RoleUpdated, after.TenantID, after.ID, RolePayload{Role: after})
+		}
+	}
+
+	return r.events, r.err
+}
+
+// raiser collects events, keeping the first error.
+type raiser struct {
+	ctx    context.Context
+	events []*entities.Event
+	err    error
+}
+
+func (r *raiser) raise(eventType entities.EventType, tenantID string, subject string, payload any) {
+	if r.err != nil {
+		return
+	}
+
+	event, err := New(r.ctx, eventType, tenantID, subject, payload)
+	if err != nil {
+		r.err = err
+		return
+	}
+
+	r.events = append(r.events, event)
+}
+
+// subscriptions raises subscription.updated for every subscription added, changed or removed.
+func (r *raiser) subscriptions(tenantID string, before *entities.Tenant, after *entities.Tenant) {
+	previous, current := subscriptionsOf(before), subscriptionsOf(after)
+
+	ids := make([]string, 0, len(previous)+len(current))
+	for id := range previous {
+		ids = append(ids, id)
+	}
+	for id := range current {
+		if _, ok := previous[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		old, updated := previous[id], current[id]
+		if sameJSON(old.subscription, updated.subscription) {
+			continue
+		}
+
+		companyID := updated.companyID
+		if updated.subscription == nil {
+			companyID = old.companyID
+		}
+
+		r.raise(
+			entities.SubscriptionUpdated, tenantID, id, SubscriptionPayload{
+				CompanyID:    companyID,
+				Subscription: updated.subscription,
+				Previous:     old.subscription,
+			},
+		)
+	}
+}
+
+type companySubscription struct {
+	companyID    string
+	subscription *entities.TenantSubscriptionDetails
+}
+
+func subscriptionsOf(tenant *entities.Tenant) map[string]companySubscription {
+	subscriptions := map[string]companySubscription{}
+	if tenant == nil {
+		return subscriptions
+	}
+
+	for _, company := range tenant.Companies {
+		if company == nil {
+			continue
+		}
+
+		for i, subscription := range company.Subscriptions {
+			if subscription == nil {
+				continue
+			}
+
+			id := subscription.ID
+			if id == "" {
+				id = company.ID + "/" + strconv.Itoa(i)
+			}
+			subscriptions[id] = companySubscription{companyID: company.ID, subscription: subscription}
+		}
+	}
+
+	return subscriptions
+}
+
+// assignments raises role.assigned and role.unassigned for the roles granted to and
+// taken from the primary contacts of a tenant.
+func (r *raiser) assignments(tenantID string, before *entities.Tenant, after *entities.Tenant) {
+	previous, current := rolesOf(before), rolesOf(after)
+
+	for _, key := range sortedKeys(current) {
+		if _, ok := previous[key]; !ok {
+			r.raise(entities.RoleAssigned, tenantID, current[key].ContactID, current[key])
+		}
+	}
+
+	for _, key := range sortedKeys(previous) {
+		if _, ok := current[key]; !ok {
+			r.raise(entities.RoleUnassigned, tenantID, previous[key].ContactID, previous[key])
+		}
+	}
+}
+
+func rolesOf(tenant *entities.Tenant) map[string]RoleAssignmentPayload {
+	assignments := map[string]RoleAssignmentPayload{}
+	if tenant == nil {
+		return assignments
+	}
+
+	for _, contact := range tenant.PrimaryContacts {
+		if contact == nil {
+			continue
+		}
+
+		for _, role := range contact.Roles {
+			if role == nil || role.ID == "" {
+				continue
+			}
+
+			assignments[contact.ID+"/"+role.ID] = RoleAssignmentPayload{
+				ContactID: contact.ID,
+				RoleID:    role.ID,
+				RoleName:  role.Name,
+			}
+		}
+	}
+
+	return assignments
+}
+
+func sortedKeys(m map[string]RoleAssignmentPayload) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// changedFields returns the top level fields of a tenant that changed, other than bookkeeping.
+// Changes to subscriptions are left to subscription.updated, they do not change companies.
+func changedFields(before *entities.Tenant, after *entities.Tenant) ([]string, error) {
+	changes, err := audit.Diff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	seen := map[string]bool{}
+	for _, change := range changes {
+		path := strings.SplitN(change.Field, ".", 4)
+		field := path[0]
+		if bookkeepingFields[field] || seen[field] {
+			continue
+		}
+		if field == "companies" && len(path) > 2 && path[2] == "subscriptions" {
+			continue
+		}
+
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func tenantPayload(tenant *entities.Tenant, fields []string) TenantPayload {
+	return TenantPayload{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		Subdomain: tenant.Subdomain,
+		IsActive:  tenant.IsActive,
+		Fields:    fields,
+	}
+}
+
+// sameJSON compares values by their encoding, times read back from a store are equal
+// without being identical.
+func sameJSON(a any, b any) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
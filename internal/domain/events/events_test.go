@@ -0,0 +1,216 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/events"
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTenant() *entities.Tenant {
+	return &entities.Tenant{
+		ID:       "tenant-1",
+		Name:     "Acme",
+		IsActive: true,
+		Companies: []*entities.TenantCompanyDetails{
+			{
+				ID: "company-1",
+				Subscriptions: []*entities.TenantSubscriptionDetails{
+					{ID: "subscription-1", Plan: "starter", Active: true},
+				},
+			},
+		},
+		PrimaryContacts: []*entities.TenantContactDetails{
+			{ID: "contact-1", Roles: []*entities.Role{{ID: "role-1", Name: "admin"}}},
+		},
+	}
+}
+
+func TestTenantEvents(t *testing.T) {
+	var testCases = []struct {
+		Name          string
+		Operation     entities.AuditOperation
+		Before        *entities.Tenant
+		Change        func(tenant *entities.Tenant)
+		ExpectedTypes []entities.EventType
+	}{
+		{
+			Name:          "Created tenant",
+			Operation:     entities.AuditCreate,
+			ExpectedTypes: []entities.EventType{entities.TenantCreated, entities.RoleAssigned},
+		},
+		{
+			Name:      "Suspended tenant",
+			Operation: entities.AuditUpdate,
+			Before:    newTenant(),
+			Change: func(tenant *entities.Tenant) {
+				tenant.IsActive = false
+			},
+			ExpectedTypes: []entities.EventType{entities.TenantSuspended},
+		},
+		{
+			Name:      "Renamed tenant with a new plan",
+			Operation: entities.AuditUpdate,
+			Before:    newTenant(),
+			Change: func(tenant *entities.Tenant) {
+				tenant.Name = "Acme Inc"
+				tenant.Companies[0].Subscriptions[0].Plan = "premium"
+			},
+			ExpectedTypes: []entities.EventType{entities.TenantUpdated, entities.SubscriptionUpdated},
+		},
+		{
+			Name:      "New plan",
+			Operation: entities.AuditUpdate,
+			Before:    newTenant(),
+			Change: func(tenant *entities.Tenant) {
+				tenant.Companies[0].Subscriptions[0].Plan = "premium"
+			},
+			ExpectedTypes: []entities.EventType{entities.SubscriptionUpdated},
+		},
+		{
+			Name:      "Reassigned role",
+			Operation: entities.AuditUpdate,
+			Before:    newTenant(),
+			Change: func(tenant *entities.Tenant) {
+				tenant.PrimaryContacts[0].Roles = []*entities.Role{{ID: "role-2", Name: "viewer"}}
+			},
+			ExpectedTypes: []entities.EventType{
+				entities.TenantUpdated, entities.RoleAssigned, entities.RoleUnassigned,
+			},
+		},
+		{
+			Name:      "Bookkeeping only",
+			Operation: entities.AuditUpdate,
+			Before:    newTenant(),
+			Change: func(tenant *entities.Tenant) {
+				tenant.UpdatedBy = "contact-1"
+			},
+		},
+		{
+			Name:      "Deleted tenant",
+			Operation: entities.AuditDelete,
+			Before:    newTenant(),
+			Change: func(tenant *entities.Tenant) {
+				tenant.IsActive = false
+			},
+			ExpectedTypes: []entities.EventType{entities.TenantDeleted},
+		},
+	}
+
+	ctx := audit.WithRequestID(audit.WithActor(context.Background(), audit.Actor{ID: "contact-1"}), "request-1")
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				after := newTenant()
+				if tt.Change != nil {
+					tt.Change(after)
+				}
+
+				raised, err := events.TenantEvents(ctx, tt.Operation, tt.Before, after)
+				require.NoError(t, err)
+
+				var types []entities.EventType
+				for _, event := range raised {
+					types = append(types, event.Type)
+					assert.Equal(t, "tenant-1", event.TenantID)
+					assert.Equal(t, "contact-1", event.Actor)
+					assert.Equal(t, "request-1", event.RequestID)
+					assert.NotEmpty(t, event.ID)
+				}
+				assert.Equal(t, tt.ExpectedTypes, types)
+			},
+		)
+	}
+}
+
+func TestTenantEvents_Payloads(t *testing.T) {
+	before := newTenant()
+	after := newTenant()
+	after.Name = "Acme Inc"
+	after.Companies[0].Subscriptions = nil
+
+	raised, err := events.TenantEvents(context.Background(), entities.AuditUpdate, before, after)
+	require.NoError(t, err)
+	require.Len(t, raised, 2)
+
+	var updated events.TenantPayload
+	require.NoError(t, json.Unmarshal(raised[0].Payload, &updated))
+	assert.Equal(t, []string{"name"}, updated.Fields)
+
+	var subscription events.SubscriptionPayload
+	require.NoError(t, json.Unmarshal(raised[1].Payload, &subscription))
+	assert.Equal(t, "subscription-1", raised[1].Subject)
+	assert.Equal(t, "company-1", subscription.CompanyID)
+	assert.Nil(t, subscription.Subscription, "the subscription was removed")
+	assert.Equal(t, "starter", subscription.Previous.Plan)
+}
+
+func TestTenantEvents_UpdateTenantSubscription(t *testing.T) {
+	ctx := context.Background()
+	logger := utils.NewLogger()
+	repository := memory.NewTenantRepository(logger)
+	require.NoError(t, repository.CreateTenant(ctx, newTenant()))
+
+	before, err := repository.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+	err = service.NewTenantService(logger, repository).UpdateTenantSubscription(
+		ctx, "tenant-1", &entities.TenantSubscriptionDetails{ID: "subscription-1", Plan: "premium", Active: true},
+	)
+	require.NoError(t, err)
+	after, err := repository.GetTenantByID(ctx, "tenant-1")
+	require.NoError(t, err)
+
+	raised, err := events.TenantEvents(ctx, entities.AuditUpdate, before, after)
+	require.NoError(t, err)
+	require.Len(t, raised, 1)
+	assert.Equal(t, entities.SubscriptionUpdated, raised[0].Type)
+
+	var subscription events.SubscriptionPayload
+	require.NoError(t, json.Unmarshal(raised[0].Payload, &subscription))
+	assert.Equal(t, "premium", subscription.Subscription.Plan)
+	assert.Equal(t, "starter", subscription.Previous.Plan)
+}
+
+func TestRoleEvents(t *testing.T) {
+	role := &entities.Role{ID: "role-1", TenantID: "tenant-1", Name: "editor"}
+	renamed := &entities.Role{ID: "role-1", TenantID: "tenant-1", Name: "author"}
+
+	var testCases = []struct {
+		Name          string
+		Operation     entities.AuditOperation
+		Before        *entities.Role
+		After         *entities.Role
+		ExpectedTypes []entities.EventType
+	}{
+		{Name: "Created", Operation: entities.AuditCreate, After: role, ExpectedTypes: []entities.EventType{entities.RoleCreated}},
+		{Name: "Updated", Operation: entities.AuditUpdate, Before: role, After: renamed, ExpectedTypes: []entities.EventType{entities.RoleUpdated}},
+		{Name: "Unchanged", Operation: entities.AuditUpdate, Before: role, After: role},
+		{Name: "Deleted", Operation: entities.AuditDelete, Before: role, ExpectedTypes: []entities.EventType{entities.RoleDeleted}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				raised, err := events.RoleEvents(context.Background(), tt.Operation, tt.Before, tt.After)
+				require.NoError(t, err)
+
+				var types []entities.EventType
+				for _, event := range raised {
+					types = append(types, event.Type)
+					assert.Equal(t, "tenant-1", event.TenantID)
+					assert.Equal(t, "role-1", event.Subject)
+				}
+				assert.Equal(t, tt.ExpectedTypes, types)
+			},
+		)
+	}
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+type AuditRepository interface {
+	QueryAuditRecords(ctx context.Context, filter entities.AuditFilter) ([]*entities.AuditRecord, error)
+	VerifyAuditChain(ctx context.Context) error
+}
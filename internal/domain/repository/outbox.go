@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+// Outbox holds the events waiting to be published. Claimed events are leased to a single relay
+// until they are marked, or until the lease expires when the relay died on the way.
+type Outbox interface {
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]*entities.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, id string, cause string, retryAt time.Time) error
+}
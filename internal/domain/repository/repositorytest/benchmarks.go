@@ -0,0 +1,165 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/tests"
+)
+
+// TenantsEnv sets the sizes of the repositories benchmarks run against, comma separated.
+const TenantsEnv = "BENCH_TENANTS"
+
+// defaultSizes are the sizes of the repositories benchmarks run against when TenantsEnv is unset.
+var defaultSizes = []int{1000, 100000}
+
+// TenantBenchmarkFactory returns an empty tenant repository for a benchmark.
+type TenantBenchmarkFactory func(b *testing.B) repository.TenantRepository
+
+// batchInserter is implemented by repositories creating many tenants in a single round trip.
+type batchInserter interface {
+	InsertTenants(ctx context.Context, tenants []*entities.Tenant) error
+}
+
+// BenchmarkTenantRepository benchmarks the reads and updates of the repositories returned by
+// newRepository, once per size set by TenantsEnv. Every size gets its own repository, seeded
+// with generated tenants before the benchmarks start.
+func BenchmarkTenantRepository(b *testing.B, newRepository TenantBenchmarkFactory) {
+	sizes, err := benchmarkSizes()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, size := range sizes {
+		size := size
+		b.Run(
+			fmt.Sprintf("tenants=%d", size), func(b *testing.B) {
+				repo := newRepository(b)
+				tenants := seedBenchmark(b, repo, size)
+				benchmarkTenantRepository(b, repo, tenants)
+			},
+		)
+	}
+}
+
+func benchmarkSizes() ([]int, error) {
+	value := os.Getenv(TenantsEnv)
+	if value == "" {
+		return defaultSizes, nil
+	}
+
+	var sizes []int
+	for _, entry := range strings.Split(value, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(entry))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid %s %q, expected sizes such as 1000,100000", TenantsEnv, value)
+		}
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+// seedBenchmark creates size generated tenants, in batches when the repository supports them.
+func seedBenchmark(b *testing.B, repo repository.TenantRepository, size int) []*entities.Tenant {
+	b.Helper()
+	ctx := context.Background()
+	tenants := tests.NewGenerator(tests.WithSeed(int64(size))).Tenants(size)
+
+	if inserter, ok := repo.(batchInserter); ok {
+		const batchSize = 1000
+		for start := 0; start < len(tenants); start += batchSize {
+			end := start + batchSize
+			if end > len(tenants) {
+				end = len(tenants)
+			}
+			if err := inserter.InsertTenants(ctx, tenants[start:end]); err != nil {
+				b.Fatal(err)
+			}
+		}
+		return tenants
+	}
+
+	for _, tenant := range tenants {
+		if err := repo.CreateTenant(ctx, tenant); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	return tenants
+}
+
+func benchmarkTenantRepository(b *testing.B, repo repository.TenantRepository, tenants []*entities.Tenant) {
+	ctx := context.Background()
+	random := rand.New(rand.NewSource(1))
+	pick := func() *entities.Tenant { return tenants[random.Intn(len(tenants))] }
+
+	// searches look tenants up by the email of a contact, an indexed field of every backend
+	search := func(tenant *entities.Tenant) map[string]any {
+		return map[string]any{"primary_contacts.email": tenant.PrimaryContacts[0].Email}
+	}
+
+	suite := []struct {
+		Name string
+		Run  func() error
+	}{
+		{
+			Name: "GetTenantByID",
+			Run: func() error {
+				_, err := repo.GetTenantByID(ctx, pick().ID)
+				return err
+			},
+		},
+		{
+			Name: "GetTenants",
+			Run: func() error {
+				_, err := repo.GetTenants(ctx)
+				return err
+			},
+		},
+		{
+			Name: "SearchTenant",
+			Run: func() error {
+				_, err := repo.SearchTenant(ctx, search(pick()))
+				return err
+			},
+		},
+		{
+			Name: "SearchTenants",
+			Run: func() error {
+				_, err := repo.SearchTenants(ctx, search(pick()))
+				return err
+			},
+		},
+		{
+			Name: "UpdateTenant",
+			Run: func() error {
+				// updates change a copy, the seeded tenants stay as stored
+				updated := *pick()
+				updated.Name = fmt.Sprintf("%s %d", updated.Name, random.Intn(1000))
+				return repo.UpdateTenant(ctx, &updated)
+			},
+		},
+	}
+
+	for _, bb := range suite {
+		bb := bb
+		b.Run(
+			bb.Name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if err := bb.Run(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,285 @@
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RolesRepositoryFactory returns an empty roles repository for a test.
+type RolesRepositoryFactory func(t *testing.T) repository.RolesRepository
+
+// TestRolesRepository runs the conformance suite of roles repositories against the
+// repositories returned by newRepository, every test gets its own.
+func TestRolesRepository(t *testing.T, newRepository RolesRepositoryFactory) {
+	suite := []struct {
+		Name string
+		Test func(t *testing.T, newRepository RolesRepositoryFactory)
+	}{
+		{Name: "SaveRole", Test: testSaveRole},
+		{Name: "UpdateRole", Test: testUpdateRole},
+		{Name: "DeleteRole", Test: testDeleteRole},
+		{Name: "FindAllRoles", Test: testFindAllRoles},
+		{Name: "Concurrency", Test: testRolesConcurrency},
+		{Name: "ContextCancellation", Test: testRolesContextCancellation},
+	}
+
+	for _, tt := range suite {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				tt.Test(t, newRepository)
+			},
+		)
+	}
+}
+
+func newRole(id string) *entities.Role {
+	return &entities.Role{
+		ID:          id,
+		TenantID:    "tenant-1",
+		Name:        "Role " + id,
+		Description: "a role of the conformance suite",
+		Permissions: []entities.Permission{entities.ReadPermission},
+	}
+}
+
+func testSaveRole(t *testing.T, newRepository RolesRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.SaveRole(ctx, newRole("existing")))
+
+	var testCases = []struct {
+		Name          string
+		Role          *entities.Role
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Save a role",
+			Role: newRole("role-1"),
+		},
+		{
+			Name: "Happy Path: Save a platform role",
+			Role: &entities.Role{ID: "platform", Name: "platform", Permissions: []entities.Permission{}},
+		},
+		{
+			Name:          "Error Path: ID of another role",
+			Role:          newRole("existing"),
+			ExpectedError: apperrors.ErrCreatingRoleDocument,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := repo.SaveRole(ctx, tt.Role)
+				assertError(t, tt.ExpectedError, err)
+				if tt.ExpectedError != nil {
+					return
+				}
+
+				found, err := repo.FindRoleByID(ctx, utils.XID{ID: tt.Role.ID})
+				require.NoError(t, err)
+				assert.Equal(t, tt.Role, found)
+			},
+		)
+	}
+
+	_, err := repo.FindRoleByID(ctx, utils.XID{ID: "missing"})
+	assertError(t, apperrors.ErrNoRoleDocumentsFound, err)
+}
+
+func testUpdateRole(t *testing.T, newRepository RolesRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.SaveRole(ctx, newRole("role-1")))
+
+	renamed := newRole("role-1")
+	renamed.Name = "Renamed"
+	renamed.Permissions = append(renamed.Permissions, entities.WritePermission)
+
+	var testCases = []struct {
+		Name          string
+		Role          *entities.Role
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Update a role",
+			Role: renamed,
+		},
+		{
+			Name:          "Error Path: Unknown role",
+			Role:          newRole("missing"),
+			ExpectedError: apperrors.ErrNoRoleDocumentsFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := repo.UpdateRole(ctx, tt.Role)
+				assertError(t, tt.ExpectedError, err)
+			},
+		)
+	}
+
+	found, err := repo.FindRoleByID(ctx, utils.XID{ID: "role-1"})
+	require.NoError(t, err)
+	assert.Equal(t, renamed, found)
+
+	// unknown roles are not created
+	_, err = repo.FindRoleByID(ctx, utils.XID{ID: "missing"})
+	assertError(t, apperrors.ErrNoRoleDocumentsFound, err)
+}
+
+func testDeleteRole(t *testing.T, newRepository RolesRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	require.NoError(t, repo.SaveRole(ctx, newRole("role-1")))
+
+	var testCases = []struct {
+		Name          string
+		ID            string
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Delete a role",
+			ID:   "role-1",
+		},
+		{
+			Name:          "Error Path: Delete a deleted role",
+			ID:            "role-1",
+			ExpectedError: apperrors.ErrNoRoleDocumentsFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := repo.DeleteRole(ctx, utils.XID{ID: tt.ID})
+				assertError(t, tt.ExpectedError, err)
+			},
+		)
+	}
+
+	// roles are removed for good
+	_, err := repo.FindRoleByID(ctx, utils.XID{ID: "role-1"})
+	assertError(t, apperrors.ErrNoRoleDocumentsFound, err)
+}
+
+func testFindAllRoles(t *testing.T, newRepository RolesRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+
+	empty, err := repo.FindAllRoles(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	for _, id := range []string{"role-1", "role-2", "role-3"} {
+		require.NoError(t, repo.SaveRole(ctx, newRole(id)))
+	}
+	require.NoError(t, repo.DeleteRole(ctx, utils.XID{ID: "role-2"}))
+
+	roles, err := repo.FindAllRoles(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*entities.Role{newRole("role-1"), newRole("role-3")}, roles)
+
+	// callers get copies, changes are only kept once written
+	roles[0].Name = "Modified"
+	found, err := repo.FindRoleByID(ctx, utils.XID{ID: roles[0].ID})
+	require.NoError(t, err)
+	assert.NotEqual(t, "Modified", found.Name)
+}
+
+func testRolesConcurrency(t *testing.T, newRepository RolesRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		role := newRole(fmt.Sprintf("role-%d", i))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.SaveRole(ctx, role))
+			role.Name = "Renamed"
+			assert.NoError(t, repo.UpdateRole(ctx, role))
+			_, err := repo.FindAllRoles(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	roles, err := repo.FindAllRoles(ctx)
+	require.NoError(t, err)
+	require.Len(t, roles, 20)
+	for _, role := range roles {
+		assert.Equal(t, "Renamed", role.Name)
+	}
+}
+
+func testRolesContextCancellation(t *testing.T, newRepository RolesRepositoryFactory) {
+	repo := newRepository(t)
+	role := newRole("role-1")
+	require.NoError(t, repo.SaveRole(context.Background(), role))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var testCases = []struct {
+		Name      string
+		Operation func() error
+	}{
+		{
+			Name:      "Error Path: SaveRole",
+			Operation: func() error { return repo.SaveRole(ctx, newRole("role-2")) },
+		},
+		{
+			Name: "Error Path: UpdateRole",
+			Operation: func() error {
+				renamed := newRole("role-1")
+				renamed.Name = "Renamed"
+				return repo.UpdateRole(ctx, renamed)
+			},
+		},
+		{
+			Name:      "Error Path: DeleteRole",
+			Operation: func() error { return repo.DeleteRole(ctx, utils.XID{ID: "role-1"}) },
+		},
+		{
+			Name: "Error Path: FindRoleByID",
+			Operation: func() error {
+				_, err := repo.FindRoleByID(ctx, utils.XID{ID: "role-1"})
+				return err
+			},
+		},
+		{
+			Name: "Error Path: FindAllRoles",
+			Operation: func() error {
+				_, err := repo.FindAllRoles(ctx)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				assertError(t, context.Canceled, tt.Operation())
+			},
+		)
+	}
+
+	// nothing was written
+	roles, err := repo.FindAllRoles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*entities.Role{role}, roles)
+}
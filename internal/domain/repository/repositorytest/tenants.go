@@ -0,0 +1,529 @@
+// Package repositorytest provides the conformance suites every implementation of the
+// repository interfaces must pass, whatever the storage behind it.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TenantRepositoryFactory returns an empty tenant repository for a test. Subdomains must be
+// unique in the repository it returns.
+type TenantRepositoryFactory func(t *testing.T) repository.TenantRepository
+
+// TestTenantRepository runs the conformance suite of tenant repositories against the
+// repositories returned by newRepository, every test gets its own.
+func TestTenantRepository(t *testing.T, newRepository TenantRepositoryFactory) {
+	suite := []struct {
+		Name string
+		Test func(t *testing.T, newRepository TenantRepositoryFactory)
+	}{
+		{Name: "CreateTenant", Test: testCreateTenant},
+		{Name: "GetTenantByID", Test: testGetTenantByID},
+		{Name: "GetTenants", Test: testGetTenants},
+		{Name: "UpdateTenant", Test: testUpdateTenant},
+		{Name: "DeleteTenant", Test: testDeleteTenant},
+		{Name: "SearchTenant", Test: testSearchTenant},
+		{Name: "SearchTenants", Test: testSearchTenants},
+		{Name: "Concurrency", Test: testTenantConcurrency},
+		{Name: "ContextCancellation", Test: testTenantContextCancellation},
+	}
+
+	for _, tt := range suite {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				tt.Test(t, newRepository)
+			},
+		)
+	}
+}
+
+// newTenants returns tenants with distinct subdomains.
+func newTenants(amount int) []*entities.Tenant {
+	tenants := tests.CreateTenantList(amount)
+	for i, tenant := range tenants {
+		tenant.Subdomain = fmt.Sprintf("%s-%d", tenant.Subdomain, i)
+	}
+
+	return tenants
+}
+
+// seedTenants creates tenants in a repository.
+func seedTenants(t *testing.T, repo repository.TenantRepository, amount int) []*entities.Tenant {
+	tenants := newTenants(amount)
+	for _, tenant := range tenants {
+		require.NoError(t, repo.CreateTenant(context.Background(), tenant))
+	}
+
+	return tenants
+}
+
+func assertError(t *testing.T, expected error, err error) {
+	t.Helper()
+	if expected == nil {
+		require.NoError(t, err)
+		return
+	}
+
+	assert.True(t, errors.Is(err, expected), "expected %v, got %v", expected, err)
+}
+
+func testCreateTenant(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	existing := seedTenants(t, repo, 1)[0]
+
+	tenants := newTenants(3)
+	sameSubdomain := tenants[1]
+	sameSubdomain.Subdomain = existing.Subdomain
+	sameID := tenants[2]
+	sameID.ID = existing.ID
+
+	var testCases = []struct {
+		Name          string
+		Tenant        *entities.Tenant
+		ExpectedError error
+	}{
+		{
+			Name:   "Happy Path: Create a Tenant",
+			Tenant: tenants[0],
+		},
+		{
+			Name:          "Error Path: Subdomain of another tenant",
+			Tenant:        sameSubdomain,
+			ExpectedError: apperrors.ErrCreatingTenantDocument,
+		},
+		{
+			Name:          "Error Path: ID of another tenant",
+			Tenant:        sameID,
+			ExpectedError: apperrors.ErrCreatingTenantDocument,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := repo.CreateTenant(ctx, tt.Tenant)
+				assertError(t, tt.ExpectedError, err)
+				if tt.ExpectedError != nil {
+					return
+				}
+
+				found, err := repo.GetTenantByID(ctx, tt.Tenant.ID)
+				require.NoError(t, err)
+				assert.Equal(t, tt.Tenant, found)
+			},
+		)
+	}
+
+	// refused tenants left the existing one as it was
+	found, err := repo.GetTenantByID(ctx, existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, existing, found)
+}
+
+func testGetTenantByID(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	tenant := seedTenants(t, repo, 1)[0]
+
+	var testCases = []struct {
+		Name          string
+		ID            string
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Get a Tenant",
+			ID:   tenant.ID,
+		},
+		{
+			Name:          "Error Path: Unknown tenant",
+			ID:            "missing",
+			ExpectedError: apperrors.ErrNoTenantDocumentsFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				found, err := repo.GetTenantByID(ctx, tt.ID)
+				assertError(t, tt.ExpectedError, err)
+				if tt.ExpectedError == nil {
+					assert.Equal(t, tenant, found)
+				}
+			},
+		)
+	}
+
+	// callers get copies, changes are only kept once written
+	name := tenant.Name
+	found, err := repo.GetTenantByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	found.Name = "Modified"
+	found.Companies[0].Name = "Modified"
+	tenant.Name = "Modified too"
+
+	again, err := repo.GetTenantByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, name, again.Name)
+	assert.NotEqual(t, "Modified", again.Companies[0].Name)
+}
+
+func testGetTenants(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+
+	empty, err := repo.GetTenants(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	tenants := seedTenants(t, repo, 3)
+	require.NoError(t, repo.DeleteTenant(ctx, tenants[1].ID))
+
+	// deleted tenants are not listed
+	active, err := repo.GetTenants(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{tenants[0].ID, tenants[2].ID}, tenantIDs(active))
+}
+
+func testUpdateTenant(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	tenants := seedTenants(t, repo, 2)
+
+	renamed := *tenants[0]
+	renamed.Name = "Renamed"
+	renamed.PaymentDetails = []*entities.TenantPaymentDetails{tests.GeneratePaymentDetails()}
+
+	taken := renamed
+	taken.Subdomain = tenants[1].Subdomain
+
+	unknown := newTenants(1)[0]
+
+	var testCases = []struct {
+		Name          string
+		Tenant        *entities.Tenant
+		ExpectedError error
+	}{
+		{
+			Name:   "Happy Path: Update a Tenant",
+			Tenant: &renamed,
+		},
+		{
+			Name:          "Error Path: Subdomain of another tenant",
+			Tenant:        &taken,
+			ExpectedError: apperrors.ErrUpdatingTenantDocument,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := repo.UpdateTenant(ctx, tt.Tenant)
+				assertError(t, tt.ExpectedError, err)
+			},
+		)
+	}
+
+	found, err := repo.GetTenantByID(ctx, renamed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &renamed, found)
+
+	// updating an unknown tenant does not create it, whether it fails or not
+	_ = repo.UpdateTenant(ctx, unknown)
+	_, err = repo.GetTenantByID(ctx, unknown.ID)
+	assertError(t, apperrors.ErrNoTenantDocumentsFound, err)
+}
+
+func testDeleteTenant(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	tenant := seedTenants(t, repo, 1)[0]
+
+	var testCases = []struct {
+		Name          string
+		ID            string
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Delete a Tenant",
+			ID:   tenant.ID,
+		},
+		{
+			Name: "Happy Path: Delete a deleted Tenant",
+			ID:   tenant.ID,
+		},
+		{
+			Name:          "Error Path: Unknown tenant",
+			ID:            "missing",
+			ExpectedError: apperrors.ErrNoTenantDocumentsFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				err := repo.DeleteTenant(ctx, tt.ID)
+				assertError(t, tt.ExpectedError, err)
+			},
+		)
+	}
+
+	// deleted tenants are kept, inactive
+	deleted, err := repo.GetTenantByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted.IsActive)
+	assert.Equal(t, tenant.Name, deleted.Name)
+}
+
+func testSearchTenant(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+
+	tenants := newTenants(2)
+	tenant := tenants[0]
+	tenant.Domains = []*entities.TenantDomain{
+		{ID: "domain-1", Hostname: "portal.example.com", Status: entities.DomainStatusVerified},
+	}
+	tenant.APIKeys = []*entities.APIKey{{ID: "key-1", Name: "ci", Prefix: "tm_conformance"}}
+	for _, tenant := range tenants {
+		require.NoError(t, repo.CreateTenant(ctx, tenant))
+	}
+
+	var testCases = []struct {
+		Name          string
+		Filter        map[string]any
+		ExpectedError error
+	}{
+		{
+			Name:   "Happy Path: By subdomain",
+			Filter: map[string]any{"subdomain": tenant.Subdomain},
+		},
+		{
+			Name:   "Happy Path: By custom domain",
+			Filter: map[string]any{"domains.hostname": "portal.example.com"},
+		},
+		{
+			Name:   "Happy Path: By api key prefix",
+			Filter: map[string]any{"api_keys.prefix": "tm_conformance"},
+		},
+		{
+			Name:   "Happy Path: By payment details",
+			Filter: map[string]any{"payment_details._id": tenant.PaymentDetails[0].ID},
+		},
+		{
+			Name:   "Happy Path: By several fields",
+			Filter: map[string]any{"subdomain": tenant.Subdomain, "is_active": true},
+		},
+		{
+			Name:          "Error Path: No tenant matches every field",
+			Filter:        map[string]any{"subdomain": tenant.Subdomain, "is_active": false},
+			ExpectedError: apperrors.ErrNoTenantDocumentsFound,
+		},
+		{
+			Name:          "Error Path: Unknown subdomain",
+			Filter:        map[string]any{"subdomain": "missing"},
+			ExpectedError: apperrors.ErrNoTenantDocumentsFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				found, err := repo.SearchTenant(ctx, tt.Filter)
+				assertError(t, tt.ExpectedError, err)
+				if tt.ExpectedError == nil {
+					assert.Equal(t, tenant, found)
+				}
+			},
+		)
+	}
+}
+
+func testSearchTenants(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+	tenants := seedTenants(t, repo, 3)
+	require.NoError(t, repo.DeleteTenant(ctx, tenants[2].ID))
+
+	var testCases = []struct {
+		Name        string
+		Filter      map[string]any
+		ExpectedIDs []string
+	}{
+		{
+			Name:        "Happy Path: Active tenants",
+			Filter:      map[string]any{"is_active": true},
+			ExpectedIDs: []string{tenants[0].ID, tenants[1].ID},
+		},
+		{
+			Name:        "Happy Path: Deleted tenants are searched too",
+			Filter:      map[string]any{"is_active": false},
+			ExpectedIDs: []string{tenants[2].ID},
+		},
+		{
+			Name:        "Happy Path: Every tenant",
+			Filter:      map[string]any{},
+			ExpectedIDs: tenantIDs(tenants),
+		},
+		{
+			Name:   "Happy Path: No tenant matches",
+			Filter: map[string]any{"subdomain": "missing"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				found, err := repo.SearchTenants(ctx, tt.Filter)
+				require.NoError(t, err)
+				assert.ElementsMatch(t, tt.ExpectedIDs, tenantIDs(found))
+			},
+		)
+	}
+}
+
+func testTenantConcurrency(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	ctx := context.Background()
+
+	// distinct tenants are all created
+	tenants := newTenants(20)
+	var wg sync.WaitGroup
+	for _, tenant := range tenants {
+		tenant := tenant
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, repo.CreateTenant(ctx, tenant))
+			_, err := repo.GetTenants(ctx)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	active, err := repo.GetTenants(ctx)
+	require.NoError(t, err)
+	assert.Len(t, active, len(tenants))
+
+	// a subdomain is claimed once, whoever comes first
+	claims := newTenants(10)
+	var created sync.Map
+	for _, tenant := range claims {
+		tenant := tenant
+		tenant.Subdomain = "contended"
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := repo.CreateTenant(ctx, tenant); err == nil {
+				created.Store(tenant.ID, true)
+			}
+		}()
+	}
+	wg.Wait()
+
+	winners := 0
+	created.Range(
+		func(any, any) bool {
+			winners++
+			return true
+		},
+	)
+	assert.Equal(t, 1, winners)
+}
+
+func testTenantContextCancellation(t *testing.T, newRepository TenantRepositoryFactory) {
+	repo := newRepository(t)
+	tenants := seedTenants(t, repo, 1)
+	tenant := tenants[0]
+	created := newTenants(1)[0]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var testCases = []struct {
+		Name      string
+		Operation func() error
+	}{
+		{
+			Name:      "Error Path: CreateTenant",
+			Operation: func() error { return repo.CreateTenant(ctx, created) },
+		},
+		{
+			Name: "Error Path: GetTenantByID",
+			Operation: func() error {
+				_, err := repo.GetTenantByID(ctx, tenant.ID)
+				return err
+			},
+		},
+		{
+			Name: "Error Path: GetTenants",
+			Operation: func() error {
+				_, err := repo.GetTenants(ctx)
+				return err
+			},
+		},
+		{
+			Name: "Error Path: UpdateTenant",
+			Operation: func() error {
+				renamed := *tenant
+				renamed.Name = "Renamed"
+				return repo.UpdateTenant(ctx, &renamed)
+			},
+		},
+		{
+			Name:      "Error Path: DeleteTenant",
+			Operation: func() error { return repo.DeleteTenant(ctx, tenant.ID) },
+		},
+		{
+			Name: "Error Path: SearchTenant",
+			Operation: func() error {
+				_, err := repo.SearchTenant(ctx, map[string]any{"subdomain": tenant.Subdomain})
+				return err
+			},
+		},
+		{
+			Name: "Error Path: SearchTenants",
+			Operation: func() error {
+				_, err := repo.SearchTenants(ctx, map[string]any{"is_active": true})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				assertError(t, context.Canceled, tt.Operation())
+			},
+		)
+	}
+
+	// nothing was written
+	found, err := repo.GetTenantByID(context.Background(), tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tenant, found)
+
+	_, err = repo.GetTenantByID(context.Background(), created.ID)
+	assertError(t, apperrors.ErrNoTenantDocumentsFound, err)
+}
+
+func tenantIDs(tenants []*entities.Tenant) []string {
+	ids := make([]string, 0, len(tenants))
+	for _, tenant := range tenants {
+		ids = append(ids, tenant.ID)
+	}
+
+	return ids
+}
@@ -1,14 +1,16 @@
 package repository
 
 import (
+	"context"
+
 	"github.com/hebecoding/digital-dash-commons/utils"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
 )
 
 type RolesRepository interface {
-	SaveRole(role *entities.Role) error
-	UpdateRole(role *entities.Role) error
-	DeleteRole(roleID utils.XID) error
-	FindRoleByID(roleID utils.XID) (*entities.Role, error)
-	FindAllRoles() ([]*entities.Role, error)
+	SaveRole(ctx context.Context, role *entities.Role) error
+	UpdateRole(ctx context.Context, role *entities.Role) error
+	DeleteRole(ctx context.Context, roleID utils.XID) error
+	FindRoleByID(ctx context.Context, roleID utils.XID) (*entities.Role, error)
+	FindAllRoles(ctx context.Context) ([]*entities.Role, error)
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+type WebhookRepository interface {
+	SaveEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error
+	UpdateEndpoint(ctx context.Context, endpoint *entities.WebhookEndpoint) error
+	DeleteEndpoint(ctx context.Context, tenantID string, endpointID string) error
+	FindEndpoint(ctx context.Context, tenantID string, endpointID string) (*entities.WebhookEndpoint, error)
+	FindEndpoints(ctx context.Context, tenantID string) ([]*entities.WebhookEndpoint, error)
+
+	// SaveDelivery saves a new delivery, saving a delivery again does nothing.
+	SaveDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+	FindDelivery(ctx context.Context, tenantID string, deliveryID string) (*entities.WebhookDelivery, error)
+	FindDeliveries(ctx context.Context, filter entities.WebhookDeliveryFilter) ([]*entities.WebhookDelivery, error)
+	// ClaimDeliveries leases up to limit pending deliveries due for an attempt.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*entities.WebhookDelivery, error)
+}
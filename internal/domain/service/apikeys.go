@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+)
+
+// APIKeyPrefix starts every API key, telling them apart from JWTs and making leaked keys easy to scan for.
+const APIKeyPrefix = "tm_"
+
+// APIKeyUsageResolution is how often the last-used timestamp of an API key is recorded.
+// Uses in between are not written, so a busy key does not cause a write per request.
+const APIKeyUsageResolution = time.Minute
+
+// CreateAPIKey issues an API key to a tenant. The name, scopes, expiry and creator are taken
+// from key, the remaining fields are generated. The returned secret is the key itself, it is
+// never stored and cannot be retrieved again.
+func (s *TenantService) CreateAPIKey(ctx context.Context, tenantID string, key *entities.APIKey) (string, error) {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if err := validateAPIKey(tenant, key, now); err != nil {
+		s.Logger.Info(err)
+		return "", err
+	}
+
+	key.ID = utils.NewXID().ID
+	key.CreatedAt = now
+	key.LastUsedAt = time.Time{}
+	key.RevokedAt = time.Time{}
+
+	secret, err := setAPIKeySecret(key)
+	if err != nil {
+		s.Logger.Error(err)
+		return "", apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	tenant.APIKeys = append(tenant.APIKeys, key)
+	if err := s.Repository.UpdateTenant(ctx, tenant); err != nil {
+		return "", err
+	}
+
+	s.Logger.Infof("issued api key %s to tenant %s", key.Prefix, tenantID)
+	return secret, nil
+}
+
+// ListAPIKeys returns the API keys issued to a tenant, including revoked and expired ones.
+func (s *TenantService) ListAPIKeys(ctx context.Context, tenantID string) ([]*entities.APIKey, error) {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant.APIKeys, nil
+}
+
+// RotateAPIKey replaces the secret of an API key, the previous secret stops working immediately.
+// The returned secret is the new key.
+func (s *TenantService) RotateAPIKey(ctx context.Context, tenantID string, keyID string) (
+	*entities.APIKey, string, error,
+) {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := findAPIKey(tenant, keyID)
+	if key == nil {
+		s.Logger.Infof("api key with ID %s not found", keyID)
+		return nil, "", apperrors.ErrNoAPIKeyFound
+	}
+
+	if key.IsRevoked() {
+		s.Logger.Infof("api key %s is revoked", key.Prefix)
+		return nil, "", apperrors.ErrInvalidAPIKey.WithFields(
+			apperrors.FieldError{Field: "revoked_at", Message: "revoked keys cannot be rotated"},
+		)
+	}
+
+	previous := key.Prefix
+	secret, err := setAPIKeySecret(key)
+	if err != nil {
+		s.Logger.Error(err)
+		return nil, "", apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	if err := s.Repository.UpdateTenant(ctx, tenant); err != nil {
+		return nil, "", err
+	}
+
+	s.Logger.Infof("rotated api key %s of tenant %s to %s", previous, tenantID, key.Prefix)
+	return key, secret, nil
+}
+
+// RevokeAPIKey disables an API key for good. Revoking a revoked key has no effect.
+func (s *TenantService) RevokeAPIKey(ctx context.Context, tenantID string, keyID string) error {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	key := findAPIKey(tenant, keyID)
+	if key == nil {
+		s.Logger.Infof("api key with ID %s not found", keyID)
+		return apperrors.ErrNoAPIKeyFound
+	}
+
+	if key.IsRevoked() {
+		return nil
+	}
+
+	key.RevokedAt = time.Now().UTC().Truncate(time.Millisecond)
+	if err := s.Repository.UpdateTenant(ctx, tenant); err != nil {
+		return err
+	}
+
+	s.Logger.Infof("revoked api key %s of tenant %s", key.Prefix, tenantID)
+	return nil
+}
+
+// TouchAPIKey records that an API key was used at the given time, at most once per APIKeyUsageResolution.
+func (s *TenantService) TouchAPIKey(ctx context.Context, tenantID string, keyID string, at time.Time) error {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	key := findAPIKey(tenant, keyID)
+	if key == nil {
+		return apperrors.ErrNoAPIKeyFound
+	}
+
+	if at.Sub(key.LastUsedAt) < APIKeyUsageResolution {
+		return nil
+	}
+
+	key.LastUsedAt = at.UTC().Truncate(time.Millisecond)
+	return s.Repository.UpdateTenant(ctx, tenant)
+}
+
+// ParseAPIKeyPrefix returns the public part of an API key. The secret follows the first
+// separator after APIKeyPrefix and may itself contain separators.
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return "", false
+	}
+
+	rest := key[len(APIKeyPrefix):]
+	i := strings.IndexByte(rest, '_')
+	if i <= 0 || i == len(rest)-1 {
+		return "", false
+	}
+
+	return key[:len(APIKeyPrefix)+i], true
+}
+
+// setAPIKeySecret generates a new prefix and secret for key and returns the resulting API key.
+func setAPIKeySecret(key *entities.APIKey) (string, error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	key.Prefix = APIKeyPrefix + hex.EncodeToString(id)
+	secret := key.Prefix + "_" + base64.RawURLEncoding.EncodeToString(random)
+
+	if err := key.SetSecret(secret); err != nil {
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func validateAPIKey(tenant *entities.Tenant, key *entities.APIKey, now time.Time) error {
+	if key == nil {
+		return apperrors.ErrInvalidAPIKey
+	}
+
+	var fields []apperrors.FieldError
+	if strings.TrimSpace(key.Name) == "" {
+		fields = append(fields, apperrors.FieldError{Field: "name", Message: "is required"})
+	}
+
+	if len(key.Scopes) == 0 {
+		fields = append(fields, apperrors.FieldError{Field: "scopes", Message: "at least one scope is required"})
+	}
+
+	for i, scope := range key.Scopes {
+		if !scope.Resource.IsValid() {
+			fields = append(
+				fields, apperrors.FieldError{
+					Field:   apperrors.FieldPath("scopes", i, "resource"),
+					Message: fmt.Sprintf("unknown resource %q", scope.Resource),
+				},
+			)
+		}
+
+		for j, permission := range scope.Permissions {
+			if !permission.IsValid() {
+				fields = append(
+					fields, apperrors.FieldError{
+						Field:   apperrors.FieldPath("scopes", i, "permissions", j),
+						Message: fmt.Sprintf("unknown permission %q", permission),
+					},
+				)
+			}
+		}
+	}
+
+	if !key.ExpiresAt.IsZero() && !key.ExpiresAt.After(now) {
+		fields = append(fields, apperrors.FieldError{Field: "expires_at", Message: "must be in the future"})
+	}
+
+	if !hasContact(tenant, key.CreatedBy) {
+		fields = append(fields, apperrors.FieldError{Field: "created_by", Message: "must be a contact of the tenant"})
+	}
+
+	if len(fields) > 0 {
+		return apperrors.ErrInvalidAPIKey.WithFields(fields...)
+	}
+
+	return nil
+}
+
+func hasContact(tenant *entities.Tenant, contactID string) bool {
+	for _, contact := range tenant.PrimaryContacts {
+		if contactID != "" && contact.ID == contactID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func findAPIKey(tenant *entities.Tenant, keyID string) *entities.APIKey {
+	for _, key := range tenant.APIKeys {
+		if key.ID == keyID {
+			return key
+		}
+	}
+
+	return nil
+}
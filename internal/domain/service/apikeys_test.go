@@ -0,0 +1,149 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	serv "github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantService_CreateAPIKey(t *testing.T) {
+	tenant := tests.CreateTenant()
+	contactID := tenant.PrimaryContacts[0].ID
+
+	var testCases = []struct {
+		Name          string
+		Key           *entities.APIKey
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Create an API Key",
+			Key: &entities.APIKey{
+				Name: "ci",
+				Scopes: []entities.APIKeyScope{
+					{Resource: entities.DomainsResource, Permissions: []entities.Permission{entities.ReadPermission}},
+				},
+				CreatedBy: contactID,
+				ExpiresAt: time.Now().Add(time.Hour),
+			},
+		},
+		{
+			Name: "Error Path: API Key without scopes",
+			Key: &entities.APIKey{
+				Name:      "ci",
+				CreatedBy: contactID,
+			},
+			ExpectedError: apperrors.ErrInvalidAPIKey,
+		},
+		{
+			Name: "Error Path: API Key of an unknown contact",
+			Key: &entities.APIKey{
+				Name: "ci",
+				Scopes: []entities.APIKeyScope{
+					{Resource: entities.DomainsResource, Permissions: []entities.Permission{entities.ReadPermission}},
+				},
+				CreatedBy: "unknown",
+			},
+			ExpectedError: apperrors.ErrInvalidAPIKey,
+		},
+		{
+			Name: "Error Path: expired API Key",
+			Key: &entities.APIKey{
+				Name: "ci",
+				Scopes: []entities.APIKeyScope{
+					{Resource: entities.DomainsResource, Permissions: []entities.Permission{entities.ReadPermission}},
+				},
+				CreatedBy: contactID,
+				ExpiresAt: time.Now().Add(-time.Hour),
+			},
+			ExpectedError: apperrors.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				defer func() {
+					err := dropTestCollections()
+					if err != nil {
+						logger.Error(err)
+					}
+				}()
+
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+
+				require.NoError(t, mock.Service.CreateTenant(ctx, tenant))
+
+				secret, err := mock.Service.CreateAPIKey(ctx, tenant.ID, tt.Key)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+					return
+				}
+
+				require.NoError(t, err)
+				prefix, ok := serv.ParseAPIKeyPrefix(secret)
+				require.True(t, ok)
+				assert.Equal(t, tt.Key.Prefix, prefix)
+				assert.NotContains(t, tt.Key.Hash, secret)
+
+				found, err := mock.Service.GetTenantByAPIKeyPrefix(ctx, prefix)
+				require.NoError(t, err)
+				require.Len(t, found.APIKeys, 1)
+				assert.True(t, found.APIKeys[0].Matches(secret))
+			},
+		)
+	}
+}
+
+func TestTenantService_RotateAndRevokeAPIKey(t *testing.T) {
+	defer func() {
+		err := dropTestCollections()
+		if err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	tenant := tests.CreateTenant()
+	require.NoError(t, mock.Service.CreateTenant(ctx, tenant))
+
+	key := &entities.APIKey{
+		Name: "ci",
+		Scopes: []entities.APIKeyScope{
+			{Resource: entities.TenantsResource, Permissions: []entities.Permission{entities.ReadPermission}},
+		},
+		CreatedBy: tenant.PrimaryContacts[0].ID,
+	}
+	secret, err := mock.Service.CreateAPIKey(ctx, tenant.ID, key)
+	require.NoError(t, err)
+
+	rotated, rotatedSecret, err := mock.Service.RotateAPIKey(ctx, tenant.ID, key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, rotated.ID)
+	assert.NotEqual(t, secret, rotatedSecret)
+	assert.True(t, strings.HasPrefix(rotatedSecret, rotated.Prefix+"_"))
+	assert.False(t, rotated.Matches(secret))
+
+	require.NoError(t, mock.Service.RevokeAPIKey(ctx, tenant.ID, key.ID))
+	// revoking twice has no effect
+	require.NoError(t, mock.Service.RevokeAPIKey(ctx, tenant.ID, key.ID))
+
+	keys, err := mock.Service.ListAPIKeys(ctx, tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.True(t, keys[0].IsRevoked())
+
+	_, _, err = mock.Service.RotateAPIKey(ctx, tenant.ID, key.ID)
+	assert.True(t, errors.Is(err, apperrors.ErrInvalidAPIKey), "unexpected error: %v", err)
+
+	err = mock.Service.RevokeAPIKey(ctx, tenant.ID, "missing")
+	assert.True(t, errors.Is(err, apperrors.ErrNoAPIKeyFound), "unexpected error: %v", err)
+}
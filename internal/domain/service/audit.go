@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+)
+
+const (
+	// DefaultAuditPageSize is the number of audit records returned when no limit is given.
+	DefaultAuditPageSize = 100
+	// MaxAuditPageSize is the largest number of audit records returned at once.
+	MaxAuditPageSize = 1000
+)
+
+// AuditService reads the audit trail of the changes made to tenants and roles.
+// The trail is written by the repositories, together with the changes.
+type AuditService struct {
+	Repository repository.AuditRepository
+	Logger     utils.LoggerInterface
+}
+
+func NewAuditService(logger utils.LoggerInterface, repository repository.AuditRepository) *AuditService {
+	return &AuditService{
+		Repository: repository,
+		Logger:     logger,
+	}
+}
+
+// QueryAuditRecords returns a page of the audit records matching filter, in the order they were
+// recorded. The next page starts after the sequence of the last record.
+func (s *AuditService) QueryAuditRecords(ctx context.Context, filter entities.AuditFilter) (
+	[]*entities.AuditRecord, error,
+) {
+	var fields []apperrors.FieldError
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		fields = append(fields, apperrors.FieldError{Field: "from", Message: "must be before to"})
+	}
+
+	if filter.Limit < 0 || filter.Limit > MaxAuditPageSize {
+		fields = append(
+			fields, apperrors.FieldError{Field: "limit", Message: "must be between 1 and 1000"},
+		)
+	}
+
+	if filter.AfterSequence < 0 {
+		fields = append(fields, apperrors.FieldError{Field: "after", Message: "must not be negative"})
+	}
+
+	if len(fields) > 0 {
+		return nil, apperrors.ErrValidation.WithFields(fields...)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = DefaultAuditPageSize
+	}
+
+	return s.Repository.QueryAuditRecords(ctx, filter)
+}
+
+// VerifyAuditChain checks that no record of the audit trail was altered, removed or inserted.
+func (s *AuditService) VerifyAuditChain(ctx context.Context) error {
+	return s.Repository.VerifyAuditChain(ctx)
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/pkg/errors"
+)
+
+// DomainVerifier proves ownership of a custom domain.
+type DomainVerifier interface {
+	Verify(ctx context.Context, domain *entities.TenantDomain) error
+}
+
+type DomainService struct {
+	Repository repository.TenantRepository
+	Verifier   DomainVerifier
+	Logger     utils.LoggerInterface
+}
+
+func NewDomainService(
+	logger utils.LoggerInterface,
+	repository repository.TenantRepository,
+	verifier DomainVerifier,
+) *DomainService {
+	return &DomainService{
+		Repository: repository,
+		Verifier:   verifier,
+		Logger:     logger,
+	}
+}
+
+// AddDomain attaches a custom domain to a tenant in the pending state.
+// The returned domain holds the token the tenant has to publish to prove ownership.
+func (s *DomainService) AddDomain(
+	ctx context.Context, tenantID string, hostname string, method entities.VerificationMethod,
+) (*entities.TenantDomain, error) {
+	hostname, err := NormalizeHostname(hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	if method != entities.DNSTXTVerification && method != entities.HTTPVerification {
+		s.Logger.Infof("unsupported verification method: %v", method)
+		return nil, apperrors.ErrInvalidDomain.WithFields(
+			apperrors.FieldError{Field: "verification_method", Message: fmt.Sprintf("unsupported method %q", method)},
+		)
+	}
+
+	// a hostname belongs to the tenant it was verified for, pending and failed claims of other
+	// tenants do not keep its owner from adding it
+	owner, err := verifiedOwner(ctx, s.Repository, hostname)
+	switch {
+	case err == nil:
+		s.Logger.Infof("domain %s is already claimed by tenant %s", hostname, owner.ID)
+		return nil, apperrors.ErrDomainAlreadyClaimed
+	case !errors.Is(err, apperrors.ErrNoTenantDocumentsFound):
+		return nil, err
+	}
+
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, domain := range tenant.Domains {
+		if domain.Hostname == hostname {
+			s.Logger.Infof("domain %s is already attached to tenant %s", hostname, tenantID)
+			return nil, apperrors.ErrDomainAlreadyClaimed
+		}
+	}
+
+	token, err := generateVerificationToken()
+	if err != nil {
+		s.Logger.Error(err)
+		return nil, apperrors.ErrUpdatingTenantDocument.Wrap(err)
+	}
+
+	domain := &entities.TenantDomain{
+		ID:                 utils.NewXID().ID,
+		Hostname:           hostname,
+		VerificationMethod: method,
+		VerificationToken:  token,
+		Status:             entities.DomainStatusPending,
+		CreatedAt:          time.Now().UTC().Truncate(time.Millisecond),
+	}
+
+	tenant.Domains = append(tenant.Domains, domain)
+	if err := s.Repository.UpdateTenant(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("added domain %s to tenant %s", hostname, tenantID)
+	return domain, nil
+}
+
+// GetDomains returns the custom domains attached to a tenant.
+func (s *DomainService) GetDomains(ctx context.Context, tenantID string) ([]*entities.TenantDomain, error) {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant.Domains, nil
+}
+
+// VerifyDomain checks the ownership challenge of a domain and records the outcome.
+// The updated domain is returned even when the verification fails.
+func (s *DomainService) VerifyDomain(ctx context.Context, tenantID string, domainID string) (
+	*entities.TenantDomain, error,
+) {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := findDomain(tenant, domainID)
+	if domain == nil {
+		s.Logger.Infof("domain with ID %s not found", domainID)
+		return nil, apperrors.ErrNoDomainFound
+	}
+
+	verifyErr := s.check(ctx, tenant, domain)
+
+	if err := s.Repository.UpdateTenant(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	return domain, verifyErr
+}
+
+// RemoveDomain detaches a custom domain from a tenant.
+func (s *DomainService) RemoveDomain(ctx context.Context, tenantID string, domainID string) error {
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	domains := make([]*entities.TenantDomain, 0, len(tenant.Domains))
+	for _, domain := range tenant.Domains {
+		if domain.ID != domainID {
+			domains = append(domains, domain)
+		}
+	}
+
+	if len(domains) == len(tenant.Domains) {
+		s.Logger.Infof("domain with ID %s not found", domainID)
+		return apperrors.ErrNoDomainFound
+	}
+
+	tenant.Domains = domains
+	return s.Repository.UpdateTenant(ctx, tenant)
+}
+
+// ReverifyDomains re-runs the ownership challenge of every domain that is not failed.
+// Domains whose token was removed are marked as failed and stop resolving to their tenant,
+// domains that could not be checked keep their status until the next run.
+func (s *DomainService) ReverifyDomains(ctx context.Context) error {
+	tenants, err := s.Repository.GetTenants(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, tenant := range tenants {
+		checked := false
+		for _, domain := range tenant.Domains {
+			if domain.Status == entities.DomainStatusFailed {
+				continue
+			}
+
+			if err := s.check(ctx, tenant, domain); err != nil {
+				s.Logger.Infof("re-verification of domain %s failed: %v", domain.Hostname, err)
+			}
+			checked = true
+		}
+
+		if !checked {
+			continue
+		}
+
+		if err := s.Repository.UpdateTenant(ctx, tenant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartReverification runs ReverifyDomains every interval until the context is cancelled.
+func (s *DomainService) StartReverification(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.ReverifyDomains(ctx); err != nil {
+				s.Logger.Error(err)
+			}
+		}
+	}
+}
+
+// check runs the verification challenge and updates the state of the domain accordingly. Domains
+// verified for another tenant in the meantime fail, domains that could not be checked keep their
+// status.
+func (s *DomainService) check(ctx context.Context, tenant *entities.Tenant, domain *entities.TenantDomain) error {
+	if domain.Status != entities.DomainStatusVerified {
+		owner, err := verifiedOwner(ctx, s.Repository, domain.Hostname)
+		switch {
+		case err == nil && owner.ID != tenant.ID:
+			domain.LastCheckedAt = time.Now().UTC().Truncate(time.Millisecond)
+			domain.Status = entities.DomainStatusFailed
+			domain.FailureReason = apperrors.ErrDomainAlreadyClaimed.Message
+			return apperrors.ErrDomainAlreadyClaimed
+		case err != nil && !errors.Is(err, apperrors.ErrNoTenantDocumentsFound):
+			return err
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	domain.LastCheckedAt = now
+
+	if err := s.Verifier.Verify(ctx, domain); err != nil {
+		if errors.Is(err, apperrors.ErrDomainVerificationUnavailable) {
+			return err
+		}
+
+		domain.Status = entities.DomainStatusFailed
+		domain.FailureReason = err.Error()
+		return err
+	}
+
+	if domain.Status != entities.DomainStatusVerified {
+		domain.VerifiedAt = now
+	}
+	domain.Status = entities.DomainStatusVerified
+	domain.FailureReason = ""
+
+	return nil
+}
+
+// verifiedOwner returns the tenant hostname was verified for. Several tenants may claim a
+// hostname, only the one proving its ownership owns it.
+func verifiedOwner(
+	ctx context.Context, tenants repository.TenantRepository, hostname string,
+) (*entities.Tenant, error) {
+	claimants, err := tenants.SearchTenants(ctx, map[string]any{"domains.hostname": hostname})
+	if err != nil && !errors.Is(err, apperrors.ErrNoTenantDocumentsFound) {
+		return nil, err
+	}
+
+	for _, tenant := range claimants {
+		for _, domain := range tenant.Domains {
+			if domain.Hostname == hostname && domain.Status == entities.DomainStatusVerified {
+				return tenant, nil
+			}
+		}
+	}
+
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func findDomain(tenant *entities.Tenant, domainID string) *entities.TenantDomain {
+	for _, domain := range tenant.Domains {
+		if domain.ID == domainID {
+			return domain
+		}
+	}
+
+	return nil
+}
+
+// NormalizeHostname lower-cases a host, strips any port and trailing dot, and checks
+// that what remains is a valid fully qualified domain name.
+func NormalizeHostname(host string) (string, error) {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if len(host) == 0 || len(host) > 253 || !strings.Contains(host, ".") {
+		return "", apperrors.ErrInvalidDomain
+	}
+
+	for _, label := range strings.Split(host, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", apperrors.ErrInvalidDomain
+		}
+
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return "", apperrors.ErrInvalidDomain
+			}
+		}
+	}
+
+	return host, nil
+}
+
+func generateVerificationToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
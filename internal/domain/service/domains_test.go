@@ -0,0 +1,232 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	serv "github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVerifier fails the challenges of the hostnames it has an error for.
+type fakeVerifier map[string]error
+
+func (v fakeVerifier) Verify(_ context.Context, domain *entities.TenantDomain) error {
+	return v[domain.Hostname]
+}
+
+func TestDomainService_VerifyDomain(t *testing.T) {
+	var testCases = []struct {
+		Name           string
+		Status         entities.DomainStatus
+		VerifyError    error
+		ExpectedStatus entities.DomainStatus
+		ExpectedError  error
+	}{
+		{
+			Name:           "Happy Path: pending domain is verified",
+			Status:         entities.DomainStatusPending,
+			ExpectedStatus: entities.DomainStatusVerified,
+		},
+		{
+			Name:           "Error Path: missing token fails the domain",
+			Status:         entities.DomainStatusVerified,
+			VerifyError:    errors.Wrap(apperrors.ErrDomainVerificationFailed, "no matching TXT record"),
+			ExpectedStatus: entities.DomainStatusFailed,
+			ExpectedError:  apperrors.ErrDomainVerificationFailed,
+		},
+		{
+			Name:           "Error Path: verified domain is kept on transient errors",
+			Status:         entities.DomainStatusVerified,
+			VerifyError:    errors.Wrap(apperrors.ErrDomainVerificationUnavailable, "i/o timeout"),
+			ExpectedStatus: entities.DomainStatusVerified,
+			ExpectedError:  apperrors.ErrDomainVerificationUnavailable,
+		},
+		{
+			Name:           "Error Path: pending domain is kept on transient errors",
+			Status:         entities.DomainStatusPending,
+			VerifyError:    errors.Wrap(apperrors.ErrDomainVerificationUnavailable, "unexpected status 503"),
+			ExpectedStatus: entities.DomainStatusPending,
+			ExpectedError:  apperrors.ErrDomainVerificationUnavailable,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				defer func() {
+					if err := dropTestCollections(); err != nil {
+						logger.Error(err)
+					}
+				}()
+
+				tenant := tests.CreateTenant()
+				tenant.Domains = []*entities.TenantDomain{
+					{ID: "domain-1", Hostname: "portal.example.com", Status: tt.Status},
+				}
+				require.NoError(t, mock.Repo.CreateTenant(ctx, tenant))
+
+				domains := serv.NewDomainService(
+					logger, mock.Repo, fakeVerifier{"portal.example.com": tt.VerifyError},
+				)
+				domain, err := domains.VerifyDomain(ctx, tenant.ID, "domain-1")
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+				} else {
+					assert.NoError(t, err)
+				}
+
+				stored, err := mock.Repo.GetTenantByID(ctx, tenant.ID)
+				require.NoError(t, err)
+				require.Len(t, stored.Domains, 1)
+				assert.Equal(t, tt.ExpectedStatus, domain.Status)
+				assert.Equal(t, tt.ExpectedStatus, stored.Domains[0].Status)
+			},
+		)
+	}
+}
+
+func TestDomainService_ReverifyDomains(t *testing.T) {
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	tenant := tests.CreateTenant()
+	tenant.Domains = []*entities.TenantDomain{
+		{ID: "domain-1", Hostname: "live.example.com", Status: entities.DomainStatusVerified},
+		{ID: "domain-2", Hostname: "flaky.example.com", Status: entities.DomainStatusVerified},
+		{ID: "domain-3", Hostname: "gone.example.com", Status: entities.DomainStatusVerified},
+	}
+	require.NoError(t, mock.Repo.CreateTenant(ctx, tenant))
+
+	domains := serv.NewDomainService(
+		logger, mock.Repo, fakeVerifier{
+			"flaky.example.com": errors.Wrap(apperrors.ErrDomainVerificationUnavailable, "i/o timeout"),
+			"gone.example.com":  errors.Wrap(apperrors.ErrDomainVerificationFailed, "no matching TXT record"),
+		},
+	)
+	require.NoError(t, domains.ReverifyDomains(ctx))
+
+	stored, err := mock.Repo.GetTenantByID(ctx, tenant.ID)
+	require.NoError(t, err)
+
+	statuses := map[string]entities.DomainStatus{}
+	for _, domain := range stored.Domains {
+		statuses[domain.Hostname] = domain.Status
+	}
+	assert.Equal(
+		t, map[string]entities.DomainStatus{
+			"live.example.com":  entities.DomainStatusVerified,
+			"flaky.example.com": entities.DomainStatusVerified,
+			"gone.example.com":  entities.DomainStatusFailed,
+		}, statuses,
+	)
+}
+
+// claims returns a claim of portal.example.com in status.
+func claims(status entities.DomainStatus) []*entities.TenantDomain {
+	return []*entities.TenantDomain{{ID: utils.NewXID().ID, Hostname: "portal.example.com", Status: status}}
+}
+
+func TestDomainService_AddDomain(t *testing.T) {
+	var testCases = []struct {
+		Name          string
+		Claims        []*entities.TenantDomain
+		OwnClaims     []*entities.TenantDomain
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: unclaimed hostname",
+		},
+		{
+			Name:   "Happy Path: hostname left pending by another tenant",
+			Claims: claims(entities.DomainStatusPending),
+		},
+		{
+			Name:   "Happy Path: hostname failed for another tenant",
+			Claims: claims(entities.DomainStatusFailed),
+		},
+		{
+			Name:          "Error Path: hostname verified for another tenant",
+			Claims:        claims(entities.DomainStatusVerified),
+			ExpectedError: apperrors.ErrDomainAlreadyClaimed,
+		},
+		{
+			Name:          "Error Path: hostname already attached to the tenant",
+			OwnClaims:     claims(entities.DomainStatusFailed),
+			ExpectedError: apperrors.ErrDomainAlreadyClaimed,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				defer func() {
+					if err := dropTestCollections(); err != nil {
+						logger.Error(err)
+					}
+				}()
+
+				other := tests.CreateTenant()
+				other.Domains = tt.Claims
+				require.NoError(t, mock.Repo.CreateTenant(ctx, other))
+
+				tenant := tests.CreateTenant()
+				tenant.Domains = tt.OwnClaims
+				require.NoError(t, mock.Repo.CreateTenant(ctx, tenant))
+
+				domains := serv.NewDomainService(logger, mock.Repo, fakeVerifier{})
+				domain, err := domains.AddDomain(ctx, tenant.ID, "Portal.Example.com", entities.DNSTXTVerification)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, "portal.example.com", domain.Hostname)
+				assert.Equal(t, entities.DomainStatusPending, domain.Status)
+			},
+		)
+	}
+}
+
+func TestDomainService_VerifyDomain_VerifiedElsewhere(t *testing.T) {
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	owner := tests.CreateTenant()
+	require.NoError(t, mock.Repo.CreateTenant(ctx, owner))
+	squatter := tests.CreateTenant()
+	require.NoError(t, mock.Repo.CreateTenant(ctx, squatter))
+
+	domains := serv.NewDomainService(logger, mock.Repo, fakeVerifier{})
+	claim, err := domains.AddDomain(ctx, squatter.ID, "portal.example.com", entities.DNSTXTVerification)
+	require.NoError(t, err)
+	domain, err := domains.AddDomain(ctx, owner.ID, "portal.example.com", entities.DNSTXTVerification)
+	require.NoError(t, err)
+
+	_, err = domains.VerifyDomain(ctx, owner.ID, domain.ID)
+	require.NoError(t, err)
+
+	// the hostname resolves to the tenant that verified it, the other claim can no longer be verified
+	resolved, err := serv.NewTenantService(logger, mock.Repo).GetTenantByHost(ctx, "portal.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, owner.ID, resolved.ID)
+
+	claim, err = domains.VerifyDomain(ctx, squatter.ID, claim.ID)
+	assert.True(t, errors.Is(err, apperrors.ErrDomainAlreadyClaimed), "unexpected error: %v", err)
+	assert.Equal(t, entities.DomainStatusFailed, claim.Status)
+}
@@ -1,45 +1,128 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
 )
 
 type RoleService interface {
-	CreateRole(role *entities.Role) error
-	CreateCustomRole(role *entities.Role) error
-	UpdateRole(role *entities.Role) error
-	DeleteRole(id utils.XID) error
-	GetRole(id utils.XID) (*entities.Role, error)
-	GetRoles() ([]*entities.Role, error)
+	CreateRole(ctx context.Context, role *entities.Role) error
+	CreateCustomRole(ctx context.Context, role *entities.Role) error
+	UpdateRole(ctx context.Context, role *entities.Role) error
+	DeleteRole(ctx context.Context, id utils.XID) error
+	GetRole(ctx context.Context, id utils.XID) (*entities.Role, error)
+	GetRoles(ctx context.Context) ([]*entities.Role, error)
 }
 
-type roleServiceImp struct{}
+type roleServiceImp struct {
+	repository repository.RolesRepository
+	logger     utils.LoggerInterface
+}
 
-func NewRoleService() RoleService {
-	return &roleServiceImp{}
+func NewRoleService(logger utils.LoggerInterface, repository repository.RolesRepository) RoleService {
+	return &roleServiceImp{
+		repository: repository,
+		logger:     logger,
+	}
 }
 
-func (r *roleServiceImp) CreateRole(role *entities.Role) error {
-	return nil
+// CreateRole creates a platform role, available to every tenant.
+func (r *roleServiceImp) CreateRole(ctx context.Context, role *entities.Role) error {
+	if role.TenantID != "" {
+		r.logger.Info("platform roles cannot belong to a tenant")
+		return apperrors.ErrInvalidRole.WithFields(
+			apperrors.FieldError{Field: "tenant_id", Message: "platform roles cannot belong to a tenant"},
+		)
+	}
+
+	return r.save(ctx, role)
 }
 
-func (r *roleServiceImp) CreateCustomRole(role *entities.Role) error {
-	return nil
+// CreateCustomRole creates a role defined by, and only available to, a single tenant.
+func (r *roleServiceImp) CreateCustomRole(ctx context.Context, role *entities.Role) error {
+	if role.TenantID == "" {
+		r.logger.Info("custom roles must belong to a tenant")
+		return apperrors.ErrInvalidRole.WithFields(
+			apperrors.FieldError{Field: "tenant_id", Message: "custom roles must belong to a tenant"},
+		)
+	}
+
+	return r.save(ctx, role)
 }
 
-func (r *roleServiceImp) UpdateRole(role *entities.Role) error {
-	return nil
+func (r *roleServiceImp) UpdateRole(ctx context.Context, role *entities.Role) error {
+	if err := validateRole(role); err != nil {
+		r.logger.Info(err)
+		return err
+	}
+
+	// make sure the role exists before updating it
+	if _, err := r.repository.FindRoleByID(ctx, utils.XID{ID: role.ID}); err != nil {
+		return err
+	}
+
+	return r.repository.UpdateRole(ctx, role)
 }
 
-func (r *roleServiceImp) DeleteRole(id utils.XID) error {
-	return nil
+func (r *roleServiceImp) DeleteRole(ctx context.Context, id utils.XID) error {
+	return r.repository.DeleteRole(ctx, id)
+}
+
+func (r *roleServiceImp) GetRole(ctx context.Context, id utils.XID) (*entities.Role, error) {
+	return r.repository.FindRoleByID(ctx, id)
+}
+
+func (r *roleServiceImp) GetRoles(ctx context.Context) ([]*entities.Role, error) {
+	return r.repository.FindAllRoles(ctx)
 }
 
-func (r *roleServiceImp) GetRole(id utils.XID) (*entities.Role, error) {
-	return nil, nil
+func (r *roleServiceImp) save(ctx context.Context, role *entities.Role) error {
+	if role.ID == "" {
+		role.ID = utils.NewXID().ID
+	}
+
+	if err := validateRole(role); err != nil {
+		r.logger.Info(err)
+		return err
+	}
+
+	return r.repository.SaveRole(ctx, role)
 }
 
-func (r *roleServiceImp) GetRoles() ([]*entities.Role, error) {
-	return nil, nil
+func validateRole(role *entities.Role) error {
+	if role == nil {
+		return apperrors.ErrInvalidRole
+	}
+
+	var fields []apperrors.FieldError
+	if role.ID == "" {
+		fields = append(fields, apperrors.FieldError{Field: "_id", Message: "is required"})
+	}
+
+	if strings.TrimSpace(role.Name) == "" {
+		fields = append(fields, apperrors.FieldError{Field: "name", Message: "is required"})
+	}
+
+	for i, permission := range role.Permissions {
+		if !permission.IsValid() {
+			fields = append(
+				fields, apperrors.FieldError{
+					Field:   apperrors.FieldPath("permissions", i),
+					Message: fmt.Sprintf("unknown permission %q", permission),
+				},
+			)
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.ErrInvalidRole.WithFields(fields...)
+	}
+
+	return nil
 }
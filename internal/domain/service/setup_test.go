@@ -7,6 +7,7 @@ import (
 
 	"github.com/hebecoding/digital-dash-commons/utils"
 	"github.com/hebecoding/tenant-management/infrastructure/config"
+	"github.com/hebecoding/tenant-management/infrastructure/repositories/memory"
 	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
 	serv "github.com/hebecoding/tenant-management/internal/domain/service"
 	"github.com/pkg/errors"
@@ -49,6 +50,16 @@ func TestMain(m *testing.M) {
 	// configure test environment
 	// initialize logger
 	logger = utils.NewLogger()
+
+	// tests run against the in-memory repository unless mongo is asked for
+	if os.Getenv("TEST_REPOSITORY") != "mongo" {
+		logger.Info("Creating new in-memory tenant repository")
+		resetMemoryRepository()
+
+		logger.Info("Test setup complete... Running tests")
+		os.Exit(m.Run())
+	}
+
 	// read in config
 	if err := config.ReadInConfig(logger); err != nil {
 		logger.Fatal(err)
@@ -116,7 +127,17 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+func resetMemoryRepository() {
+	mock.Repo = memory.NewTenantRepository(logger)
+	mock.Service = serv.NewTenantService(logger, mock.Repo)
+}
+
 func dropTestCollections() error {
+	if mock.DB == nil {
+		resetMemoryRepository()
+		return nil
+	}
+
 	// drop existing collections
 	logger.Info("Dropping existing test collections")
 	if err := mock.DB.Drop(context.Background()); err != nil {
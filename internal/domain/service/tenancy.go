@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/pkg/tenancy"
+)
+
+// tenancyLookup finds the tenants of the tenancy resolver through a TenantService.
+type tenancyLookup struct {
+	tenants *TenantService
+}
+
+// NewTenancyLookup returns the lookup of the tenancy resolver backed by tenants.
+func NewTenancyLookup(tenants *TenantService) tenancy.Lookup {
+	return &tenancyLookup{tenants: tenants}
+}
+
+func (l *tenancyLookup) GetTenantByID(ctx context.Context, id string) (*tenancy.Tenant, error) {
+	return toTenancyTenant(l.tenants.GetTenantByID(ctx, id))
+}
+
+func (l *tenancyLookup) GetTenantBySubdomain(ctx context.Context, subdomain string) (*tenancy.Tenant, error) {
+	return toTenancyTenant(l.tenants.GetTenantBySubdomain(ctx, subdomain))
+}
+
+func (l *tenancyLookup) GetTenantByHost(ctx context.Context, host string) (*tenancy.Tenant, error) {
+	return toTenancyTenant(l.tenants.GetTenantByHost(ctx, host))
+}
+
+func toTenancyTenant(tenant *entities.Tenant, err error) (*tenancy.Tenant, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return &tenancy.Tenant{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		Subdomain: tenant.Subdomain,
+		IsActive:  tenant.IsActive,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	serv "github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/hebecoding/tenant-management/pkg/tenancy"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenancyLookup(t *testing.T) {
+	defer func() {
+		if err := dropTestCollections(); err != nil {
+			logger.Error(err)
+		}
+	}()
+
+	tenant := tests.CreateTenant()
+	tenant.Domains = []*entities.TenantDomain{
+		{ID: "domain-1", Hostname: "portal.example.com", Status: entities.DomainStatusVerified},
+	}
+	require.NoError(t, mock.Repo.CreateTenant(ctx, tenant))
+
+	lookup := serv.NewTenancyLookup(serv.NewTenantService(logger, mock.Repo))
+	expected := &tenancy.Tenant{
+		ID: tenant.ID, Name: tenant.Name, Subdomain: tenant.Subdomain, IsActive: tenant.IsActive,
+	}
+
+	found, err := lookup.GetTenantByID(ctx, tenant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, expected, found)
+
+	found, err = lookup.GetTenantBySubdomain(ctx, tenant.Subdomain)
+	require.NoError(t, err)
+	assert.Equal(t, expected, found)
+
+	found, err = lookup.GetTenantByHost(ctx, "portal.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, expected, found)
+
+	_, err = lookup.GetTenantByID(ctx, "unknown")
+	assert.True(t, errors.Is(err, apperrors.ErrNoTenantDocumentsFound), "unexpected error: %v", err)
+}
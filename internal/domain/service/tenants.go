@@ -2,11 +2,17 @@ package service
 
 import (
 	"context"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/hebecoding/digital-dash-commons/utils"
 	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/audit"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
 	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/pkg/errors"
 )
 
 type TenantService struct {
@@ -25,6 +31,14 @@ func NewTenantService(
 }
 
 func (s *TenantService) CreateTenant(ctx context.Context, tenant *entities.Tenant) error {
+	if tenant.ID == "" {
+		tenant.ID = utils.NewXID().ID
+	}
+
+	if tenant.CreatedAt.IsZero() {
+		tenant.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	}
+
 	return s.Repository.CreateTenant(ctx, tenant)
 }
 
@@ -32,11 +46,51 @@ func (s *TenantService) GetTenantByID(ctx context.Context, id string) (*entities
 	return s.Repository.GetTenantByID(ctx, id)
 }
 
+func (s *TenantService) GetTenantBySubdomain(ctx context.Context, subdomain string) (*entities.Tenant, error) {
+	return s.Repository.SearchTenant(ctx, map[string]any{"subdomain": strings.ToLower(subdomain)})
+}
+
+// GetTenantByHost returns the tenant owning the custom domain host.
+// Only verified domains resolve, pending or failed domains are reported as not found.
+func (s *TenantService) GetTenantByHost(ctx context.Context, host string) (*entities.Tenant, error) {
+	hostname, err := NormalizeHostname(host)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := verifiedOwner(ctx, s.Repository, hostname)
+	if errors.Is(err, apperrors.ErrNoTenantDocumentsFound) {
+		s.Logger.Infof("domain %s is not verified", hostname)
+	}
+
+	return tenant, err
+}
+
+// UpdateTenant replaces the tenant. Custom domains and API keys are managed by
+// their own operations and are kept as they are.
 func (s *TenantService) UpdateTenant(ctx context.Context, id string, tenant *entities.Tenant) error {
+	current, err := s.Repository.GetTenantByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	tenant.ID = id
+	tenant.Domains = current.Domains
+	tenant.APIKeys = current.APIKeys
+	tenant.CreatedAt = current.CreatedAt
+	tenant.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	if actor, ok := audit.ActorFromContext(ctx); ok {
+		tenant.UpdatedBy = actor.ID
+	}
+
 	return s.Repository.UpdateTenant(ctx, tenant)
 }
 
+// GetTenantByAPIKeyPrefix returns the tenant that was issued the API key with the given prefix.
+func (s *TenantService) GetTenantByAPIKeyPrefix(ctx context.Context, prefix string) (*entities.Tenant, error) {
+	return s.Repository.SearchTenant(ctx, map[string]any{"api_keys.prefix": prefix})
+}
+
 func (s *TenantService) DeleteTenant(ctx context.Context, id string) error {
 	return s.Repository.DeleteTenant(ctx, id)
 }
@@ -45,13 +99,18 @@ func (s *TenantService) GetTenants(ctx context.Context) ([]*entities.Tenant, err
 	return s.Repository.GetTenants(ctx)
 }
 
+// SearchTenants returns the tenants matching filter, inactive ones included. Filters are equality
+// conditions on the fields of tenant documents, such as {"is_active": false}.
+func (s *TenantService) SearchTenants(ctx context.Context, filter map[string]any) ([]*entities.Tenant, error) {
+	return s.Repository.SearchTenants(ctx, filter)
+}
+
 func (s *TenantService) GetTenantCompanies(ctx context.Context, id string) ([]*entities.TenantCompanyDetails, error) {
 	var companies []*entities.TenantCompanyDetails
 
 	tenant, err := s.Repository.GetTenantByID(ctx, id)
 	if err != nil {
-		s.Logger.Error(err)
-		return nil, apperrors.ErrRetrievingTenantDocument
+		return nil, err
 	}
 
 	for _, company := range tenant.Companies {
@@ -74,8 +133,7 @@ func (s *TenantService) GetTenantCompaniesSubscriptions(ctx context.Context, id
 
 	tenant, err := s.Repository.GetTenantByID(ctx, id)
 	if err != nil {
-		s.Logger.Error(err)
-		return nil, apperrors.ErrRetrievingTenantDocument
+		return nil, err
 	}
 
 	for _, company := range tenant.Companies {
@@ -93,36 +151,26 @@ func (s *TenantService) GetTenantCompaniesSubscriptions(ctx context.Context, id
 	return subscriptions, nil
 }
 
+// UpdateTenantSubscription replaces the subscription with the ID of subscription in the company it belongs to.
 func (s *TenantService) UpdateTenantSubscription(
 	ctx context.Context, tenantID string, subscription *entities.TenantSubscriptionDetails,
 ) error {
-	var tenant = &entities.Tenant{}
-
 	if subscription == nil {
 		s.Logger.Info("subscription is nil")
 		return apperrors.ErrInvalidTenantSubscription
 	}
 
-	// Get the t by ID
-	s.Logger.Infof("getting tenant by ID: %s", tenantID)
-	t, err := s.GetTenantByID(ctx, tenantID)
+	tenant, err := s.Repository.GetTenantByID(ctx, tenantID)
 	if err != nil {
 		return err
 	}
 
-	// Update the subscription
-	tenant.ID = tenantID
-
 	found := false
 outer:
-	for _, company := range t.Companies {
-		for _, sub := range company.Subscriptions {
+	for _, company := range tenant.Companies {
+		for j, sub := range company.Subscriptions {
 			if sub.ID == subscription.ID {
-				tenant.Companies = append(
-					tenant.Companies, &entities.TenantCompanyDetails{
-						Subscriptions: []*entities.TenantSubscriptionDetails{subscription},
-					},
-				)
+				company.Subscriptions[j] = subscription
 				found = true
 				break outer
 			}
@@ -136,3 +184,164 @@ outer:
 
 	return s.Repository.UpdateTenant(ctx, tenant)
 }
+
+func (s *TenantService) GetTenantCompanyByID(ctx context.Context, id string, companyID string) (
+	*entities.TenantCompanyDetails, error,
+) {
+	tenant, err := s.Repository.GetTenantByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, company := range tenant.Companies {
+		if company.ID == companyID {
+			return company, nil
+		}
+	}
+
+	s.Logger.Infof("company with ID %s not found", companyID)
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (s *TenantService) UpdateTenantCompany(
+	ctx context.Context, id string, company *entities.TenantCompanyDetails,
+) error {
+	if company == nil {
+		s.Logger.Info("company is nil")
+		return apperrors.ErrValidation.WithFields(apperrors.FieldError{Field: "company", Message: "is required"})
+	}
+
+	tenant, err := s.Repository.GetTenantByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, c := range tenant.Companies {
+		if c.ID == company.ID {
+			tenant.Companies[i] = company
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		s.Logger.Infof("company with ID %s not found", company.ID)
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return s.Repository.UpdateTenant(ctx, tenant)
+}
+
+func (s *TenantService) GetTenantPaymentDetails(ctx context.Context, id string) (
+	[]*entities.TenantPaymentDetails, error,
+) {
+	tenant, err := s.Repository.GetTenantByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tenant.PaymentDetails) == 0 {
+		s.Logger.Info("no tenant payment details found")
+		return nil, apperrors.ErrNoTenantDocumentsFound
+	}
+
+	s.Logger.Infof("found %d tenant payment details", len(tenant.PaymentDetails))
+	return tenant.PaymentDetails, nil
+}
+
+func (s *TenantService) GetTenantByPaymentID(ctx context.Context, paymentID string) (*entities.Tenant, error) {
+	return s.Repository.SearchTenant(ctx, map[string]any{"payment_details._id": paymentID})
+}
+
+func (s *TenantService) UpdateTenantPaymentDetails(
+	ctx context.Context, id string, paymentDetails *entities.TenantPaymentDetails,
+) error {
+	if paymentDetails == nil {
+		s.Logger.Info("payment details are nil")
+		return apperrors.ErrValidation.WithFields(
+			apperrors.FieldError{Field: "payment_details", Message: "is required"},
+		)
+	}
+
+	tenant, err := s.Repository.GetTenantByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, p := range tenant.PaymentDetails {
+		if p.ID == paymentDetails.ID {
+			tenant.PaymentDetails[i] = paymentDetails
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		s.Logger.Infof("payment details with ID %s not found", paymentDetails.ID)
+		return apperrors.ErrNoTenantDocumentsFound
+	}
+
+	return s.Repository.UpdateTenant(ctx, tenant)
+}
+
+// subdomainPattern is the pattern subdomains of tenants must match, a single DNS label.
+var subdomainPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateTenant checks a tenant against the rules the API enforces on the tenants it is given,
+// for tenants entering the service by other ways.
+func ValidateTenant(tenant *entities.Tenant) error {
+	var fields []apperrors.FieldError
+	invalid := func(field string, message string) {
+		fields = append(fields, apperrors.FieldError{Field: field, Message: message})
+	}
+
+	if tenant.Name == "" || len(tenant.Name) > 200 {
+		invalid("name", "must be between 1 and 200 characters")
+	}
+	if !subdomainPattern.MatchString(tenant.Subdomain) {
+		invalid("subdomain", "must be a lowercase DNS label")
+	}
+
+	for i, contact := range tenant.PrimaryContacts {
+		if address, err := mail.ParseAddress(contact.Email); err != nil || address.Address != contact.Email {
+			invalid(apperrors.FieldPath("primary_contacts", i, "email"), "must be an email address")
+		}
+	}
+
+	for i, company := range tenant.Companies {
+		path := apperrors.FieldPath("companies", i)
+		if company.Name == "" {
+			invalid(apperrors.FieldPath(path, "name"), "is required")
+		}
+		if address := company.Address; address != nil &&
+			(address.Address == "" || address.City == "" || address.Country == "") {
+			invalid(apperrors.FieldPath(path, "address"), "address, city and country are required")
+		}
+
+		for j, subscription := range company.Subscriptions {
+			path := apperrors.FieldPath(path, "subscriptions", j)
+			if subscription.Plan == "" {
+				invalid(apperrors.FieldPath(path, "plan"), "is required")
+			}
+			switch subscription.BillingCycle {
+			case "", "weekly", "monthly", "yearly":
+			default:
+				invalid(apperrors.FieldPath(path, "billing_cycle"), "must be weekly, monthly or yearly")
+			}
+			if subscription.DiscountRate < 0 || subscription.DiscountRate > 100 {
+				invalid(apperrors.FieldPath(path, "discount_rate"), "must be between 0 and 100")
+			}
+			if !subscription.EndDate.IsZero() && subscription.EndDate.Before(subscription.StartDate) {
+				invalid(apperrors.FieldPath(path, "end_date"), "must not be before the start date")
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.ErrValidation.WithFields(fields...)
+	}
+
+	return nil
+}
@@ -6,19 +6,21 @@ import (
 	"time"
 
 	"github.com/hebecoding/digital-dash-commons/utils"
-	"github.com/hebecoding/tenant-management/infrastructure/repositories/mongo"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
 	serv "github.com/hebecoding/tenant-management/internal/domain/service"
 	"github.com/hebecoding/tenant-management/tests"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	mgo "go.mongodb.org/mongo-driver/mongo"
 )
 
 type TestTenantService struct {
 	Service *serv.TenantService
 	DB      *mgo.Collection
-	Repo    *mongo.TenantRepository
+	Repo    repository.TenantRepository
 }
 
 var (
@@ -414,7 +416,7 @@ func TestTenantService_UpdateTenantSubscription(t *testing.T) {
 				newSub.ID = tenant.Companies[0].Subscriptions[0].ID
 
 				updatedTenantVals := &entities.Tenant{ID: tenant.ID, Companies: tenant.Companies, IsActive: true}
-				updatedTenantVals.Companies[0].Subscriptions = []*entities.TenantSubscriptionDetails{newSub}
+				updatedTenantVals.Companies[0].Subscriptions[0] = newSub
 
 				return updatedTenantVals
 			}(),
@@ -461,3 +463,167 @@ func TestTenantService_UpdateTenantSubscription(t *testing.T) {
 	}
 
 }
+
+func TestTenantService_UpdateTenantSubscription_KeepsTenant(t *testing.T) {
+	var testCases = []struct {
+		Name          string
+		Subscription  func(tenant *entities.Tenant) *entities.TenantSubscriptionDetails
+		ExpectedError error
+	}{
+		{
+			Name: "Happy Path: Only the subscription is replaced",
+			Subscription: func(tenant *entities.Tenant) *entities.TenantSubscriptionDetails {
+				subscription := tests.GenerateSubscriptionDetails()
+				subscription.ID = tenant.Companies[1].Subscriptions[1].ID
+				return subscription
+			},
+		},
+		{
+			Name: "Error Path: Unknown subscription",
+			Subscription: func(*entities.Tenant) *entities.TenantSubscriptionDetails {
+				return tests.GenerateSubscriptionDetails()
+			},
+			ExpectedError: apperrors.ErrNoTenantDocumentsFound,
+		},
+		{
+			Name: "Error Path: Missing subscription",
+			Subscription: func(*entities.Tenant) *entities.TenantSubscriptionDetails {
+				return nil
+			},
+			ExpectedError: apperrors.ErrInvalidTenantSubscription,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				defer func() {
+					if err := dropTestCollections(); err != nil {
+						logger.Error(err)
+					}
+				}()
+
+				tenant := tests.CreateTenant()
+				tenant.IsActive = true
+				tenant.PrimaryContacts = append(tenant.PrimaryContacts, tests.GenerateContactDetails())
+				tenant.Companies = []*entities.TenantCompanyDetails{tests.GenerateCompany(), tests.GenerateCompany()}
+				for _, company := range tenant.Companies {
+					company.Subscriptions = []*entities.TenantSubscriptionDetails{
+						tests.GenerateSubscriptionDetails(), tests.GenerateSubscriptionDetails(),
+					}
+				}
+				require.NoError(t, mock.Service.CreateTenant(ctx, tenant))
+
+				expected, err := mock.Service.GetTenantByID(ctx, tenant.ID)
+				require.NoError(t, err)
+
+				subscription := tt.Subscription(expected)
+				err = mock.Service.UpdateTenantSubscription(ctx, tenant.ID, subscription)
+				if tt.ExpectedError != nil {
+					assert.True(t, errors.Is(err, tt.ExpectedError), "unexpected error: %v", err)
+				} else {
+					require.NoError(t, err)
+					expected.Companies[1].Subscriptions[1] = subscription
+				}
+
+				stored, err := mock.Service.GetTenantByID(ctx, tenant.ID)
+				require.NoError(t, err)
+				assert.Equal(t, expected.Name, stored.Name)
+				assert.Equal(t, expected.Subdomain, stored.Subdomain)
+				assert.True(t, stored.IsActive)
+				assert.Equal(t, expected.PrimaryContacts, stored.PrimaryContacts)
+				assert.Equal(t, expected.PaymentDetails, stored.PaymentDetails)
+				assert.Equal(t, expected.Companies, stored.Companies)
+			},
+		)
+	}
+}
+
+func TestValidateTenant(t *testing.T) {
+	valid := func() *entities.Tenant {
+		return &entities.Tenant{
+			Name:            "Acme",
+			Subdomain:       "acme-corp",
+			PrimaryContacts: []*entities.TenantContactDetails{{Email: "ada@acme.io"}},
+			Companies: []*entities.TenantCompanyDetails{
+				{
+					Name: "Acme Inc",
+					Subscriptions: []*entities.TenantSubscriptionDetails{
+						{Plan: "pro", BillingCycle: "monthly", DiscountRate: 10},
+					},
+				},
+			},
+		}
+	}
+
+	var testCases = []struct {
+		Name           string
+		Change         func(tenant *entities.Tenant)
+		ExpectedFields []string
+	}{
+		{
+			Name:   "Happy Path: Valid tenant",
+			Change: func(*entities.Tenant) {},
+		},
+		{
+			Name: "Error Path: Missing name and invalid subdomain",
+			Change: func(tenant *entities.Tenant) {
+				tenant.Name = ""
+				tenant.Subdomain = "Acme Corp"
+			},
+			ExpectedFields: []string{"name", "subdomain"},
+		},
+		{
+			Name: "Error Path: Invalid contact email",
+			Change: func(tenant *entities.Tenant) {
+				tenant.PrimaryContacts[0].Email = "Ada <ada@acme.io>"
+			},
+			ExpectedFields: []string{"primary_contacts.0.email"},
+		},
+		{
+			Name: "Error Path: Invalid subscription",
+			Change: func(tenant *entities.Tenant) {
+				subscription := tenant.Companies[0].Subscriptions[0]
+				subscription.Plan = ""
+				subscription.BillingCycle = "daily"
+				subscription.StartDate = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+				subscription.EndDate = time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+			},
+			ExpectedFields: []string{
+				"companies.0.subscriptions.0.plan", "companies.0.subscriptions.0.billing_cycle",
+				"companies.0.subscriptions.0.end_date",
+			},
+		},
+		{
+			Name: "Error Path: Incomplete company address",
+			Change: func(tenant *entities.Tenant) {
+				tenant.Companies[0].Address = &entities.Address{City: "Springfield"}
+			},
+			ExpectedFields: []string{"companies.0.address"},
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				tenant := valid()
+				tt.Change(tenant)
+
+				err := serv.ValidateTenant(tenant)
+				if len(tt.ExpectedFields) == 0 {
+					assert.NoError(t, err)
+					return
+				}
+
+				assert.True(t, errors.Is(err, apperrors.ErrValidation), "unexpected error: %v", err)
+				var fields []string
+				for _, field := range apperrors.From(err).Fields {
+					fields = append(fields, field.Field)
+				}
+				assert.Equal(t, tt.ExpectedFields, fields)
+			},
+		)
+	}
+}
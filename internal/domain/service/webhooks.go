@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/hebecoding/digital-dash-commons/utils"
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/internal/domain/entities"
+	"github.com/hebecoding/tenant-management/internal/domain/repository"
+	"github.com/pkg/errors"
+)
+
+// WebhookSecretPrefix starts the secrets signing webhook payloads.
+const WebhookSecretPrefix = "whsec_"
+
+const (
+	defaultWebhookMaxAttempts = 8
+	defaultWebhookMinBackoff  = 30 * time.Second
+	defaultWebhookMaxBackoff  = 6 * time.Hour
+
+	// webhookBatchSize is how many deliveries are attempted at once.
+	webhookBatchSize = 20
+	// webhookLease is how long claimed deliveries are reserved, longer than attempting a batch takes.
+	webhookLease = 10 * time.Minute
+
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 1000
+)
+
+// WebhookSender posts the payload of a delivery to an endpoint. Failures are reported in the attempt.
+type WebhookSender interface {
+	Send(ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery) entities.WebhookAttempt
+}
+
+// WebhookService sends the domain events of tenants to their webhook endpoints. Deliveries
+// are retried with exponential backoff, and end up in the dead-letter list once they ran out
+// of attempts.
+type WebhookService struct {
+	Repository repository.WebhookRepository
+	Tenants    repository.TenantRepository
+	Sender     WebhookSender
+	Logger     utils.LoggerInterface
+	// MaxAttempts is how many times a delivery is attempted before it is dead.
+	MaxAttempts int
+	// MinBackoff is the delay before the second attempt, it doubles with every attempt up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// AllowHTTP accepts endpoints without TLS, for local development.
+	AllowHTTP bool
+}
+
+func NewWebhookService(
+	logger utils.LoggerInterface,
+	repository repository.WebhookRepository,
+	tenants repository.TenantRepository,
+	sender WebhookSender,
+) *WebhookService {
+	return &WebhookService{
+		Repository:  repository,
+		Tenants:     tenants,
+		Sender:      sender,
+		Logger:      logger,
+		MaxAttempts: defaultWebhookMaxAttempts,
+		MinBackoff:  defaultWebhookMinBackoff,
+		MaxBackoff:  defaultWebhookMaxBackoff,
+	}
+}
+
+// CreateEndpoint registers a webhook endpoint of a tenant. The URL, description and event types
+// are taken from endpoint, the remaining fields are generated. The returned endpoint holds the
+// secret signing the payloads, it is not shown again.
+func (s *WebhookService) CreateEndpoint(
+	ctx context.Context, tenantID string, endpoint *entities.WebhookEndpoint,
+) (*entities.WebhookEndpoint, error) {
+	if _, err := s.Tenants.GetTenantByID(ctx, tenantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateEndpoint(endpoint); err != nil {
+		s.Logger.Info(err)
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		s.Logger.Error(err)
+		return nil, apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	endpoint.ID = utils.NewXID().ID
+	endpoint.TenantID = tenantID
+	endpoint.Secret = secret
+	endpoint.Active = true
+	endpoint.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	endpoint.UpdatedAt = time.Time{}
+
+	if err := s.Repository.SaveEndpoint(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("registered webhook %s of tenant %s", endpoint.ID, tenantID)
+	return endpoint, nil
+}
+
+// GetEndpoints returns the webhook endpoints of a tenant.
+func (s *WebhookService) GetEndpoints(ctx context.Context, tenantID string) ([]*entities.WebhookEndpoint, error) {
+	return s.Repository.FindEndpoints(ctx, tenantID)
+}
+
+// GetEndpoint returns a webhook endpoint of a tenant.
+func (s *WebhookService) GetEndpoint(ctx context.Context, tenantID string, endpointID string) (
+	*entities.WebhookEndpoint, error,
+) {
+	return s.Repository.FindEndpoint(ctx, tenantID, endpointID)
+}
+
+// UpdateEndpoint replaces the URL, description, event types and state of a webhook endpoint.
+// The secret is kept.
+func (s *WebhookService) UpdateEndpoint(
+	ctx context.Context, tenantID string, endpoint *entities.WebhookEndpoint,
+) (*entities.WebhookEndpoint, error) {
+	existing, err := s.Repository.FindEndpoint(ctx, tenantID, endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validateEndpoint(endpoint); err != nil {
+		s.Logger.Info(err)
+		return nil, err
+	}
+
+	existing.URL = endpoint.URL
+	existing.Description = endpoint.Description
+	existing.EventTypes = endpoint.EventTypes
+	existing.Active = endpoint.Active
+	existing.UpdatedAt = time.Now().UTC().Truncate(time.Millisecond)
+
+	if err := s.Repository.UpdateEndpoint(ctx, existing); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// DeleteEndpoint removes a webhook endpoint, its pending deliveries are abandoned.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, tenantID string, endpointID string) error {
+	return s.Repository.DeleteEndpoint(ctx, tenantID, endpointID)
+}
+
+// DispatchEvent schedules the delivery of a domain event to the active endpoints of its tenant
+// subscribed to it. Dispatching an event again does not deliver it twice.
+func (s *WebhookService) DispatchEvent(ctx context.Context, event *entities.Event) error {
+	if event.TenantID == "" {
+		return nil
+	}
+
+	endpoints, err := s.Repository.FindEndpoints(ctx, event.TenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if !endpoint.Active || !endpoint.Subscribes(event.Type) {
+			continue
+		}
+
+		delivery, err := newDelivery(endpoint, event)
+		if err != nil {
+			return err
+		}
+
+		if err := s.Repository.SaveDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeliverPending attempts a batch of the deliveries due, it returns how many were attempted.
+func (s *WebhookService) DeliverPending(ctx context.Context) (int, error) {
+	deliveries, err := s.Repository.ClaimDeliveries(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range deliveries {
+		if err := ctx.Err(); err != nil {
+			return len(deliveries), err
+		}
+
+		endpoint, err := s.Repository.FindEndpoint(ctx, delivery.TenantID, delivery.EndpointID)
+		switch {
+		case errors.Is(err, apperrors.ErrNoWebhookFound):
+			s.abandon(ctx, delivery, "endpoint was removed")
+			continue
+		case err != nil:
+			s.Logger.Error(err)
+			continue
+		case !endpoint.Active:
+			s.abandon(ctx, delivery, "endpoint is disabled")
+			continue
+		}
+
+		s.attempt(ctx, endpoint, delivery, s.MaxAttempts)
+		if err := s.Repository.UpdateDelivery(ctx, delivery); err != nil {
+			s.Logger.Error(err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// StartDelivery attempts the deliveries due every interval until ctx is cancelled.
+func (s *WebhookService) StartDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for {
+				attempted, err := s.DeliverPending(ctx)
+				if err != nil {
+					s.Logger.Error(err)
+				}
+				if err != nil || attempted < webhookBatchSize {
+					break
+				}
+			}
+		}
+	}
+}
+
+// SendTestEvent delivers a webhook.test event to an endpoint right away, disabled endpoints included.
+// The test is not retried, the returned delivery holds the outcome.
+func (s *WebhookService) SendTestEvent(ctx context.Context, tenantID string, endpointID string) (
+	*entities.WebhookDelivery, error,
+) {
+	endpoint, err := s.Repository.FindEndpoint(ctx, tenantID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(map[string]string{"message": "test event of webhook " + endpoint.ID})
+	if err != nil {
+		return nil, apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	delivery, err := newDelivery(
+		endpoint, &entities.Event{
+			ID:         utils.NewXID().ID,
+			Type:       entities.WebhookTest,
+			TenantID:   tenantID,
+			Subject:    endpoint.ID,
+			Payload:    payload,
+			OccurredAt: time.Now().UTC().Truncate(time.Millisecond),
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.attempt(ctx, endpoint, delivery, 1)
+	if err := s.Repository.SaveDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// GetDeliveries returns the delivery logs of a tenant, newest first. Dead deliveries are the
+// dead-letter list.
+func (s *WebhookService) GetDeliveries(ctx context.Context, filter entities.WebhookDeliveryFilter) (
+	[]*entities.WebhookDelivery, error,
+) {
+	var fields []apperrors.FieldError
+	if filter.Status != "" && !filter.Status.IsValid() {
+		fields = append(
+			fields, apperrors.FieldError{Field: "status", Message: fmt.Sprintf("unknown status %q", filter.Status)},
+		)
+	}
+	if filter.Limit < 0 || filter.Limit > maxDeliveryLimit {
+		fields = append(
+			fields, apperrors.FieldError{
+				Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", maxDeliveryLimit),
+			},
+		)
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.ErrValidation.WithFields(fields...)
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = defaultDeliveryLimit
+	}
+
+	return s.Repository.FindDeliveries(ctx, filter)
+}
+
+// Redeliver schedules a completed delivery again, typically one from the dead-letter list.
+// It gets a fresh set of attempts.
+func (s *WebhookService) Redeliver(ctx context.Context, tenantID string, deliveryID string) (
+	*entities.WebhookDelivery, error,
+) {
+	delivery, err := s.Repository.FindDelivery(ctx, tenantID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.Status == entities.WebhookPending {
+		return delivery, nil
+	}
+
+	delivery.Status = entities.WebhookPending
+	delivery.Failures = 0
+	delivery.NextAttemptAt = time.Now().UTC().Truncate(time.Millisecond)
+	delivery.CompletedAt = time.Time{}
+
+	if err := s.Repository.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+
+	s.Logger.Infof("redelivering webhook delivery %s of tenant %s", deliveryID, tenantID)
+	return delivery, nil
+}
+
+// attempt posts a delivery to its endpoint and records the outcome, the delivery is dead once
+// it failed maxAttempts times.
+func (s *WebhookService) attempt(
+	ctx context.Context, endpoint *entities.WebhookEndpoint, delivery *entities.WebhookDelivery, maxAttempts int,
+) {
+	attempt := s.Sender.Send(ctx, endpoint, delivery)
+	delivery.Attempts = append(delivery.Attempts, attempt)
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	if attempt.Succeeded() {
+		delivery.Status = entities.WebhookSucceeded
+		delivery.NextAttemptAt = time.Time{}
+		delivery.CompletedAt = now
+		return
+	}
+
+	delivery.Failures++
+	if delivery.Failures >= maxAttempts {
+		s.Logger.Infof("webhook delivery %s to %s is dead after %d attempts", delivery.ID, endpoint.URL, delivery.Failures)
+		delivery.Status = entities.WebhookDead
+		delivery.NextAttemptAt = time.Time{}
+		delivery.CompletedAt = now
+		return
+	}
+
+	delivery.NextAttemptAt = now.Add(s.backoff(delivery.Failures))
+}
+
+// abandon moves a delivery that cannot be attempted to the dead-letter list.
+func (s *WebhookService) abandon(ctx context.Context, delivery *entities.WebhookDelivery, reason string) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	delivery.Attempts = append(delivery.Attempts, entities.WebhookAttempt{At: now, Error: reason})
+	delivery.Status = entities.WebhookDead
+	delivery.NextAttemptAt = time.Time{}
+	delivery.CompletedAt = now
+
+	if err := s.Repository.UpdateDelivery(ctx, delivery); err != nil {
+		s.Logger.Error(err)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of failures.
+func (s *WebhookService) backoff(failures int) time.Duration {
+	delay := s.MinBackoff
+	for i := 1; i < failures && delay < s.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > s.MaxBackoff {
+		return s.MaxBackoff
+	}
+	return delay
+}
+
+func (s *WebhookService) validateEndpoint(endpoint *entities.WebhookEndpoint) error {
+	if endpoint == nil {
+		return apperrors.ErrInvalidWebhook
+	}
+
+	var fields []apperrors.FieldError
+	u, err := url.Parse(endpoint.URL)
+	switch {
+	case err != nil || !u.IsAbs() || u.Host == "":
+		fields = append(fields, apperrors.FieldError{Field: "url", Message: "must be an absolute URL"})
+	case u.User != nil:
+		fields = append(fields, apperrors.FieldError{Field: "url", Message: "must not contain credentials"})
+	case u.Scheme == "https", u.Scheme == "http" && s.AllowHTTP:
+	default:
+		fields = append(fields, apperrors.FieldError{Field: "url", Message: "must use https"})
+	}
+
+	for i, eventType := range endpoint.EventTypes {
+		if !eventType.IsValid() {
+			fields = append(
+				fields, apperrors.FieldError{
+					Field:   apperrors.FieldPath("event_types", i),
+					Message: fmt.Sprintf("unknown event type %q", eventType),
+				},
+			)
+		}
+	}
+
+	if len(fields) > 0 {
+		return apperrors.ErrInvalidWebhook.WithFields(fields...)
+	}
+
+	return nil
+}
+
+// newDelivery wraps an event into the CloudEvent delivered to an endpoint. The delivery of an
+// event to an endpoint always has the same ID, so dispatching an event again is detected.
+func newDelivery(endpoint *entities.WebhookEndpoint, event *entities.Event) (*entities.WebhookDelivery, error) {
+	payload, err := json.Marshal(
+		entities.CloudEvent{
+			SpecVersion:     "1.0",
+			ID:              event.ID,
+			Source:          "/tenants/" + event.TenantID,
+			Type:            event.Type,
+			Subject:         event.Subject,
+			Time:            event.OccurredAt,
+			DataContentType: "application/json",
+			Data:            event.Payload,
+			TenantID:        event.TenantID,
+		},
+	)
+	if err != nil {
+		return nil, apperrors.ErrSavingWebhook.Wrap(err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return &entities.WebhookDelivery{
+		ID:            endpoint.ID + "-" + event.ID,
+		TenantID:      endpoint.TenantID,
+		EndpointID:    endpoint.ID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        entities.WebhookPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}, nil
+}
+
+func generateWebhookSecret() (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", errors.Wrap(err, "failed to generate webhook secret")
+	}
+
+	return WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
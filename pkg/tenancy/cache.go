@@ -0,0 +1,93 @@
+package tenancy
+
+import (
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	tenant    *Tenant
+	expiresAt time.Time
+}
+
+// cache is a small in-process TTL cache of resolved tenants keyed by lookup key.
+type cache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	size    int
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+func newCache(ttl time.Duration, size int) *cache {
+	return &cache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *cache) get(key string) (*Tenant, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || c.now().After(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.tenant, true
+}
+
+func (c *cache) set(key string, tenant *Tenant) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.size {
+		c.evict()
+	}
+
+	c.entries[key] = cacheEntry{tenant: tenant, expiresAt: c.now().Add(c.ttl)}
+}
+
+// invalidate drops every entry resolving to the tenant.
+func (c *cache) invalidate(tenantID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, entry := range c.entries {
+		if entry.tenant.ID == tenantID {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *cache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]cacheEntry)
+}
+
+// evict drops expired entries, and an arbitrary entry when the cache is still full.
+// Callers must hold the write lock.
+func (c *cache) evict() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+
+	for key := range c.entries {
+		if len(c.entries) < c.size {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
@@ -0,0 +1,38 @@
+package tenancy
+
+import "context"
+
+type contextKey struct{}
+
+var tenantKey = contextKey{}
+
+// WithTenant returns a copy of ctx carrying the resolved tenant.
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// FromContext returns the tenant stored in ctx by the resolver, if any.
+func FromContext(ctx context.Context) (*Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey).(*Tenant)
+	return tenant, ok && tenant != nil
+}
+
+// MustFromContext returns the tenant stored in ctx and panics when there is none.
+// It is meant for handlers mounted behind the resolver middleware.
+func MustFromContext(ctx context.Context) *Tenant {
+	tenant, ok := FromContext(ctx)
+	if !ok {
+		panic("tenancy: no tenant in context")
+	}
+
+	return tenant
+}
+
+// TenantIDFromContext returns the ID of the tenant stored in ctx, or an empty string.
+func TenantIDFromContext(ctx context.Context) string {
+	if tenant, ok := FromContext(ctx); ok {
+		return tenant.ID
+	}
+
+	return ""
+}
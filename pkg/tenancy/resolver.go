@@ -0,0 +1,309 @@
+package tenancy
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultHeader is the header carrying the tenant ID when no other strategy applies.
+	DefaultHeader = "X-Tenant-ID"
+	// DefaultClaim is the JWT claim carrying the tenant ID.
+	DefaultClaim = "tenant_id"
+
+	defaultCacheTTL  = time.Minute
+	defaultCacheSize = 10000
+)
+
+var (
+	ErrTenantNotResolved = errors.New("tenant could not be resolved from request")
+	ErrTenantInactive    = errors.New("tenant is not active")
+)
+
+// Strategy identifies where in a request the resolver looks for the tenant.
+type Strategy string
+
+const (
+	SubdomainStrategy    Strategy = "subdomain"
+	CustomDomainStrategy Strategy = "custom_domain"
+	// HeaderStrategy trusts the tenant ID sent by the client, it is not tried unless enabled with
+	// WithStrategies and must only be enabled behind a proxy setting the header itself.
+	HeaderStrategy Strategy = "header"
+	ClaimStrategy  Strategy = "claim"
+)
+
+// Lookup finds tenants, service.NewTenancyLookup adapts the TenantService of this repository.
+type Lookup interface {
+	GetTenantByID(ctx context.Context, id string) (*Tenant, error)
+	GetTenantBySubdomain(ctx context.Context, subdomain string) (*Tenant, error)
+	GetTenantByHost(ctx context.Context, host string) (*Tenant, error)
+}
+
+// ClaimsFunc returns the verified JWT claims of the request, as stored in the context
+// by the authentication layer.
+type ClaimsFunc func(ctx context.Context) (map[string]any, bool)
+
+// ErrorHandler writes the response when no tenant could be resolved.
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+type Config struct {
+	BaseDomain   string
+	Header       string
+	Claim        string
+	ClaimsFunc   ClaimsFunc
+	Strategies   []Strategy
+	CacheTTL     time.Duration
+	CacheSize    int
+	AllowMissing bool
+	ErrorHandler ErrorHandler
+}
+
+type Option func(*Config)
+
+// WithBaseDomain sets the platform domain under which tenants are addressed by subdomain,
+// e.g. with "example.com" the host "acme.example.com" resolves the tenant "acme".
+func WithBaseDomain(domain string) Option {
+	return func(c *Config) {
+		c.BaseDomain = strings.ToLower(strings.Trim(domain, "."))
+	}
+}
+
+// WithHeader overrides the header carrying the tenant ID.
+func WithHeader(header string) Option {
+	return func(c *Config) {
+		c.Header = header
+	}
+}
+
+// WithClaims enables resolution from a JWT claim. Claims are read through fn so that
+// only tokens verified by the authentication layer are trusted.
+func WithClaims(claim string, fn ClaimsFunc) Option {
+	return func(c *Config) {
+		c.Claim = claim
+		c.ClaimsFunc = fn
+	}
+}
+
+// WithStrategies sets which strategies are tried and in which order. By default they are the
+// subdomain, the custom domain and the claim.
+func WithStrategies(strategies ...Strategy) Option {
+	return func(c *Config) {
+		c.Strategies = strategies
+	}
+}
+
+// WithCache configures the in-process cache of resolved tenants. A ttl of zero disables it.
+func WithCache(ttl time.Duration, size int) Option {
+	return func(c *Config) {
+		c.CacheTTL = ttl
+		c.CacheSize = size
+	}
+}
+
+// WithAllowMissing lets requests without a resolvable tenant through the middleware.
+func WithAllowMissing() Option {
+	return func(c *Config) {
+		c.AllowMissing = true
+	}
+}
+
+// WithErrorHandler overrides how resolution failures are written to the client.
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(c *Config) {
+		c.ErrorHandler = handler
+	}
+}
+
+// Resolver works out which tenant a request belongs to.
+type Resolver struct {
+	lookup Lookup
+	config Config
+	cache  *cache
+}
+
+func NewResolver(lookup Lookup, opts ...Option) *Resolver {
+	cfg := Config{
+		Header:       DefaultHeader,
+		Claim:        DefaultClaim,
+		Strategies:   []Strategy{SubdomainStrategy, CustomDomainStrategy, ClaimStrategy},
+		CacheTTL:     defaultCacheTTL,
+		CacheSize:    defaultCacheSize,
+		ErrorHandler: defaultErrorHandler,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return &Resolver{
+		lookup: lookup,
+		config: cfg,
+		cache:  newCache(cfg.CacheTTL, cfg.CacheSize),
+	}
+}
+
+// Resolve tries each strategy in order and returns the first tenant found.
+// Inactive tenants are rejected with ErrTenantInactive.
+func (r *Resolver) Resolve(req *http.Request) (*Tenant, error) {
+	ctx := req.Context()
+
+	for _, strategy := range r.config.Strategies {
+		var (
+			tenant *Tenant
+			err    error
+		)
+
+		switch strategy {
+		case SubdomainStrategy:
+			subdomain, ok := r.subdomain(req.Host)
+			if !ok {
+				continue
+			}
+			tenant, err = r.cached(ctx, "subdomain:"+subdomain, r.lookup.GetTenantBySubdomain, subdomain)
+		case CustomDomainStrategy:
+			host := hostWithoutPort(req.Host)
+			if host == "" || r.isPlatformHost(host) {
+				continue
+			}
+			tenant, err = r.cached(ctx, "host:"+host, r.lookup.GetTenantByHost, host)
+		case ClaimStrategy:
+			id, ok := r.claim(ctx)
+			if !ok {
+				continue
+			}
+			tenant, err = r.cached(ctx, "id:"+id, r.lookup.GetTenantByID, id)
+		case HeaderStrategy:
+			id := strings.TrimSpace(req.Header.Get(r.config.Header))
+			if id == "" {
+				continue
+			}
+			tenant, err = r.cached(ctx, "id:"+id, r.lookup.GetTenantByID, id)
+		}
+
+		switch {
+		case err == nil && tenant != nil:
+			if !tenant.IsActive {
+				return nil, ErrTenantInactive
+			}
+			return tenant, nil
+		case err != nil && !isNotFound(err):
+			return nil, err
+		}
+	}
+
+	return nil, ErrTenantNotResolved
+}
+
+// Middleware resolves the tenant of each request and stores it in the request context.
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			tenant, err := r.Resolve(req)
+			if err != nil {
+				if r.config.AllowMissing && errors.Is(err, ErrTenantNotResolved) {
+					next.ServeHTTP(w, req)
+					return
+				}
+
+				r.config.ErrorHandler(w, req, err)
+				return
+			}
+
+			next.ServeHTTP(w, req.WithContext(WithTenant(req.Context(), tenant)))
+		},
+	)
+}
+
+// Invalidate drops the cached lookups of a tenant, e.g. after it was updated.
+func (r *Resolver) Invalidate(tenantID string) {
+	r.cache.invalidate(tenantID)
+}
+
+// Purge empties the cache.
+func (r *Resolver) Purge() {
+	r.cache.purge()
+}
+
+func (r *Resolver) cached(
+	ctx context.Context, key string, lookup func(context.Context, string) (*Tenant, error), value string,
+) (*Tenant, error) {
+	if tenant, ok := r.cache.get(key); ok {
+		return tenant, nil
+	}
+
+	tenant, err := lookup(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+
+	r.cache.set(key, tenant)
+	return tenant, nil
+}
+
+// subdomain returns the left-most label of host when host sits directly under the base domain.
+func (r *Resolver) subdomain(host string) (string, bool) {
+	if r.config.BaseDomain == "" {
+		return "", false
+	}
+
+	host = hostWithoutPort(host)
+	suffix := "." + r.config.BaseDomain
+	if !strings.HasSuffix(host, suffix) {
+		return "", false
+	}
+
+	subdomain := strings.TrimSuffix(host, suffix)
+	if subdomain == "" || strings.Contains(subdomain, ".") {
+		return "", false
+	}
+
+	return subdomain, true
+}
+
+func (r *Resolver) isPlatformHost(host string) bool {
+	return r.config.BaseDomain != "" &&
+		(host == r.config.BaseDomain || strings.HasSuffix(host, "."+r.config.BaseDomain))
+}
+
+func (r *Resolver) claim(ctx context.Context) (string, bool) {
+	if r.config.ClaimsFunc == nil {
+		return "", false
+	}
+
+	claims, ok := r.config.ClaimsFunc(ctx)
+	if !ok {
+		return "", false
+	}
+
+	id, ok := claims[r.config.Claim].(string)
+	return id, ok && id != ""
+}
+
+func hostWithoutPort(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, apperrors.ErrNoTenantDocumentsFound) || errors.Is(err, apperrors.ErrInvalidDomain)
+}
+
+func defaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrTenantNotResolved):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrTenantInactive):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,196 @@
+package tenancy_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/infrastructure/apperrors"
+	"github.com/hebecoding/tenant-management/pkg/tenancy"
+	"github.com/stretchr/testify/assert"
+)
+
+type claimsKey struct{}
+
+type fakeLookup struct {
+	mu      sync.Mutex
+	tenants []*tenancy.Tenant
+	// hosts are the verified custom domains, by hostname
+	hosts map[string]string
+	calls int
+}
+
+func (f *fakeLookup) find(match func(*tenancy.Tenant) bool) (*tenancy.Tenant, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	for _, tenant := range f.tenants {
+		if match(tenant) {
+			return tenant, nil
+		}
+	}
+
+	return nil, apperrors.ErrNoTenantDocumentsFound
+}
+
+func (f *fakeLookup) GetTenantByID(_ context.Context, id string) (*tenancy.Tenant, error) {
+	return f.find(func(t *tenancy.Tenant) bool { return t.ID == id })
+}
+
+func (f *fakeLookup) GetTenantBySubdomain(_ context.Context, subdomain string) (*tenancy.Tenant, error) {
+	return f.find(func(t *tenancy.Tenant) bool { return t.Subdomain == subdomain })
+}
+
+func (f *fakeLookup) GetTenantByHost(_ context.Context, host string) (*tenancy.Tenant, error) {
+	return f.find(func(t *tenancy.Tenant) bool { return f.hosts[host] == t.ID })
+}
+
+func newFakeLookup() *fakeLookup {
+	return &fakeLookup{
+		tenants: []*tenancy.Tenant{
+			{ID: "tenant-1", Subdomain: "acme", IsActive: true},
+			{ID: "tenant-2", Subdomain: "globex", IsActive: true},
+			{ID: "tenant-3", Subdomain: "initech", IsActive: false},
+		},
+		hosts: map[string]string{"portal.acme.com": "tenant-1"},
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	claims := func(ctx context.Context) (map[string]any, bool) {
+		c, ok := ctx.Value(claimsKey{}).(map[string]any)
+		return c, ok
+	}
+
+	var testCases = []struct {
+		Name             string
+		Host             string
+		Header           string
+		Claims           map[string]any
+		Strategies       []tenancy.Strategy
+		ExpectedTenantID string
+		ExpectedError    error
+	}{
+		{
+			Name:             "Happy Path: Resolve from subdomain",
+			Host:             "acme.example.com:8443",
+			ExpectedTenantID: "tenant-1",
+		},
+		{
+			Name:             "Happy Path: Resolve from verified custom domain",
+			Host:             "portal.acme.com",
+			ExpectedTenantID: "tenant-1",
+		},
+		{
+			Name:             "Happy Path: Resolve from JWT claim",
+			Host:             "api.example.com",
+			Claims:           map[string]any{"tenant_id": "tenant-2"},
+			ExpectedTenantID: "tenant-2",
+		},
+		{
+			Name:             "Happy Path: Resolve from header when enabled",
+			Host:             "localhost",
+			Header:           "tenant-2",
+			Strategies:       []tenancy.Strategy{tenancy.ClaimStrategy, tenancy.HeaderStrategy},
+			ExpectedTenantID: "tenant-2",
+		},
+		{
+			Name:          "Error Path: Header is not trusted by default",
+			Host:          "localhost",
+			Header:        "tenant-2",
+			ExpectedError: tenancy.ErrTenantNotResolved,
+		},
+		{
+			Name:          "Error Path: Pending custom domain does not resolve",
+			Host:          "pending.acme.com",
+			ExpectedError: tenancy.ErrTenantNotResolved,
+		},
+		{
+			Name:          "Error Path: Unknown subdomain",
+			Host:          "unknown.example.com",
+			ExpectedError: tenancy.ErrTenantNotResolved,
+		},
+		{
+			Name:          "Error Path: Inactive tenant",
+			Host:          "initech.example.com",
+			ExpectedError: tenancy.ErrTenantInactive,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				opts := []tenancy.Option{
+					tenancy.WithBaseDomain("example.com"),
+					tenancy.WithClaims(tenancy.DefaultClaim, claims),
+				}
+				if tt.Strategies != nil {
+					opts = append(opts, tenancy.WithStrategies(tt.Strategies...))
+				}
+				resolver := tenancy.NewResolver(newFakeLookup(), opts...)
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Host = tt.Host
+				if tt.Header != "" {
+					req.Header.Set(tenancy.DefaultHeader, tt.Header)
+				}
+				if tt.Claims != nil {
+					req = req.WithContext(context.WithValue(req.Context(), claimsKey{}, tt.Claims))
+				}
+
+				tenant, err := resolver.Resolve(req)
+				if tt.ExpectedError != nil {
+					assert.ErrorIs(t, err, tt.ExpectedError)
+					return
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, tt.ExpectedTenantID, tenant.ID)
+			},
+		)
+	}
+}
+
+func TestResolver_Middleware(t *testing.T) {
+	lookup := newFakeLookup()
+	resolver := tenancy.NewResolver(lookup, tenancy.WithBaseDomain("example.com"), tenancy.WithCache(time.Minute, 10))
+
+	handler := resolver.Middleware(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tenancy.MustFromContext(r.Context()).ID))
+			},
+		),
+	)
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = "globex.example.com"
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "tenant-2", rec.Body.String())
+	}
+
+	// subsequent requests are served from the cache
+	assert.Equal(t, 1, lookup.calls)
+
+	resolver.Invalidate("tenant-2")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "globex.example.com"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, 2, lookup.calls)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Host = "nobody.example.com"
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,10 @@
+package tenancy
+
+// Tenant is the tenant a request belongs to. It only carries what services need to scope their
+// work, the full tenant is served by the tenant management API.
+type Tenant struct {
+	ID        string
+	Name      string
+	Subdomain string
+	IsActive  bool
+}
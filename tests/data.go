@@ -2,118 +2,308 @@ package tests
 
 import (
 	"fmt"
-	"math/rand"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/brianvoe/gofakeit/v6/data"
 	"github.com/hebecoding/tenant-management/internal/domain/entities"
 )
 
-var generator = gofakeit.NewCrypto()
+// generator generates the tenants of tests, it is not seeded.
+var generator = NewGenerator()
 
 func CreateTenant() *entities.Tenant {
-	rand.New(rand.NewSource(time.Now().UnixNano()))
-	var mockTenant = entities.Tenant{}
-	mockTenant.ID = generator.UUID()
-	mockTenant.Name = generator.Company()
-	mockTenant.Subdomain = strings.ToLower(strings.Replace(mockTenant.Name, " ", "", -1))
-	mockTenant.IsActive = true
+	return generator.Tenant()
+}
+
+func CreateTenantList(amount int) []*entities.Tenant {
+	return generator.Tenants(amount)
+}
+
+func GeneratePaymentDetails() *entities.TenantPaymentDetails {
+	return generator.PaymentDetails()
+}
+
+func GenerateSubscriptionDetails() *entities.TenantSubscriptionDetails {
+	return generator.SubscriptionDetails()
+}
+
+func GenerateCompany() *entities.TenantCompanyDetails {
+	return generator.Company()
+}
+
+func GenerateContactDetails() *entities.TenantContactDetails {
+	return generator.ContactDetails()
+}
 
-	// generate companies
-	for i := 0; i < rand.Intn(3); i++ {
-		mockTenant.Companies = append(mockTenant.Companies, GenerateCompany())
+func GenerateTenantMetadata(mockTenant entities.Tenant) *entities.TenantMetadata {
+	return generator.TenantMetadata(mockTenant)
+}
+
+// Range is an inclusive range of counts.
+type Range struct {
+	Min int
+	Max int
+}
+
+// ParseRange parses a count, 2, or a range of counts, 1-3.
+func ParseRange(value string) (Range, error) {
+	bounds := strings.SplitN(value, "-", 2)
+	min, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid range %q", value)
+	}
+	max := min
+	if len(bounds) == 2 {
+		if max, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+			return Range{}, fmt.Errorf("invalid range %q", value)
+		}
+	}
+	if min < 0 || max < min {
+		return Range{}, fmt.Errorf("invalid range %q", value)
 	}
 
-	if len(mockTenant.Companies) == 0 {
-		mockTenant.Companies = append(mockTenant.Companies, GenerateCompany())
-		mockTenant.Companies[0].IsActive = true
+	return Range{Min: min, Max: max}, nil
+}
+
+// Profile is the distribution of generated tenants.
+type Profile struct {
+	// Companies, Contacts and PaymentMethods are the counts of each per tenant.
+	Companies      Range
+	Contacts       Range
+	PaymentMethods Range
+	// Subscriptions is the count of subscriptions per company.
+	Subscriptions Range
+	// Plans are the plans of subscriptions by weight, plans are picked in proportion to their
+	// weight.
+	Plans map[string]int
+	// InactiveRatio is the share of suspended tenants, from 0 to 1.
+	InactiveRatio float64
+}
+
+// DefaultProfile is the distribution of the tenants of tests.
+func DefaultProfile() Profile {
+	return Profile{
+		Companies:      Range{Min: 1, Max: 2},
+		Contacts:       Range{Min: 1, Max: 2},
+		PaymentMethods: Range{Min: 1, Max: 2},
+		Subscriptions:  Range{Min: 1, Max: 2},
+		Plans:          map[string]int{"starter": 1, "basic": 1, "premium": 1, "enterprise": 1},
 	}
+}
 
-	// generate payment details
-	for i := 0; i < rand.Intn(3); i++ {
-		mockTenant.PaymentDetails = append(mockTenant.PaymentDetails, GeneratePaymentDetails())
+// ParsePlans parses plans by weight, starter=4,enterprise=1. Plans without weight weigh 1.
+func ParsePlans(value string) (map[string]int, error) {
+	plans := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if name == "" {
+			return nil, fmt.Errorf("invalid plans %q", value)
+		}
+
+		plans[name] = 1
+		if found {
+			w, err := strconv.Atoi(weight)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight of plan %s", name)
+			}
+			plans[name] = w
+		}
 	}
 
-	if len(mockTenant.PaymentDetails) == 0 {
-		mockTenant.PaymentDetails = append(mockTenant.PaymentDetails, GeneratePaymentDetails())
-		mockTenant.PaymentDetails[0].IsActive = true
+	return plans, nil
+}
+
+// Generator generates realistic tenants. Seeded generators generate the same tenants on every
+// run, given the same profile and epoch.
+type Generator struct {
+	mu         sync.Mutex
+	faker      *gofakeit.Faker
+	profile    Profile
+	epoch      time.Time
+	subdomains map[string]int
+	plans      []string
+	weights    []int
+}
+
+type GeneratorOption func(*Generator)
+
+// WithSeed makes the generator deterministic.
+func WithSeed(seed int64) GeneratorOption {
+	return func(g *Generator) {
+		g.faker = gofakeit.NewUnlocked(seed)
 	}
+}
 
-	// generate contact details
-	for i := 0; i < rand.Intn(3); i++ {
-		mockTenant.PrimaryContacts = append(mockTenant.PrimaryContacts, GenerateContactDetails())
+// WithProfile sets the distribution of generated tenants, instead of DefaultProfile.
+func WithProfile(profile Profile) GeneratorOption {
+	return func(g *Generator) {
+		g.profile = profile
 	}
+}
 
-	if len(mockTenant.PrimaryContacts) == 0 {
-		mockTenant.PrimaryContacts = append(mockTenant.PrimaryContacts, GenerateContactDetails())
-		mockTenant.PrimaryContacts[0].IsActive = true
+// WithEpoch sets the time tenants are generated at, instead of the current time. Seeded
+// generators need one to be reproducible.
+func WithEpoch(epoch time.Time) GeneratorOption {
+	return func(g *Generator) {
+		g.epoch = epoch.UTC()
 	}
+}
 
-	// generate tenant metadata
-	mockTenant.TenantMetadata = GenerateTenantMetadata(mockTenant)
+func NewGenerator(opts ...GeneratorOption) *Generator {
+	g := &Generator{
+		faker:      gofakeit.NewCrypto(),
+		profile:    DefaultProfile(),
+		subdomains: make(map[string]int),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
 
-	mockTenant.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+	// plans are sorted, maps are iterated in random order
+	for plan, weight := range g.profile.Plans {
+		if weight > 0 {
+			g.plans = append(g.plans, plan)
+		}
+	}
+	sort.Strings(g.plans)
+	for _, plan := range g.plans {
+		g.weights = append(g.weights, g.profile.Plans[plan])
+	}
 
-	return &mockTenant
+	return g
 }
 
-func CreateTenantList(amount int) []*entities.Tenant {
-	var tenants []*entities.Tenant
+// Tenant generates a tenant with a unique subdomain.
+func (g *Generator) Tenant() *entities.Tenant {
+	g.mu.Lock()
+	defer g.mu.Unlock()
 
+	return g.tenant()
+}
+
+// Tenants generates amount tenants.
+func (g *Generator) Tenants(amount int) []*entities.Tenant {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	tenants := make([]*entities.Tenant, 0, amount)
 	for i := 0; i < amount; i++ {
-		tenants = append(tenants, CreateTenant())
+		tenants = append(tenants, g.tenant())
 	}
 
 	return tenants
 }
 
-func GeneratePaymentDetails() *entities.TenantPaymentDetails {
-	cc := generator.CreditCard()
-	addr := generator.Address()
-	addressInfo := entities.Address{
-		ID:      generator.UUID(),
-		Address: addr.Street,
-		City:    addr.City,
-		State:   addr.State,
-		ZipCode: addr.Zip,
-		Country: addr.Country,
+func (g *Generator) PaymentDetails() *entities.TenantPaymentDetails {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.paymentDetails()
+}
+
+func (g *Generator) SubscriptionDetails() *entities.TenantSubscriptionDetails {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.subscriptionDetails()
+}
+
+func (g *Generator) Company() *entities.TenantCompanyDetails {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.company()
+}
+
+func (g *Generator) ContactDetails() *entities.TenantContactDetails {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.contactDetails()
+}
+
+func (g *Generator) TenantMetadata(mockTenant entities.Tenant) *entities.TenantMetadata {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.tenantMetadata(mockTenant)
+}
+
+func (g *Generator) tenant() *entities.Tenant {
+	var mockTenant = entities.Tenant{}
+	mockTenant.ID = g.faker.UUID()
+	mockTenant.Name = g.faker.Company()
+	mockTenant.Subdomain = g.subdomain(mockTenant.Name)
+	mockTenant.IsActive = g.faker.Float64Range(0, 1) >= g.profile.InactiveRatio
+
+	// the first company, payment method and contact are the active ones
+	for i := g.count(g.profile.Companies); i > 0; i-- {
+		mockTenant.Companies = append(mockTenant.Companies, g.company())
 	}
+	if len(mockTenant.Companies) > 0 {
+		mockTenant.Companies[0].IsActive = true
+	}
+
+	for i := g.count(g.profile.PaymentMethods); i > 0; i-- {
+		mockTenant.PaymentDetails = append(mockTenant.PaymentDetails, g.paymentDetails())
+	}
+	if len(mockTenant.PaymentDetails) > 0 {
+		mockTenant.PaymentDetails[0].IsActive = true
+	}
+
+	for i := g.count(g.profile.Contacts); i > 0; i-- {
+		mockTenant.PrimaryContacts = append(mockTenant.PrimaryContacts, g.contactDetails())
+	}
+	if len(mockTenant.PrimaryContacts) > 0 {
+		mockTenant.PrimaryContacts[0].IsActive = true
+	}
+
+	// generate tenant metadata
+	mockTenant.TenantMetadata = g.tenantMetadata(mockTenant)
+
+	mockTenant.CreatedAt = g.now()
 
-	expiration := strings.Split(cc.Exp, "/")
-	month, _ := strconv.Atoi(expiration[0])
-	year, _ := strconv.Atoi(expiration[1])
+	return &mockTenant
+}
+
+func (g *Generator) paymentDetails() *entities.TenantPaymentDetails {
+	// the type of cards matches their number, which passes the Luhn check
+	cardType := g.faker.RandomString([]string{"visa", "mastercard", "american-express", "discover"})
+	card := data.CreditCards[cardType]
+	expiration := g.now().AddDate(0, g.faker.IntRange(1, 60), 0)
 
 	return &entities.TenantPaymentDetails{
-		ID:           generator.UUID(),
-		Address:      &addressInfo,
-		CardType:     cc.Type,
-		CardNumber:   cc.Number,
-		SecurityCode: cc.Cvv,
-		ExpMonth:     month,
-		ExpYear:      year,
+		ID:           g.faker.UUID(),
+		Address:      g.address(),
+		CardType:     card.Display,
+		CardNumber:   g.faker.CreditCardNumber(&gofakeit.CreditCardOptions{Types: []string{cardType}}),
+		SecurityCode: g.faker.Numerify(strings.Repeat("#", int(card.Code.Size))),
+		ExpMonth:     int(expiration.Month()),
+		ExpYear:      expiration.Year(),
 		IsActive:     false,
 	}
 }
 
-func GenerateSubscriptionDetails() *entities.TenantSubscriptionDetails {
-
-	plans := []string{"starter", "basic", "premium", "enterprise"}
-	startDate := gofakeit.DateRange(time.Now(), time.Now()).UTC().Truncate(time.Millisecond)
-	endDate := startDate.Add(time.Hour * 24 * 30).UTC().UTC().Truncate(time.Millisecond)
-	billingDate := startDate.Add(time.Hour * 24 * 29).UTC().Truncate(time.Millisecond)
+func (g *Generator) subscriptionDetails() *entities.TenantSubscriptionDetails {
+	startDate := g.now()
+	endDate := startDate.Add(time.Hour * 24 * 30)
+	billingDate := startDate.Add(time.Hour * 24 * 29)
 
 	return &entities.TenantSubscriptionDetails{
-		ID:              generator.UUID(),
-		Plan:            gofakeit.RandomString(plans),
-		BillingCycle:    gofakeit.RandomString([]string{"weekly", "monthly", "yearly"}),
-		PaymentStatus:   gofakeit.RandomString([]string{"active", "inactive", "suspended"}),
-		PaymentGateway:  gofakeit.RandomString([]string{"stripe", "paypal", "braintree"}),
-		DiscountRate:    float64(gofakeit.RandomInt([]int{0, 5, 10, 15, 20, 25})),
-		Discount:        gofakeit.Bool(),
-		Active:          gofakeit.Bool(),
-		AutoRenew:       gofakeit.Bool(),
+		ID:              g.faker.UUID(),
+		Plan:            g.plan(),
+		BillingCycle:    g.faker.RandomString([]string{"weekly", "monthly", "yearly"}),
+		PaymentStatus:   g.faker.RandomString([]string{"active", "inactive", "suspended"}),
+		PaymentGateway:  g.faker.RandomString([]string{"stripe", "paypal", "braintree"}),
+		DiscountRate:    float64(g.faker.RandomInt([]int{0, 5, 10, 15, 20, 25})),
+		Discount:        g.faker.Bool(),
+		Active:          g.faker.Bool(),
+		AutoRenew:       g.faker.Bool(),
 		StartDate:       startDate,
 		EndDate:         endDate,
 		NextBillingDate: billingDate,
@@ -121,19 +311,8 @@ func GenerateSubscriptionDetails() *entities.TenantSubscriptionDetails {
 	}
 }
 
-func GenerateCompany() *entities.TenantCompanyDetails {
-
-	addr := generator.Address()
-	addressInfo := entities.Address{
-		ID:      generator.UUID(),
-		Address: addr.Street,
-		City:    addr.City,
-		State:   addr.State,
-		ZipCode: addr.Zip,
-		Country: addr.Country,
-	}
-
-	industry := generator.RandomString(
+func (g *Generator) company() *entities.TenantCompanyDetails {
+	industry := g.faker.RandomString(
 		[]string{
 			"IT", "Finance", "Healthcare", "Education", "Retail", "Manufacturing", "Transportation", "Hospitality",
 			"Real Estate", "Construction", "Agriculture", "Mining", "Utilities", "Telecommunications", "Media",
@@ -142,49 +321,48 @@ func GenerateCompany() *entities.TenantCompanyDetails {
 	)
 
 	subscriptions := []*entities.TenantSubscriptionDetails{}
-
-	for i := 0; i <= rand.Intn(2); i++ {
-		subscriptions = append(subscriptions, GenerateSubscriptionDetails())
+	for i := g.count(g.profile.Subscriptions); i > 0; i-- {
+		subscriptions = append(subscriptions, g.subscriptionDetails())
 	}
 
 	return &entities.TenantCompanyDetails{
-		ID:                 generator.UUID(),
-		Name:               generator.Company(),
-		WebsiteURL:         generator.URL(),
-		LogoURL:            generator.ImageURL(4, 6),
+		ID:                 g.faker.UUID(),
+		Name:               g.faker.Company(),
+		WebsiteURL:         g.faker.URL(),
+		LogoURL:            g.faker.ImageURL(4, 6),
 		Industry:           industry,
-		RegistrationNumber: strconv.FormatInt(rand.Int63(), 10),
-		IsActive:           generator.Bool(),
+		RegistrationNumber: strconv.FormatInt(g.faker.Int64(), 10),
+		IsActive:           g.faker.Bool(),
 		Subscriptions:      subscriptions,
-		Address:            &addressInfo,
+		Address:            g.address(),
 	}
 }
 
-func GenerateContactDetails() *entities.TenantContactDetails {
+func (g *Generator) contactDetails() *entities.TenantContactDetails {
 	languages := []string{"en", "fr", "es", "de", "it", "pt", "ru", "zh", "ja", "ko"}
 
 	roles := []*entities.Role{
 		{
-			ID:          generator.UUID(),
+			ID:          g.faker.UUID(),
 			Name:        "Admin",
 			Description: "The admin role has full access to the tenant",
 			Permissions: []entities.Permission{
 				entities.ReadPermission, entities.WritePermission, entities.DeletePermission, entities.EditPermission,
 			},
 		}, {
-			ID:          generator.UUID(),
+			ID:          g.faker.UUID(),
 			Name:        "Manager",
 			Description: "The admin role has full access to the tenant",
 			Permissions: []entities.Permission{
 				entities.ReadPermission, entities.WritePermission, entities.EditPermission,
 			},
 		}, {
-			ID:          generator.UUID(),
+			ID:          g.faker.UUID(),
 			Name:        "Staff",
 			Description: "The admin role has full access to the tenant",
 			Permissions: []entities.Permission{entities.ReadPermission},
 		}, {
-			ID:          generator.UUID(),
+			ID:          g.faker.UUID(),
 			Name:        "Back Office",
 			Description: "The admin role has full access to the tenant",
 			Permissions: []entities.Permission{
@@ -195,37 +373,126 @@ func GenerateContactDetails() *entities.TenantContactDetails {
 
 	// generate random roles from the list above
 	randomRoles := []*entities.Role{}
-	for i := 0; i < rand.Intn(2); i++ {
-		randomRoles = append(randomRoles, roles[rand.Intn(len(roles))])
+	for i := g.faker.IntRange(0, 1); i > 0; i-- {
+		randomRoles = append(randomRoles, roles[g.faker.IntRange(0, len(roles)-1)])
 	}
 
 	return &entities.TenantContactDetails{
-		ID:                generator.UUID(),
-		FirstName:         generator.FirstName(),
-		LastName:          generator.LastName(),
-		Email:             generator.Email(),
-		PhoneNumber:       generator.PhoneFormatted(),
-		AvatarURL:         generator.ImageURL(4, 6),
-		JobTitle:          generator.JobTitle(),
-		PreferredLanguage: generator.RandomString(languages),
-		Timezone:          generator.TimeZone(),
-		IsActive:          generator.Bool(),
+		ID:                g.faker.UUID(),
+		FirstName:         g.faker.FirstName(),
+		LastName:          g.faker.LastName(),
+		Email:             g.faker.Email(),
+		PhoneNumber:       g.faker.PhoneFormatted(),
+		AvatarURL:         g.faker.ImageURL(4, 6),
+		JobTitle:          g.faker.JobTitle(),
+		PreferredLanguage: g.faker.RandomString(languages),
+		Timezone:          g.faker.TimeZone(),
+		IsActive:          g.faker.Bool(),
 		Roles:             randomRoles,
 	}
 }
 
-func GenerateTenantMetadata(mockTenant entities.Tenant) *entities.TenantMetadata {
-	startDate := gofakeit.DateRange(time.Now(), time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC))
+func (g *Generator) tenantMetadata(mockTenant entities.Tenant) *entities.TenantMetadata {
+	startDate := g.faker.DateRange(g.now(), g.now().AddDate(1, 0, 0))
 
 	updated := startDate.Add(time.Hour * 24 * 30)
 
 	return &entities.TenantMetadata{
-		ID:           generator.UUID(),
-		DatabaseName: fmt.Sprintf("%s-%s", mockTenant.Name, generator.UUID()),
-		TimeZone:     generator.TimeZone(),
-		StorageQuota: int64(generator.IntRange(100, 1000000000)),
-		StorageUsed:  int64(generator.IntRange(0, 100000)),
+		ID:           g.faker.UUID(),
+		DatabaseName: fmt.Sprintf("%s-%s", mockTenant.Subdomain, g.faker.UUID()),
+		TimeZone:     g.faker.TimeZone(),
+		StorageQuota: int64(g.faker.IntRange(100, 1000000000)),
+		StorageUsed:  int64(g.faker.IntRange(0, 100000)),
 		CreatedAt:    startDate.Truncate(time.Millisecond),
 		UpdatedAt:    updated.Truncate(time.Millisecond),
 	}
 }
+
+func (g *Generator) address() *entities.Address {
+	addr := g.faker.Address()
+	return &entities.Address{
+		ID:      g.faker.UUID(),
+		Address: addr.Street,
+		City:    addr.City,
+		State:   addr.State,
+		ZipCode: addr.Zip,
+		Country: addr.Country,
+	}
+}
+
+// subdomain returns a DNS label made of a name, numbered when it was already returned.
+func (g *Generator) subdomain(name string) string {
+	var label strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			label.WriteRune(r)
+		case r == ' ' || r == '-':
+			if label.Len() > 0 && !strings.HasSuffix(label.String(), "-") {
+				label.WriteByte('-')
+			}
+		}
+	}
+
+	base := strings.Trim(label.String(), "-")
+	if len(base) > 50 {
+		base = strings.Trim(base[:50], "-")
+	}
+	if base == "" {
+		base = "tenant"
+	}
+
+	// subdomains holds the returned subdomains, with the last number given to each
+	number := g.subdomains[base]
+	subdomain := base
+	for {
+		if _, taken := g.subdomains[subdomain]; !taken {
+			break
+		}
+		number++
+		subdomain = fmt.Sprintf("%s-%d", base, number+1)
+	}
+	g.subdomains[base] = number
+	if subdomain != base {
+		g.subdomains[subdomain] = 0
+	}
+
+	return subdomain
+}
+
+// plan picks a plan in proportion to its weight.
+func (g *Generator) plan() string {
+	total := 0
+	for _, weight := range g.weights {
+		total += weight
+	}
+	if total == 0 {
+		return "starter"
+	}
+
+	pick := g.faker.IntRange(0, total-1)
+	for i, weight := range g.weights {
+		if pick < weight {
+			return g.plans[i]
+		}
+		pick -= weight
+	}
+
+	return g.plans[len(g.plans)-1]
+}
+
+func (g *Generator) count(r Range) int {
+	if r.Max <= r.Min {
+		return r.Min
+	}
+
+	return g.faker.IntRange(r.Min, r.Max)
+}
+
+func (g *Generator) now() time.Time {
+	if !g.epoch.IsZero() {
+		return g.epoch.Truncate(time.Millisecond)
+	}
+
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
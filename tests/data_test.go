@@ -0,0 +1,109 @@
+package tests_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hebecoding/tenant-management/internal/domain/service"
+	"github.com/hebecoding/tenant-management/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// luhn reports whether a card number passes the Luhn check.
+func luhn(number string) bool {
+	sum := 0
+	for i := range number {
+		digit := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+func TestGenerator_Reproducible(t *testing.T) {
+	first := tests.NewGenerator(tests.WithSeed(42), tests.WithEpoch(epoch)).Tenants(20)
+	second := tests.NewGenerator(tests.WithSeed(42), tests.WithEpoch(epoch)).Tenants(20)
+	other := tests.NewGenerator(tests.WithSeed(43), tests.WithEpoch(epoch)).Tenants(20)
+
+	assert.Equal(t, first, second)
+	assert.NotEqual(t, first[0].ID, other[0].ID)
+	assert.Equal(t, epoch, first[0].CreatedAt)
+}
+
+func TestGenerator_Tenants(t *testing.T) {
+	profile := tests.Profile{
+		Companies:      tests.Range{Min: 2, Max: 3},
+		Contacts:       tests.Range{Min: 1, Max: 1},
+		PaymentMethods: tests.Range{Min: 1, Max: 2},
+		Subscriptions:  tests.Range{Min: 1, Max: 1},
+		Plans:          map[string]int{"starter": 3, "enterprise": 1, "legacy": 0},
+		InactiveRatio:  0.25,
+	}
+	tenants := tests.NewGenerator(tests.WithSeed(7), tests.WithEpoch(epoch), tests.WithProfile(profile)).Tenants(2000)
+
+	subdomains := make(map[string]bool)
+	plans := make(map[string]int)
+	inactive := 0
+	for _, tenant := range tenants {
+		require.NoError(t, service.ValidateTenant(tenant), "tenant %s", tenant.Subdomain)
+		assert.False(t, subdomains[tenant.Subdomain], "duplicate subdomain %s", tenant.Subdomain)
+		subdomains[tenant.Subdomain] = true
+		if !tenant.IsActive {
+			inactive++
+		}
+
+		assert.True(t, len(tenant.Companies) >= 2 && len(tenant.Companies) <= 3)
+		assert.Len(t, tenant.PrimaryContacts, 1)
+		for _, company := range tenant.Companies {
+			require.Len(t, company.Subscriptions, 1)
+			plans[company.Subscriptions[0].Plan]++
+		}
+		for _, payment := range tenant.PaymentDetails {
+			assert.True(t, luhn(payment.CardNumber), "card %s", payment.CardNumber)
+			assert.True(t, payment.ExpYear > epoch.Year() || payment.ExpMonth > int(epoch.Month()))
+		}
+	}
+
+	assert.InDelta(t, 500, inactive, 100)
+	assert.Zero(t, plans["legacy"])
+	assert.InDelta(t, 3, float64(plans["starter"])/float64(plans["enterprise"]), 0.5)
+}
+
+func TestParseRange(t *testing.T) {
+	var testCases = []struct {
+		Name          string
+		Value         string
+		ExpectedRange tests.Range
+		ExpectedError string
+	}{
+		{Name: "Happy Path: Count", Value: "2", ExpectedRange: tests.Range{Min: 2, Max: 2}},
+		{Name: "Happy Path: Range", Value: "0-3", ExpectedRange: tests.Range{Min: 0, Max: 3}},
+		{Name: "Error Path: Reversed range", Value: "3-1", ExpectedError: `invalid range "3-1"`},
+		{Name: "Error Path: Not a number", Value: "few", ExpectedError: `invalid range "few"`},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(
+			tt.Name, func(t *testing.T) {
+				r, err := tests.ParseRange(tt.Value)
+				if tt.ExpectedError != "" {
+					require.Error(t, err)
+					assert.Equal(t, tt.ExpectedError, err.Error())
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tt.ExpectedRange, r)
+			},
+		)
+	}
+}